@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/bench"
+	"github.com/kest-labs/kest/cli/internal/config"
+	"github.com/kest-labs/kest/cli/internal/output"
+	"github.com/kest-labs/kest/cli/internal/report"
+	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchUsers      int
+	benchDuration   time.Duration
+	benchWarmup     time.Duration
+	benchRPS        float64
+	benchRamp       string
+	benchMethod     string
+	benchHeaders    []string
+	benchData       string
+	benchAsserts    []string
+	benchThresholds []string
+	benchReports    []string
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <flow|url>",
+	Short: "Load test a URL or a whole flow with concurrent virtual users",
+	Long: `Drive a single request or a whole flow (.flow.md or .kest) with N virtual
+users and report latency percentiles (p50/p90/p95/p99) with a histogram,
+throughput, and errors broken down by status code, failed assertion and
+request error.
+
+Each virtual user repeats the target until --duration is over. A flow
+iteration starts from a fresh set of variables, like kest run does. --rps
+caps the iterations per second across all users (requests per second for a
+URL); --ramp replaces --users and --duration with a load profile. Traffic
+during --warmup runs at the starting load and is not measured.
+
+Benchmark requests are not recorded in history, the request log or the
+variable store. Use --threshold to fail the command (exit code 1) when the
+numbers are off, and --report to write the time series as JSON or HTML.`,
+	Example: `  # 20 users hitting one endpoint for a minute
+  kest bench /api/products -u 20 -d 1m
+
+  # A whole checkout flow at a steady 50 iterations per second
+  kest bench checkout.flow.md -u 10 --rps 50 -d 2m --warmup 10s
+
+  # Ramp up to 100 users, hold, ramp down
+  kest bench /api/search --ramp 30s:100,2m:100,30s:0
+
+  # Fail CI when the API gets slow, and keep an HTML report
+  kest bench /api/users --threshold "p95<300ms" --threshold "errors<1%" --report html`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := newBenchPlan(args[0])
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: err}
+		}
+		rep := plan.run()
+
+		for _, t := range plan.thresholds {
+			rep.Thresholds = append(rep.Thresholds, t.Check(rep))
+		}
+		if output.JSONOutput {
+			if err := rep.WriteJSON(os.Stdout); err != nil {
+				return err
+			}
+		} else {
+			rep.Print(os.Stdout)
+		}
+		if err := writeBenchReports(rep, plan.reports, args[0]); err != nil {
+			return &ExitError{Code: ExitRuntimeError, Err: err}
+		}
+		if rep.Failed() {
+			return &ExitError{Code: ExitAssertionFailed, Err: fmt.Errorf("benchmark thresholds failed")}
+		}
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntVarP(&benchUsers, "users", "u", 10, "Number of concurrent virtual users")
+	benchCmd.Flags().DurationVarP(&benchDuration, "duration", "d", 30*time.Second, "How long to measure (e.g. 30s, 2m)")
+	benchCmd.Flags().DurationVar(&benchWarmup, "warmup", 0, "Unmeasured warmup before the measurement starts")
+	benchCmd.Flags().Float64Var(&benchRPS, "rps", 0, "Target iterations per second across all users (0 = as fast as possible)")
+	benchCmd.Flags().StringVar(&benchRamp, "ramp", "", "Load profile of DURATION:USERS stages (e.g. 30s:50,1m:50,10s:0); overrides --users and --duration")
+	benchCmd.Flags().StringVarP(&benchMethod, "method", "X", "GET", "HTTP method for a URL target")
+	benchCmd.Flags().StringArrayVarP(&benchHeaders, "header", "H", []string{}, "Request headers for a URL target")
+	benchCmd.Flags().StringVar(&benchData, "data", "", "Request body for a URL target")
+	benchCmd.Flags().StringSliceVarP(&benchAsserts, "assert", "a", []string{}, "Assertions for a URL target (default: status < 400)")
+	benchCmd.Flags().StringArrayVar(&benchThresholds, "threshold", []string{}, `Fail when a metric is off, e.g. "p95<300ms", "errors<1%" or "rps>=100"`)
+	benchCmd.Flags().StringArrayVar(&benchReports, "report", []string{}, "Write a report: json[=path] or html[=path] (default path: ~/.kest/reports)")
+	benchCmd.Flags().StringArrayVar(&runVars, "var", []string{}, "Set variables (e.g. --var key=value)")
+	benchCmd.Flags().StringVarP(&runEnv, "env", "e", "", "Override active environment for this benchmark")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchPlan is a validated kest bench invocation.
+type benchPlan struct {
+	cfg        bench.Config
+	flowPath   string // empty for a URL target
+	thresholds []bench.Threshold
+	reports    []runReport
+	cliVars    map[string]string
+
+	// Opened once for the whole run and shared by every request.
+	store *storage.Store
+	conf  *config.Config
+}
+
+// newRunContext returns the context of one virtual user or flow iteration.
+func (p *benchPlan) newRunContext() *RunContext {
+	rc := NewRunContext(p.cliVars)
+	rc.NoRecord = true
+	rc.Store, rc.Config = p.store, p.conf
+	return rc
+}
+
+func newBenchPlan(target string) (*benchPlan, error) {
+	plan := &benchPlan{
+		cfg:     bench.Config{Target: target, Kind: "url", Users: benchUsers, RPS: benchRPS, Duration: benchDuration, Warmup: benchWarmup},
+		cliVars: parseVarFlags(runVars),
+	}
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		if !strings.HasSuffix(target, ".md") && !strings.HasSuffix(target, ".kest") {
+			return nil, fmt.Errorf("%s is not a .flow.md or .kest file", target)
+		}
+		plan.cfg.Kind, plan.flowPath = "flow", target
+	}
+
+	if benchRamp != "" {
+		stages, err := bench.ParseRamp(benchRamp)
+		if err != nil {
+			return nil, err
+		}
+		plan.cfg.Ramp = stages
+		plan.cfg.Users = bench.MaxUsers(stages)
+		plan.cfg.Duration = bench.RampDuration(stages)
+	}
+	switch {
+	case plan.cfg.Users < 1:
+		return nil, fmt.Errorf("need at least one virtual user")
+	case plan.cfg.Duration <= 0:
+		return nil, fmt.Errorf("--duration must be positive")
+	case plan.cfg.Warmup < 0:
+		return nil, fmt.Errorf("--warmup cannot be negative")
+	case plan.cfg.RPS < 0:
+		return nil, fmt.Errorf("--rps cannot be negative")
+	}
+
+	for _, expr := range benchThresholds {
+		t, err := bench.ParseThreshold(expr)
+		if err != nil {
+			return nil, err
+		}
+		plan.thresholds = append(plan.thresholds, t)
+	}
+	for _, value := range benchReports {
+		format, path, _ := strings.Cut(value, "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format != "json" && format != "html" {
+			return nil, fmt.Errorf("unknown report format %q (supported: json, html)", format)
+		}
+		plan.reports = append(plan.reports, runReport{Format: format, Path: strings.TrimSpace(path)})
+	}
+	return plan, nil
+}
+
+// usersAt returns how many virtual users should be busy elapsed into the
+// run. The warmup runs at the starting load: --users, or the first stage of
+// the ramp.
+func (p *benchPlan) usersAt(elapsed time.Duration) int {
+	if len(p.cfg.Ramp) == 0 {
+		return p.cfg.Users
+	}
+	if elapsed < p.cfg.Warmup {
+		return p.cfg.Ramp[0].Target
+	}
+	return bench.UsersAt(p.cfg.Ramp, elapsed-p.cfg.Warmup)
+}
+
+// iterate runs the target once and returns its results.
+func (p *benchPlan) iterate(rc *RunContext) []summary.TestResult {
+	if p.flowPath != "" {
+		// Every iteration starts from scratch, like a separate kest run.
+		rc = p.newRunContext()
+		summ, err := executeScenarioFile(p.flowPath, rc)
+		if err != nil {
+			return []summary.TestResult{{Name: p.flowPath, StartTime: time.Now(), Error: err}}
+		}
+		return summ.Results
+	}
+
+	asserts := benchAsserts
+	if len(asserts) == 0 {
+		asserts = []string{"status < 400"}
+	}
+	res, err := ExecuteRequest(RequestOptions{
+		Method:          benchMethod,
+		URL:             p.cfg.Target,
+		Data:            benchData,
+		Headers:         benchHeaders,
+		Asserts:         asserts,
+		SilentOutput:    true,
+		SkipHistorySync: true,
+		RunCtx:          rc,
+	})
+	res.Success = err == nil
+	res.Error = err
+	return []summary.TestResult{res}
+}
+
+// run drives the virtual users until the warmup and the measurement are
+// over, or until Ctrl+C, and reports what was measured.
+func (p *benchPlan) run() *bench.Report {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Warmup+p.cfg.Duration)
+	defer cancel()
+
+	// Step and request output would interleave across users; only the
+	// progress lines below reach the terminal.
+	progress := io.Writer(os.Stdout)
+	if output.JSONOutput || QuietMode {
+		progress = io.Discard
+	}
+	restoreOutput := suppressStdout()
+	defer restoreOutput()
+
+	// Opening the database and loading config per request would show up in
+	// the latencies and make the users contend for the database file.
+	p.conf = loadConfigWarn()
+	if store, err := storage.NewStore(); err == nil {
+		p.store = store
+		defer store.Close()
+	}
+
+	start := time.Now()
+	measureStart := start.Add(p.cfg.Warmup)
+	rec := bench.NewRecorder(measureStart)
+
+	fmt.Fprintf(progress, "\n🏋️  Benchmarking %s with %d user(s) for %s", p.cfg.Target, p.cfg.Users, p.cfg.Duration)
+	if p.cfg.Warmup > 0 {
+		fmt.Fprintf(progress, " after a %s warmup", p.cfg.Warmup)
+	}
+	fmt.Fprintln(progress, " (Ctrl+C stops early)")
+
+	var pace *benchPacer
+	if p.cfg.RPS > 0 {
+		pace = &benchPacer{interval: time.Duration(float64(time.Second) / p.cfg.RPS)}
+	}
+
+	var wg sync.WaitGroup
+	for vu := 0; vu < p.cfg.Users; vu++ {
+		wg.Add(1)
+		go func(vu int) {
+			defer wg.Done()
+			rc := p.newRunContext()
+			for ctx.Err() == nil {
+				if vu >= p.usersAt(time.Since(start)) {
+					sleepContext(ctx, 50*time.Millisecond)
+					continue
+				}
+				if pace != nil && !pace.wait(ctx) {
+					return
+				}
+				iterStart := time.Now()
+				results := p.iterate(rc)
+				rec.AddIteration(iterStart, time.Since(iterStart), results)
+			}
+		}(vu)
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	lastProgress := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case now := <-ticker.C:
+			rec.SetUsers(now, p.usersAt(now.Sub(start)))
+			if now.Sub(lastProgress) >= 5*time.Second {
+				lastProgress = now
+				requests, errors := rec.Progress()
+				phase := "warmup"
+				if now.After(measureStart) {
+					phase = now.Sub(measureStart).Round(time.Second).String()
+				}
+				fmt.Fprintf(progress, "   ⏱  %-8s %d user(s)  %d request(s)  %d error(s)\n", phase, p.usersAt(now.Sub(start)), requests, errors)
+			}
+		}
+	}
+	measured := max(time.Since(measureStart), 0)
+	wg.Wait()
+	return rec.Report(p.cfg, measured)
+}
+
+// benchPacer hands out evenly spaced start times so all users together
+// keep to the target rate. A slow target does not make up for lost time
+// with a burst later.
+type benchPacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (p *benchPacer) wait(ctx context.Context) bool {
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	slot := p.next
+	p.next = p.next.Add(p.interval)
+	p.mu.Unlock()
+	return sleepContext(ctx, time.Until(slot))
+}
+
+// sleepContext sleeps for d and reports whether ctx is still alive.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func writeBenchReports(rep *bench.Report, reports []runReport, sourcePath string) error {
+	var errs []string
+	for _, r := range reports {
+		opts := report.BenchReportOptions{OutputPath: r.Path, SourcePath: sourcePath}
+		write, label := report.WriteBenchJSON, "JSON"
+		if r.Format == "html" {
+			write, label = report.WriteBenchHTML, "HTML"
+		}
+		path, err := write(rep, opts)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s report: %v", r.Format, err))
+			continue
+		}
+		if !output.JSONOutput {
+			fmt.Printf("\n🧾 %s report written to: %s\n", label, path)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to write %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
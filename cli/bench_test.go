@@ -0,0 +1,160 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+// withBenchFlags sets the bench flags for one test and restores them after.
+func withBenchFlags(t *testing.T, users int, duration time.Duration, rps float64) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	prevUsers, prevDuration, prevRPS, prevAsserts := benchUsers, benchDuration, benchRPS, benchAsserts
+	benchUsers, benchDuration, benchRPS, benchAsserts = users, duration, rps, nil
+	t.Cleanup(func() {
+		benchUsers, benchDuration, benchRPS, benchAsserts = prevUsers, prevDuration, prevRPS, prevAsserts
+	})
+}
+
+func TestBenchURLCountsErrorsAndSkipsHistory(t *testing.T) {
+	withBenchFlags(t, 4, 500*time.Millisecond, 0)
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1)%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	plan, err := newBenchPlan(server.URL + "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := plan.run()
+
+	if rep.Kind != "url" || rep.Requests == 0 || int64(rep.Requests) > hits.Load() {
+		t.Fatalf("unexpected report: %d requests, %d hits", rep.Requests, hits.Load())
+	}
+	if rep.Statuses["503"] == 0 || rep.Errors != rep.Statuses["503"] || rep.Assertions["status < 400"] != rep.Errors {
+		t.Fatalf("503s should fail the default assertion: statuses %v, assertions %v", rep.Statuses, rep.Assertions)
+	}
+
+	store, err := storage.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	records, err := store.GetAllRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("bench traffic should not be recorded, found %d record(s)", len(records))
+	}
+}
+
+func TestBenchFlowWithRPS(t *testing.T) {
+	withBenchFlags(t, 3, time.Second, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Write([]byte(`{"token":"t-1"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	flow := "```step\n@id login\nPOST " + server.URL + "/login\n[Captures]\ntoken = token\n[Asserts]\nstatus == 200\n```\n\n" +
+		"```step\n@id profile\nGET " + server.URL + "/me\nAuthorization: Bearer {{token}}\n[Asserts]\nstatus == 200\n```\n"
+	path := filepath.Join(t.TempDir(), "load.flow.md")
+	if err := os.WriteFile(path, []byte(flow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := newBenchPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := plan.run()
+
+	if rep.Kind != "flow" || rep.Errors != 0 || len(rep.Steps) != 2 {
+		t.Fatalf("unexpected report: kind %s, %d errors, steps %+v", rep.Kind, rep.Errors, rep.Steps)
+	}
+	// 10 iterations per second for a second, give or take the edges.
+	if rep.Iterations < 8 || rep.Iterations > 12 || rep.Requests != 2*rep.Iterations {
+		t.Fatalf("--rps 10 should pace the iterations: %d iterations, %d requests", rep.Iterations, rep.Requests)
+	}
+}
+
+func TestBenchPlanValidation(t *testing.T) {
+	withBenchFlags(t, 0, time.Second, 0)
+	if _, err := newBenchPlan("/items"); err == nil {
+		t.Fatal("zero users should be rejected")
+	}
+
+	benchUsers, benchRamp = 5, "10s:20,5s:0"
+	defer func() { benchRamp = "" }()
+	plan, err := newBenchPlan("/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.cfg.Users != 20 || plan.cfg.Duration != 15*time.Second {
+		t.Fatalf("the ramp should set users and duration: %+v", plan.cfg)
+	}
+
+	benchThresholds = []string{"p95<soon"}
+	defer func() { benchThresholds = nil }()
+	if _, err := newBenchPlan("/items"); err == nil {
+		t.Fatal("a bad threshold should be rejected")
+	}
+}
+
+func TestRequestsUseTheRunStore(t *testing.T) {
+	withBenchFlags(t, 1, time.Second, 0)
+	store, err := storage.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	conf := loadConfigWarn()
+	if err := store.SaveVariable(&storage.Variable{Name: "tenant", Value: "abc", Project: conf.ProjectID, Environment: conf.ActiveEnv}); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	// A HOME where ~/.kest cannot be created: only the shared store works.
+	blocked := filepath.Join(t.TempDir(), "home")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", blocked)
+
+	plan := &benchPlan{store: store, conf: conf}
+	_, err = ExecuteRequest(RequestOptions{
+		Method:       "get",
+		URL:          server.URL,
+		Headers:      []string{"X-Tenant: {{tenant}}"},
+		Asserts:      []string{"status == 200"},
+		SilentOutput: true,
+		RunCtx:       plan.newRunContext(),
+	})
+	if err != nil {
+		t.Fatalf("expected the request to resolve variables from the run store: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/kest-labs/kest/cli/internal/xmlpath"
+	"github.com/tidwall/gjson"
+)
+
+// ResolveResponseCapture extracts a value from an HTTP response for a
+// [Captures] query.
+//
+// Supported query formats:
+//   - header.<Name>      → response header (case-insensitive)
+//   - cookie.<name>      → value of a Set-Cookie cookie
+//   - status             → HTTP status code
+//   - duration           → response time in milliseconds
+//   - regex "<pattern>"  → first capture group (or whole match) in the raw body
+//   - xpath <expr>       → XPath over an XML body
+//   - <gjson path>       → JSON body path (default)
+//
+// found is false when the query is valid but matched nothing; err reports an
+// invalid regex or XPath expression.
+func ResolveResponseCapture(query string, res summary.TestResult) (value string, found bool, err error) {
+	query = strings.TrimSpace(query)
+	headers := http.Header(res.ResponseHeaders)
+
+	switch {
+	case query == "status":
+		return strconv.Itoa(res.Status), true, nil
+
+	case query == "duration":
+		return strconv.FormatInt(res.Duration.Milliseconds(), 10), true, nil
+
+	case strings.HasPrefix(query, "header."):
+		name := strings.TrimPrefix(query, "header.")
+		values := headers.Values(name)
+		if len(values) == 0 {
+			return "", false, nil
+		}
+		return values[0], true, nil
+
+	case strings.HasPrefix(query, "cookie."):
+		name := strings.TrimPrefix(query, "cookie.")
+		for _, cookie := range (&http.Response{Header: headers}).Cookies() {
+			if cookie.Name == name {
+				return cookie.Value, true, nil
+			}
+		}
+		return "", false, nil
+
+	case strings.HasPrefix(query, "regex "):
+		pattern := unquoteCapturePattern(strings.TrimPrefix(query, "regex "))
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid capture regex %q: %w", pattern, err)
+		}
+		match := re.FindStringSubmatch(res.ResponseBody)
+		if match == nil {
+			return "", false, nil
+		}
+		if len(match) > 1 {
+			return match[1], true, nil
+		}
+		return match[0], true, nil
+
+	case strings.HasPrefix(query, "xpath "):
+		expr := unquoteCapturePattern(strings.TrimPrefix(query, "xpath "))
+		return xmlpath.Query([]byte(res.ResponseBody), expr)
+
+	default:
+		result := gjson.Get(res.ResponseBody, NormalizeJSONPath(query))
+		if !result.Exists() {
+			return "", false, nil
+		}
+		return result.String(), true, nil
+	}
+}
+
+// unquoteCapturePattern strips one pair of matching quotes without
+// interpreting escapes, so regex "order-(\d+)" keeps its backslash.
+func unquoteCapturePattern(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'' || first == '`') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+func TestResolveResponseCaptureSources(t *testing.T) {
+	res := summary.TestResult{
+		Status:   201,
+		Duration: 42 * time.Millisecond,
+		ResponseHeaders: map[string][]string{
+			"X-Auth-Token": {"tok-123"},
+			"Set-Cookie":   {"theme=dark; Path=/", "session_id=abc; HttpOnly"},
+		},
+		ResponseBody: `{"data":{"items":[{"id":"first"}]},"message":"created order-981"}`,
+	}
+
+	cases := map[string]string{
+		"header.X-Auth-Token":     "tok-123",
+		"header.x-auth-token":     "tok-123",
+		"cookie.session_id":       "abc",
+		"status":                  "201",
+		"duration":                "42",
+		`regex "order-(\d+)"`:     "981",
+		`regex 'created \w+-\d+'`: "created order-981",
+		"data.items[0].id":        "first",
+	}
+	for query, want := range cases {
+		got, found, err := ResolveResponseCapture(query, res)
+		if err != nil || !found || got != want {
+			t.Errorf("ResolveResponseCapture(%q) = %q, %v, %v; want %q", query, got, found, err, want)
+		}
+	}
+
+	for _, query := range []string{"header.X-Missing", "cookie.nope", `regex "invoice-(\d+)"`, "data.missing"} {
+		if _, found, err := ResolveResponseCapture(query, res); found || err != nil {
+			t.Errorf("ResolveResponseCapture(%q) expected no match, got found=%v err=%v", query, found, err)
+		}
+	}
+	if _, _, err := ResolveResponseCapture(`regex "("`, res); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestResolveResponseCaptureXPath(t *testing.T) {
+	res := summary.TestResult{ResponseBody: `<order id="7"><total currency="EUR">19.90</total></order>`}
+
+	got, found, err := ResolveResponseCapture("xpath /order/total", res)
+	if err != nil || !found || got != "19.90" {
+		t.Fatalf("unexpected xpath capture: %q, %v, %v", got, found, err)
+	}
+	got, _, _ = ResolveResponseCapture(`xpath "//total/@currency"`, res)
+	if got != "EUR" {
+		t.Fatalf("unexpected xpath attribute capture: %q", got)
+	}
+}
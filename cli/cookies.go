@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/client"
+	"github.com/kest-labs/kest/cli/internal/secret"
+	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var cookiesCmd = &cobra.Command{
+	Use:     "cookies",
+	Aliases: []string{"cookie"},
+	Short:   "List saved cookies for the current project and environment",
+	Long: `List the cookies kept for the current project and environment.
+
+Ad-hoc requests (kest get/post/...) run with --save-cookies replay and update
+these cookies, so a login request keeps later requests authenticated. Flows
+save their cookie jar here when they declare "@cookies persist". Values are encrypted at rest;
+cookies with secret names (e.g. auth_token, or --secret-var) are masked.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf := loadConfigWarn()
+		store, err := storage.NewStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		cookies, err := store.GetCookies(conf.ProjectID, conf.ActiveEnv)
+		if err != nil {
+			return err
+		}
+		if len(cookies) == 0 {
+			fmt.Println("No cookies found.")
+			return nil
+		}
+
+		fmt.Printf("Cookies for project %s (env: %s):\n", conf.ProjectID, conf.ActiveEnv)
+		for _, c := range cookies {
+			expires := "session"
+			if !c.Expires.IsZero() {
+				expires = c.Expires.Local().Format(time.DateTime)
+			}
+			fmt.Printf("  %s%s  %s = %s  (expires: %s)\n", c.Domain, c.Path, c.Name, secret.Mask(c.Value), expires)
+		}
+		return nil
+	},
+}
+
+var cookiesClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete saved cookies for the current project and environment",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf := loadConfigWarn()
+		store, err := storage.NewStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		n, err := store.DeleteCookies(conf.ProjectID, conf.ActiveEnv)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🧹 Deleted %d cookie(s) for env %s\n", n, conf.ActiveEnv)
+		return nil
+	},
+}
+
+func init() {
+	cookiesCmd.AddCommand(cookiesClearCmd)
+	rootCmd.AddCommand(cookiesCmd)
+}
+
+// loadSavedCookies fills jar with the cookies persisted for a project and
+// environment.
+func loadSavedCookies(store *storage.Store, project, environment string, jar *client.Jar) error {
+	saved, err := store.GetCookies(project, environment)
+	if err != nil {
+		return err
+	}
+	cookies := make([]client.SavedCookie, 0, len(saved))
+	for _, c := range saved {
+		cookies = append(cookies, client.SavedCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HostOnly: c.HostOnly,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			Expires:  c.Expires,
+		})
+	}
+	jar.Load(cookies)
+	return nil
+}
+
+// saveCookies persists the jar's contents for a project and environment,
+// replacing the previously saved cookies.
+func saveCookies(store *storage.Store, project, environment string, jar *client.Jar) error {
+	saved := jar.Saved()
+	cookies := make([]storage.Cookie, 0, len(saved))
+	for _, c := range saved {
+		cookies = append(cookies, storage.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HostOnly: c.HostOnly,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			Expires:  c.Expires,
+		})
+	}
+	return store.ReplaceCookies(project, environment, cookies)
+}
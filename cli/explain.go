@@ -38,6 +38,7 @@ Great for onboarding new team members or understanding unfamiliar APIs.`,
 		history, _ := store.GetHistory(10, record.Project)
 
 		conf := loadConfigWarn()
+		maskStoredRecords(store, conf, record, history)
 		client := ai.NewClient(conf.AIKey, conf.AIBaseURL, conf.AIModel)
 
 		fmt.Printf("🧠 Explaining record #%d: %s %s → %d ...\n\n",
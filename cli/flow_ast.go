@@ -6,7 +6,19 @@ type FlowMeta struct {
 	Version        string
 	Env            string
 	Tags           []string
-	DefaultHeaders map[string]string // Flow-level default headers
+	DefaultHeaders map[string]string // @header defaults for every HTTP step; step headers override them
+	BaseURL        string            // @base-url for relative step URLs; overrides the environment's base_url
+	TimeoutMs      int               // @timeout default for every step; a step's own @timeout wins
+	Data           string            // @data file; the whole flow runs once per row
+	Matrix         []FlowMatrixAxis  // @matrix axes; combined with Data rows
+	Cookies        string            // @cookies: "" or "on" (default), "off" or "persist"
+	Auth           string            // @auth default for every HTTP step; a step's own @auth (or @auth none) wins
+}
+
+// FlowMatrixAxis is one @matrix dimension: a variable and the values it takes.
+type FlowMatrixAxis struct {
+	Name   string
+	Values []string
 }
 
 type FlowStep struct {
@@ -19,14 +31,28 @@ type FlowStep struct {
 	WaitMs         int
 	PollTimeoutMs  int
 	PollIntervalMs int
-	ExecTimeoutMs  int // per-step exec timeout (overrides global --exec-timeout)
-	OnFail         string
+	ExecTimeoutMs  int    // per-step @timeout: exec command timeout (overrides --exec-timeout) or HTTP request timeout
+	OnFail         string // failure policy: continue, abort (alias stop) or goto
+	OnFailGoto     string // compensation step ID for "@on-fail goto <step-id>"
+	Data           string // @data file; the step runs once per row
+	Matrix         []FlowMatrixAxis
+	NoCookies      bool             // @cookies off: the step neither sends nor stores cookies
+	Tags           []string         // @tags, added to the flow's for --tags/--skip-tags
+	Snapshot       *SnapshotOptions // [Snapshot] section; nil when the step has none
 	LineNum        int
 	Raw            string
 	Request        RequestOptions
 	Exec           ExecOptions
 }
 
+// SnapshotOptions configures a step's [Snapshot] check against the response
+// body stored under __snapshots__ next to the flow file.
+type SnapshotOptions struct {
+	Name   string   // snapshot file name; defaults to the step ID
+	Ignore []string // paths left out of the comparison, e.g. body.items[*].id
+	Shape  bool     // compare keys and value types only
+}
+
 type ExecOptions struct {
 	Command  string
 	Captures []string
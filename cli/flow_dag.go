@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/kest-labs/kest/cli/internal/variable"
+)
+
+// flowDAG is the dependency graph built from a flow's @edge blocks. Edges
+// that reference unknown step IDs are ignored.
+type flowDAG struct {
+	steps      []FlowStep
+	upstream   [][]int
+	downstream [][]int
+}
+
+func newFlowDAG(steps []FlowStep, edges []FlowEdge) *flowDAG {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if step.ID != "" {
+			index[step.ID] = i
+		}
+	}
+
+	g := &flowDAG{
+		steps:      steps,
+		upstream:   make([][]int, len(steps)),
+		downstream: make([][]int, len(steps)),
+	}
+	seen := make(map[[2]int]bool)
+	for _, edge := range edges {
+		from, okFrom := index[edge.From]
+		to, okTo := index[edge.To]
+		if !okFrom || !okTo || from == to || seen[[2]int{from, to}] {
+			continue
+		}
+		seen[[2]int{from, to}] = true
+		g.upstream[to] = append(g.upstream[to], from)
+		g.downstream[from] = append(g.downstream[from], to)
+	}
+	return g
+}
+
+// hasCycle reports whether the graph cannot be fully ordered.
+func (g *flowDAG) hasCycle() bool {
+	indeg := make([]int, len(g.steps))
+	var queue []int
+	for i := range g.steps {
+		indeg[i] = len(g.upstream[i])
+		if indeg[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, next := range g.downstream[i] {
+			indeg[next]--
+			if indeg[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	return visited != len(g.steps)
+}
+
+// run executes the graph with at most jobs steps in flight. A step starts as
+// soon as all of its upstream steps have finished; ready steps are started in
+// document order. When exec returns false no further steps are started, but
+// steps already running are allowed to finish. It returns the number of steps
+// that were executed.
+func (g *flowDAG) run(jobs int, exec func(step FlowStep) bool) int {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	type outcome struct {
+		index int
+		ok    bool
+	}
+
+	indeg := make([]int, len(g.steps))
+	var ready []int
+	for i := range g.steps {
+		indeg[i] = len(g.upstream[i])
+		if indeg[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	done := make(chan outcome)
+	running, executed := 0, 0
+	stopped := false
+	for running > 0 || (!stopped && len(ready) > 0) {
+		for !stopped && running < jobs && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			executed++
+			go func(i int) {
+				done <- outcome{index: i, ok: exec(g.steps[i])}
+			}(i)
+		}
+
+		out := <-done
+		running--
+		if !out.ok {
+			stopped = true
+		}
+		for _, next := range g.downstream[out.index] {
+			indeg[next]--
+			if indeg[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+		sort.Ints(ready)
+	}
+	return executed
+}
+
+// edgeConditionHolds reports whether an edge's @on condition is met by the
+// upstream step's result. An empty condition behaves like "always". Anything
+// other than success/failure/always is an assertion expression evaluated
+// against the upstream response, e.g. "status == 404" or {{role}} == "admin".
+func edgeConditionHolds(on string, upstream summary.TestResult, vars func() map[string]string) bool {
+	cond := strings.TrimSpace(on)
+	switch strings.ToLower(cond) {
+	case "", "always":
+		return true
+	case "success":
+		return !upstream.Skipped && upstream.Success
+	case "failure":
+		return !upstream.Skipped && !upstream.Success
+	}
+	if upstream.Skipped {
+		return false
+	}
+	ok, _ := variable.AssertResponse(assertionResponse(upstream), vars(), cond)
+	return ok
+}
+
+// gateFlowStep decides whether a step should run given the results of its
+// upstream steps. Every incoming edge must hold; the returned reason names the
+// first one that did not. Edges from steps without a result (unknown IDs) are
+// ignored.
+func gateFlowStep(incoming []FlowEdge, outcomes map[string]summary.TestResult, vars func() map[string]string) (bool, string) {
+	for _, edge := range incoming {
+		upstream, ok := outcomes[edge.From]
+		if !ok {
+			continue
+		}
+		if edgeConditionHolds(edge.On, upstream, vars) {
+			continue
+		}
+		if upstream.Skipped {
+			return false, fmt.Sprintf("upstream step %s was skipped", edge.From)
+		}
+		return false, fmt.Sprintf("@on %s not met by %s", strings.TrimSpace(edge.On), edge.From)
+	}
+	return true, ""
+}
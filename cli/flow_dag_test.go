@@ -0,0 +1,147 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+func TestFlowDAGRunsIndependentBranchesConcurrently(t *testing.T) {
+	steps := []FlowStep{{ID: "login"}, {ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "done"}}
+	edges := []FlowEdge{
+		{From: "login", To: "a"},
+		{From: "login", To: "b"},
+		{From: "login", To: "c"},
+		{From: "a", To: "done"},
+		{From: "b", To: "done"},
+		{From: "c", To: "done"},
+	}
+	dag := newFlowDAG(steps, edges)
+	if dag.hasCycle() {
+		t.Fatal("unexpected cycle")
+	}
+
+	var mu sync.Mutex
+	finished := make(map[string]bool)
+	var inFlight, peak int32
+	executed := dag.run(3, func(step FlowStep) bool {
+		mu.Lock()
+		switch step.ID {
+		case "a", "b", "c":
+			if !finished["login"] {
+				t.Errorf("%s started before login finished", step.ID)
+			}
+		case "done":
+			if !finished["a"] || !finished["b"] || !finished["c"] {
+				t.Errorf("done started before its upstream steps finished")
+			}
+		}
+		mu.Unlock()
+
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		mu.Lock()
+		finished[step.ID] = true
+		mu.Unlock()
+		return true
+	})
+
+	if executed != len(steps) {
+		t.Fatalf("expected %d executed steps, got %d", len(steps), executed)
+	}
+	if peak != 3 {
+		t.Fatalf("expected 3 concurrent branches, got peak %d", peak)
+	}
+}
+
+func TestFlowDAGRespectsJobLimit(t *testing.T) {
+	steps := []FlowStep{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	dag := newFlowDAG(steps, []FlowEdge{{From: "x", To: "a"}})
+
+	var inFlight, peak int32
+	dag.run(2, func(step FlowStep) bool {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return true
+	})
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent steps, got %d", peak)
+	}
+}
+
+func TestFlowDAGStopsSchedulingAfterFailure(t *testing.T) {
+	steps := []FlowStep{{ID: "login"}, {ID: "profile"}, {ID: "orders"}}
+	edges := []FlowEdge{
+		{From: "login", To: "profile"},
+		{From: "profile", To: "orders"},
+	}
+	dag := newFlowDAG(steps, edges)
+
+	var ran []string
+	executed := dag.run(4, func(step FlowStep) bool {
+		ran = append(ran, step.ID)
+		return step.ID != "profile"
+	})
+	if executed != 2 || len(ran) != 2 || ran[1] != "profile" {
+		t.Fatalf("expected login and profile only, got %v", ran)
+	}
+}
+
+func TestFlowDAGDetectsCycle(t *testing.T) {
+	steps := []FlowStep{{ID: "a"}, {ID: "b"}}
+	edges := []FlowEdge{{From: "a", To: "b"}, {From: "b", To: "a"}}
+	if !newFlowDAG(steps, edges).hasCycle() {
+		t.Fatal("expected cycle to be detected")
+	}
+}
+
+func TestGateFlowStepConditions(t *testing.T) {
+	outcomes := map[string]summary.TestResult{
+		"login":   {StepID: "login", Success: true, Status: 200, ResponseBody: `{"role":"admin"}`},
+		"lookup":  {StepID: "lookup", Success: false, Status: 404},
+		"missing": {StepID: "missing", Skipped: true},
+	}
+	vars := func() map[string]string { return map[string]string{"role": "admin"} }
+
+	cases := []struct {
+		edge FlowEdge
+		want bool
+	}{
+		{FlowEdge{From: "login", On: "success"}, true},
+		{FlowEdge{From: "login", On: "failure"}, false},
+		{FlowEdge{From: "lookup", On: "failure"}, true},
+		{FlowEdge{From: "lookup", On: "always"}, true},
+		{FlowEdge{From: "lookup", On: "status == 404"}, true},
+		{FlowEdge{From: "login", On: "status == 404"}, false},
+		{FlowEdge{From: "login", On: `{{role}} == "admin"`}, true},
+		{FlowEdge{From: "login", On: `body.role == "viewer"`}, false},
+		{FlowEdge{From: "missing", On: "success"}, false},
+		{FlowEdge{From: "missing", On: "always"}, true},
+		{FlowEdge{From: "missing"}, true},
+		{FlowEdge{From: "unknown", On: "success"}, true},
+	}
+	for _, tc := range cases {
+		got, reason := gateFlowStep([]FlowEdge{tc.edge}, outcomes, vars)
+		if got != tc.want {
+			t.Errorf("edge %+v: expected %v, got %v (%s)", tc.edge, tc.want, got, reason)
+		}
+	}
+}
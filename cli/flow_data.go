@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dataRow is one iteration of a data-driven flow or step. Keys keeps the
+// column order of the source so labels read like the dataset.
+type dataRow struct {
+	Keys   []string
+	Values map[string]string
+}
+
+// label renders the row for group names, e.g. "row 2: email=a@b.io, age=17".
+func (r dataRow) label(index int) string {
+	parts := make([]string, 0, len(r.Keys))
+	for _, key := range r.Keys {
+		parts = append(parts, key+"="+r.Values[key])
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("row %d", index+1)
+	}
+	return fmt.Sprintf("row %d: %s", index+1, strings.Join(parts, ", "))
+}
+
+// parseMatrixAxis parses "@matrix role = admin, viewer" into an axis.
+func parseMatrixAxis(value string) (FlowMatrixAxis, bool) {
+	name, values, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return FlowMatrixAxis{}, false
+	}
+	axis := FlowMatrixAxis{Name: name, Values: splitCSV(values)}
+	return axis, len(axis.Values) > 0
+}
+
+// loadDataRows expands an @data file and any @matrix axes into iteration
+// rows. Every data row is combined with every matrix combination. A relative
+// data path is resolved against baseDir (the flow file's directory).
+func loadDataRows(dataPath string, matrix []FlowMatrixAxis, baseDir string) ([]dataRow, error) {
+	rows := []dataRow{{Values: map[string]string{}}}
+	if dataPath != "" {
+		path := dataPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		loaded, err := readDataFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("@data %s: %w", dataPath, err)
+		}
+		if len(loaded) == 0 {
+			return nil, fmt.Errorf("@data %s: no rows found", dataPath)
+		}
+		rows = loaded
+	}
+
+	for _, axis := range matrix {
+		next := make([]dataRow, 0, len(rows)*len(axis.Values))
+		for _, row := range rows {
+			for _, value := range axis.Values {
+				combined := dataRow{
+					Keys:   append(append([]string{}, row.Keys...), axis.Name),
+					Values: make(map[string]string, len(row.Values)+1),
+				}
+				for k, v := range row.Values {
+					combined.Values[k] = v
+				}
+				combined.Values[axis.Name] = value
+				next = append(next, combined)
+			}
+		}
+		rows = next
+	}
+	return rows, nil
+}
+
+func readDataFile(path string) ([]dataRow, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseCSVRows(content)
+	case ".json":
+		var records []map[string]any
+		if err := json.Unmarshal(content, &records); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+		}
+		return recordsToRows(records), nil
+	case ".yaml", ".yml":
+		var records []map[string]any
+		if err := yaml.Unmarshal(content, &records); err != nil {
+			return nil, fmt.Errorf("expected a YAML list of mappings: %w", err)
+		}
+		return recordsToRows(records), nil
+	default:
+		return nil, fmt.Errorf("unsupported data file type %q (use .csv, .json, .yaml or .yml)", filepath.Ext(path))
+	}
+}
+
+// parseCSVRows treats the first record as the header row.
+func parseCSVRows(content []byte) ([]dataRow, error) {
+	reader := csv.NewReader(strings.NewReader(string(content)))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := make([]string, len(records[0]))
+	for i, name := range records[0] {
+		header[i] = strings.TrimSpace(name)
+	}
+	rows := make([]dataRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := dataRow{Keys: header, Values: make(map[string]string, len(header))}
+		for i, name := range header {
+			if i < len(record) {
+				row.Values[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// recordsToRows stringifies JSON/YAML records. Map keys have no inherent
+// order, so columns are sorted by name; nested values are encoded as JSON.
+func recordsToRows(records []map[string]any) []dataRow {
+	rows := make([]dataRow, 0, len(records))
+	for _, record := range records {
+		row := dataRow{Values: make(map[string]string, len(record))}
+		for key, value := range record {
+			row.Keys = append(row.Keys, key)
+			row.Values[key] = stringifyDataValue(value)
+		}
+		sort.Strings(row.Keys)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func stringifyDataValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		// JSON numbers decode as float64; keep 12345678 out of exponent form.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDataFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestLoadDataRowsFormats(t *testing.T) {
+	dir := t.TempDir()
+	writeDataFile(t, dir, "users.csv", "email,age\na@example.com,17\nb@example.com,18\n")
+	writeDataFile(t, dir, "users.json", `[{"email":"a@example.com","age":17},{"email":"b@example.com","age":18,"tags":["x"]}]`)
+	writeDataFile(t, dir, "users.yaml", "- email: a@example.com\n  age: 17\n- email: b@example.com\n  age: 18\n")
+
+	for _, name := range []string{"users.csv", "users.json", "users.yaml"} {
+		rows, err := loadDataRows(name, nil, dir)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(rows) != 2 || rows[0].Values["email"] != "a@example.com" || rows[1].Values["age"] != "18" {
+			t.Fatalf("%s: unexpected rows %+v", name, rows)
+		}
+	}
+
+	rows, _ := loadDataRows("users.csv", nil, dir)
+	if got := rows[1].label(1); got != "row 2: email=b@example.com, age=18" {
+		t.Fatalf("unexpected label %q", got)
+	}
+	rows, _ = loadDataRows("users.json", nil, dir)
+	if rows[1].Values["tags"] != `["x"]` {
+		t.Fatalf("expected nested values encoded as JSON, got %q", rows[1].Values["tags"])
+	}
+}
+
+func TestLoadDataRowsLargeNumbers(t *testing.T) {
+	dir := t.TempDir()
+	writeDataFile(t, dir, "ids.json", `[{"id":12345678,"amount":1000000,"rate":0.25}]`)
+	writeDataFile(t, dir, "ids.yaml", "- id: 12345678\n  amount: 1000000\n  rate: 0.25\n")
+
+	for _, name := range []string{"ids.json", "ids.yaml"} {
+		rows, err := loadDataRows(name, nil, dir)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := rows[0].Values
+		if got["id"] != "12345678" || got["amount"] != "1000000" || got["rate"] != "0.25" {
+			t.Fatalf("%s: numbers not kept in plain form: %+v", name, got)
+		}
+	}
+}
+
+func TestLoadDataRowsMatrixProduct(t *testing.T) {
+	dir := t.TempDir()
+	writeDataFile(t, dir, "users.csv", "email\na@example.com\nb@example.com\n")
+
+	matrix := []FlowMatrixAxis{{Name: "role", Values: []string{"admin", "viewer"}}}
+	rows, err := loadDataRows("users.csv", matrix, dir)
+	if err != nil {
+		t.Fatalf("loadDataRows: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 combinations, got %d", len(rows))
+	}
+	if rows[3].Values["email"] != "b@example.com" || rows[3].Values["role"] != "viewer" {
+		t.Fatalf("unexpected last combination %+v", rows[3])
+	}
+
+	rows, err = loadDataRows("", append(matrix, FlowMatrixAxis{Name: "status", Values: []string{"active", "banned"}}), dir)
+	if err != nil || len(rows) != 4 {
+		t.Fatalf("expected 4 matrix-only rows, got %d (%v)", len(rows), err)
+	}
+}
+
+func TestLoadDataRowsErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeDataFile(t, dir, "users.txt", "a,b\n")
+	writeDataFile(t, dir, "empty.csv", "email\n")
+
+	for _, name := range []string{"missing.csv", "users.txt", "empty.csv"} {
+		if _, err := loadDataRows(name, nil, dir); err == nil {
+			t.Errorf("expected error for %s", name)
+		}
+	}
+}
+
+func TestParseFlowDataDirectives(t *testing.T) {
+	content := "```flow\n@data users.csv\n@matrix role = admin, viewer\n```\n\n" +
+		"```step\n@id boundary\n@matrix age = 17, 18\nGET /users?age={{age}}\n```\n"
+	doc, _ := ParseFlowDocument(content)
+	if doc.Meta.Data != "users.csv" || len(doc.Meta.Matrix) != 1 || doc.Meta.Matrix[0].Name != "role" {
+		t.Fatalf("unexpected flow meta: %+v", doc.Meta)
+	}
+	step := doc.Steps[0]
+	if len(step.Matrix) != 1 || len(step.Matrix[0].Values) != 2 || step.Matrix[0].Values[1] != "18" {
+		t.Fatalf("unexpected step matrix: %+v", step.Matrix)
+	}
+}
@@ -78,6 +78,28 @@ func mergeFlowMeta(base FlowMeta, next FlowMeta) FlowMeta {
 	if len(next.Tags) > 0 {
 		base.Tags = next.Tags
 	}
+	for name, value := range next.DefaultHeaders {
+		if base.DefaultHeaders == nil {
+			base.DefaultHeaders = make(map[string]string)
+		}
+		base.DefaultHeaders[name] = value
+	}
+	if next.BaseURL != "" {
+		base.BaseURL = next.BaseURL
+	}
+	if next.TimeoutMs > 0 {
+		base.TimeoutMs = next.TimeoutMs
+	}
+	if next.Data != "" {
+		base.Data = next.Data
+	}
+	base.Matrix = append(base.Matrix, next.Matrix...)
+	if next.Cookies != "" {
+		base.Cookies = next.Cookies
+	}
+	if next.Auth != "" {
+		base.Auth = next.Auth
+	}
 	return base
 }
 
@@ -107,6 +129,37 @@ func parseFlowMeta(raw string) FlowMeta {
 			meta.Env = val
 		case "tags":
 			meta.Tags = splitCSV(val)
+		case "header":
+			name, value, ok := strings.Cut(val, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				fmt.Printf("⚠️  Warning: invalid @header %q; expected Name: value\n", val)
+				continue
+			}
+			if meta.DefaultHeaders == nil {
+				meta.DefaultHeaders = make(map[string]string)
+			}
+			meta.DefaultHeaders[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		case "base-url":
+			meta.BaseURL = val
+		case "timeout":
+			meta.TimeoutMs = parseDurationToMS(val)
+		case "data":
+			meta.Data = val
+		case "matrix":
+			if axis, ok := parseMatrixAxis(val); ok {
+				meta.Matrix = append(meta.Matrix, axis)
+			} else {
+				fmt.Printf("⚠️  Warning: invalid @matrix %q; expected name = value1, value2\n", val)
+			}
+		case "cookies":
+			switch mode := strings.ToLower(val); mode {
+			case "on", "off", "persist":
+				meta.Cookies = mode
+			default:
+				fmt.Printf("⚠️  Warning: unknown @cookies mode %q; expected on, off or persist\n", val)
+			}
+		case "auth":
+			meta.Auth = val
 		}
 	}
 	return meta
@@ -116,6 +169,10 @@ func parseFlowStep(b FlowBlock) FlowStep {
 	step := FlowStep{LineNum: b.LineNum, Raw: b.Raw}
 	lines := strings.Split(b.Raw, "\n")
 	var requestLines []string
+	var gqlQuery, gqlVariables, gqlOperation []string
+	var script []RealtimeAction
+	allowErrors := false
+	var grpcOpts GRPCOptions
 	directivePhase := true
 	section := "request"
 
@@ -148,8 +205,44 @@ func parseFlowStep(b FlowBlock) FlowStep {
 			case "timeout":
 				step.ExecTimeoutMs = parseDurationToMS(val)
 			case "on-fail":
-				fmt.Printf("⚠️  Warning: @on-fail is not yet implemented (line %d), ignoring.\n", b.LineNum)
-				step.OnFail = val
+				policy, target, ok := parseOnFail(val)
+				if !ok {
+					fmt.Printf("⚠️  Warning: unknown @on-fail policy %q (line %d); expected continue, abort or goto <step-id>.\n", val, b.LineNum)
+					continue
+				}
+				step.OnFail = policy
+				step.OnFailGoto = target
+			case "data":
+				step.Data = val
+			case "matrix":
+				if axis, ok := parseMatrixAxis(val); ok {
+					step.Matrix = append(step.Matrix, axis)
+				} else {
+					fmt.Printf("⚠️  Warning: invalid @matrix %q (line %d); expected name = value1, value2\n", val, b.LineNum)
+				}
+			case "tags":
+				step.Tags = append(step.Tags, splitCSV(val)...)
+			case "cookies":
+				switch strings.ToLower(val) {
+				case "off":
+					step.NoCookies = true
+				case "on":
+					step.NoCookies = false
+				default:
+					fmt.Printf("⚠️  Warning: unknown @cookies mode %q (line %d); expected on or off\n", val, b.LineNum)
+				}
+			case "auth":
+				step.Request.Auth = val
+			case "allow-errors":
+				allowErrors = val == "" || strings.EqualFold(val, "true")
+			case "proto":
+				grpcOpts.ProtoPath = val
+			case "tls":
+				// @tls, or @tls ca.pem to trust a custom CA
+				grpcOpts.TLS = !strings.EqualFold(val, "false")
+				if grpcOpts.TLS && !strings.EqualFold(val, "true") {
+					grpcOpts.CertFile = val
+				}
 			}
 			continue
 		}
@@ -176,6 +269,30 @@ func parseFlowStep(b FlowBlock) FlowStep {
 			section = "poll"
 			continue
 		}
+		if step.Type == "graphql" {
+			switch trimmed {
+			case "[Query]":
+				section = "query"
+				continue
+			case "[Variables]":
+				section = "variables"
+				continue
+			case "[Operation]", "[OperationName]":
+				section = "operation"
+				continue
+			}
+		}
+		if (step.Type == "ws" || step.Type == "sse") && trimmed == "[Script]" {
+			section = "script"
+			continue
+		}
+		if trimmed == "[Snapshot]" {
+			section = "snapshot"
+			if step.Snapshot == nil {
+				step.Snapshot = &SnapshotOptions{}
+			}
+			continue
+		}
 
 		switch section {
 		case "request":
@@ -204,12 +321,31 @@ func parseFlowStep(b FlowBlock) FlowStep {
 			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
 				parsePollOption(trimmed, &step)
 			}
+		case "snapshot":
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				parseSnapshotOption(trimmed, step.Snapshot, b.LineNum)
+			}
+		case "query":
+			gqlQuery = append(gqlQuery, line)
+		case "variables":
+			gqlVariables = append(gqlVariables, line)
+		case "operation":
+			gqlOperation = append(gqlOperation, line)
+		case "script":
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				action, err := parseRealtimeAction(trimmed, step.Type)
+				if err != nil {
+					fmt.Printf("⚠️  Warning: %v (step at line %d); line ignored.\n", err, b.LineNum)
+					continue
+				}
+				script = append(script, action)
+			}
 		}
 	}
 
 	// Validate @type
 	switch step.Type {
-	case "", "http", "exec":
+	case "", "http", "exec", "graphql", "ws", "sse", "grpc":
 		// valid
 	default:
 		fmt.Printf("⚠️  Warning: unknown step type '%s' at line %d, treating as http.\n", step.Type, b.LineNum)
@@ -217,6 +353,29 @@ func parseFlowStep(b FlowBlock) FlowStep {
 	}
 
 	requestRaw := strings.TrimSpace(strings.Join(requestLines, "\n"))
+	if step.Type == "grpc" && requestRaw != "" {
+		// "<address> <package.Service/Method>": ParseBlock reads the rest
+		// like any request, with the method in place of the URL.
+		first, rest, _ := strings.Cut(requestRaw, "\n")
+		if fields := strings.Fields(first); len(fields) == 2 {
+			grpcOpts.Address = fields[0]
+			requestRaw = "GRPC " + fields[1] + "\n" + rest
+		} else {
+			fmt.Printf("⚠️  Warning: gRPC step at line %d needs a request line like \"localhost:50051 pkg.Service/Method\".\n", b.LineNum)
+		}
+	}
+	if requestRaw != "" {
+		// GraphQL goes over POST and ws/sse over GET unless the step says
+		// otherwise, so the request line may be just the endpoint.
+		if first, _, _ := strings.Cut(requestRaw, "\n"); len(strings.Fields(first)) == 1 {
+			switch step.Type {
+			case "graphql":
+				requestRaw = "POST " + requestRaw
+			case "ws", "sse":
+				requestRaw = "GET " + requestRaw
+			}
+		}
+	}
 	if requestRaw != "" {
 		if step.Type == "exec" {
 			savedCaptures := step.Exec.Captures
@@ -228,12 +387,35 @@ func parseFlowStep(b FlowBlock) FlowStep {
 			savedCaptures := step.Request.Captures
 			savedAsserts := step.Request.Asserts
 			savedSoftAsserts := step.Request.SoftAsserts
+			savedAuth := step.Request.Auth
 			step.Request = opts
+			step.Request.Auth = savedAuth
 			step.Request.Captures = append(step.Request.Captures, savedCaptures...)
 			step.Request.Asserts = append(step.Request.Asserts, savedAsserts...)
 			step.Request.SoftAsserts = append(step.Request.SoftAsserts, savedSoftAsserts...)
 		}
 	}
+	if step.Type == "graphql" {
+		gql := &GraphQLOptions{
+			Query:         strings.TrimSpace(strings.Join(gqlQuery, "\n")),
+			Variables:     strings.TrimSpace(strings.Join(gqlVariables, "\n")),
+			OperationName: strings.TrimSpace(strings.Join(gqlOperation, "\n")),
+			AllowErrors:   allowErrors,
+		}
+		// Without a [Query] section the request body is the query.
+		if gql.Query == "" {
+			gql.Query, step.Request.Data = strings.TrimSpace(step.Request.Data), ""
+		}
+		step.Request.GraphQL = gql
+	}
+	if step.Type == "grpc" {
+		grpcOpts.AllowErrors = allowErrors
+		step.Request.Method = "GRPC"
+		step.Request.GRPC = &grpcOpts
+	}
+	if step.Type == "ws" || step.Type == "sse" {
+		step.Request.Realtime = &RealtimeOptions{Protocol: step.Type, Script: script}
+	}
 
 	return step
 }
@@ -264,6 +446,27 @@ func parseExecBlock(raw string) ExecOptions {
 	return opts
 }
 
+// parseOnFail splits an @on-fail value into its policy and, for goto, the
+// target step ID.
+func parseOnFail(value string) (string, string, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	policy := strings.ToLower(fields[0])
+	switch policy {
+	case "continue", "abort", "stop":
+		if len(fields) == 1 {
+			return policy, "", true
+		}
+	case "goto":
+		if len(fields) == 2 {
+			return policy, fields[1], true
+		}
+	}
+	return "", "", false
+}
+
 func parseFlowEdge(b FlowBlock) FlowEdge {
 	edge := FlowEdge{LineNum: b.LineNum}
 	lines := strings.Split(b.Raw, "\n")
@@ -377,3 +580,44 @@ func parsePollOption(line string, step *FlowStep) {
 		}
 	}
 }
+
+// parseSnapshotOption reads one [Snapshot] line:
+//
+//	ignore: body.created_at, body.items[*].id
+//	mode: shape            (or just "shape only")
+//	name: users-list
+func parseSnapshotOption(line string, opts *SnapshotOptions, lineNum int) {
+	key, val, ok := strings.Cut(line, ":")
+	if !ok {
+		key, val, ok = strings.Cut(line, "=")
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	val = strings.TrimSpace(val)
+	if !ok {
+		switch key {
+		case "shape", "shape only":
+			opts.Shape = true
+		default:
+			fmt.Printf("⚠️  Warning: unknown [Snapshot] option %q (line %d)\n", line, lineNum)
+		}
+		return
+	}
+
+	switch key {
+	case "ignore":
+		opts.Ignore = append(opts.Ignore, splitCSV(val)...)
+	case "mode":
+		switch strings.ToLower(val) {
+		case "shape", "shape only":
+			opts.Shape = true
+		case "exact", "values":
+			opts.Shape = false
+		default:
+			fmt.Printf("⚠️  Warning: unknown snapshot mode %q (line %d); expected exact or shape\n", val, lineNum)
+		}
+	case "name":
+		opts.Name = val
+	default:
+		fmt.Printf("⚠️  Warning: unknown [Snapshot] option %q (line %d)\n", line, lineNum)
+	}
+}
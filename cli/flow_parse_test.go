@@ -270,3 +270,26 @@ POST /login
 		t.Fatalf("expected retry/retry-wait/on-fail parsed, got %+v", step)
 	}
 }
+
+func TestParseOnFail(t *testing.T) {
+	cases := []struct {
+		value  string
+		policy string
+		target string
+		ok     bool
+	}{
+		{"continue", "continue", "", true},
+		{"ABORT", "abort", "", true},
+		{"stop", "stop", "", true},
+		{"goto rollback", "goto", "rollback", true},
+		{"goto", "", "", false},
+		{"retry", "", "", false},
+		{"continue now", "", "", false},
+	}
+	for _, tc := range cases {
+		policy, target, ok := parseOnFail(tc.value)
+		if policy != tc.policy || target != tc.target || ok != tc.ok {
+			t.Errorf("parseOnFail(%q) = %q, %q, %v", tc.value, policy, target, ok)
+		}
+	}
+}
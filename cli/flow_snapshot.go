@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/kest-labs/kest/cli/internal/variable"
+)
+
+// snapshotPath returns where a step's snapshot lives: a __snapshots__
+// directory next to the flow file, one folder per flow.
+func snapshotPath(flowPath, name string) string {
+	flow := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(flowPath), ".md"), ".flow")
+	return filepath.Join(filepath.Dir(flowPath), "__snapshots__", sanitizeFilename(flow), sanitizeFilename(name)+".json")
+}
+
+// snapshotName names a step's snapshot. Each row of a data-driven step gets
+// its own snapshot, keyed by the row's values.
+func snapshotName(step FlowStep, iteration *dataRow) string {
+	name := step.ID
+	if step.Snapshot != nil && step.Snapshot.Name != "" {
+		name = step.Snapshot.Name
+	}
+	if iteration != nil {
+		for _, key := range iteration.Keys {
+			name += "." + key + "-" + iteration.Values[key]
+		}
+	}
+	return name
+}
+
+// checkSnapshot compares a response body with the stored snapshot. A missing
+// snapshot is created from the body; with --update-snapshots a mismatching
+// one is rewritten. save is false when the step already failed, so a broken
+// response never becomes the new baseline.
+func checkSnapshot(opts SnapshotOptions, path, body string, save bool) summary.AssertionResult {
+	check := summary.AssertionResult{
+		Expression: "snapshot",
+		Expected:   "matches " + path,
+	}
+	if opts.Shape {
+		check.Expression = "snapshot (shape)"
+	}
+
+	stored, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if !save {
+			check.Message = fmt.Sprintf("no snapshot at %s", path)
+			return check
+		}
+		if err := writeSnapshot(path, body); err != nil {
+			check.Message = err.Error()
+			return check
+		}
+		fmt.Printf("    📸 Snapshot saved: %s\n", path)
+		check.Passed = true
+		check.Actual = "saved"
+		return check
+	}
+	if err != nil {
+		check.Message = fmt.Sprintf("cannot read snapshot: %v", err)
+		return check
+	}
+
+	diff := snapshotDiff(opts, string(stored), body)
+	if len(diff) == 0 {
+		check.Passed = true
+		check.Actual = "matches"
+		return check
+	}
+
+	if save && runUpdateSnapshots {
+		if err := writeSnapshot(path, body); err != nil {
+			check.Message = err.Error()
+			return check
+		}
+		fmt.Printf("    📸 Snapshot updated: %s\n", path)
+		check.Passed = true
+		check.Actual = "updated"
+		return check
+	}
+
+	check.Actual = fmt.Sprintf("%d difference(s)", len(diff))
+	check.Message = fmt.Sprintf("response does not match snapshot %s (run with --update-snapshots to accept it)", path)
+	check.Diff = strings.Join(diff, "\n")
+	return check
+}
+
+// snapshotDiff compares two bodies after removing the ignored paths. Bodies
+// that are not JSON must match exactly.
+func snapshotDiff(opts SnapshotOptions, stored, body string) []string {
+	var expected, actual any
+	if json.Unmarshal([]byte(stored), &expected) != nil || json.Unmarshal([]byte(body), &actual) != nil {
+		if strings.TrimSpace(stored) == strings.TrimSpace(body) {
+			return nil
+		}
+		return []string{"~ /: body differs from snapshot"}
+	}
+
+	for _, path := range opts.Ignore {
+		segments := snapshotPathSegments(path)
+		expected = dropJSONPath(expected, segments)
+		actual = dropJSONPath(actual, segments)
+	}
+	if opts.Shape {
+		return variable.JSONShapeDiff(expected, actual)
+	}
+	return variable.JSONDiff(expected, actual)
+}
+
+func writeSnapshot(path, body string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(prettyJSONSnap(body)), 0644)
+}
+
+// snapshotPathSegments splits an ignore path such as body.items[*].id into
+// ["items", "*", "id"]. The leading "body" is optional.
+func snapshotPathSegments(path string) []string {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimSpace(path))
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	if len(segments) > 0 && (segments[0] == "body" || segments[0] == "$") {
+		segments = segments[1:]
+	}
+	return segments
+}
+
+// dropJSONPath removes the values at segments from a decoded JSON value. "*"
+// matches every key or element; a number selects one array element.
+func dropJSONPath(node any, segments []string) any {
+	if len(segments) == 0 {
+		return nil
+	}
+	segment, rest := segments[0], segments[1:]
+
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if segment != "*" && segment != key {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(n, key)
+			} else {
+				n[key] = dropJSONPath(value, rest)
+			}
+		}
+	case []any:
+		if segment == "*" {
+			if len(rest) == 0 {
+				return []any{}
+			}
+			for i := range n {
+				n[i] = dropJSONPath(n[i], rest)
+			}
+			return n
+		}
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= len(n) {
+			return n
+		}
+		if len(rest) == 0 {
+			return append(n[:i:i], n[i+1:]...)
+		}
+		n[i] = dropJSONPath(n[i], rest)
+	}
+	return node
+}
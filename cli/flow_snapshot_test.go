@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDropJSONPath(t *testing.T) {
+	var doc any
+	json.Unmarshal([]byte(`{"created_at":"now","items":[{"id":1,"sku":"a"},{"id":2,"sku":"b"}],"tags":["x","y"]}`), &doc) //nolint: errcheck
+
+	for _, path := range []string{"body.created_at", "body.items[*].id", "tags[1]"} {
+		doc = dropJSONPath(doc, snapshotPathSegments(path))
+	}
+	got, _ := json.Marshal(doc)
+	if string(got) != `{"items":[{"sku":"a"},{"sku":"b"}],"tags":["x"]}` {
+		t.Fatalf("unexpected result: %s", got)
+	}
+}
+
+func TestParseSnapshotSection(t *testing.T) {
+	doc, _ := ParseFlowDocument("```step\n@id users\nGET /users\n\n[Snapshot]\nignore: body.created_at, body.items[*].id\nshape only\nname: users-list\n```\n")
+	snap := doc.Steps[0].Snapshot
+	if snap == nil || !snap.Shape || snap.Name != "users-list" || strings.Join(snap.Ignore, "|") != "body.created_at|body.items[*].id" {
+		t.Fatalf("unexpected snapshot options: %+v", snap)
+	}
+}
+
+func TestFlowSnapshotLifecycle(t *testing.T) {
+	version := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch version {
+		case 1, 2:
+			// The request ID and timestamp change on every call.
+			fmt.Fprintf(w, `{"request_id":"r-%d","user":{"id":%d,"name":"ann"}}`, version, version)
+		case 3:
+			fmt.Fprint(w, `{"request_id":"r-3","user":{"id":"3","name":"ann"}}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	prevUpdate := runUpdateSnapshots
+	t.Cleanup(func() { runUpdateSnapshots = prevUpdate })
+
+	dir := t.TempDir()
+	flowPath := filepath.Join(dir, "users.flow.md")
+	run := func(section string) (bool, string) {
+		t.Helper()
+		t.Setenv("HOME", t.TempDir())
+		doc, _ := ParseFlowDocument("```step\n@id profile\nGET " + srv.URL + "\n\n[Snapshot]\n" + section + "\n```\n")
+		summ := runFlowDocument(doc, flowPath, NewRunContext(nil))
+		result := summ.Results[0]
+		last := result.Assertions[len(result.Assertions)-1]
+		return result.Success, last.Diff
+	}
+
+	runUpdateSnapshots = false
+	if ok, _ := run("ignore: body.request_id"); !ok {
+		t.Fatalf("first run should record the snapshot")
+	}
+	stored := filepath.Join(dir, "__snapshots__", "users", "profile.json")
+	if _, err := os.Stat(stored); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+
+	version = 2
+	if ok, diff := run("ignore: body.request_id"); ok || diff != "~ /user/id: 1 → 2" {
+		t.Fatalf("expected value mismatch, got ok=%v diff=%q", ok, diff)
+	}
+	if ok, _ := run("ignore: body.request_id\nmode: shape"); !ok {
+		t.Fatalf("shape mode should ignore changed values")
+	}
+
+	version = 3
+	if ok, diff := run("shape"); ok || diff != "~ /user/id: number → string" {
+		t.Fatalf("expected type mismatch, got ok=%v diff=%q", ok, diff)
+	}
+
+	runUpdateSnapshots = true
+	if ok, _ := run("shape"); !ok {
+		t.Fatalf("--update-snapshots should accept the new response")
+	}
+	content, _ := os.ReadFile(stored)
+	if !strings.Contains(string(content), `"id": "3"`) {
+		t.Fatalf("snapshot was not rewritten:\n%s", content)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+// tagFilter selects flows and steps by tag (kest run --tags/--skip-tags).
+// A step carries its flow's @tags plus its own. It is selected when it has
+// at least one of the --tags (or no --tags were given) and none of the
+// --skip-tags. Tags are compared case-insensitively.
+type tagFilter struct {
+	include []string
+	exclude []string
+}
+
+func newTagFilter(include, exclude []string) tagFilter {
+	return tagFilter{include: normalizeTags(include), exclude: normalizeTags(exclude)}
+}
+
+// runTagFilter builds the filter from the run command's flags.
+func runTagFilter() tagFilter {
+	return newTagFilter(runTags, runSkipTags)
+}
+
+func (f tagFilter) active() bool {
+	return len(f.include) > 0 || len(f.exclude) > 0
+}
+
+// skipReason explains why something carrying tags is filtered out, or
+// returns "" when it is selected. Setup and teardown steps support the
+// selected steps, so only --skip-tags applies to them (support = true).
+func (f tagFilter) skipReason(tags []string, support bool) string {
+	tags = normalizeTags(tags)
+	for _, tag := range f.exclude {
+		if containsTag(tags, tag) {
+			return fmt.Sprintf("tagged %s (--skip-tags)", tag)
+		}
+	}
+	if support || len(f.include) == 0 {
+		return ""
+	}
+	for _, tag := range f.include {
+		if containsTag(tags, tag) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("not tagged %s (--tags)", strings.Join(f.include, ", "))
+}
+
+// flowSkipReason explains why a whole flow is filtered out: its own @tags
+// are skipped, or none of its main steps is selected. It returns "" when
+// the flow should run.
+func flowSkipReason(doc FlowDoc, f tagFilter) string {
+	if !f.active() {
+		return ""
+	}
+	if reason := f.skipReason(doc.Meta.Tags, true); reason != "" {
+		return reason
+	}
+	for _, step := range doc.Steps {
+		if f.skipReason(stepTags(doc.Meta, step), false) == "" {
+			return ""
+		}
+	}
+	if reason := f.skipReason(doc.Meta.Tags, false); reason != "" {
+		return reason
+	}
+	return "no step selected by --tags/--skip-tags"
+}
+
+// skippedFileSummary reports a filtered-out flow or scenario as a single
+// skipped result.
+func skippedFileSummary(name, filePath string, tags []string, reason string) *summary.Summary {
+	fmt.Printf("\n⏭️  %s skipped (%s)\n", name, reason)
+	summ := summary.NewSummary()
+	summ.AddResult(summary.TestResult{
+		Name:       name,
+		URL:        filePath,
+		StartTime:  time.Now(),
+		Tags:       tags,
+		Skipped:    true,
+		SkipReason: reason,
+	})
+	return summ
+}
+
+// stepTags returns the tags a step carries: its flow's followed by its own.
+func stepTags(meta FlowMeta, step FlowStep) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, meta.Tags...), step.Tags...) {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, strings.TrimSpace(tag))
+	}
+	return tags
+}
+
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagFilterSkipReason(t *testing.T) {
+	filter := newTagFilter([]string{"Smoke", "auth"}, []string{"slow"})
+
+	cases := []struct {
+		tags    []string
+		support bool
+		want    string
+	}{
+		{tags: []string{"smoke"}, want: ""},
+		{tags: []string{"AUTH", "users"}, want: ""},
+		{tags: []string{"users"}, want: "not tagged smoke, auth (--tags)"},
+		{tags: nil, want: "not tagged smoke, auth (--tags)"},
+		{tags: []string{"smoke", "slow"}, want: "tagged slow (--skip-tags)"},
+		{tags: nil, support: true, want: ""},
+		{tags: []string{"slow"}, support: true, want: "tagged slow (--skip-tags)"},
+	}
+	for _, tc := range cases {
+		if got := filter.skipReason(tc.tags, tc.support); got != tc.want {
+			t.Errorf("skipReason(%v, %v) = %q, want %q", tc.tags, tc.support, got, tc.want)
+		}
+	}
+}
+
+func TestStepTagsMergesFlowAndStepTags(t *testing.T) {
+	got := stepTags(FlowMeta{Tags: []string{"auth", "smoke"}}, FlowStep{Tags: []string{"Smoke", "slow"}})
+	if strings.Join(got, ",") != "auth,smoke,slow" {
+		t.Fatalf("unexpected tags: %v", got)
+	}
+}
+
+func setRunTags(t *testing.T, include, exclude []string) {
+	t.Helper()
+	prevTags, prevSkip := runTags, runSkipTags
+	runTags, runSkipTags = include, exclude
+	t.Cleanup(func() { runTags, runSkipTags = prevTags, prevSkip })
+}
+
+func taggedExecBlock(kind, id, tags, command string) string {
+	return "```" + kind + "\n@id " + id + "\n@type exec\n@tags " + tags + "\n" + command + "\n```\n\n"
+}
+
+func TestRunFlowFiltersStepsByTag(t *testing.T) {
+	setRunTags(t, []string{"smoke"}, []string{"slow"})
+	content := execBlock("setup", "seed", "", "true") +
+		taggedExecBlock("step", "health", "smoke", "true") +
+		taggedExecBlock("step", "report", "regression", "true") +
+		taggedExecBlock("step", "export", "smoke, slow", "true") +
+		execBlock("teardown", "cleanup", "", "true")
+
+	t.Setenv("HOME", t.TempDir())
+	doc, _ := ParseFlowDocument(content)
+	summ := runFlowDocument(doc, "test.flow.md", NewRunContext(nil))
+
+	var got []string
+	for _, result := range summ.Results {
+		status := "pass"
+		if result.Skipped {
+			status = "skip"
+		} else if !result.Success {
+			status = "fail"
+		}
+		got = append(got, result.StepID+":"+status)
+	}
+	if strings.Join(got, ",") != "seed:pass,health:pass,report:skip,export:skip,cleanup:pass" {
+		t.Fatalf("unexpected results: %v", got)
+	}
+	if summ.Results[3].SkipReason != "tagged slow (--skip-tags)" {
+		t.Fatalf("unexpected skip reason: %q", summ.Results[3].SkipReason)
+	}
+	if strings.Join(summ.Results[1].Tags, ",") != "smoke" {
+		t.Fatalf("expected step tags on the result, got %v", summ.Results[1].Tags)
+	}
+}
+
+func TestRunFlowSkipsWholeFlowWithoutSelectedSteps(t *testing.T) {
+	setRunTags(t, []string{"smoke"}, nil)
+	content := "```flow\n@flow billing\n@name Billing\n@tags regression\n```\n\n" +
+		execBlock("setup", "seed", "", "true") +
+		execBlock("step", "invoice", "", "true")
+
+	t.Setenv("HOME", t.TempDir())
+	doc, _ := ParseFlowDocument(content)
+	summ := runFlowDocument(doc, "billing.flow.md", NewRunContext(nil))
+
+	if summ.TotalTests != 1 || summ.SkippedTests != 1 {
+		t.Fatalf("expected one skipped result for the flow, got %+v", summ.Results)
+	}
+	result := summ.Results[0]
+	if result.Name != "Billing" || result.SkipReason != "not tagged smoke (--tags)" || result.Tags[0] != "regression" {
+		t.Fatalf("unexpected skipped flow result: %+v", result)
+	}
+}
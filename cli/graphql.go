@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/graphql"
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/kest-labs/kest/cli/internal/variable"
+	"github.com/spf13/cobra"
+)
+
+// GraphQLOptions is the operation of a GraphQL request (kest gql, @type
+// graphql). Query, Variables and OperationName are interpolated, then sent
+// as the JSON body (or as query parameters for GET).
+type GraphQLOptions struct {
+	Query         string
+	Variables     string // JSON object
+	OperationName string
+	AllowErrors   bool // a response with an "errors" array does not fail the request
+}
+
+// request interpolates the operation and builds the GraphQL request.
+func (g *GraphQLOptions) request(vars map[string]string, strict bool) (graphql.Request, error) {
+	parts := []string{g.Query, g.Variables, g.OperationName}
+	for i, text := range parts {
+		if !strict {
+			parts[i] = variable.Interpolate(text, vars)
+			continue
+		}
+		resolved, err := variable.InterpolateStrict(text, vars)
+		if err != nil {
+			return graphql.Request{}, err
+		}
+		parts[i] = resolved
+	}
+	return graphql.NewRequest(parts[0], parts[1], parts[2])
+}
+
+// graphQLErrorsCheck fails when the response carries GraphQL errors, which
+// servers usually send with status 200.
+func graphQLErrorsCheck(body []byte) summary.AssertionResult {
+	check := summary.AssertionResult{Expression: "no GraphQL errors", Path: "errors", Passed: true}
+	if errs := graphql.Errors(body); len(errs) > 0 {
+		check.Passed = false
+		check.Actual = fmt.Sprintf("%d error(s)", len(errs))
+		check.Message = "GraphQL errors:\n  " + strings.Join(errs, "\n  ")
+	}
+	return check
+}
+
+// readGraphQLQuery returns the query in a .graphql/.gql file, or value
+// itself when it is an inline query.
+func readGraphQLQuery(value string) (string, error) {
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		value = path
+	} else if !strings.HasSuffix(value, ".graphql") && !strings.HasSuffix(value, ".gql") {
+		return value, nil
+	}
+	content, err := os.ReadFile(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to read query: %w", err)
+	}
+	return string(content), nil
+}
+
+var (
+	gqlQuery       string
+	gqlVariables   string
+	gqlOperation   string
+	gqlAllowErrors bool
+	gqlMethod      string
+	gqlHeaders     []string
+	gqlCaptures    []string
+	gqlAsserts     []string
+	gqlVerbose     bool
+	gqlNoRecord    bool
+	gqlAuth        string
+	gqlVars        []string
+	gqlSchemaOut   string
+)
+
+var gqlCmd = &cobra.Command{
+	Use:   "gql <url>",
+	Short: "Send a GraphQL query or mutation",
+	Long: `Send a GraphQL operation as a JSON POST (or as query parameters with -X GET).
+
+The query comes from a .graphql/.gql file, @path, or inline text. A response
+whose "errors" array is not empty fails like an assertion would, even with
+status 200; pass --allow-errors to accept partial results. Captures and
+assertions address the response body, so data.* paths work as is.`,
+	Example: `  # Query from a file with variables
+  kest gql /graphql -q users.graphql --variables '{"first": 10}'
+
+  # Inline query, capture and assert on data.*
+  kest gql /graphql -q '{ me { id name } }' -c "user_id=data.me.id" -a "data.me.name exists"
+
+  # Pick one operation out of a document
+  kest gql /graphql -q ops.graphql --operation-name CreateUser --variables @vars.json
+
+  # Dump the schema as SDL
+  kest gql schema /graphql -o schema.graphql`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gqlQuery == "" {
+			return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("a query is required: -q query.graphql or -q '{ ... }'")}
+		}
+		query, err := readGraphQLQuery(gqlQuery)
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: err}
+		}
+		variables := gqlVariables
+		if path, ok := strings.CutPrefix(variables, "@"); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("failed to read variables: %w", err)}
+			}
+			variables = string(content)
+		}
+
+		if len(gqlVars) > 0 {
+			ActiveRunCtx = NewRunContext(parseVarFlags(gqlVars))
+			defer func() { ActiveRunCtx = nil }()
+		}
+		_, err = ExecuteRequest(RequestOptions{
+			Method:   gqlMethod,
+			URL:      args[0],
+			Headers:  gqlHeaders,
+			Captures: gqlCaptures,
+			Asserts:  gqlAsserts,
+			Verbose:  gqlVerbose,
+			NoRecord: gqlNoRecord,
+			Auth:     gqlAuth,
+			GraphQL: &GraphQLOptions{
+				Query:         query,
+				Variables:     variables,
+				OperationName: gqlOperation,
+				AllowErrors:   gqlAllowErrors,
+			},
+		})
+		return err
+	},
+}
+
+var gqlSchemaCmd = &cobra.Command{
+	Use:   "schema <url>",
+	Short: "Print a GraphQL server's schema as SDL using introspection",
+	Example: `  kest gql schema https://api.example.com/graphql
+  kest gql schema /graphql -H "Authorization: Bearer {{token}}" -o schema.graphql`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(gqlVars) > 0 {
+			ActiveRunCtx = NewRunContext(parseVarFlags(gqlVars))
+			defer func() { ActiveRunCtx = nil }()
+		}
+		// The request output would get mixed into the SDL on stdout.
+		restore := suppressStdout()
+		res, err := ExecuteRequest(RequestOptions{
+			URL:          args[0],
+			Headers:      gqlHeaders,
+			Auth:         gqlAuth,
+			NoRecord:     true,
+			SilentOutput: true,
+			GraphQL:      &GraphQLOptions{Query: graphql.IntrospectionQuery, AllowErrors: true},
+		})
+		restore()
+		if err != nil {
+			return err
+		}
+		if res.Status >= 400 {
+			return &ExitError{Code: ExitRuntimeError, Err: fmt.Errorf("introspection request failed with status %d", res.Status)}
+		}
+
+		sdl, err := graphql.SDL([]byte(res.ResponseBody))
+		if err != nil {
+			return &ExitError{Code: ExitRuntimeError, Err: err}
+		}
+		if gqlSchemaOut == "" {
+			fmt.Print(sdl)
+			return nil
+		}
+		if err := os.WriteFile(gqlSchemaOut, []byte(sdl), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Schema written to %s\n", gqlSchemaOut)
+		return nil
+	},
+}
+
+func init() {
+	gqlCmd.Flags().StringVarP(&gqlQuery, "query", "q", "", "GraphQL query: a .graphql/.gql file, @path or inline text")
+	gqlCmd.Flags().StringVar(&gqlVariables, "variables", "", "Variables as a JSON object, or @path to a JSON file")
+	gqlCmd.Flags().StringVar(&gqlOperation, "operation-name", "", "Operation to run when the query defines several")
+	gqlCmd.Flags().BoolVar(&gqlAllowErrors, "allow-errors", false, "Do not fail when the response has GraphQL errors")
+	gqlCmd.Flags().StringVarP(&gqlMethod, "method", "X", "POST", "HTTP method: POST (JSON body) or GET (query parameters)")
+	gqlCmd.Flags().StringSliceVarP(&gqlCaptures, "capture", "c", []string{}, "Capture values from response (e.g. user_id=data.me.id)")
+	gqlCmd.Flags().StringSliceVarP(&gqlAsserts, "assert", "a", []string{}, "Assert response (e.g. data.me.name exists)")
+	gqlCmd.Flags().BoolVarP(&gqlVerbose, "verbose", "v", false, "Show detailed request/response info")
+	gqlCmd.Flags().BoolVar(&gqlNoRecord, "no-record", false, "Do not record this request")
+	gqlSchemaCmd.Flags().StringVarP(&gqlSchemaOut, "output", "o", "", "Write the SDL to a file instead of stdout")
+
+	for _, cmd := range []*cobra.Command{gqlCmd, gqlSchemaCmd} {
+		cmd.Flags().StringArrayVarP(&gqlHeaders, "header", "H", []string{}, "Request headers")
+		cmd.Flags().StringVar(&gqlAuth, "auth", "", `Authenticate the request, e.g. "bearer {{token}}" (see kest get --help)`)
+		cmd.Flags().StringArrayVar(&gqlVars, "var", []string{}, "Set variables (e.g. --var key=value)")
+	}
+	gqlCmd.AddCommand(gqlSchemaCmd)
+	rootCmd.AddCommand(gqlCmd)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newGraphQLServer answers "me" with a user, "broken" with a GraphQL error
+// and status 200, and echoes the variables of anything else.
+func newGraphQLServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query         string         `json:"query"`
+			Variables     map[string]any `json:"variables"`
+			OperationName string         `json:"operationName"`
+		}
+		if r.Method == http.MethodGet {
+			req.Query = r.URL.Query().Get("query")
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var resp any
+		switch {
+		case strings.Contains(req.Query, "broken"):
+			resp = map[string]any{"data": map[string]any{"broken": nil}, "errors": []any{map[string]any{"message": "boom", "path": []any{"broken"}}}}
+		case strings.Contains(req.Query, "me"):
+			resp = map[string]any{"data": map[string]any{"me": map[string]any{"id": "u-1", "name": "Ada"}}}
+		default:
+			resp = map[string]any{"data": map[string]any{"echo": req.Variables, "operation": req.OperationName}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGraphQLFlowSteps(t *testing.T) {
+	server := newGraphQLServer(t)
+	content := "```step\n@id me\n@type graphql\n" + server.URL + "\n\n[Query]\nquery Me {\n  me { id name }\n}\n\n[Captures]\nuser_id = data.me.id\n\n[Asserts]\ndata.me.name == Ada\n```\n\n" +
+		"```step\n@id echo\n@type graphql\nPOST " + server.URL + "\n[Query]\nquery Echo($id: ID!) { echo(id: $id) }\n[Variables]\n{\"id\": \"{{user_id}}\"}\n[Operation]\nEcho\n[Asserts]\ndata.echo.id == u-1\ndata.operation == Echo\n```\n\n" +
+		"```step\n@id broken\n@type graphql\n@on-fail continue\n" + server.URL + "\n[Query]\n{ broken }\n```\n\n" +
+		"```step\n@id partial\n@type graphql\n@allow-errors\n" + server.URL + "\n[Query]\n{ broken }\n[Asserts]\nerrors.0.message == boom\n```\n\n" +
+		"```step\n@id get\n@type graphql\nGET " + server.URL + "\n\n{ me { id } }\n[Asserts]\ndata.me.id == u-1\n```\n"
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "me:pass,echo:pass,broken:fail,partial:pass,get:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestGraphQLErrorsFailRequest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := newGraphQLServer(t)
+
+	res, err := ExecuteRequest(RequestOptions{
+		URL:          server.URL,
+		SilentOutput: true,
+		GraphQL:      &GraphQLOptions{Query: "{ broken }"},
+	})
+	if err == nil || res.Status != 200 || res.FailedAssertion != "no GraphQL errors" {
+		t.Fatalf("a 200 with errors should fail: status %d, assertion %q, err %v", res.Status, res.FailedAssertion, err)
+	}
+	if len(res.Assertions) != 1 || !strings.Contains(res.Assertions[0].Message, "boom (at broken)") {
+		t.Fatalf("unexpected assertions: %+v", res.Assertions)
+	}
+
+	if _, err := ExecuteRequest(RequestOptions{URL: server.URL, SilentOutput: true, GraphQL: &GraphQLOptions{Query: "{ me { id } }", Variables: "[1]"}}); err == nil {
+		t.Fatal("invalid variables should be rejected")
+	}
+}
@@ -1,106 +1,437 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/kest-labs/kest/cli/internal/auth"
 	"github.com/kest-labs/kest/cli/internal/client"
 	"github.com/kest-labs/kest/cli/internal/logger"
 	"github.com/kest-labs/kest/cli/internal/output"
 	"github.com/kest-labs/kest/cli/internal/platformsync"
+	"github.com/kest-labs/kest/cli/internal/secret"
 	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/kest-labs/kest/cli/internal/variable"
 	"github.com/spf13/cobra"
 )
 
+// GRPCOptions is the connection side of a gRPC call (kest grpc, @type
+// grpc). The method goes in RequestOptions.URL, the JSON request in Data
+// and the metadata in Headers.
+type GRPCOptions struct {
+	Address     string
+	ProtoPath   string // descriptors come from server reflection when empty
+	TLS         bool
+	CertFile    string
+	AllowErrors bool // a status other than OK does not fail the call
+}
+
+// grpcStatusCheck fails calls that did not end with status OK.
+func grpcStatusCheck(resp *client.GRPCResponse) summary.AssertionResult {
+	check := summary.AssertionResult{Expression: "gRPC status OK", Path: "grpc-status", Passed: resp.Code == 0, Actual: resp.Status()}
+	if !check.Passed {
+		check.Message = fmt.Sprintf("gRPC status %s (%d): %s", resp.Status(), resp.Code, resp.Message)
+	}
+	return check
+}
+
+// executeGRPCRequest is ExecuteRequest for gRPC calls: interpolation,
+// captures, assertions and history work the same way. The response body is
+// the JSON-rendered response (an array of them for streaming responses),
+// status is the HTTP equivalent of the gRPC code, and the response headers
+// are the metadata, including grpc-status.
+func executeGRPCRequest(opts RequestOptions) (summary.TestResult, error) {
+	startTime := time.Now()
+	result := summary.TestResult{StartTime: startTime, Method: "GRPC", URL: opts.URL}
+	rc := opts.RunCtx
+	if rc == nil {
+		rc = ActiveRunCtx
+	}
+	if rc != nil && rc.NoRecord {
+		opts.NoRecord = true
+	}
+	fail := func(code int, err error) (summary.TestResult, error) {
+		result.Error = err
+		result.Success = false
+		return result, &ExitError{Code: code, Err: err}
+	}
+
+	conf := loadRunConfig(rc)
+	store, releaseStore := openRunStore(rc)
+	defer releaseStore()
+	vars := buildVarChain(rc, store)
+	secret.TrackAll(vars)
+
+	resolve := func(text string) (string, error) {
+		if opts.StrictVars {
+			return variable.InterpolateStrict(text, vars)
+		}
+		return variable.Interpolate(text, vars), nil
+	}
+	fields := []string{opts.GRPC.Address, opts.URL, opts.Data, opts.GRPC.ProtoPath}
+	for i, text := range fields {
+		resolved, err := resolve(text)
+		if err != nil {
+			return fail(ExitRuntimeError, err)
+		}
+		fields[i] = resolved
+	}
+	address, method, data, protoPath := fields[0], fields[1], fields[2], fields[3]
+	if path, ok := strings.CutPrefix(data, "@"); ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fail(ExitRuntimeError, err)
+		}
+		data = string(content)
+	}
+	metadata := make(map[string]string)
+	for k, v := range opts.DefaultHeaders {
+		value, err := resolve(v)
+		if err != nil {
+			return fail(ExitRuntimeError, err)
+		}
+		metadata[strings.ToLower(strings.TrimSpace(k))] = value
+	}
+	for _, h := range opts.Headers {
+		header, err := resolve(h)
+		if err != nil {
+			return fail(ExitRuntimeError, err)
+		}
+		if k, v, ok := strings.Cut(header, ":"); ok {
+			metadata[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+	if strings.TrimSpace(opts.Auth) != "" {
+		if err := applyGRPCAuth(opts.Auth, resolve, metadata, store); err != nil {
+			return fail(ExitConfigError, err)
+		}
+	}
+	result.URL = address + "/" + method
+	result.RequestHeaders = cloneStringMap(metadata)
+	result.RequestBody = data
+
+	timeout := 30 * time.Second
+	if opts.TimeoutMs > 0 {
+		timeout = time.Duration(opts.TimeoutMs) * time.Millisecond
+	}
+	var resp *client.GRPCResponse
+	var err error
+	for attempt := 0; attempt <= max(opts.Retry, 0); attempt++ {
+		if attempt > 0 {
+			fmt.Printf("⏱️  Retry attempt %d/%d (waiting %dms)...\n", attempt, opts.Retry, opts.RetryWait)
+			time.Sleep(time.Duration(opts.RetryWait) * time.Millisecond)
+		}
+		resp, err = client.ExecuteGRPC(client.GRPCOptions{
+			Address:   address,
+			Method:    method,
+			Data:      data,
+			ProtoPath: protoPath,
+			Metadata:  metadata,
+			Timeout:   timeout,
+			TLS:       opts.GRPC.TLS,
+			CertFile:  opts.GRPC.CertFile,
+		})
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		fmt.Printf("❌ gRPC Request Failed: %v\n", err)
+		return fail(ExitRuntimeError, err)
+	}
+
+	responseHeaders := http.Header{}
+	for k, values := range resp.Metadata {
+		for _, v := range values {
+			responseHeaders.Add(k, v)
+		}
+	}
+	result.Status = resp.HTTPStatus()
+	result.Duration = resp.Duration
+	result.ResponseHeaders = responseHeaders
+	result.ResponseBody = string(resp.Data)
+
+	captured := resolveCaptures(opts.Captures, result)
+	if rc == nil || !rc.NoRecord {
+		logger.LogRequest("GRPC", result.URL, metadata, data, result.Status, responseHeaders, result.ResponseBody, resp.Duration)
+	}
+	if opts.Verbose {
+		fmt.Printf("\n--- Debug Info ---\n")
+		fmt.Printf("Endpoint: %s\n", secret.Mask(address))
+		fmt.Printf("Method: %s\n", method)
+		for k, v := range metadata {
+			fmt.Printf("  %s: %s\n", k, secret.Mask(v))
+		}
+		fmt.Printf("Data: %s\n", secret.Mask(data))
+		fmt.Printf("\nStatus: %s\n", resp.Status())
+		fmt.Printf("Response Metadata:\n")
+		for k, v := range secret.MaskHeaders(responseHeaders) {
+			fmt.Printf("  %s: %s\n", k, v)
+		}
+	}
+	applyCaptures(&result, captured, store, conf, rc == nil || !rc.NoRecord)
+
+	var checks []summary.AssertionResult
+	if !opts.GRPC.AllowErrors {
+		checks = append(checks, grpcStatusCheck(resp))
+	}
+	assertResponse := assertionResponse(result)
+	assertResponse.BaseDir = opts.BaseDir
+	for _, assertion := range opts.Asserts {
+		checks = append(checks, variable.EvaluateAssertion(assertResponse, vars, assertion))
+	}
+	if err := reportChecks(&result, checks, resp.Data); err != nil {
+		return result, err
+	}
+	reportSoftAsserts(&result, assertResponse, vars, opts.SoftAsserts)
+
+	var recordID int64
+	if !opts.NoRecord && store != nil {
+		headerJSON, _ := json.Marshal(metadata)
+		respHeaderJSON, _ := json.Marshal(responseHeaders)
+		record := &storage.Record{
+			Method:          "GRPC",
+			URL:             result.URL,
+			Path:            "/" + method,
+			RequestHeaders:  headerJSON,
+			RequestBody:     data,
+			ResponseStatus:  result.Status,
+			ResponseHeaders: respHeaderJSON,
+			ResponseBody:    result.ResponseBody,
+			DurationMs:      resp.Duration.Milliseconds(),
+			Environment:     conf.ActiveEnv,
+			Project:         conf.ProjectID,
+			CreatedAt:       startTime.UTC(),
+		}
+		recordID, _ = store.SaveRecord(record)
+		record.ID = recordID
+		if recordID > 0 && !opts.SkipHistorySync {
+			if err := platformsync.QueueRequestHistory(conf, store, record, "grpc"); err != nil {
+				logger.LogToSession("history auto-sync enqueue failed for gRPC record %d: %v", recordID, err)
+			} else {
+				platformsync.MaybeFlushHistoryOutbox(conf, store, 5)
+			}
+		}
+	}
+
+	result.Success = true
+	result.RecordID = recordID
+	if !opts.SilentOutput {
+		output.PrintResponse("gRPC", result.URL, result.Status, resp.Duration.String(), resp.Data, recordID, startTime)
+	}
+	return result, nil
+}
+
+// applyGRPCAuth adds the metadata of an --auth / @auth spec. Only schemes
+// that set a header apply; request signing needs an HTTP request.
+func applyGRPCAuth(spec string, resolve func(string) (string, error), metadata map[string]string, store *storage.Store) error {
+	var resolveErr error
+	config, err := auth.Parse(spec, func(value string) string {
+		resolved, err := resolve(value)
+		if err != nil && resolveErr == nil {
+			resolveErr = err
+		}
+		return resolved
+	})
+	if err == nil {
+		err = resolveErr
+	}
+	if err == nil && (config.Type == "hmac" || config.Type == "aws-sigv4" || (config.Type == "api-key" && config.APIKey.In == "query")) {
+		err = fmt.Errorf("%s auth is not supported for gRPC calls", config.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid auth: %w", err)
+	}
+
+	var tokens auth.TokenStore
+	if store != nil {
+		tokens = store
+	}
+	headers := make(map[string]string)
+	if err := config.Apply(&auth.Request{Method: http.MethodPost, URL: &url.URL{}, Headers: headers}, tokens); err != nil {
+		return fmt.Errorf("auth failed: %w", err)
+	}
+	for k, v := range headers {
+		metadata[strings.ToLower(k)] = v
+	}
+	return nil
+}
+
 var (
-	grpcData      string
-	grpcProtoPath string
-	grpcTimeout   int
-	grpcVerbose   bool
-	grpcTLS       bool
-	grpcCertFile  string
+	grpcAuth        string
+	grpcData        string
+	grpcProtoPath   string
+	grpcTimeout     int
+	grpcVerbose     bool
+	grpcTLS         bool
+	grpcCertFile    string
+	grpcHeaders     []string
+	grpcCaptures    []string
+	grpcAsserts     []string
+	grpcAllowErrors bool
+	grpcNoRecord    bool
+	grpcVars        []string
 )
 
 var grpcCmd = &cobra.Command{
 	Use:   "grpc [address] [service/method]",
 	Short: "Send a gRPC request",
-	Args:  cobra.ExactArgs(2),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		addr := args[0]
-		method := args[1]
+	Long: `Call a gRPC method with a JSON request. Descriptors come from the --proto file
+or, without one, from the server's reflection service.
 
-		opts := client.GRPCOptions{
-			Address:   addr,
-			Method:    method,
+Unary, client, server and bidi streaming methods are supported. For client
+and bidi streaming, -d takes a JSON array or several objects one after
+another. Server and bidi streaming responses are a JSON array of messages.
+
+A status other than OK fails the call unless --allow-errors is given. The
+gRPC status is available as header.grpc-status (e.g. 5 for NOT_FOUND) and
+status is its HTTP equivalent (404).`,
+	Example: `  # Unary call using server reflection
+  kest grpc localhost:50051 users.v1.UserService/GetUser -d '{"id": "42"}' -a "name == Ada"
+
+  # With a proto file and metadata
+  kest grpc api.example.com:443 users.v1.UserService/GetUser --tls -p users.proto -H "authorization: Bearer {{token}}"
+
+  # Client streaming
+  kest grpc localhost:50051 metrics.v1.Metrics/Upload -d '[{"value": 1}, {"value": 2}]'
+
+  # Explore a server
+  kest grpc list localhost:50051
+  kest grpc describe localhost:50051 users.v1.UserService`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(grpcVars) > 0 {
+			ActiveRunCtx = NewRunContext(parseVarFlags(grpcVars))
+			defer func() { ActiveRunCtx = nil }()
+		}
+		_, err := ExecuteRequest(RequestOptions{
+			URL:       args[1],
 			Data:      grpcData,
-			ProtoPath: grpcProtoPath,
-			Timeout:   time.Duration(grpcTimeout) * time.Second,
-			TLS:       grpcTLS,
-			CertFile:  grpcCertFile,
+			Headers:   grpcHeaders,
+			Captures:  grpcCaptures,
+			Asserts:   grpcAsserts,
+			Verbose:   grpcVerbose,
+			NoRecord:  grpcNoRecord,
+			Auth:      grpcAuth,
+			TimeoutMs: grpcTimeout * 1000,
+			GRPC: &GRPCOptions{
+				Address:     args[0],
+				ProtoPath:   grpcProtoPath,
+				TLS:         grpcTLS,
+				CertFile:    grpcCertFile,
+				AllowErrors: grpcAllowErrors,
+			},
+		})
+		return err
+	},
+}
+
+// grpcExploreOptions are the connection options of kest grpc list/describe.
+func grpcExploreOptions(address string) client.GRPCOptions {
+	metadata := make(map[string]string)
+	for _, h := range grpcHeaders {
+		if k, v, ok := strings.Cut(h, ":"); ok {
+			metadata[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
 		}
+	}
+	return client.GRPCOptions{
+		Address:   address,
+		ProtoPath: grpcProtoPath,
+		Metadata:  metadata,
+		Timeout:   time.Duration(grpcTimeout) * time.Second,
+		TLS:       grpcTLS,
+		CertFile:  grpcCertFile,
+	}
+}
 
-		resp, err := client.ExecuteGRPC(opts)
+var grpcListCmd = &cobra.Command{
+	Use:   "list <address> [service]",
+	Short: "List the services of a gRPC server, or the methods of one service",
+	Example: `  kest grpc list localhost:50051
+  kest grpc list localhost:50051 users.v1.UserService
+  kest grpc list -p users.proto`,
+	Args:         cobra.RangeArgs(0, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, target, err := grpcExploreArgs(args)
 		if err != nil {
-			fmt.Printf("❌ gRPC Request Failed: %v\n", err)
 			return err
 		}
-
-		// Logging
-		logger.LogRequest("GRPC", addr+"/"+method, nil, grpcData, 200, nil, string(resp.Data), resp.Duration)
-
-		// Save to history database
-		conf := loadConfigWarn()
-		store, storeErr := storage.NewStore()
-		if storeErr == nil {
-			defer store.Close()
-			projectID := ""
-			env := "default"
-			if conf != nil {
-				projectID = conf.ProjectID
-				env = conf.ActiveEnv
-			}
-
-			record := &storage.Record{
-				Method:         "GRPC",
-				URL:            addr + "/" + method,
-				RequestBody:    grpcData,
-				ResponseBody:   string(resp.Data),
-				ResponseStatus: 200,
-				DurationMs:     resp.Duration.Milliseconds(),
-				Project:        projectID,
-				Environment:    env,
-				CreatedAt:      time.Now().UTC(),
-			}
-			recordID, _ := store.SaveRecord(record)
-			record.ID = recordID
-			if recordID > 0 {
-				if err := platformsync.QueueRequestHistory(conf, store, record, "grpc"); err != nil {
-					logger.LogToSession("history auto-sync enqueue failed for gRPC record %d: %v", recordID, err)
-				} else {
-					platformsync.MaybeFlushHistoryOutbox(conf, store, 5)
-				}
-			}
+		names, err := client.ListGRPC(grpcExploreOptions(address), target)
+		if err != nil {
+			return &ExitError{Code: ExitRuntimeError, Err: err}
 		}
-
-		if grpcVerbose {
-			fmt.Printf("\n--- Debug Info ---\n")
-			fmt.Printf("Endpoint: %s\n", addr)
-			fmt.Printf("Method: %s\n", method)
-			fmt.Printf("Data: %s\n", grpcData)
+		for _, name := range names {
+			fmt.Println(name)
 		}
+		return nil
+	},
+}
 
-		// Use the same formatter as REST for now, or a specific one if needed
-		output.PrintResponse("gRPC", method, 200, resp.Duration.String(), resp.Data, 0, time.Now())
+var grpcDescribeCmd = &cobra.Command{
+	Use:   "describe <address> [service|method|message]",
+	Short: "Print gRPC services, methods or messages as .proto source",
+	Example: `  kest grpc describe localhost:50051
+  kest grpc describe localhost:50051 users.v1.UserService.GetUser
+  kest grpc describe -p users.proto users.v1.User`,
+	Args:         cobra.RangeArgs(0, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		address, target, err := grpcExploreArgs(args)
+		if err != nil {
+			return err
+		}
+		text, err := client.DescribeGRPC(grpcExploreOptions(address), target)
+		if err != nil {
+			return &ExitError{Code: ExitRuntimeError, Err: err}
+		}
+		fmt.Print(text)
 		return nil
 	},
 }
 
+// grpcExploreArgs splits list/describe arguments: the address is optional
+// with --proto, where the only argument is the symbol.
+func grpcExploreArgs(args []string) (address, target string, err error) {
+	if grpcProtoPath != "" && len(args) < 2 {
+		if len(args) == 1 {
+			target = args[0]
+		}
+		return "", target, nil
+	}
+	if len(args) == 0 {
+		return "", "", &ExitError{Code: ExitConfigError, Err: fmt.Errorf("a server address (or --proto) is required")}
+	}
+	if len(args) == 2 {
+		target = args[1]
+	}
+	return args[0], target, nil
+}
+
 func init() {
-	grpcCmd.Flags().StringVarP(&grpcData, "data", "d", "{}", "JSON data for the gRPC request")
-	grpcCmd.Flags().StringVarP(&grpcProtoPath, "proto", "p", "", "Path to .proto file")
-	grpcCmd.Flags().IntVarP(&grpcTimeout, "timeout", "t", 10, "Timeout in seconds")
+	grpcCmd.Flags().StringVarP(&grpcData, "data", "d", "", "JSON request; a JSON array or several objects for client and bidi streaming")
+	grpcCmd.Flags().StringSliceVarP(&grpcCaptures, "capture", "c", []string{}, "Capture values from the response (e.g. user_id=id)")
+	grpcCmd.Flags().StringSliceVarP(&grpcAsserts, "assert", "a", []string{}, "Assert the response (e.g. name == Ada, header.grpc-status == 0)")
+	grpcCmd.Flags().BoolVar(&grpcAllowErrors, "allow-errors", false, "Do not fail when the status is not OK")
 	grpcCmd.Flags().BoolVarP(&grpcVerbose, "verbose", "v", false, "Show detailed debug info")
-	grpcCmd.Flags().BoolVar(&grpcTLS, "tls", false, "Use TLS for the connection")
-	grpcCmd.Flags().StringVar(&grpcCertFile, "cert", "", "Path to CA certificate file (for custom CAs)")
+	grpcCmd.Flags().BoolVar(&grpcNoRecord, "no-record", false, "Do not record this request")
+	grpcCmd.Flags().StringVar(&grpcAuth, "auth", "", `Authenticate the call, e.g. "bearer {{token}}" (basic, bearer, api-key and oauth2 schemes)`)
+	grpcCmd.Flags().StringArrayVar(&grpcVars, "var", []string{}, "Set variables (e.g. --var key=value)")
+
+	for _, cmd := range []*cobra.Command{grpcCmd, grpcListCmd, grpcDescribeCmd} {
+		cmd.Flags().StringVarP(&grpcProtoPath, "proto", "p", "", "Path to .proto file (default: use server reflection)")
+		cmd.Flags().IntVarP(&grpcTimeout, "timeout", "t", 10, "Timeout in seconds")
+		cmd.Flags().BoolVar(&grpcTLS, "tls", false, "Use TLS for the connection")
+		cmd.Flags().StringVar(&grpcCertFile, "cert", "", "Path to CA certificate file (for custom CAs)")
+		cmd.Flags().StringArrayVarP(&grpcHeaders, "header", "H", []string{}, "Request metadata (e.g. -H \"authorization: Bearer TOKEN\")")
+	}
+	grpcCmd.AddCommand(grpcListCmd, grpcDescribeCmd)
 	rootCmd.AddCommand(grpcCmd)
 }
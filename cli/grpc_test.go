@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/kest-labs/kest/cli/internal/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const usersProto = `syntax = "proto3";
+
+package kest.test.v1;
+
+// Users is a small service with one method of each kind.
+service Users {
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc ListUsers(ListUsersRequest) returns (stream User);
+  rpc CreateUsers(stream User) returns (CreateUsersResponse);
+  rpc Shout(stream Message) returns (stream Message);
+}
+
+message GetUserRequest { string id = 1; }
+message ListUsersRequest { int32 limit = 1; }
+message User {
+  string id = 1;
+  string name = 2;
+  bool admin = 3;
+}
+message CreateUsersResponse {
+  int32 created = 1;
+  repeated string ids = 2;
+}
+message Message { string text = 1; }
+`
+
+// newGRPCServer serves the Users service with server reflection and
+// returns its address and the path of its .proto file. GetUser needs
+// "authorization: Bearer t0k".
+func newGRPCServer(t *testing.T) (string, string) {
+	t.Helper()
+	protoPath := filepath.Join(t.TempDir(), "users.proto")
+	if err := os.WriteFile(protoPath, []byte(usersProto), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fds, err := (&protoparse.Parser{ImportPaths: []string{filepath.Dir(protoPath)}}).ParseFiles("users.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := fds[0]
+	msg := func(name string) *dynamic.Message {
+		return dynamic.NewMessage(fd.FindMessage("kest.test.v1." + name))
+	}
+	user := func(id, name string) *dynamic.Message {
+		u := msg("User")
+		u.SetFieldByName("id", id)
+		u.SetFieldByName("name", name)
+		return u
+	}
+
+	service := grpc.ServiceDesc{
+		ServiceName: "kest.test.v1.Users",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "GetUser",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := msg("GetUserRequest")
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				md, _ := metadata.FromIncomingContext(ctx)
+				if auth := md.Get("authorization"); len(auth) == 0 || auth[0] != "Bearer t0k" {
+					return nil, status.Error(codes.Unauthenticated, "missing token")
+				}
+				id := req.GetFieldByName("id").(string)
+				if id == "missing" {
+					return nil, status.Errorf(codes.NotFound, "user %s not found", id)
+				}
+				grpc.SetHeader(ctx, metadata.Pairs("x-request-id", "req-1"))
+				return user(id, "Ada"), nil
+			},
+		}},
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    "ListUsers",
+				ServerStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					req := msg("ListUsersRequest")
+					if err := stream.RecvMsg(req); err != nil {
+						return err
+					}
+					for i := int32(1); i <= req.GetFieldByName("limit").(int32); i++ {
+						if err := stream.SendMsg(user(string(rune('a'+i-1)), "user")); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+			{
+				StreamName:    "CreateUsers",
+				ClientStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					resp := msg("CreateUsersResponse")
+					for {
+						u := msg("User")
+						if err := stream.RecvMsg(u); err == io.EOF {
+							break
+						} else if err != nil {
+							return err
+						}
+						resp.AddRepeatedFieldByName("ids", u.GetFieldByName("id"))
+					}
+					resp.SetFieldByName("created", int32(len(resp.GetFieldByName("ids").([]any))))
+					return stream.SendMsg(resp)
+				},
+			},
+			{
+				StreamName:    "Shout",
+				ServerStreams: true,
+				ClientStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					for {
+						in := msg("Message")
+						if err := stream.RecvMsg(in); err == io.EOF {
+							return nil
+						} else if err != nil {
+							return err
+						}
+						out := msg("Message")
+						out.SetFieldByName("text", strings.ToUpper(in.GetFieldByName("text").(string)))
+						if err := stream.SendMsg(out); err != nil {
+							return err
+						}
+					}
+				},
+			},
+		},
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&service, struct{}{})
+	files := new(protoregistry.Files)
+	if err := files.RegisterFile(fd.UnwrapFile()); err != nil {
+		t.Fatal(err)
+	}
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files,
+	}))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String(), protoPath
+}
+
+func TestGRPCFlowSteps(t *testing.T) {
+	addr, protoPath := newGRPCServer(t)
+	content := "```step\n@id get\n@type grpc\n" + addr + " kest.test.v1.Users/GetUser\nauthorization: Bearer t0k\n\n{\"id\": \"u1\"}\n\n[Captures]\nuser_name = name\n\n[Asserts]\nname == Ada\nadmin == false\nheader.x-request-id == req-1\nheader.grpc-status == 0\nstatus == 200\n```\n\n" +
+		"```step\n@id missing\n@type grpc\n@allow-errors\n" + addr + " kest.test.v1.Users/GetUser\nauthorization: Bearer t0k\n\n{\"id\": \"missing\"}\n[Asserts]\nheader.grpc-status == 5\nstatus == 404\ncode == 5\n```\n\n" +
+		"```step\n@id unauthenticated\n@type grpc\n@on-fail continue\n" + addr + " kest.test.v1.Users.GetUser\n\n{\"id\": \"u1\"}\n```\n\n" +
+		"```step\n@id list\n@type grpc\n@proto " + protoPath + "\n" + addr + " kest.test.v1.Users/ListUsers\n\n{\"limit\": 3}\n[Asserts]\nbody.# == 3\nbody.2.id == c\n```\n\n" +
+		"```step\n@id create\n@type grpc\n" + addr + " kest.test.v1.Users/CreateUsers\n\n[{\"id\": \"a\"}, {\"id\": \"b\"}]\n[Asserts]\ncreated == 2\nids.1 == b\n```\n\n" +
+		"```step\n@id shout\n@type grpc\n" + addr + " kest.test.v1.Users/Shout\n\n{\"text\": \"hi\"}\n{\"text\": \"{{user_name}}\"}\n[Asserts]\nbody.# == 2\nbody.1.text == ADA\n```\n"
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "get:pass,missing:pass,unauthenticated:fail,list:pass,create:pass,shout:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestGRPCListAndDescribe(t *testing.T) {
+	addr, protoPath := newGRPCServer(t)
+	opts := client.GRPCOptions{Address: addr, Timeout: 5 * time.Second}
+
+	services, err := client.ListGRPC(opts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(services, ",") != "grpc.reflection.v1.ServerReflection,kest.test.v1.Users" {
+		t.Fatalf("unexpected services: %v", services)
+	}
+	methods, err := client.ListGRPC(opts, "kest.test.v1.Users")
+	if err != nil || len(methods) != 4 || methods[0] != "kest.test.v1.Users.GetUser" {
+		t.Fatalf("unexpected methods: %v, %v", methods, err)
+	}
+
+	text, err := client.DescribeGRPC(opts, "kest.test.v1.Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"service Users {", "rpc ListUsers ( ListUsersRequest ) returns ( stream User );"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("describe output lacks %q:\n%s", want, text)
+		}
+	}
+	if text, err := client.DescribeGRPC(client.GRPCOptions{ProtoPath: protoPath, Timeout: time.Second}, "kest.test.v1.User"); err != nil || !strings.Contains(text, "bool admin = 3;") {
+		t.Fatalf("describe from a proto file: %v\n%s", err, text)
+	}
+	if _, err := client.DescribeGRPC(opts, "kest.test.v1.Nope"); err == nil || !strings.Contains(err.Error(), "symbol not found") {
+		t.Fatalf("expected symbol not found, got %v", err)
+	}
+}
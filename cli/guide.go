@@ -126,6 +126,7 @@ Capture modes: $stdout (all output), $line.N (Nth line), or a gjson path for JSO
 - Use "kest history" to see the results of previous runs.
 - Use "--debug-vars" to see how variables are resolved.
 - Exec steps default to 30s timeout. Override with --exec-timeout.
+- "@timeout 5s" limits an exec command or, on HTTP steps, the request itself.
 - Use "--quiet --output json" for CI/CD pipelines.
 - Exit codes: 0=success, 1=assertion fail, 2=runtime error.
 
@@ -91,11 +91,11 @@ var historyCmd = &cobra.Command{
 
 func init() {
 	historyCmd.Flags().IntVarP(&historyLimit, "number", "n", 20, "Number of records to show")
-	historyCmd.Flags().BoolVarP(&globalHistory, "global", "g", false, "Show history across all projects")
-	historyCmd.Flags().StringVar(&historyStatusFilter, "status", "", "Filter by status code or class (e.g. 200, 4xx, 5xx)")
-	historyCmd.Flags().StringVar(&historyMethodFilter, "method", "", "Filter by HTTP method (e.g. GET, POST)")
-	historyCmd.Flags().StringVar(&historyURLFilter, "url", "", "Filter by URL substring")
-	historyCmd.Flags().StringVar(&historySince, "since", "", "Filter records newer than duration (e.g. 1h, 30m, 2h30m)")
+	historyCmd.PersistentFlags().BoolVarP(&globalHistory, "global", "g", false, "Show history across all projects")
+	historyCmd.PersistentFlags().StringVar(&historyStatusFilter, "status", "", "Filter by status code or class (e.g. 200, 4xx, 5xx)")
+	historyCmd.PersistentFlags().StringVar(&historyMethodFilter, "method", "", "Filter by HTTP method (e.g. GET, POST)")
+	historyCmd.PersistentFlags().StringVar(&historyURLFilter, "url", "", "Filter by URL substring")
+	historyCmd.PersistentFlags().StringVar(&historySince, "since", "", "Filter records newer than duration (e.g. 1h, 30m, 2h30m)")
 	rootCmd.AddCommand(historyCmd)
 }
 
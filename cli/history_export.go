@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/har"
+	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyExportFormat string
+	historyExportOutput string
+	historyExportLimit  int
+)
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export history as a HAR file",
+	Long: `Export recorded requests with full headers and bodies as an HTTP Archive (HAR 1.2)
+file, oldest first. The history filters (--status, --method, --url, --since,
+--global) select which records are exported. HAR files open in browser devtools,
+Charles, Postman and Insomnia, and can be loaded back with kest import har.`,
+	Example: `  # Export this project's history
+  kest history export --format har -o session.har
+
+  # Export only failures from the last hour
+  kest history export --status 5xx --since 1h > failures.har`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !strings.EqualFold(historyExportFormat, "har") {
+			return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("unsupported export format %q (supported: har)", historyExportFormat)}
+		}
+		conf := loadConfigWarn()
+
+		store, err := storage.NewStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var records []storage.Record
+		if globalHistory || conf.ProjectID == "" {
+			records, err = store.GetAllRecords()
+		} else {
+			records, err = store.GetRecordsByProject(conf.ProjectID, 0)
+		}
+		if err != nil {
+			return err
+		}
+		records = applyHistoryFilters(records)
+		if historyExportLimit > 0 && len(records) > historyExportLimit {
+			records = records[:historyExportLimit]
+		}
+
+		doc := har.FromRecords(records, Version)
+		if historyExportOutput == "" || historyExportOutput == "-" {
+			return har.Write(os.Stdout, doc)
+		}
+		f, err := os.Create(historyExportOutput)
+		if err != nil {
+			return err
+		}
+		if err := har.Write(f, doc); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "📤 Exported %d records to %s\n", len(records), historyExportOutput)
+		return nil
+	},
+}
+
+func init() {
+	historyExportCmd.Flags().StringVar(&historyExportFormat, "format", "har", "Export format (har)")
+	historyExportCmd.Flags().StringVarP(&historyExportOutput, "output", "o", "", "Output file path (default: stdout)")
+	historyExportCmd.Flags().IntVarP(&historyExportLimit, "number", "n", 0, "Export only the N most recent matching records (0 = all)")
+	historyCmd.AddCommand(historyExportCmd)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/config"
+	"github.com/kest-labs/kest/cli/internal/curl"
+	"github.com/kest-labs/kest/cli/internal/har"
+	"github.com/kest-labs/kest/cli/internal/logger"
+	"github.com/kest-labs/kest/cli/internal/platformsync"
+	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import requests from other tools into history",
+	Long: `Import requests recorded by other tools into your history, tagged with the
+active project and environment. Imported records work everywhere history does:
+kest history, kest mock, kest diff and kest sync push.`,
+}
+
+var importHarCmd = &cobra.Command{
+	Use:   "har <file.har>",
+	Short: "Import a HAR file into history",
+	Long: `Import every entry of an HTTP Archive (HAR) file, as exported by browser devtools,
+Charles, Postman or kest history export, into history. Entries keep their
+original timestamps; entries without a response and non-HTTP URLs are skipped.`,
+	Example: `  # Import a session saved from Chrome devtools
+  kest import har session.har
+
+  # Tag the imported records with another environment
+  kest import har staging.har --env staging`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: err}
+		}
+		doc, err := har.Read(f)
+		f.Close()
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("%s: %w", args[0], err)}
+		}
+
+		records, skipped := har.ToRecords(doc)
+		for _, reason := range skipped {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: skipped %s\n", reason)
+		}
+
+		store, err := storage.NewStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		conf := loadConfigWarn()
+		imported, err := importRecords(store, conf, records)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("📥 Imported %d records from %s\n", imported, args[0])
+		return nil
+	},
+}
+
+var (
+	importCurlFlow bool
+	importCurlRun  bool
+)
+
+var importCurlCmd = &cobra.Command{
+	Use:   "curl [command]",
+	Short: "Convert a curl command into a kest request or flow step",
+	Long: `Convert a curl command, as copied from browser devtools ("Copy as cURL (bash)")
+or API docs, into the equivalent kest command. Use --flow to get a .flow.md step
+instead, or --run to send the request right away and record it in history.
+
+The command is read from the argument, or from stdin when it is omitted or "-".
+-X, -H, -d/--data/--data-raw/--data-binary/--data-urlencode/--json, -F, -u,
+-A, -b, -e, -G, -I and --compressed are understood; URLs under the active
+environment's base_url become relative paths.`,
+	Example: `  # Print the equivalent kest command
+  kest import curl "curl -X POST https://api.example.com/login -H 'Content-Type: application/json' -d '{\"user\":\"admin\"}'"
+
+  # Paste a multi-line command from devtools and append it as a flow step
+  pbpaste | kest import curl --flow >> login.flow.md
+
+  # Send it now
+  kest import curl --run "curl -u admin:secret https://api.example.com/me"`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := ""
+		if len(args) == 1 && args[0] != "-" {
+			command = args[0]
+		} else {
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			command = string(input)
+		}
+		req, err := curl.Parse(command)
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("invalid curl command: %w", err)}
+		}
+		if req.Insecure {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: -k/--insecure has no kest equivalent and was ignored\n")
+		}
+
+		conf := loadConfigWarn()
+		baseURL := conf.GetActiveEnv().BaseURL
+		switch {
+		case importCurlRun:
+			_, err := ExecuteRequest(requestOptionsFromCurl(req, baseURL))
+			return err
+		case importCurlFlow:
+			if len(req.Form) > 0 {
+				return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("flow steps do not support multipart (-F) bodies yet; use the kest command instead")}
+			}
+			fmt.Print(flowStepFromCurl(req, baseURL))
+		default:
+			line, err := kestCommandFromCurl(req, baseURL)
+			if err != nil {
+				return &ExitError{Code: ExitConfigError, Err: err}
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+func init() {
+	importHarCmd.Flags().StringVarP(&runEnv, "env", "e", "", "Environment to tag imported records with (default: active environment)")
+	importCmd.AddCommand(importHarCmd)
+	importCurlCmd.Flags().BoolVar(&importCurlFlow, "flow", false, "Print a .flow.md step instead of a kest command")
+	importCurlCmd.Flags().BoolVar(&importCurlRun, "run", false, "Send the request now and record it in history")
+	importCurlCmd.MarkFlagsMutuallyExclusive("flow", "run")
+	importCmd.AddCommand(importCurlCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+// importRecords saves records tagged with the active project and
+// environment and queues them for history sync.
+func importRecords(store *storage.Store, conf *config.Config, records []storage.Record) (int, error) {
+	imported := 0
+	for i := range records {
+		record := &records[i]
+		record.Project = conf.ProjectID
+		record.Environment = conf.ActiveEnv
+		id, err := store.SaveRecord(record)
+		if err != nil {
+			return imported, fmt.Errorf("failed to save %s %s: %w", record.Method, record.URL, err)
+		}
+		record.ID = id
+		imported++
+		if err := platformsync.QueueRequestHistory(conf, store, record, "import"); err != nil {
+			logger.LogToSession("history auto-sync enqueue failed for record %d: %v", id, err)
+		}
+	}
+	if imported > 0 {
+		platformsync.MaybeFlushHistoryOutbox(conf, store, 5)
+	}
+	return imported, nil
+}
+
+// curlTarget returns the request URL relative to baseURL when it lives
+// under it, so the command follows environment switches.
+func curlTarget(rawURL, baseURL string) string {
+	base := strings.TrimSuffix(baseURL, "/")
+	if base == "" || !strings.HasPrefix(rawURL, base) {
+		return rawURL
+	}
+	rest := rawURL[len(base):]
+	if rest == "" {
+		return "/"
+	}
+	if rest[0] != '/' && rest[0] != '?' {
+		return rawURL
+	}
+	if rest[0] == '?' {
+		return "/" + rest
+	}
+	return rest
+}
+
+// kestCommandFromCurl renders req as a kest request command line.
+func kestCommandFromCurl(req *curl.Request, baseURL string) (string, error) {
+	method := strings.ToLower(req.Method)
+	switch method {
+	case "get", "post", "put", "delete", "patch":
+	default:
+		return "", fmt.Errorf("kest has no %s command; use --flow or --run instead", req.Method)
+	}
+	parts := []string{"kest", method, curl.Quote(curlTarget(req.URL, baseURL))}
+	for _, h := range req.Headers {
+		if len(req.Form) > 0 && strings.EqualFold(h.Name, "Content-Type") {
+			continue // kest sets the multipart boundary itself
+		}
+		parts = append(parts, "-H", curl.Quote(h.Name+": "+h.Value))
+	}
+	if req.Body != "" {
+		parts = append(parts, "-d", curl.Quote(req.Body))
+	}
+	for _, f := range req.Form {
+		value := f.Value
+		if f.File {
+			value = "@" + value
+		}
+		parts = append(parts, "-F", curl.Quote(f.Name+"="+value))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+var flowStepIDPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// flowStepFromCurl renders req as a ```step block. JSON bodies are
+// pretty-printed.
+func flowStepFromCurl(req *curl.Request, baseURL string) string {
+	target := curlTarget(req.URL, baseURL)
+	path := target
+	if u, err := url.Parse(target); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	id := strings.Trim(flowStepIDPattern.ReplaceAllString(strings.ToLower(req.Method+" "+path), "-"), "-")
+
+	var b strings.Builder
+	b.WriteString("```step\n")
+	fmt.Fprintf(&b, "@id %s\n", id)
+	fmt.Fprintf(&b, "@name %s %s\n\n", req.Method, path)
+	fmt.Fprintf(&b, "%s %s\n", req.Method, target)
+	for _, h := range req.Headers {
+		fmt.Fprintf(&b, "%s: %s\n", h.Name, h.Value)
+	}
+	if req.Body != "" {
+		body := req.Body
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, []byte(body), "", "  ") == nil {
+			body = pretty.String()
+		}
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+	b.WriteString("\n[Asserts]\nstatus < 400\n```\n")
+	return b.String()
+}
+
+// requestOptionsFromCurl maps req onto the options of an ad-hoc request.
+func requestOptionsFromCurl(req *curl.Request, baseURL string) RequestOptions {
+	opts := RequestOptions{
+		Method: strings.ToLower(req.Method),
+		URL:    curlTarget(req.URL, baseURL),
+		Data:   req.Body,
+	}
+	for _, h := range req.Headers {
+		if len(req.Form) > 0 && strings.EqualFold(h.Name, "Content-Type") {
+			continue
+		}
+		opts.Headers = append(opts.Headers, h.Name+": "+h.Value)
+	}
+	for _, f := range req.Form {
+		value := f.Value
+		if f.File {
+			value = "@" + value
+		}
+		opts.Forms = append(opts.Forms, f.Name+"="+value)
+	}
+	return opts
+}
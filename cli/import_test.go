@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kest-labs/kest/cli/internal/curl"
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+func TestKestCommandFromCurl(t *testing.T) {
+	req, err := curl.Parse(`curl 'https://api.example.com/v1/login' -H 'Content-Type: application/json' -H 'Accept: a, b' --data-raw '{"user":"admin"}'`)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	line, err := kestCommandFromCurl(req, "https://api.example.com/v1/")
+	if err != nil {
+		t.Fatalf("kestCommandFromCurl returned error: %v", err)
+	}
+	want := `kest post /login -H 'Content-Type: application/json' -H 'Accept: a, b' -d '{"user":"admin"}'`
+	if line != want {
+		t.Fatalf("unexpected command:\n got %s\nwant %s", line, want)
+	}
+
+	if line, _ := kestCommandFromCurl(req, "https://other.example.com"); !strings.Contains(line, "kest post https://api.example.com/v1/login") {
+		t.Fatalf("expected an absolute URL outside the base URL, got %s", line)
+	}
+
+	head, _ := curl.Parse(`curl -I https://api.example.com/health`)
+	if _, err := kestCommandFromCurl(head, ""); err == nil {
+		t.Fatalf("expected HEAD to have no kest command")
+	}
+}
+
+// Imported commands pass header values such as "Accept: a, b" through -H,
+// so a comma must not split one header into two.
+func TestRequestHeaderFlagKeepsCommas(t *testing.T) {
+	prev := reqHeaders
+	t.Cleanup(func() { reqHeaders = prev })
+
+	cmd := createRequestCmd("get")
+	if err := cmd.ParseFlags([]string{"-H", "Accept: a, b", "-H", "X-Tenant: 1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqHeaders) != 2 || reqHeaders[0] != "Accept: a, b" || reqHeaders[1] != "X-Tenant: 1" {
+		t.Fatalf("unexpected headers %q", reqHeaders)
+	}
+}
+
+func TestFlowStepFromCurl(t *testing.T) {
+	req, err := curl.Parse(`curl -X PUT 'https://api.example.com/users/7?notify=1' -H 'Content-Type: application/json' -d '{"name":"Ada"}'`)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	doc, _ := ParseFlowDocument(flowStepFromCurl(req, "https://api.example.com"))
+	if len(doc.Steps) != 1 {
+		t.Fatalf("expected one step, got %d", len(doc.Steps))
+	}
+	step := doc.Steps[0]
+	if step.ID != "put-users-7" || step.Request.Method != "put" || step.Request.URL != "/users/7?notify=1" {
+		t.Fatalf("unexpected step: %s %s %s", step.ID, step.Request.Method, step.Request.URL)
+	}
+	if len(step.Request.Headers) != 1 || !json.Valid([]byte(step.Request.Data)) || !strings.Contains(step.Request.Data, "\n") {
+		t.Fatalf("unexpected headers or body: %v %q", step.Request.Headers, step.Request.Data)
+	}
+	if len(step.Request.Asserts) != 1 {
+		t.Fatalf("expected a default status assertion, got %v", step.Request.Asserts)
+	}
+}
+
+func TestRecordCurlRequest(t *testing.T) {
+	record := &storage.Record{
+		Method:         "POST",
+		URL:            "https://api.example.com/users",
+		RequestHeaders: json.RawMessage(`{"Content-Type":"application/json","Content-Length":"13","Authorization":"Bearer t"}`),
+		RequestBody:    `{"name":"Ada"}`,
+	}
+	snippet, err := curl.Snippet(recordCurlRequest(record), "curl")
+	if err != nil {
+		t.Fatalf("Snippet returned error: %v", err)
+	}
+	want := "curl https://api.example.com/users \\\n  -H 'Authorization: Bearer t' \\\n  -H 'Content-Type: application/json' \\\n  --data-raw '{\"name\":\"Ada\"}'"
+	if snippet != want {
+		t.Fatalf("unexpected snippet:\n%s", snippet)
+	}
+}
@@ -0,0 +1,274 @@
+// Package auth implements the authentication schemes behind --auth and the
+// @auth flow directive: Basic, Bearer, API keys, OAuth2 (client credentials
+// and password grants), HMAC request signing and AWS Signature Version 4.
+package auth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+// Config mirrors the platform's request AuthConfig and extends it with the
+// signing schemes that only make sense client side.
+type Config struct {
+	Type   string        `json:"type"` // none, basic, bearer, api-key, oauth2, hmac, aws-sigv4
+	Basic  *BasicAuth    `json:"basic,omitempty"`
+	Bearer *BearerToken  `json:"bearer,omitempty"`
+	APIKey *APIKeyAuth   `json:"api_key,omitempty"`
+	OAuth2 *OAuth2Config `json:"oauth2,omitempty"`
+	HMAC   *HMACConfig   `json:"hmac,omitempty"`
+	SigV4  *SigV4Config  `json:"aws_sigv4,omitempty"`
+}
+
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type BearerToken struct {
+	Token string `json:"token"`
+}
+
+type APIKeyAuth struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	In    string `json:"in"` // header, query
+}
+
+// Request is the outgoing request a scheme decorates or signs. Headers use
+// canonical names, as in ExecuteRequest.
+type Request struct {
+	Method  string
+	URL     *url.URL
+	Headers map[string]string
+	Body    []byte
+}
+
+// TokenStore caches OAuth2 tokens between runs; *storage.Store implements it.
+type TokenStore interface {
+	GetAuthToken(key string) (*storage.AuthToken, error)
+	SaveAuthToken(t *storage.AuthToken) error
+	DeleteAuthToken(key string) error
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+// Parse parses an auth spec such as "basic user:pass", "bearer {{token}}"
+// or "oauth2 client_credentials token_url=... client_id=...". Values may be
+// quoted; resolve, when set, is applied to every value (e.g. variable
+// interpolation) after the spec has been split.
+func Parse(spec string, resolve func(string) string) (*Config, error) {
+	if resolve == nil {
+		resolve = func(s string) string { return s }
+	}
+	fields, err := splitFields(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty auth spec")
+	}
+	scheme, args := strings.ToLower(fields[0]), fields[1:]
+
+	switch scheme {
+	case "none", "off":
+		return &Config{Type: "none"}, nil
+	case "basic":
+		if len(args) == 1 && !strings.HasPrefix(strings.ToLower(args[0]), "username=") {
+			user, pass, _ := strings.Cut(args[0], ":")
+			return &Config{Type: "basic", Basic: &BasicAuth{Username: resolve(user), Password: resolve(pass)}}, nil
+		}
+		kv, err := parseOptions(args, resolve, "username", "password")
+		if err != nil {
+			return nil, fmt.Errorf("basic auth: %w (expected basic user:pass)", err)
+		}
+		return &Config{Type: "basic", Basic: &BasicAuth{Username: kv["username"], Password: kv["password"]}}, nil
+	case "bearer":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("bearer auth needs a token")
+		}
+		return &Config{Type: "bearer", Bearer: &BearerToken{Token: resolve(strings.Join(args, " "))}}, nil
+	case "api-key", "apikey":
+		var key *APIKeyAuth
+		if len(args) >= 2 && !strings.Contains(args[0], "=") {
+			key = &APIKeyAuth{Key: resolve(args[0]), Value: resolve(args[1]), In: "header"}
+			args = args[2:]
+		}
+		kv, err := parseOptions(args, resolve, "key", "value", "in")
+		if err != nil {
+			return nil, fmt.Errorf("api-key auth: %w", err)
+		}
+		if key == nil {
+			key = &APIKeyAuth{Key: kv["key"], Value: kv["value"]}
+		}
+		key.In = strings.ToLower(firstNonEmpty(kv["in"], key.In, "header"))
+		if key.Key == "" {
+			return nil, fmt.Errorf("api-key auth needs a name, e.g. api-key X-API-Key {{api_key}}")
+		}
+		if key.In != "header" && key.In != "query" {
+			return nil, fmt.Errorf("api-key auth: in must be header or query, got %q", key.In)
+		}
+		return &Config{Type: "api-key", APIKey: key}, nil
+	case "oauth2":
+		cfg, err := parseOAuth2(args, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &Config{Type: "oauth2", OAuth2: cfg}, nil
+	case "hmac":
+		cfg, err := parseHMAC(args, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &Config{Type: "hmac", HMAC: cfg}, nil
+	case "aws-sigv4", "sigv4", "aws":
+		cfg, err := parseSigV4(args, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &Config{Type: "aws-sigv4", SigV4: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown auth scheme %q (supported: none, basic, bearer, api-key, oauth2, hmac, aws-sigv4)", fields[0])
+}
+
+// Apply authenticates req, fetching an OAuth2 token first when needed.
+// tokens may be nil, in which case tokens are only cached in memory.
+func (c *Config) Apply(req *Request, tokens TokenStore) error {
+	if req.Headers == nil {
+		req.Headers = make(map[string]string)
+	}
+	switch c.Type {
+	case "", "none":
+		return nil
+	case "basic":
+		credentials := base64.StdEncoding.EncodeToString([]byte(c.Basic.Username + ":" + c.Basic.Password))
+		req.Headers["Authorization"] = "Basic " + credentials
+	case "bearer":
+		req.Headers["Authorization"] = "Bearer " + c.Bearer.Token
+	case "api-key":
+		if c.APIKey.In == "query" {
+			q := req.URL.Query()
+			q.Set(c.APIKey.Key, c.APIKey.Value)
+			req.URL.RawQuery = q.Encode()
+		} else {
+			req.Headers[http.CanonicalHeaderKey(c.APIKey.Key)] = c.APIKey.Value
+		}
+	case "oauth2":
+		token, err := c.OAuth2.token(tokens)
+		if err != nil {
+			return err
+		}
+		req.Headers["Authorization"] = "Bearer " + token
+	case "hmac":
+		return c.HMAC.sign(req)
+	case "aws-sigv4":
+		return c.SigV4.sign(req)
+	default:
+		return fmt.Errorf("unknown auth type %q", c.Type)
+	}
+	return nil
+}
+
+// Refreshable reports whether a rejected request (401) may succeed after
+// Invalidate, i.e. whether the credentials are fetched rather than given.
+func (c *Config) Refreshable() bool {
+	return c.Type == "oauth2"
+}
+
+// Invalidate drops the cached OAuth2 token so the next Apply fetches a new
+// one.
+func (c *Config) Invalidate(tokens TokenStore) {
+	if c.Type == "oauth2" {
+		c.OAuth2.invalidate(tokens)
+	}
+}
+
+// splitFields splits a spec on whitespace, honouring single and double
+// quotes.
+func splitFields(spec string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range spec {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in auth spec")
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+// parseOptions parses key=value arguments. Keys are case-insensitive and
+// dashes and underscores are interchangeable.
+func parseOptions(args []string, resolve func(string) string, allowed ...string) (map[string]string, error) {
+	kv := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("unexpected argument %q; expected key=value", arg)
+		}
+		key = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+		known := false
+		for _, name := range allowed {
+			if key == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			sorted := append([]string{}, allowed...)
+			sort.Strings(sorted)
+			return nil, fmt.Errorf("unknown option %q (supported: %s)", key, strings.Join(sorted, ", "))
+		}
+		kv[key] = resolve(value)
+	}
+	return kv, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func envDefault(value, name string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(name)
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+func newRequest(method, rawURL, body string) *Request {
+	u, _ := url.Parse(rawURL)
+	return &Request{Method: method, URL: u, Headers: map[string]string{}, Body: []byte(body)}
+}
+
+func TestParseAndApplySimpleSchemes(t *testing.T) {
+	vars := map[string]string{"{{token}}": "abc", "{{pass}}": "p a:ss"}
+	resolve := func(s string) string {
+		for k, v := range vars {
+			s = strings.ReplaceAll(s, k, v)
+		}
+		return s
+	}
+
+	tests := []struct {
+		spec   string
+		header string
+		want   string
+		query  string
+	}{
+		{spec: "basic admin:{{pass}}", header: "Authorization", want: "Basic YWRtaW46cCBhOnNz"},
+		{spec: `basic username=admin password="{{pass}}"`, header: "Authorization", want: "Basic YWRtaW46cCBhOnNz"},
+		{spec: "bearer {{token}}", header: "Authorization", want: "Bearer abc"},
+		{spec: "api-key x-api-key {{token}}", header: "X-Api-Key", want: "abc"},
+		{spec: "api-key api_key {{token}} in=query", query: "api_key=abc&page=1"},
+	}
+	for _, tt := range tests {
+		cfg, err := Parse(tt.spec, resolve)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tt.spec, err)
+		}
+		req := newRequest("GET", "https://api.example.com/users?page=1", "")
+		if err := cfg.Apply(req, nil); err != nil {
+			t.Fatalf("Apply(%q) returned error: %v", tt.spec, err)
+		}
+		if tt.header != "" && req.Headers[tt.header] != tt.want {
+			t.Fatalf("%q: expected %s %q, got %v", tt.spec, tt.header, tt.want, req.Headers)
+		}
+		if tt.query != "" && req.URL.RawQuery != tt.query {
+			t.Fatalf("%q: expected query %q, got %q", tt.spec, tt.query, req.URL.RawQuery)
+		}
+	}
+
+	for _, bad := range []string{"", "digest a:b", "bearer", "api-key", "oauth2 implicit token_url=x", "oauth2 client_credentials client_id=x", "hmac", "hmac secret=x canonical={verb}", "aws-sigv4 us-east-1", `basic "a:b`} {
+		if _, err := Parse(bad, nil); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestHMACSignsCanonicalString(t *testing.T) {
+	now = func() time.Time { return time.Unix(1700000000, 0) }
+	defer func() { now = time.Now }()
+
+	cfg, err := Parse(`hmac secret=s3cr3t header=X-Sig prefix=v1= canonical="{method}\n{path}?{query}\n{timestamp}\n{header.X-Tenant}\n{body}"`, nil)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	req := newRequest("post", "https://api.example.com/orders?id=7", `{"a":1}`)
+	req.Headers["X-Tenant"] = "acme"
+	if err := cfg.Apply(req, nil); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte("POST\n/orders?id=7\n1700000000\nacme\n" + `{"a":1}`))
+	want := "v1=" + hex.EncodeToString(mac.Sum(nil))
+	if req.Headers["X-Sig"] != want || req.Headers["X-Timestamp"] != "1700000000" {
+		t.Fatalf("unexpected signature headers: %v (want %s)", req.Headers, want)
+	}
+}
+
+func TestSigV4MatchesAWSTestSuite(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite.
+	now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	cfg, err := Parse("aws-sigv4 us-east-1 service access_key=AKIDEXAMPLE secret_key=wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", nil)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	req := newRequest("GET", "https://example.amazonaws.com/", "")
+	if err := cfg.Apply(req, nil); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if req.Headers["Authorization"] != want || req.Headers["X-Amz-Date"] != "20150830T123600Z" {
+		t.Fatalf("unexpected signature:\n got %s\nwant %s", req.Headers["Authorization"], want)
+	}
+}
+
+type memoryTokens map[string]*storage.AuthToken
+
+func (m memoryTokens) GetAuthToken(key string) (*storage.AuthToken, error) { return m[key], nil }
+func (m memoryTokens) SaveAuthToken(t *storage.AuthToken) error            { m[t.Key] = t; return nil }
+func (m memoryTokens) DeleteAuthToken(key string) error                    { delete(m, key); return nil }
+
+func TestOAuth2ClientCredentialsCachesAndRefreshes(t *testing.T) {
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		user, pass, _ := r.BasicAuth()
+		if user != "app" || pass != "secret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		n := atomic.AddInt32(&issued, 1)
+		switch r.Form.Get("grant_type") {
+		case "client_credentials":
+			if r.Form.Get("scope") != "read write" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"cc-%d","token_type":"Bearer","expires_in":3600,"refresh_token":"r-%d"}`, n, n)
+		case "refresh_token":
+			fmt.Fprintf(w, `{"access_token":"refreshed-%s","expires_in":"3600"}`, r.Form.Get("refresh_token"))
+		}
+	}))
+	defer server.Close()
+
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	spec := fmt.Sprintf(`oauth2 client_credentials token_url=%s/token client_id=app client_secret=secret scope="read write"`, server.URL)
+	cfg, err := Parse(spec, nil)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	store := memoryTokens{}
+	apply := func() string {
+		t.Helper()
+		req := newRequest("GET", "https://api.example.com/me", "")
+		if err := cfg.Apply(req, store); err != nil {
+			t.Fatalf("Apply returned error: %v", err)
+		}
+		return req.Headers["Authorization"]
+	}
+
+	if got := apply(); got != "Bearer cc-1" {
+		t.Fatalf("unexpected first token: %s", got)
+	}
+	if got := apply(); got != "Bearer cc-1" || atomic.LoadInt32(&issued) != 1 {
+		t.Fatalf("expected the cached token, got %s after %d requests", got, issued)
+	}
+
+	// A new process starts with an empty memory cache and reads the store.
+	memo.Lock()
+	memo.tokens = make(map[string]*storage.AuthToken)
+	memo.Unlock()
+	if got := apply(); got != "Bearer cc-1" || atomic.LoadInt32(&issued) != 1 {
+		t.Fatalf("expected the stored token, got %s", got)
+	}
+
+	clock = clock.Add(time.Hour)
+	if got := apply(); got != "Bearer refreshed-r-1" {
+		t.Fatalf("expected the token to be refreshed, got %s", got)
+	}
+
+	cfg.Invalidate(store)
+	if got := apply(); got != "Bearer cc-3" {
+		t.Fatalf("expected a new token after invalidation, got %s", got)
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultHMACCanonical is the string signed when no canonical= is given.
+const DefaultHMACCanonical = `{method}\n{path}\n{timestamp}\n{body}`
+
+// HMACConfig signs a canonical string built from the request with a shared
+// secret and sends the signature in a header.
+type HMACConfig struct {
+	Secret    string `json:"secret"`
+	Algorithm string `json:"algorithm"` // sha256 (default), sha1, sha512
+	Encoding  string `json:"encoding"`  // hex (default), base64
+	Header    string `json:"header"`    // default X-Signature
+	Prefix    string `json:"prefix,omitempty"`
+	// Canonical is a template over {method}, {path}, {query}, {url},
+	// {host}, {body}, {body_sha256}, {timestamp}, {timestamp_ms}, {nonce}
+	// and {header.Name}; \n and \t are escapes.
+	Canonical       string `json:"canonical"`
+	TimestampHeader string `json:"timestamp_header"` // sent when the template uses a timestamp; default X-Timestamp
+	NonceHeader     string `json:"nonce_header"`     // sent when the template uses {nonce}; default X-Nonce
+}
+
+var hmacPlaceholder = regexp.MustCompile(`\{([a-z_0-9]+)(?:\.([A-Za-z0-9-]+))?\}`)
+
+func parseHMAC(args []string, resolve func(string) string) (*HMACConfig, error) {
+	kv, err := parseOptions(args, resolve, "secret", "algorithm", "encoding", "header", "prefix", "canonical", "timestamp_header", "nonce_header")
+	if err != nil {
+		return nil, fmt.Errorf("hmac auth: %w", err)
+	}
+	cfg := &HMACConfig{
+		Secret:          kv["secret"],
+		Algorithm:       strings.ToLower(firstNonEmpty(kv["algorithm"], "sha256")),
+		Encoding:        strings.ToLower(firstNonEmpty(kv["encoding"], "hex")),
+		Header:          firstNonEmpty(kv["header"], "X-Signature"),
+		Prefix:          kv["prefix"],
+		Canonical:       firstNonEmpty(kv["canonical"], DefaultHMACCanonical),
+		TimestampHeader: firstNonEmpty(kv["timestamp_header"], "X-Timestamp"),
+		NonceHeader:     firstNonEmpty(kv["nonce_header"], "X-Nonce"),
+	}
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("hmac auth needs secret=...")
+	}
+	if cfg.hash() == nil {
+		return nil, fmt.Errorf("hmac auth: unsupported algorithm %q (supported: sha1, sha256, sha512)", cfg.Algorithm)
+	}
+	if cfg.Encoding != "hex" && cfg.Encoding != "base64" {
+		return nil, fmt.Errorf("hmac auth: encoding must be hex or base64, got %q", cfg.Encoding)
+	}
+	for _, m := range hmacPlaceholder.FindAllStringSubmatch(cfg.Canonical, -1) {
+		switch m[1] {
+		case "method", "path", "query", "url", "host", "body", "body_sha256", "timestamp", "timestamp_ms", "nonce":
+			if m[2] != "" {
+				return nil, fmt.Errorf("hmac auth: unknown placeholder %s in canonical", m[0])
+			}
+		case "header":
+			if m[2] == "" {
+				return nil, fmt.Errorf("hmac auth: use {header.Name} in canonical")
+			}
+		default:
+			return nil, fmt.Errorf("hmac auth: unknown placeholder %s in canonical", m[0])
+		}
+	}
+	return cfg, nil
+}
+
+func (c *HMACConfig) hash() func() hash.Hash {
+	switch c.Algorithm {
+	case "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	}
+	return nil
+}
+
+func (c *HMACConfig) sign(req *Request) error {
+	at := now()
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	timestampMs := strconv.FormatInt(at.UnixMilli(), 10)
+	var nonce string
+	if strings.Contains(c.Canonical, "{nonce}") {
+		raw := make([]byte, 16)
+		if _, err := rand.Read(raw); err != nil {
+			return err
+		}
+		nonce = hex.EncodeToString(raw)
+		req.Headers[http.CanonicalHeaderKey(c.NonceHeader)] = nonce
+	}
+	switch {
+	case strings.Contains(c.Canonical, "{timestamp}"):
+		req.Headers[http.CanonicalHeaderKey(c.TimestampHeader)] = timestamp
+	case strings.Contains(c.Canonical, "{timestamp_ms}"):
+		req.Headers[http.CanonicalHeaderKey(c.TimestampHeader)] = timestampMs
+	}
+
+	bodySum := sha256.Sum256(req.Body)
+	template := strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(c.Canonical)
+	canonical := hmacPlaceholder.ReplaceAllStringFunc(template, func(token string) string {
+		m := hmacPlaceholder.FindStringSubmatch(token)
+		switch m[1] {
+		case "method":
+			return strings.ToUpper(req.Method)
+		case "path":
+			path := req.URL.EscapedPath()
+			if path == "" {
+				path = "/"
+			}
+			return path
+		case "query":
+			return req.URL.RawQuery
+		case "url":
+			return req.URL.String()
+		case "host":
+			return req.URL.Host
+		case "body":
+			return string(req.Body)
+		case "body_sha256":
+			return hex.EncodeToString(bodySum[:])
+		case "timestamp":
+			return timestamp
+		case "timestamp_ms":
+			return timestampMs
+		case "nonce":
+			return nonce
+		case "header":
+			return req.Headers[http.CanonicalHeaderKey(m[2])]
+		}
+		return token
+	})
+
+	mac := hmac.New(c.hash(), []byte(c.Secret))
+	mac.Write([]byte(canonical))
+	sum := mac.Sum(nil)
+	signature := hex.EncodeToString(sum)
+	if c.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+	req.Headers[http.CanonicalHeaderKey(c.Header)] = c.Prefix + signature
+	return nil
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/secret"
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+// expirySkew renews tokens slightly before they expire so a request never
+// leaves with a token that dies in flight.
+const expirySkew = 30 * time.Second
+
+type OAuth2Config struct {
+	GrantType    string `json:"grant_type"` // client_credentials, password
+	TokenURL     string `json:"token_url,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	Audience     string `json:"audience,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	// ClientAuth sends the client credentials as HTTP Basic ("basic", the
+	// default) or in the form body ("body").
+	ClientAuth string `json:"client_auth,omitempty"`
+}
+
+// tokenClient fetches tokens; replaced in tests.
+var tokenClient = &http.Client{Timeout: 30 * time.Second}
+
+// memo keeps tokens for the lifetime of the process, so a flow fetches or
+// loads its token once.
+var memo = struct {
+	sync.Mutex
+	tokens map[string]*storage.AuthToken
+}{tokens: make(map[string]*storage.AuthToken)}
+
+func parseOAuth2(args []string, resolve func(string) string) (*OAuth2Config, error) {
+	if len(args) == 0 || strings.Contains(args[0], "=") {
+		return nil, fmt.Errorf("oauth2 auth needs a grant type, e.g. oauth2 client_credentials token_url=...")
+	}
+	grant := strings.ReplaceAll(strings.ToLower(args[0]), "-", "_")
+	kv, err := parseOptions(args[1:], resolve, "token_url", "client_id", "client_secret", "scope", "audience", "username", "password", "client_auth")
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 auth: %w", err)
+	}
+	cfg := &OAuth2Config{
+		GrantType:    grant,
+		TokenURL:     kv["token_url"],
+		ClientID:     kv["client_id"],
+		ClientSecret: kv["client_secret"],
+		Scope:        kv["scope"],
+		Audience:     kv["audience"],
+		Username:     kv["username"],
+		Password:     kv["password"],
+		ClientAuth:   strings.ToLower(firstNonEmpty(kv["client_auth"], "basic")),
+	}
+	switch grant {
+	case "client_credentials":
+		if cfg.ClientID == "" {
+			return nil, fmt.Errorf("oauth2 client_credentials needs client_id")
+		}
+	case "password":
+		if cfg.Username == "" {
+			return nil, fmt.Errorf("oauth2 password needs username")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported oauth2 grant %q (supported: client_credentials, password)", args[0])
+	}
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("oauth2 auth needs token_url")
+	}
+	if cfg.ClientAuth != "basic" && cfg.ClientAuth != "body" {
+		return nil, fmt.Errorf("oauth2 auth: client_auth must be basic or body, got %q", cfg.ClientAuth)
+	}
+	return cfg, nil
+}
+
+// cacheKey identifies the grant a token was issued for. Secrets are part of
+// the hash so a changed secret never reuses an old token.
+func (c *OAuth2Config) cacheKey() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		c.GrantType, c.TokenURL, c.ClientID, c.ClientSecret, c.Scope, c.Audience, c.Username, c.Password,
+	}, "\x00")))
+	return "oauth2:" + hex.EncodeToString(sum[:16])
+}
+
+func valid(t *storage.AuthToken) bool {
+	return t != nil && t.AccessToken != "" && (t.ExpiresAt.IsZero() || now().Add(expirySkew).Before(t.ExpiresAt))
+}
+
+// token returns a usable access token: from memory, then from the store,
+// then by refreshing or requesting a new one.
+func (c *OAuth2Config) token(store TokenStore) (string, error) {
+	key := c.cacheKey()
+	memo.Lock()
+	defer memo.Unlock()
+
+	cached := memo.tokens[key]
+	if !valid(cached) && store != nil {
+		if stored, err := store.GetAuthToken(key); err == nil && stored != nil {
+			cached = stored
+		}
+	}
+	if valid(cached) {
+		memo.tokens[key] = cached
+		secret.Add(cached.AccessToken)
+		return cached.AccessToken, nil
+	}
+
+	var fresh *storage.AuthToken
+	var err error
+	if cached != nil && cached.RefreshToken != "" {
+		fresh, err = c.request(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {cached.RefreshToken}})
+	}
+	if fresh == nil {
+		form := url.Values{"grant_type": {c.GrantType}}
+		if c.GrantType == "password" {
+			form.Set("username", c.Username)
+			form.Set("password", c.Password)
+		}
+		if c.Scope != "" {
+			form.Set("scope", c.Scope)
+		}
+		if c.Audience != "" {
+			form.Set("audience", c.Audience)
+		}
+		fresh, err = c.request(form)
+		if err != nil {
+			return "", err
+		}
+	}
+	fresh.Key = key
+	if fresh.RefreshToken == "" && cached != nil {
+		fresh.RefreshToken = cached.RefreshToken
+	}
+	memo.tokens[key] = fresh
+	secret.Add(fresh.AccessToken)
+	if store != nil {
+		_ = store.SaveAuthToken(fresh)
+	}
+	return fresh.AccessToken, nil
+}
+
+func (c *OAuth2Config) invalidate(store TokenStore) {
+	key := c.cacheKey()
+	memo.Lock()
+	delete(memo.tokens, key)
+	memo.Unlock()
+	if store != nil {
+		_ = store.DeleteAuthToken(key)
+	}
+}
+
+// request posts form to the token endpoint.
+func (c *OAuth2Config) request(form url.Values) (*storage.AuthToken, error) {
+	if c.ClientAuth == "body" {
+		form.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			form.Set("client_secret", c.ClientSecret)
+		}
+	} else if c.ClientID != "" && c.ClientSecret == "" {
+		form.Set("client_id", c.ClientID)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientAuth == "basic" && c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	resp, err := tokenClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 token request to %s failed: %w", c.TokenURL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("oauth2 token request to %s failed: %d %s", c.TokenURL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		AccessToken  string          `json:"access_token"`
+		TokenType    string          `json:"token_type"`
+		RefreshToken string          `json:"refresh_token"`
+		ExpiresIn    json.RawMessage `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2 token response from %s has no access_token", c.TokenURL)
+	}
+	token := &storage.AuthToken{AccessToken: payload.AccessToken, TokenType: payload.TokenType, RefreshToken: payload.RefreshToken}
+	// expires_in is a number, but some servers send it as a string.
+	var seconds float64
+	if err := json.Unmarshal(payload.ExpiresIn, &seconds); err != nil {
+		var text string
+		if json.Unmarshal(payload.ExpiresIn, &text) == nil {
+			fmt.Sscanf(text, "%g", &seconds)
+		}
+	}
+	if seconds > 0 {
+		token.ExpiresAt = now().Add(time.Duration(seconds * float64(time.Second)))
+	}
+	return token, nil
+}
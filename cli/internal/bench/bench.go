@@ -0,0 +1,479 @@
+// Package bench collects the samples of a load test (kest bench) and turns
+// them into a report: latency percentiles and histogram, throughput, an
+// error breakdown and a per-second time series.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/secret"
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+// Config describes how a benchmark was driven.
+type Config struct {
+	Target   string        `json:"target"`
+	Kind     string        `json:"kind"` // "url" or "flow"
+	Users    int           `json:"users"`
+	RPS      float64       `json:"rps,omitempty"`
+	Duration time.Duration `json:"-"`
+	Warmup   time.Duration `json:"-"`
+	Ramp     []Stage       `json:"ramp,omitempty"`
+}
+
+// Recorder collects the results of every iteration. It is safe for
+// concurrent use by the virtual users.
+type Recorder struct {
+	mu         sync.Mutex
+	start      time.Time // end of the warmup: earlier iterations are dropped
+	samples    []sample
+	iterations []time.Duration
+	failed     int
+	users      map[int]int // second → most active users
+}
+
+type sample struct {
+	step      string
+	offset    time.Duration // since the measurement start
+	duration  time.Duration
+	status    int
+	failed    bool
+	assertion string
+	err       string
+}
+
+// NewRecorder returns a recorder that measures from start on.
+func NewRecorder(start time.Time) *Recorder {
+	return &Recorder{start: start, users: make(map[int]int)}
+}
+
+// AddIteration records one pass over the target: a single request or a
+// whole flow run. Iterations that started during the warmup are dropped.
+func (r *Recorder) AddIteration(start time.Time, elapsed time.Duration, results []summary.TestResult) {
+	if start.Before(r.start) {
+		return
+	}
+	failed := false
+	samples := make([]sample, 0, len(results))
+	for _, res := range results {
+		// Exec steps add to the iteration time, not to the request metrics.
+		if res.Skipped || res.Command != "" {
+			failed = failed || (!res.Skipped && !res.Success)
+			continue
+		}
+		s := sample{
+			step:     res.Name,
+			offset:   res.StartTime.Sub(r.start),
+			duration: res.Duration,
+			status:   res.Status,
+			failed:   !res.Success,
+		}
+		if res.StartTime.IsZero() {
+			s.offset = start.Sub(r.start)
+		}
+		if s.failed {
+			failed = true
+			if checks := res.FailedAssertions(); len(checks) > 0 {
+				s.assertion = checks[0].Expression
+			} else if res.FailedAssertion != "" {
+				s.assertion = res.FailedAssertion
+			} else if res.Error != nil {
+				s.err = res.Error.Error()
+			}
+		}
+		samples = append(samples, s)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, samples...)
+	r.iterations = append(r.iterations, elapsed)
+	if failed {
+		r.failed++
+	}
+}
+
+// SetUsers notes the number of active virtual users at t.
+func (r *Recorder) SetUsers(t time.Time, users int) {
+	if t.Before(r.start) {
+		return
+	}
+	second := int(t.Sub(r.start) / time.Second)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[second] = max(r.users[second], users)
+}
+
+// Progress returns the requests and errors recorded so far.
+func (r *Recorder) Progress() (requests, errors int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.samples {
+		if s.failed {
+			errors++
+		}
+	}
+	return len(r.samples), errors
+}
+
+// Latency summarises a set of durations, in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min_ms"`
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P95  float64 `json:"p95_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// Bucket is one bar of the latency histogram: Count requests took at most
+// LE milliseconds (and more than the previous bucket). The last bucket has
+// no upper bound and LE 0.
+type Bucket struct {
+	LE    float64 `json:"le_ms,omitempty"`
+	Count int     `json:"count"`
+}
+
+// Label is "≤ 50ms", or "> 10.00s" for the unbounded bucket.
+func (b Bucket) Label() string {
+	if b.LE > 0 {
+		return "≤ " + FormatMs(b.LE)
+	}
+	return "> " + FormatMs(histogramBounds[len(histogramBounds)-1])
+}
+
+// StepStats are the numbers of one flow step.
+type StepStats struct {
+	Name     string  `json:"name"`
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	Latency  Latency `json:"latency"`
+}
+
+// Point is one second of the time series.
+type Point struct {
+	Second   int     `json:"second"`
+	Users    int     `json:"users"`
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	P50      float64 `json:"p50_ms"`
+	P95      float64 `json:"p95_ms"`
+	P99      float64 `json:"p99_ms"`
+}
+
+// Report is the outcome of a benchmark.
+type Report struct {
+	Config
+	StartedAt     time.Time         `json:"started_at"`
+	DurationMs    float64           `json:"duration_ms"`
+	WarmupMs      float64           `json:"warmup_ms,omitempty"`
+	Iterations    int               `json:"iterations"`
+	FailedIters   int               `json:"failed_iterations"`
+	Requests      int               `json:"requests"`
+	Errors        int               `json:"errors"`
+	ErrorRate     float64           `json:"error_rate"`
+	Throughput    float64           `json:"requests_per_second"`
+	IterationRate float64           `json:"iterations_per_second"`
+	Latency       Latency           `json:"latency"`
+	IterationTime Latency           `json:"iteration_latency"`
+	Histogram     []Bucket          `json:"histogram"`
+	Statuses      map[string]int    `json:"statuses"`
+	Assertions    map[string]int    `json:"failed_assertions,omitempty"`
+	ErrorKinds    map[string]int    `json:"request_errors,omitempty"`
+	Steps         []StepStats       `json:"steps,omitempty"`
+	Series        []Point           `json:"series"`
+	Thresholds    []ThresholdResult `json:"thresholds,omitempty"`
+}
+
+// histogramBounds are the bucket upper bounds in milliseconds.
+var histogramBounds = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// Report builds the report for a measurement of the given length.
+func (r *Recorder) Report(cfg Config, measured time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := &Report{
+		Config:      cfg,
+		StartedAt:   r.start.Add(-cfg.Warmup),
+		DurationMs:  ms(measured),
+		WarmupMs:    ms(cfg.Warmup),
+		Iterations:  len(r.iterations),
+		FailedIters: r.failed,
+		Requests:    len(r.samples),
+		Statuses:    make(map[string]int),
+		Assertions:  make(map[string]int),
+		ErrorKinds:  make(map[string]int),
+	}
+	rep.Target = secret.Mask(cfg.Target)
+
+	all := make([]time.Duration, 0, len(r.samples))
+	bySecond := make(map[int][]time.Duration)
+	requestsBySecond := make(map[int]int)
+	errorsBySecond := make(map[int]int)
+	byStep := make(map[string]*stepAccumulator)
+	var stepOrder []string
+	lastSecond := int(measured/time.Second) - 1
+	for _, s := range r.samples {
+		second := int(s.offset / time.Second)
+		lastSecond = max(lastSecond, second)
+		requestsBySecond[second]++
+
+		acc, ok := byStep[s.step]
+		if !ok {
+			acc = &stepAccumulator{}
+			byStep[s.step] = acc
+			stepOrder = append(stepOrder, s.step)
+		}
+		acc.requests++
+
+		// Requests that got no response have no latency to report.
+		status := "no response"
+		if s.status > 0 {
+			status = strconv.Itoa(s.status)
+			all = append(all, s.duration)
+			bySecond[second] = append(bySecond[second], s.duration)
+			acc.durations = append(acc.durations, s.duration)
+		}
+		rep.Statuses[status]++
+
+		if !s.failed {
+			continue
+		}
+		rep.Errors++
+		errorsBySecond[second]++
+		acc.errors++
+		switch {
+		case s.assertion != "":
+			rep.Assertions[secret.Mask(s.assertion)]++
+		case s.err != "":
+			rep.ErrorKinds[errorKind(s.err)]++
+		}
+	}
+
+	if rep.Requests > 0 {
+		rep.ErrorRate = float64(rep.Errors) / float64(rep.Requests)
+	}
+	if measured > 0 {
+		rep.Throughput = float64(rep.Requests) / measured.Seconds()
+		rep.IterationRate = float64(rep.Iterations) / measured.Seconds()
+	}
+	rep.Latency = latencyOf(all)
+	rep.IterationTime = latencyOf(append([]time.Duration(nil), r.iterations...))
+	rep.Histogram = histogram(all)
+
+	if cfg.Kind == "flow" {
+		for _, name := range stepOrder {
+			acc := byStep[name]
+			rep.Steps = append(rep.Steps, StepStats{
+				Name:     secret.Mask(name),
+				Requests: acc.requests,
+				Errors:   acc.errors,
+				Latency:  latencyOf(acc.durations),
+			})
+		}
+	}
+
+	for second := 0; second <= lastSecond; second++ {
+		lat := latencyOf(bySecond[second])
+		rep.Series = append(rep.Series, Point{
+			Second:   second,
+			Users:    r.users[second],
+			Requests: requestsBySecond[second],
+			Errors:   errorsBySecond[second],
+			P50:      lat.P50,
+			P95:      lat.P95,
+			P99:      lat.P99,
+		})
+	}
+	return rep
+}
+
+type stepAccumulator struct {
+	requests  int
+	durations []time.Duration
+	errors    int
+}
+
+// latencyOf sorts durations in place and summarises them.
+func latencyOf(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return Latency{
+		Min:  ms(durations[0]),
+		Mean: ms(total / time.Duration(len(durations))),
+		P50:  ms(summary.Percentile(durations, 50)),
+		P90:  ms(summary.Percentile(durations, 90)),
+		P95:  ms(summary.Percentile(durations, 95)),
+		P99:  ms(summary.Percentile(durations, 99)),
+		Max:  ms(durations[len(durations)-1]),
+	}
+}
+
+// histogram spreads durations over histogramBounds, trimming empty buckets
+// at both ends.
+func histogram(durations []time.Duration) []Bucket {
+	buckets := make([]Bucket, len(histogramBounds)+1)
+	for i, bound := range histogramBounds {
+		buckets[i].LE = bound
+	}
+	for _, d := range durations {
+		i := sort.SearchFloat64s(histogramBounds, ms(d))
+		buckets[i].Count++
+	}
+	first, last := -1, -1
+	for i, b := range buckets {
+		if b.Count > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	return buckets[first : last+1]
+}
+
+// errorKind shortens a transport error for grouping, e.g. "connection
+// refused" or "context deadline exceeded".
+func errorKind(msg string) string {
+	msg = secret.Mask(strings.TrimSpace(msg))
+	if i := strings.LastIndex(msg, ": "); i >= 0 && i+2 < len(msg) {
+		msg = msg[i+2:]
+	}
+	if len(msg) > 120 {
+		msg = msg[:117] + "..."
+	}
+	return msg
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// FormatMs renders milliseconds the way the terminal summary does.
+func FormatMs(v float64) string {
+	switch {
+	case v >= 1000:
+		return strconv.FormatFloat(v/1000, 'f', 2, 64) + "s"
+	case v >= 10:
+		return strconv.FormatFloat(v, 'f', 0, 64) + "ms"
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + "ms"
+}
+
+// WriteJSON writes the report as indented JSON.
+func (rep *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// Failed reports whether any threshold failed.
+func (rep *Report) Failed() bool {
+	for _, t := range rep.Thresholds {
+		if !t.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the terminal summary.
+func (rep *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "\n📊 Benchmark: %s\n", rep.Target)
+	fmt.Fprintf(w, "   %d iteration(s), %d request(s) in %s", rep.Iterations, rep.Requests, time.Duration(rep.DurationMs*float64(time.Millisecond)).Round(time.Millisecond))
+	if rep.WarmupMs > 0 {
+		fmt.Fprintf(w, " (after %s warmup)", time.Duration(rep.WarmupMs*float64(time.Millisecond)).Round(time.Millisecond))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "   Throughput: %.1f req/s", rep.Throughput)
+	if rep.Kind == "flow" {
+		fmt.Fprintf(w, ", %.1f iterations/s", rep.IterationRate)
+	}
+	fmt.Fprintf(w, "\n   Errors:     %d (%.2f%%)\n", rep.Errors, rep.ErrorRate*100)
+
+	l := rep.Latency
+	fmt.Fprintf(w, "\n   Latency  min %s  avg %s  p50 %s  p90 %s  p95 %s  p99 %s  max %s\n",
+		FormatMs(l.Min), FormatMs(l.Mean), FormatMs(l.P50), FormatMs(l.P90), FormatMs(l.P95), FormatMs(l.P99), FormatMs(l.Max))
+
+	if len(rep.Histogram) > 0 {
+		fmt.Fprintln(w)
+		peak := 0
+		for _, b := range rep.Histogram {
+			peak = max(peak, b.Count)
+		}
+		for _, b := range rep.Histogram {
+			bar := strings.Repeat("█", (b.Count*40+peak-1)/peak)
+			fmt.Fprintf(w, "   %9s │%s %d\n", b.Label(), bar, b.Count)
+		}
+	}
+
+	if len(rep.Steps) > 0 {
+		fmt.Fprintln(w, "\n   Steps:")
+		for _, s := range rep.Steps {
+			fmt.Fprintf(w, "     %-24s %6d req  %5d err  p50 %-7s p95 %-7s p99 %s\n",
+				truncate(s.Name, 24), s.Requests, s.Errors, FormatMs(s.Latency.P50), FormatMs(s.Latency.P95), FormatMs(s.Latency.P99))
+		}
+	}
+
+	fmt.Fprintln(w, "\n   Status codes:")
+	for _, key := range sortedKeys(rep.Statuses) {
+		fmt.Fprintf(w, "     %-12s %d\n", key, rep.Statuses[key])
+	}
+	if len(rep.Assertions) > 0 {
+		fmt.Fprintln(w, "\n   Failed assertions:")
+		for _, key := range sortedKeys(rep.Assertions) {
+			fmt.Fprintf(w, "     %6d  %s\n", rep.Assertions[key], key)
+		}
+	}
+	if len(rep.ErrorKinds) > 0 {
+		fmt.Fprintln(w, "\n   Request errors:")
+		for _, key := range sortedKeys(rep.ErrorKinds) {
+			fmt.Fprintf(w, "     %6d  %s\n", rep.ErrorKinds[key], key)
+		}
+	}
+
+	if len(rep.Thresholds) > 0 {
+		fmt.Fprintln(w, "\n   Thresholds:")
+		for _, t := range rep.Thresholds {
+			mark := "✅"
+			if !t.Passed {
+				mark = "❌"
+			}
+			fmt.Fprintf(w, "     %s %s (actual %s)\n", mark, t.Expression, t.Actual)
+		}
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func truncate(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
@@ -0,0 +1,122 @@
+package bench
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+func TestParseRampAndUsersAt(t *testing.T) {
+	stages, err := ParseRamp("10s:50, 20s:50, 10s:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if RampDuration(stages) != 40*time.Second || MaxUsers(stages) != 50 {
+		t.Fatalf("unexpected ramp %+v", stages)
+	}
+	for _, tt := range []struct {
+		at   time.Duration
+		want int
+	}{
+		{0, 0}, {5 * time.Second, 25}, {10 * time.Second, 50}, {25 * time.Second, 50},
+		{35 * time.Second, 25}, {40 * time.Second, 0}, {time.Minute, 0},
+	} {
+		if got := UsersAt(stages, tt.at); got != tt.want {
+			t.Errorf("UsersAt(%s) = %d, want %d", tt.at, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "30s", "soon:10", "30s:-1", "0s:10"} {
+		if _, err := ParseRamp(spec); err == nil {
+			t.Errorf("ParseRamp(%q) should fail", spec)
+		}
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	rep := &Report{Requests: 200, Errors: 3, ErrorRate: 0.015, Throughput: 120, Latency: Latency{P95: 250, P99: 800}}
+	for _, tt := range []struct {
+		expr   string
+		passed bool
+		actual string
+	}{
+		{"p95<300ms", true, "250ms"},
+		{"p99 < 500ms", false, "800ms"},
+		{"errors<1%", false, "1.50%"},
+		{"errors<=3", true, "3"},
+		{"rps>=100", true, "120.0"},
+	} {
+		th, err := ParseThreshold(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseThreshold(%q): %v", tt.expr, err)
+		}
+		got := th.Check(rep)
+		if got.Passed != tt.passed || got.Actual != tt.actual {
+			t.Errorf("%s: got %+v", tt.expr, got)
+		}
+	}
+
+	for _, expr := range []string{"p95", "latency<3ms", "p95<fast", "errors<lots"} {
+		if _, err := ParseThreshold(expr); err == nil {
+			t.Errorf("ParseThreshold(%q) should fail", expr)
+		}
+	}
+}
+
+func TestRecorderReport(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	rec := NewRecorder(start)
+
+	// Dropped: it started during the warmup.
+	rec.AddIteration(start.Add(-time.Second), time.Millisecond, []summary.TestResult{{Status: 500, Duration: time.Second}})
+
+	for i := 1; i <= 100; i++ {
+		at := start.Add(time.Duration(i) * 15 * time.Millisecond)
+		res := summary.TestResult{Name: "list", StartTime: at, Status: 200, Duration: time.Duration(i) * time.Millisecond, Success: true}
+		if i%25 == 0 {
+			res.Status, res.Success, res.FailedAssertion = 503, false, "status < 400"
+		}
+		rec.AddIteration(at, res.Duration, []summary.TestResult{res})
+	}
+	rec.AddIteration(start, time.Millisecond, []summary.TestResult{
+		{Name: "list", StartTime: start, Error: errors.New(`Get "http://x": dial tcp: connection refused`)},
+		{Name: "seed", Command: "true", Success: true},
+	})
+	rec.SetUsers(start.Add(500*time.Millisecond), 4)
+
+	rep := rec.Report(Config{Target: "/api/list", Kind: "flow", Users: 4}, 2*time.Second)
+	if rep.Iterations != 101 || rep.Requests != 101 || rep.Errors != 5 || rep.FailedIters != 5 {
+		t.Fatalf("unexpected counts: %d iterations, %d requests, %d errors, %d failed", rep.Iterations, rep.Requests, rep.Errors, rep.FailedIters)
+	}
+	if rep.Throughput != 50.5 {
+		t.Fatalf("throughput = %v", rep.Throughput)
+	}
+	want := Latency{Min: 1, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}
+	if rep.Latency != want {
+		t.Fatalf("latency = %+v, want %+v", rep.Latency, want)
+	}
+	if want := map[string]int{"200": 96, "503": 4, "no response": 1}; !reflect.DeepEqual(rep.Statuses, want) {
+		t.Fatalf("statuses = %v", rep.Statuses)
+	}
+	if rep.Assertions["status < 400"] != 4 || rep.ErrorKinds["connection refused"] != 1 {
+		t.Fatalf("error breakdown: %v %v", rep.Assertions, rep.ErrorKinds)
+	}
+	if len(rep.Steps) != 1 || rep.Steps[0].Name != "list" || rep.Steps[0].Requests != 101 {
+		t.Fatalf("steps = %+v", rep.Steps)
+	}
+
+	var histTotal int
+	for _, b := range rep.Histogram {
+		histTotal += b.Count
+	}
+	if histTotal != 100 || rep.Histogram[0].Label() != "≤ 1.0ms" || rep.Histogram[len(rep.Histogram)-1].Label() != "≤ 100ms" {
+		t.Fatalf("histogram = %+v", rep.Histogram)
+	}
+
+	if len(rep.Series) != 2 || rep.Series[0].Users != 4 || rep.Series[0].Requests+rep.Series[1].Requests != 101 {
+		t.Fatalf("series = %+v", rep.Series)
+	}
+}
@@ -0,0 +1,188 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage ramps the number of virtual users linearly to Target over Duration.
+type Stage struct {
+	Duration time.Duration
+	Target   int
+}
+
+// MarshalJSON writes the duration in Go notation, e.g. {"duration":"30s"}.
+func (s Stage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Duration string `json:"duration"`
+		Target   int    `json:"target"`
+	}{s.Duration.String(), s.Target})
+}
+
+// ParseRamp parses a ramp profile such as "30s:50,1m:50,10s:0": ramp up to
+// 50 users over 30s, hold 50 for a minute, then ramp down over 10s.
+func ParseRamp(spec string) ([]Stage, error) {
+	var stages []Stage
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		dur, target, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ramp stage %q: expected DURATION:USERS, e.g. 30s:50", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(dur))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid ramp stage %q: bad duration %q", part, dur)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(target))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid ramp stage %q: bad user count %q", part, target)
+		}
+		stages = append(stages, Stage{Duration: d, Target: n})
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("empty ramp profile")
+	}
+	return stages, nil
+}
+
+// RampDuration is the total length of the stages.
+func RampDuration(stages []Stage) time.Duration {
+	var total time.Duration
+	for _, s := range stages {
+		total += s.Duration
+	}
+	return total
+}
+
+// MaxUsers is the highest target of the stages.
+func MaxUsers(stages []Stage) int {
+	peak := 0
+	for _, s := range stages {
+		peak = max(peak, s.Target)
+	}
+	return peak
+}
+
+// UsersAt returns how many users should be active elapsed into the ramp,
+// starting from zero. After the last stage its target holds.
+func UsersAt(stages []Stage, elapsed time.Duration) int {
+	from := 0
+	for _, s := range stages {
+		if elapsed < s.Duration {
+			progress := float64(elapsed) / float64(s.Duration)
+			return from + int(math.Round(float64(s.Target-from)*progress))
+		}
+		elapsed -= s.Duration
+		from = s.Target
+	}
+	return from
+}
+
+// Threshold is a pass/fail criterion such as "p95<300ms" or "errors<1%".
+type Threshold struct {
+	Expression string
+	metric     string
+	op         string
+	value      float64
+	percent    bool
+}
+
+// ThresholdResult is a threshold checked against a report.
+type ThresholdResult struct {
+	Expression string `json:"expression"`
+	Actual     string `json:"actual"`
+	Passed     bool   `json:"passed"`
+}
+
+var thresholdMetrics = []string{"min", "avg", "p50", "p90", "p95", "p99", "max", "errors", "rps"}
+
+// ParseThreshold parses METRIC OP VALUE. Latency metrics (min, avg, p50,
+// p90, p95, p99, max) take a duration, errors a count or a percentage and
+// rps a number.
+func ParseThreshold(expr string) (Threshold, error) {
+	compact := strings.ReplaceAll(strings.TrimSpace(expr), " ", "")
+	for _, op := range []string{"<=", ">=", "<", ">"} {
+		metric, value, ok := strings.Cut(compact, op)
+		if !ok {
+			continue
+		}
+		t := Threshold{Expression: strings.TrimSpace(expr), metric: strings.ToLower(metric), op: op}
+		if !containsString(thresholdMetrics, t.metric) {
+			return Threshold{}, fmt.Errorf("invalid threshold %q: unknown metric %q (use %s)", expr, metric, strings.Join(thresholdMetrics, ", "))
+		}
+		switch {
+		case t.metric == "errors" && strings.HasSuffix(value, "%"):
+			n, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil {
+				return Threshold{}, fmt.Errorf("invalid threshold %q: bad percentage %q", expr, value)
+			}
+			t.value, t.percent = n, true
+		case t.metric == "errors" || t.metric == "rps":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return Threshold{}, fmt.Errorf("invalid threshold %q: bad number %q", expr, value)
+			}
+			t.value = n
+		default:
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return Threshold{}, fmt.Errorf("invalid threshold %q: bad duration %q (e.g. 300ms)", expr, value)
+			}
+			t.value = ms(d)
+		}
+		return t, nil
+	}
+	return Threshold{}, fmt.Errorf("invalid threshold %q: expected METRIC<VALUE, e.g. p95<300ms or errors<1%%", expr)
+}
+
+// Check evaluates the threshold against rep.
+func (t Threshold) Check(rep *Report) ThresholdResult {
+	var actual float64
+	var shown string
+	switch t.metric {
+	case "errors":
+		actual, shown = float64(rep.Errors), strconv.Itoa(rep.Errors)
+		if t.percent {
+			actual = rep.ErrorRate * 100
+			shown = strconv.FormatFloat(actual, 'f', 2, 64) + "%"
+		}
+	case "rps":
+		actual = rep.Throughput
+		shown = strconv.FormatFloat(actual, 'f', 1, 64)
+	default:
+		actual = map[string]float64{
+			"min": rep.Latency.Min, "avg": rep.Latency.Mean, "p50": rep.Latency.P50, "p90": rep.Latency.P90,
+			"p95": rep.Latency.P95, "p99": rep.Latency.P99, "max": rep.Latency.Max,
+		}[t.metric]
+		shown = FormatMs(actual)
+	}
+
+	var passed bool
+	switch t.op {
+	case "<":
+		passed = actual < t.value
+	case "<=":
+		passed = actual <= t.value
+	case ">":
+		passed = actual > t.value
+	case ">=":
+		passed = actual >= t.value
+	}
+	return ThresholdResult{Expression: t.Expression, Actual: shown, Passed: passed}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,6 +24,13 @@ type RequestOptions struct {
 	Body    []byte
 	Timeout time.Duration
 	Stream  bool
+	Jar     http.CookieJar // optional; receives Set-Cookie and supplies Cookie headers
+
+	// Session, when set, consumes the live response instead of it being read
+	// in full (for a 101 Switching Protocols the body is the upgraded
+	// connection) and returns the body to report. Timeout then only bounds
+	// getting the response headers; the session enforces its own deadline.
+	Session func(resp *http.Response) ([]byte, error)
 }
 
 type Response struct {
@@ -44,6 +52,7 @@ func Execute(opt RequestOptions) (*Response, error) {
 	client := &http.Client{
 		Timeout:   opt.Timeout,
 		Transport: sharedTransport,
+		Jar:       opt.Jar,
 	}
 
 	req, err := http.NewRequest(opt.Method, opt.URL, bytes.NewBuffer(opt.Body))
@@ -51,6 +60,18 @@ func Execute(opt RequestOptions) (*Response, error) {
 		return nil, err
 	}
 
+	// http.Client.Timeout would also cut a session short, so only the wait
+	// for the response headers is timed.
+	stopHeaderTimer := func() {}
+	if opt.Session != nil && opt.Timeout > 0 {
+		client.Timeout = 0
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		timer := time.AfterFunc(opt.Timeout, cancel)
+		stopHeaderTimer = func() { timer.Stop() }
+		req = req.WithContext(ctx)
+	}
+
 	for k, v := range opt.Headers {
 		req.Header.Set(k, v)
 	}
@@ -58,11 +79,25 @@ func Execute(opt RequestOptions) (*Response, error) {
 	start := time.Now()
 	resp, err := client.Do(req)
 	duration := time.Since(start)
+	stopHeaderTimer()
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if opt.Session != nil {
+		body, err := opt.Session(resp)
+		if err != nil {
+			return nil, err
+		}
+		return &Response{
+			Status:   resp.StatusCode,
+			Headers:  resp.Header,
+			Body:     body,
+			Duration: time.Since(start),
+		}, nil
+	}
+
 	if opt.Stream {
 		return handleStream(resp, duration)
 	}
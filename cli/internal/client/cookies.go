@@ -0,0 +1,134 @@
+package client
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SavedCookie is a cookie as received, flattened so it can be persisted and
+// loaded back into a Jar later.
+type SavedCookie struct {
+	Name     string
+	Value    string
+	Domain   string // host the cookie belongs to, without a leading dot
+	Path     string
+	HostOnly bool // no Domain attribute: only sent back to Domain itself
+	Secure   bool
+	HTTPOnly bool
+	Expires  time.Time // zero for session cookies
+}
+
+// Jar is an http.CookieJar that also remembers what it stored, so a run's
+// cookies can be exported (cookiejar.Jar only answers per-URL lookups).
+type Jar struct {
+	inner *cookiejar.Jar
+
+	mu    sync.Mutex
+	saved map[string]SavedCookie
+}
+
+// NewJar returns an empty cookie jar.
+func NewJar() *Jar {
+	inner, _ := cookiejar.New(nil) // only fails for a broken PublicSuffixList
+	return &Jar{inner: inner, saved: make(map[string]SavedCookie)}
+}
+
+// SetCookies implements http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.inner.SetCookies(u, cookies)
+
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, c := range cookies {
+		saved := SavedCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   strings.TrimPrefix(strings.ToLower(c.Domain), "."),
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if saved.Domain == "" {
+			saved.Domain = strings.ToLower(u.Hostname())
+			saved.HostOnly = true
+		}
+		if saved.Path == "" || !strings.HasPrefix(saved.Path, "/") {
+			saved.Path = "/"
+		}
+
+		key := saved.Domain + ";" + saved.Path + ";" + saved.Name
+		switch {
+		case c.MaxAge < 0:
+			delete(j.saved, key)
+			continue
+		case c.MaxAge > 0:
+			saved.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			saved.Expires = c.Expires
+		}
+		if !saved.Expires.IsZero() && !saved.Expires.After(now) {
+			delete(j.saved, key)
+			continue
+		}
+		j.saved[key] = saved
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.inner.Cookies(u)
+}
+
+// Saved returns the unexpired cookies the jar holds, sorted by domain, path
+// and name.
+func (j *Jar) Saved() []SavedCookie {
+	now := time.Now()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	out := make([]SavedCookie, 0, len(j.saved))
+	for _, c := range j.saved {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Domain != out[b].Domain {
+			return out[a].Domain < out[b].Domain
+		}
+		if out[a].Path != out[b].Path {
+			return out[a].Path < out[b].Path
+		}
+		return out[a].Name < out[b].Name
+	})
+	return out
+}
+
+// Load puts previously saved cookies back into the jar.
+func (j *Jar) Load(cookies []SavedCookie) {
+	for _, c := range cookies {
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+			Expires:  c.Expires,
+		}
+		if !c.HostOnly {
+			cookie.Domain = c.Domain
+		}
+		j.SetCookies(&url.URL{Scheme: scheme, Host: c.Domain, Path: c.Path}, []*http.Cookie{cookie})
+	}
+}
@@ -4,76 +4,286 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/desc/protoprint"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"github.com/jhump/protoreflect/grpcreflect"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type GRPCOptions struct {
 	Address   string
-	Method    string // service/method
-	Data      string // JSON
-	ProtoPath string
+	Method    string            // package.Service/Method
+	Data      string            // JSON request; for client and bidi streaming, a JSON array or one object after another
+	ProtoPath string            // .proto file; descriptors come from server reflection when empty
+	Metadata  map[string]string // request metadata
 	Timeout   time.Duration
 	TLS       bool   // Use TLS for the connection
 	CertFile  string // Path to CA certificate file (optional, uses system pool if empty)
 }
 
+// GRPCResponse is the outcome of a call that reached the point of being
+// sent; a call the server failed still has one, with Code set.
 type GRPCResponse struct {
-	Data     []byte
-	Status   string
+	Data     []byte // JSON: the response, an array of them for server and bidi streaming, or the error status
+	Code     codes.Code
+	Message  string      // status message
+	Metadata metadata.MD // response headers and trailers, plus grpc-status and grpc-message
 	Duration time.Duration
 }
 
+// Status is the canonical name of the response code, e.g. NOT_FOUND.
+func (r *GRPCResponse) Status() string {
+	return GRPCCodeName(r.Code)
+}
+
+var grpcCodeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// GRPCCodeName is the canonical name of a status code, e.g. NOT_FOUND.
+func GRPCCodeName(code codes.Code) string {
+	if int(code) < len(grpcCodeNames) {
+		return grpcCodeNames[code]
+	}
+	return fmt.Sprintf("CODE(%d)", code)
+}
+
+// grpcHTTPStatus maps status codes to HTTP statuses the way google.rpc.Code
+// documents them, so history, reports and status assertions stay meaningful.
+var grpcHTTPStatus = []int{200, 499, 500, 400, 504, 404, 409, 403, 429, 400, 409, 400, 501, 500, 503, 500, 401}
+
+// HTTPStatus is the HTTP status equivalent to the response code.
+func (r *GRPCResponse) HTTPStatus() int {
+	if int(r.Code) < len(grpcHTTPStatus) {
+		return grpcHTTPStatus[r.Code]
+	}
+	return 500
+}
+
 func ExecuteGRPC(opts GRPCOptions) (*GRPCResponse, error) {
-	start := time.Now()
 	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
 	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(opts.Metadata))
 
-	// Parse proto
-	p := protoparse.Parser{}
-	fds, err := p.ParseFiles(opts.ProtoPath)
+	conn, err := dialGRPC(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse proto: %v", err)
+		return nil, err
 	}
+	defer conn.Close()
 
-	var methodDesc *desc.MethodDescriptor
-	// Search in all files
-	// This is simplified, usually we'd want to specify the package or service more clearly
-	// Format expected: package.Service/Method
-	found := false
-	for _, fd := range fds {
-		for _, sd := range fd.GetServices() {
-			for _, md := range sd.GetMethods() {
-				fullName := fmt.Sprintf("%s/%s", sd.GetFullyQualifiedName(), md.GetName())
-				if fullName == opts.Method || md.GetName() == opts.Method {
-					methodDesc = md
-					found = true
-					break
+	source, err := loadGRPCDescriptors(ctx, conn, opts.ProtoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer source.close()
+
+	methodDesc, err := source.method(opts.Method)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := parseGRPCRequests(methodDesc, opts.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	responses, header, trailer, callErr := invokeGRPC(ctx, conn, methodDesc, requests)
+	duration := time.Since(start)
+
+	st := status.Convert(callErr)
+	resp := &GRPCResponse{
+		Code:     st.Code(),
+		Message:  st.Message(),
+		Metadata: metadata.Join(header, trailer),
+		Duration: duration,
+	}
+	resp.Metadata.Set("grpc-status", strconv.Itoa(int(st.Code())))
+	if st.Message() != "" {
+		resp.Metadata.Set("grpc-message", st.Message())
+	}
+
+	rendered := make([]json.RawMessage, 0, len(responses))
+	for _, msg := range responses {
+		data, err := marshalGRPCMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response to JSON: %v", err)
+		}
+		rendered = append(rendered, data)
+	}
+	switch {
+	case methodDesc.IsServerStreaming():
+		resp.Data, _ = json.Marshal(rendered)
+	case len(rendered) == 1:
+		resp.Data = rendered[0]
+	default:
+		// The status in the shape gRPC-gateway uses for errors.
+		resp.Data, _ = json.Marshal(map[string]any{"code": int(st.Code()), "message": st.Message()})
+	}
+	return resp, nil
+}
+
+// invokeGRPC makes the call in the style the method declares. Response
+// messages received before a failure are returned along with it.
+func invokeGRPC(ctx context.Context, conn *grpc.ClientConn, md *desc.MethodDescriptor, requests []proto.Message) (responses []proto.Message, header, trailer metadata.MD, err error) {
+	stub := grpcdynamic.NewStub(conn)
+	switch {
+	case md.IsClientStreaming() && md.IsServerStreaming():
+		stream, err := stub.InvokeRpcBidiStream(ctx, md)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sendErr := make(chan error, 1)
+		go func() {
+			for _, req := range requests {
+				if err := stream.SendMsg(req); err != nil {
+					// The reason surfaces from RecvMsg.
+					sendErr <- nil
+					return
 				}
 			}
-			if found {
+			sendErr <- stream.CloseSend()
+		}()
+		for {
+			msg, err := stream.RecvMsg()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				header, _ = stream.Header()
+				return responses, header, stream.Trailer(), err
+			}
+			responses = append(responses, msg)
+		}
+		if err := <-sendErr; err != nil {
+			return responses, nil, nil, err
+		}
+		header, _ = stream.Header()
+		return responses, header, stream.Trailer(), nil
+
+	case md.IsClientStreaming():
+		stream, err := stub.InvokeRpcClientStream(ctx, md)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, req := range requests {
+			if err := stream.SendMsg(req); err != nil {
+				break // CloseAndReceive reports why
+			}
+		}
+		msg, err := stream.CloseAndReceive()
+		header, _ = stream.Header()
+		if err != nil {
+			return nil, header, stream.Trailer(), err
+		}
+		return []proto.Message{msg}, header, stream.Trailer(), nil
+
+	case md.IsServerStreaming():
+		stream, err := stub.InvokeRpcServerStream(ctx, md, requests[0])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for {
+			msg, err := stream.RecvMsg()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				header, _ = stream.Header()
+				return responses, header, stream.Trailer(), err
+			}
+			responses = append(responses, msg)
+		}
+		header, _ = stream.Header()
+		return responses, header, stream.Trailer(), nil
+
+	default:
+		msg, err := stub.InvokeRpc(ctx, md, requests[0], grpc.Header(&header), grpc.Trailer(&trailer))
+		if err != nil {
+			return nil, header, trailer, err
+		}
+		return []proto.Message{msg}, header, trailer, nil
+	}
+}
+
+// parseGRPCRequests decodes the JSON request messages. Unary and server
+// streaming methods take exactly one (an empty body means {}); client and
+// bidi streaming methods take a JSON array or objects one after another.
+func parseGRPCRequests(md *desc.MethodDescriptor, data string) ([]proto.Message, error) {
+	data = strings.TrimSpace(data)
+	var raws []json.RawMessage
+	switch {
+	case data == "" && !md.IsClientStreaming():
+		raws = []json.RawMessage{json.RawMessage("{}")}
+	case md.IsClientStreaming() && strings.HasPrefix(data, "["):
+		if err := json.Unmarshal([]byte(data), &raws); err != nil {
+			return nil, fmt.Errorf("failed to parse request messages: %v", err)
+		}
+	default:
+		dec := json.NewDecoder(strings.NewReader(data))
+		for {
+			var raw json.RawMessage
+			err := dec.Decode(&raw)
+			if err == io.EOF {
 				break
 			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse request JSON: %v", err)
+			}
+			raws = append(raws, raw)
 		}
-		if found {
-			break
+	}
+	if !md.IsClientStreaming() && len(raws) != 1 {
+		return nil, fmt.Errorf("%s takes one request message, got %d", md.GetName(), len(raws))
+	}
+
+	messages := make([]proto.Message, 0, len(raws))
+	for i, raw := range raws {
+		msg := dynamic.NewMessage(md.GetInputType())
+		if err := msg.UnmarshalJSON(raw); err != nil {
+			return nil, fmt.Errorf("failed to parse request message %d as %s: %v", i+1, md.GetInputType().GetFullyQualifiedName(), err)
 		}
+		messages = append(messages, msg)
 	}
+	return messages, nil
+}
 
-	if methodDesc == nil {
-		return nil, fmt.Errorf("method not found in proto: %s", opts.Method)
+// marshalGRPCMessage renders a message as JSON with default values, so
+// assertions can address every field.
+func marshalGRPCMessage(msg proto.Message) ([]byte, error) {
+	dm, ok := msg.(*dynamic.Message)
+	if !ok {
+		var err error
+		if dm, err = dynamic.AsDynamicMessage(msg); err != nil {
+			return nil, err
+		}
 	}
+	return dm.MarshalJSONPB(&jsonpb.Marshaler{EmitDefaults: true})
+}
 
-	// Dial
+func dialGRPC(ctx context.Context, opts GRPCOptions) (*grpc.ClientConn, error) {
 	var dialOpt grpc.DialOption
 	if opts.TLS {
 		tlsConf := &tls.Config{}
@@ -96,32 +306,198 @@ func ExecuteGRPC(opts GRPCOptions) (*GRPCResponse, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial: %v", err)
 	}
-	defer conn.Close()
+	return conn, nil
+}
 
-	stub := grpcdynamic.NewStub(conn)
+// grpcDescriptors finds services and messages in parsed .proto files or,
+// without them, through the server reflection service.
+type grpcDescriptors struct {
+	files []*desc.FileDescriptor
+	refl  *grpcreflect.Client
+}
 
-	// Prepare request
-	reqMsg := dynamic.NewMessage(methodDesc.GetInputType())
-	err = reqMsg.UnmarshalJSON([]byte(opts.Data))
+func loadGRPCDescriptors(ctx context.Context, conn *grpc.ClientConn, protoPath string) (*grpcDescriptors, error) {
+	if protoPath == "" {
+		return &grpcDescriptors{refl: grpcreflect.NewClientAuto(ctx, conn)}, nil
+	}
+	p := protoparse.Parser{
+		ImportPaths:           []string{filepath.Dir(protoPath), "."},
+		IncludeSourceCodeInfo: true,
+	}
+	fds, err := p.ParseFiles(filepath.Base(protoPath))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse input JSON as proto: %v", err)
+		return nil, fmt.Errorf("failed to parse proto: %v", err)
 	}
+	return &grpcDescriptors{files: fds}, nil
+}
+
+func (d *grpcDescriptors) close() {
+	if d.refl != nil {
+		d.refl.Reset()
+	}
+}
 
-	// Call
-	resp, err := stub.InvokeRpc(ctx, methodDesc, reqMsg)
+// reflectionError explains a failed reflection lookup.
+func reflectionError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return fmt.Errorf("server reflection is not enabled on this server; pass --proto (or @proto) instead")
+	}
+	return fmt.Errorf("server reflection failed: %v", err)
+}
+
+func (d *grpcDescriptors) services() ([]string, error) {
+	var names []string
+	if d.refl != nil {
+		list, err := d.refl.ListServices()
+		if err != nil {
+			return nil, reflectionError(err)
+		}
+		names = list
+	}
+	for _, fd := range d.files {
+		for _, sd := range fd.GetServices() {
+			names = append(names, sd.GetFullyQualifiedName())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *grpcDescriptors) symbol(name string) (desc.Descriptor, error) {
+	name = strings.TrimPrefix(name, ".")
+	if d.refl != nil {
+		fd, err := d.refl.FileContainingSymbol(name)
+		if err != nil {
+			if grpcreflect.IsElementNotFoundError(err) {
+				return nil, fmt.Errorf("symbol not found: %s", name)
+			}
+			return nil, reflectionError(err)
+		}
+		if found := fd.FindSymbol(name); found != nil {
+			return found, nil
+		}
+	}
+	for _, fd := range d.files {
+		if found := fd.FindSymbol(name); found != nil {
+			return found, nil
+		}
+	}
+	return nil, fmt.Errorf("symbol not found: %s", name)
+}
+
+// method resolves "package.Service/Method" (or package.Service.Method).
+// With .proto files a bare method name is enough when it is unambiguous.
+func (d *grpcDescriptors) method(name string) (*desc.MethodDescriptor, error) {
+	service, method, ok := strings.Cut(name, "/")
+	if !ok {
+		if i := strings.LastIndex(name, "."); i > 0 {
+			service, method = name[:i], name[i+1:]
+		} else {
+			service, method = "", name
+		}
+	}
+	if service == "" {
+		for _, fd := range d.files {
+			for _, sd := range fd.GetServices() {
+				if md := sd.FindMethodByName(method); md != nil {
+					return md, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("method not found: %s (expected package.Service/Method)", name)
+	}
+
+	found, err := d.symbol(service)
 	if err != nil {
-		return nil, fmt.Errorf("gRPC call failed: %v", err)
+		return nil, err
+	}
+	sd, ok := found.(*desc.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
 	}
+	md := sd.FindMethodByName(method)
+	if md == nil {
+		return nil, fmt.Errorf("method not found: %s has no method %s", service, method)
+	}
+	return md, nil
+}
 
-	respMsg := resp.(*dynamic.Message)
-	respJSON, err := respMsg.MarshalJSON()
+// ListGRPC lists the services a server (or the .proto file) offers, or the
+// methods of service when it is not empty.
+func ListGRPC(opts GRPCOptions, service string) ([]string, error) {
+	return withGRPCDescriptors(opts, func(d *grpcDescriptors) ([]string, error) {
+		if service == "" {
+			return d.services()
+		}
+		found, err := d.symbol(service)
+		if err != nil {
+			return nil, err
+		}
+		sd, ok := found.(*desc.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", service)
+		}
+		var methods []string
+		for _, md := range sd.GetMethods() {
+			methods = append(methods, md.GetFullyQualifiedName())
+		}
+		return methods, nil
+	})
+}
+
+// DescribeGRPC prints a service, method or message as .proto source. An
+// empty symbol describes every service.
+func DescribeGRPC(opts GRPCOptions, symbol string) (string, error) {
+	printer := &protoprint.Printer{Compact: true}
+	out, err := withGRPCDescriptors(opts, func(d *grpcDescriptors) ([]string, error) {
+		names := []string{symbol}
+		if symbol == "" {
+			var err error
+			if names, err = d.services(); err != nil {
+				return nil, err
+			}
+		}
+		var blocks []string
+		for _, name := range names {
+			found, err := d.symbol(name)
+			if err != nil {
+				return nil, err
+			}
+			text, err := printer.PrintProtoToString(found)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, strings.TrimSpace(text))
+		}
+		return blocks, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response to JSON: %v", err)
+		return "", err
 	}
+	return strings.Join(out, "\n\n") + "\n", nil
+}
+
+func withGRPCDescriptors(opts GRPCOptions, fn func(*grpcDescriptors) ([]string, error)) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(opts.Metadata))
 
-	return &GRPCResponse{
-		Data:     respJSON,
-		Status:   "OK",
-		Duration: time.Since(start),
-	}, nil
+	if opts.ProtoPath != "" {
+		source, err := loadGRPCDescriptors(ctx, nil, opts.ProtoPath)
+		if err != nil {
+			return nil, err
+		}
+		return fn(source)
+	}
+	conn, err := dialGRPC(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	source, err := loadGRPCDescriptors(ctx, conn, "")
+	if err != nil {
+		return nil, err
+	}
+	defer source.close()
+	return fn(source)
 }
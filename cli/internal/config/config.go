@@ -23,6 +23,7 @@ type Config struct {
 	AIKey                   string                 `yaml:"ai_key" mapstructure:"ai_key"`
 	AIModel                 string                 `yaml:"ai_model" mapstructure:"ai_model"`
 	AIBaseURL               string                 `yaml:"ai_base_url" mapstructure:"ai_base_url"`
+	SecretVars              []string               `yaml:"secret_vars" mapstructure:"secret_vars"` // variable names or globs (e.g. "*_key") masked in output and encrypted at rest
 }
 
 type Defaults struct {
@@ -99,6 +100,9 @@ func SaveToPath(conf *Config, configPath string) error {
 	v.Set("ai_key", conf.AIKey)
 	v.Set("ai_model", conf.AIModel)
 	v.Set("ai_base_url", conf.AIBaseURL)
+	if len(conf.SecretVars) > 0 {
+		v.Set("secret_vars", conf.SecretVars)
+	}
 
 	return v.WriteConfigAs(configPath)
 }
@@ -0,0 +1,127 @@
+package curl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDevtoolsCommand(t *testing.T) {
+	t.Parallel()
+
+	command := `curl 'https://api.example.com/login?next=%2Fhome' \
+  -H 'accept: application/json, text/plain, */*' \
+  -H $'x-note: it\'s\tfine' \
+  -H 'content-type: application/json' \
+  --data-raw '{"user":"admin","pass":"it'\''s"}' \
+  --compressed`
+
+	req, err := Parse(command)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if req.Method != "POST" || req.URL != "https://api.example.com/login?next=%2Fhome" {
+		t.Fatalf("unexpected request line: %s %s", req.Method, req.URL)
+	}
+	if req.Header("Accept") != "application/json, text/plain, */*" || req.Header("X-Note") != "it's\tfine" {
+		t.Fatalf("unexpected headers: %+v", req.Headers)
+	}
+	if len(req.Headers) != 3 {
+		t.Fatalf("expected no default Content-Type on top of the explicit one, got %+v", req.Headers)
+	}
+	if req.Body != `{"user":"admin","pass":"it's"}` || !req.Compressed {
+		t.Fatalf("unexpected body or flags: %q %v", req.Body, req.Compressed)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		command string
+		check   func(*Request) bool
+	}{
+		{"explicit method", `curl -XDELETE example.com/users/1`, func(r *Request) bool {
+			return r.Method == "DELETE" && r.URL == "http://example.com/users/1"
+		}},
+		{"combined short flags", `curl -sSLk -X PUT https://x.io -d a=1 -d b=2`, func(r *Request) bool {
+			return r.Method == "PUT" && r.Body == "a=1&b=2" && r.Insecure && r.Header("Content-Type") == "application/x-www-form-urlencoded"
+		}},
+		{"basic auth", `curl -u admin:secret https://x.io`, func(r *Request) bool {
+			return r.Method == "GET" && r.Header("Authorization") == "Basic YWRtaW46c2VjcmV0"
+		}},
+		{"get moves data to query", `curl -G https://x.io/search?a=1 --data-urlencode 'q=hello world'`, func(r *Request) bool {
+			return r.Method == "GET" && r.URL == "https://x.io/search?a=1&q=hello+world" && r.Body == ""
+		}},
+		{"multipart form", `curl https://x.io/upload -F 'file=@./a.png;type=image/png' -F name=test --form-string 'at=@literal'`, func(r *Request) bool {
+			return r.Method == "POST" && len(r.Form) == 3 &&
+				r.Form[0] == (FormField{Name: "file", Value: "./a.png", File: true}) &&
+				r.Form[1] == (FormField{Name: "name", Value: "test"}) &&
+				r.Form[2] == (FormField{Name: "at", Value: "@literal"})
+		}},
+		{"json shorthand", `curl --json '{"a":1}' https://x.io`, func(r *Request) bool {
+			return r.Method == "POST" && r.Header("Content-Type") == "application/json" && r.Header("Accept") == "application/json"
+		}},
+		{"head and double quotes", `curl -I "https://x.io/\"q\"" -A "kest \$HOME"`, func(r *Request) bool {
+			return r.Method == "HEAD" && r.URL == `https://x.io/"q"` && r.Header("User-Agent") == "kest $HOME"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := Parse(tt.command)
+			if err != nil {
+				t.Fatalf("Parse returned error: %v", err)
+			}
+			if !tt.check(req) {
+				t.Fatalf("unexpected request: %+v", req)
+			}
+		})
+	}
+
+	for _, bad := range []string{`curl`, `curl -H 'x: y'`, `curl 'https://x.io`, `curl https://x.io -X`} {
+		if _, err := Parse(bad); err == nil {
+			t.Fatalf("expected %q to fail", bad)
+		}
+	}
+}
+
+func TestSnippetRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	req := &Request{
+		Method:  "POST",
+		URL:     "https://api.example.com/users?x=1",
+		Headers: []Header{{Name: "Content-Type", Value: "application/json"}, {Name: "X-Quote", Value: "it's"}},
+		Body:    "{\"name\":\"Ada\",\n\"note\":\"line\"}",
+	}
+	out, err := Snippet(req, "curl")
+	if err != nil {
+		t.Fatalf("Snippet returned error: %v", err)
+	}
+	if strings.Contains(out, "-X POST") {
+		t.Fatalf("POST with a body should not need -X:\n%s", out)
+	}
+	back, err := Parse(out)
+	if err != nil {
+		t.Fatalf("rendered curl does not parse: %v\n%s", err, out)
+	}
+	if back.Method != req.Method || back.URL != req.URL || back.Body != req.Body || back.Header("X-Quote") != "it's" {
+		t.Fatalf("curl snippet did not round-trip:\n%s\n%+v", out, back)
+	}
+
+	httpie, _ := Snippet(req, "httpie")
+	if !strings.HasPrefix(httpie, "http POST 'https://api.example.com/users?x=1'") || !strings.Contains(httpie, `'X-Quote:it'\''s'`) || !strings.Contains(httpie, "--raw ") {
+		t.Fatalf("unexpected httpie snippet:\n%s", httpie)
+	}
+
+	fetch, _ := Snippet(req, "fetch")
+	for _, want := range []string{`fetch("https://api.example.com/users?x=1"`, `method: "POST"`, `"X-Quote": "it's",`, `body: JSON.stringify({`, `"name": "Ada"`} {
+		if !strings.Contains(fetch, want) {
+			t.Fatalf("fetch snippet is missing %q:\n%s", want, fetch)
+		}
+	}
+
+	if _, err := Snippet(req, "python"); err == nil {
+		t.Fatalf("expected an unknown format to fail")
+	}
+}
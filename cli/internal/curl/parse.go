@@ -0,0 +1,407 @@
+// Package curl converts between curl command lines and HTTP requests, and
+// renders requests as curl, HTTPie or JavaScript fetch snippets.
+package curl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Request is an HTTP request as described by a curl command.
+type Request struct {
+	Method  string
+	URL     string
+	Headers []Header
+	Body    string
+	// Form holds -F fields; a request has either Form or Body.
+	Form []FormField
+	// Compressed is set by --compressed.
+	Compressed bool
+	// Insecure is set by -k/--insecure.
+	Insecure bool
+}
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+// FormField is a multipart field. File fields carry the path in Value.
+type FormField struct {
+	Name  string
+	Value string
+	File  bool
+}
+
+// Header returns the first header called name, case-insensitively.
+func (r *Request) Header(name string) string {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+func (r *Request) setDefaultHeader(name, value string) {
+	if r.Header(name) == "" {
+		r.Headers = append(r.Headers, Header{Name: name, Value: value})
+	}
+}
+
+// Options that take a value; every other option is treated as a switch.
+var valueOptions = map[string]bool{
+	"-X": true, "--request": true,
+	"-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true, "--data-urlencode": true, "--json": true,
+	"-F": true, "--form": true, "--form-string": true,
+	"-u": true, "--user": true,
+	"-A": true, "--user-agent": true,
+	"-b": true, "--cookie": true,
+	"-e": true, "--referer": true,
+	"--url": true, "--oauth2-bearer": true,
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"-x": true, "--proxy": true, "-U": true, "--proxy-user": true, "-w": true, "--write-out": true,
+	"-E": true, "--cert": true, "--key": true, "--cacert": true, "--capath": true,
+	"-c": true, "--cookie-jar": true, "-T": true, "--upload-file": true, "-r": true, "--range": true,
+	"--retry": true, "--retry-delay": true, "--retry-max-time": true, "--max-redirs": true,
+	"--resolve": true, "--connect-to": true, "--interface": true, "-K": true, "--config": true,
+	"-D": true, "--dump-header": true, "--limit-rate": true, "-y": true, "--speed-time": true, "-Y": true, "--speed-limit": true,
+}
+
+// Parse parses a curl command line as pasted from a shell, browser devtools
+// or API docs. Line continuations and bash quoting ('...', "...", $'...')
+// are understood.
+func Parse(command string) (*Request, error) {
+	args, err := Split(command)
+	if err != nil {
+		return nil, err
+	}
+	return ParseArgs(args)
+}
+
+// ParseArgs parses an already split curl command line. The leading "curl"
+// is optional.
+func ParseArgs(args []string) (*Request, error) {
+	if len(args) > 0 && isCurl(args[0]) {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty curl command")
+	}
+
+	req := &Request{}
+	var data []string
+	var get, head bool
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" || arg[0] != '-' || arg == "-" {
+			if req.URL == "" {
+				req.URL = arg
+			}
+			continue
+		}
+
+		name, value := arg, ""
+		if strings.HasPrefix(arg, "--") {
+			if valueOptions[name] {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("option %s needs a value", name)
+				}
+				i++
+				value = args[i]
+			}
+		} else {
+			// Short options may be combined (-sSL) and take an attached
+			// value (-XPOST, -HAccept:x).
+			name = ""
+			for j := 1; j < len(arg); j++ {
+				opt := "-" + string(arg[j])
+				if !valueOptions[opt] {
+					switch opt {
+					case "-G":
+						get = true
+					case "-I":
+						head = true
+					case "-k":
+						req.Insecure = true
+					}
+					continue
+				}
+				name = opt
+				if rest := arg[j+1:]; rest != "" {
+					value = rest
+				} else if i+1 < len(args) {
+					i++
+					value = args[i]
+				} else {
+					return nil, fmt.Errorf("option %s needs a value", opt)
+				}
+				break
+			}
+			if name == "" {
+				continue
+			}
+		}
+
+		switch name {
+		case "-X", "--request":
+			req.Method = strings.ToUpper(value)
+		case "-H", "--header":
+			header, ok := parseHeader(value)
+			if ok {
+				req.Headers = append(req.Headers, header)
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, urlencodeData(value))
+		case "--json":
+			data = append(data, value)
+			req.setDefaultHeader("Content-Type", "application/json")
+			req.setDefaultHeader("Accept", "application/json")
+		case "-F", "--form", "--form-string":
+			field, err := parseFormField(value, name == "--form-string")
+			if err != nil {
+				return nil, err
+			}
+			req.Form = append(req.Form, field)
+		case "-u", "--user":
+			req.Headers = append(req.Headers, Header{Name: "Authorization", Value: "Basic " + base64.StdEncoding.EncodeToString([]byte(value))})
+		case "--oauth2-bearer":
+			req.Headers = append(req.Headers, Header{Name: "Authorization", Value: "Bearer " + value})
+		case "-A", "--user-agent":
+			req.Headers = append(req.Headers, Header{Name: "User-Agent", Value: value})
+		case "-e", "--referer":
+			req.Headers = append(req.Headers, Header{Name: "Referer", Value: value})
+		case "-b", "--cookie":
+			// Without "=" the value names a cookie file, which has no
+			// request equivalent.
+			if strings.Contains(value, "=") {
+				req.Headers = append(req.Headers, Header{Name: "Cookie", Value: value})
+			}
+		case "--url":
+			req.URL = value
+		case "--compressed":
+			req.Compressed = true
+		case "--insecure":
+			req.Insecure = true
+		case "--get":
+			get = true
+		case "--head":
+			head = true
+		}
+	}
+
+	if req.URL == "" {
+		return nil, fmt.Errorf("no URL in curl command")
+	}
+	if !strings.Contains(req.URL, "://") {
+		req.URL = "http://" + req.URL
+	}
+	if _, err := url.Parse(req.URL); err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", req.URL, err)
+	}
+	if len(data) > 0 && len(req.Form) > 0 {
+		return nil, fmt.Errorf("curl command mixes -d and -F")
+	}
+
+	body := strings.Join(data, "&")
+	switch {
+	case get && len(data) > 0:
+		sep := "?"
+		if strings.Contains(req.URL, "?") {
+			sep = "&"
+		}
+		req.URL += sep + body
+	case len(data) > 0:
+		req.Body = body
+		req.setDefaultHeader("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if req.Method == "" {
+		switch {
+		case head:
+			req.Method = "HEAD"
+		case get:
+			req.Method = "GET"
+		case len(data) > 0 || len(req.Form) > 0:
+			req.Method = "POST"
+		default:
+			req.Method = "GET"
+		}
+	}
+	return req, nil
+}
+
+func isCurl(arg string) bool {
+	base := arg[strings.LastIndexAny(arg, `/\`)+1:]
+	return base == "curl" || base == "curl.exe"
+}
+
+func parseHeader(value string) (Header, bool) {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok {
+		// "-H 'X-Empty;'" sends an empty header; "-H 'X-Drop'" removes one.
+		if strings.HasSuffix(value, ";") {
+			return Header{Name: strings.TrimSpace(strings.TrimSuffix(value, ";"))}, true
+		}
+		return Header{}, false
+	}
+	return Header{Name: strings.TrimSpace(name), Value: strings.TrimSpace(val)}, true
+}
+
+// urlencodeData implements the --data-urlencode forms "content",
+// "=content" and "name=content".
+func urlencodeData(value string) string {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return url.QueryEscape(value)
+	}
+	if name == "" {
+		return url.QueryEscape(content)
+	}
+	return name + "=" + url.QueryEscape(content)
+}
+
+func parseFormField(value string, literal bool) (FormField, error) {
+	name, content, ok := strings.Cut(value, "=")
+	if !ok {
+		return FormField{}, fmt.Errorf("invalid form field %q: expected name=value", value)
+	}
+	field := FormField{Name: name, Value: content}
+	if !literal && (strings.HasPrefix(content, "@") || strings.HasPrefix(content, "<")) {
+		path := content[1:]
+		// Drop ;type=, ;filename= and similar modifiers.
+		if idx := strings.Index(path, ";"); idx >= 0 {
+			path = path[:idx]
+		}
+		field.Value = path
+		field.File = true
+	}
+	return field, nil
+}
+
+// Split splits a command line into words using bash quoting rules.
+// Backslash-newline continuations (and PowerShell backtick continuations)
+// are joined.
+func Split(command string) ([]string, error) {
+	command = strings.NewReplacer("\\\r\n", " ", "\\\n", " ", "`\r\n", " ", "`\n", " ").Replace(command)
+
+	var args []string
+	var current strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			current.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+			n, err := ansiCString(command[i+2:], &current)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			inWord = true
+		case c == '"':
+			j := i + 1
+			for ; j < len(command) && command[j] != '"'; j++ {
+				if command[j] == '\\' && j+1 < len(command) && strings.IndexByte("\"\\$`", command[j+1]) >= 0 {
+					j++
+				}
+				current.WriteByte(command[j])
+			}
+			if j >= len(command) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			i = j
+			inWord = true
+		case c == '\\' && i+1 < len(command):
+			i++
+			current.WriteByte(command[i])
+			inWord = true
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// ansiCString decodes the body of a $'...' string, returning how many bytes
+// of s it consumed including the closing quote.
+func ansiCString(s string, out *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case 'e', 'E':
+			out.WriteByte(0x1b)
+		case '0':
+			out.WriteByte(0)
+		case 'x', 'u', 'U':
+			width := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+			j := i + 1
+			for j < len(s) && j < i+1+width && isHex(s[j]) {
+				j++
+			}
+			code, err := strconv.ParseUint(s[i+1:j], 16, 32)
+			if err != nil {
+				out.WriteByte('\\')
+				out.WriteByte(s[i])
+				continue
+			}
+			if s[i] == 'x' {
+				out.WriteByte(byte(code))
+			} else {
+				out.WriteString(string(rune(code)))
+			}
+			i = j - 1
+		default:
+			// \\, \', \" and unknown escapes keep the character.
+			out.WriteByte(s[i])
+		}
+	}
+	return 0, fmt.Errorf("unterminated $'...' string")
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// isText reports whether body can be shown as-is in a snippet.
+func isText(body string) bool {
+	return utf8.ValidString(body) && !strings.ContainsRune(body, 0)
+}
@@ -0,0 +1,185 @@
+package curl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Formats lists the snippet formats understood by Snippet.
+var Formats = []string{"curl", "httpie", "fetch"}
+
+// Snippet renders req as a curl command, an HTTPie command or a JavaScript
+// fetch call.
+func Snippet(req *Request, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "curl":
+		return curlSnippet(req), nil
+	case "httpie", "http":
+		return httpieSnippet(req), nil
+	case "fetch", "js":
+		return fetchSnippet(req), nil
+	}
+	return "", fmt.Errorf("unknown format %q (supported: %s)", format, strings.Join(Formats, ", "))
+}
+
+func curlSnippet(req *Request) string {
+	lines := []string{}
+	first := "curl"
+	implied := "GET"
+	if req.Body != "" || len(req.Form) > 0 {
+		implied = "POST"
+	}
+	if req.Method != implied {
+		first += " -X " + req.Method
+	}
+	first += " " + Quote(req.URL)
+	lines = append(lines, first)
+	for _, h := range req.Headers {
+		lines = append(lines, "-H "+Quote(h.Name+": "+h.Value))
+	}
+	for _, f := range req.Form {
+		value := f.Value
+		if f.File {
+			value = "@" + value
+		}
+		lines = append(lines, "-F "+Quote(f.Name+"="+value))
+	}
+	if req.Body != "" {
+		lines = append(lines, "--data-raw "+Quote(req.Body))
+	}
+	if req.Compressed {
+		lines = append(lines, "--compressed")
+	}
+	if req.Insecure {
+		lines = append(lines, "--insecure")
+	}
+	return strings.Join(lines, " \\\n  ")
+}
+
+func httpieSnippet(req *Request) string {
+	lines := []string{}
+	first := "http"
+	if len(req.Form) > 0 {
+		first += " --multipart"
+	}
+	if req.Insecure {
+		first += " --verify=no"
+	}
+	first += " " + req.Method + " " + Quote(req.URL)
+	lines = append(lines, first)
+	for _, h := range req.Headers {
+		lines = append(lines, Quote(h.Name+":"+h.Value))
+	}
+	for _, f := range req.Form {
+		sep := "="
+		if f.File {
+			sep = "@"
+		}
+		lines = append(lines, Quote(f.Name+sep+f.Value))
+	}
+	if req.Body != "" {
+		lines = append(lines, "--raw "+Quote(req.Body))
+	}
+	return strings.Join(lines, " \\\n  ")
+}
+
+func fetchSnippet(req *Request) string {
+	var b strings.Builder
+	b.WriteString("const response = await fetch(" + jsString(req.URL) + ", {\n")
+	b.WriteString("  method: " + jsString(req.Method) + ",\n")
+	if len(req.Headers) > 0 {
+		b.WriteString("  headers: {\n")
+		for _, h := range req.Headers {
+			if len(req.Form) > 0 && strings.EqualFold(h.Name, "Content-Type") {
+				continue // fetch sets the multipart boundary itself
+			}
+			b.WriteString("    " + jsString(h.Name) + ": " + jsString(h.Value) + ",\n")
+		}
+		b.WriteString("  },\n")
+	}
+	switch {
+	case len(req.Form) > 0:
+		b.WriteString("  body: form,\n")
+	case req.Body != "":
+		b.WriteString("  body: " + jsBody(req.Body) + ",\n")
+	}
+	b.WriteString("});\n")
+	b.WriteString("const data = await response.text();")
+
+	if len(req.Form) == 0 {
+		return b.String()
+	}
+	var form strings.Builder
+	form.WriteString("const form = new FormData();\n")
+	for _, f := range req.Form {
+		if f.File {
+			// Browsers take a File from an <input>; Node 20+ can read one
+			// with fs.openAsBlob.
+			form.WriteString(fmt.Sprintf("form.append(%s, await fs.openAsBlob(%s));\n", jsString(f.Name), jsString(f.Value)))
+		} else {
+			form.WriteString(fmt.Sprintf("form.append(%s, %s);\n", jsString(f.Name), jsString(f.Value)))
+		}
+	}
+	return form.String() + "\n" + b.String()
+}
+
+// jsBody renders JSON bodies as JSON.stringify of a literal so they stay
+// readable, and everything else as a string.
+func jsBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		var out bytes.Buffer
+		if err := json.Indent(&out, []byte(trimmed), "  ", "  "); err == nil {
+			return "JSON.stringify(" + out.String() + ")"
+		}
+	}
+	return jsString(body)
+}
+
+func jsString(s string) string {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// Quote quotes s for a POSIX shell, leaving simple words bare.
+func Quote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+%") == "" {
+		return s
+	}
+	if !isText(s) || strings.ContainsAny(s, "\r") {
+		return ansiQuote(s)
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ansiQuote quotes s as a bash $'...' string, escaping bytes that do not
+// survive a copy and paste.
+func ansiQuote(s string) string {
+	var b strings.Builder
+	b.WriteString("$'")
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// Package graphql builds GraphQL-over-HTTP requests, reads the errors of a
+// GraphQL response and prints an introspected schema as SDL.
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Request is a GraphQL operation as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+}
+
+// NewRequest parses the variables, a JSON object, and returns the request.
+// Empty variables are left out.
+func NewRequest(query, variables, operationName string) (Request, error) {
+	req := Request{Query: strings.TrimSpace(query), OperationName: strings.TrimSpace(operationName)}
+	if req.Query == "" {
+		return Request{}, fmt.Errorf("GraphQL query is empty")
+	}
+	if strings.TrimSpace(variables) != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return Request{}, fmt.Errorf("invalid GraphQL variables: expected a JSON object: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// Body is the JSON body of a POST request.
+func (r Request) Body() []byte {
+	body, _ := json.Marshal(r)
+	return body
+}
+
+// AddToURL encodes the request as query parameters, for GET requests.
+func (r Request) AddToURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("query", r.Query)
+	if len(r.Variables) > 0 {
+		variables, _ := json.Marshal(r.Variables)
+		q.Set("variables", string(variables))
+	}
+	if r.OperationName != "" {
+		q.Set("operationName", r.OperationName)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Errors returns the messages of a response's "errors" array, with the
+// path of the failing field when there is one. It returns nil when the
+// response has no errors or is not JSON.
+func Errors(body []byte) []string {
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+			Path    []any  `json:"path"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return nil
+	}
+	var messages []string
+	for _, e := range resp.Errors {
+		msg := e.Message
+		if msg == "" {
+			msg = "unknown error"
+		}
+		if len(e.Path) > 0 {
+			parts := make([]string, len(e.Path))
+			for i, p := range e.Path {
+				parts[i] = fmt.Sprint(p)
+			}
+			msg += " (at " + strings.Join(parts, ".") + ")"
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestNewRequestBodyAndURL(t *testing.T) {
+	req, err := NewRequest(" query User($id: ID!) { user(id: $id) { name } } ", `{"id": "42"}`, "User")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"query":"query User($id: ID!) { user(id: $id) { name } }","variables":{"id":"42"},"operationName":"User"}`
+	if got := string(req.Body()); got != want {
+		t.Fatalf("Body() = %s\nwant %s", got, want)
+	}
+
+	rawURL, err := req.AddToURL("https://api.example.com/graphql?v=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(rawURL)
+	q := u.Query()
+	if q.Get("v") != "1" || q.Get("query") != req.Query || q.Get("variables") != `{"id":"42"}` || q.Get("operationName") != "User" {
+		t.Fatalf("unexpected GET URL %s", rawURL)
+	}
+
+	if _, err := NewRequest("{ me { id } }", `[1, 2]`, ""); err == nil {
+		t.Fatal("variables that are not an object should be rejected")
+	}
+	if _, err := NewRequest("  ", "", ""); err == nil {
+		t.Fatal("an empty query should be rejected")
+	}
+	if req, _ := NewRequest("{ me { id } }", "", ""); string(req.Body()) != `{"query":"{ me { id } }"}` {
+		t.Fatalf("empty variables should be left out: %s", req.Body())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	body := `{"data":{"user":null},"errors":[{"message":"not found","path":["user",0,"name"]},{"message":"rate limited"}]}`
+	if got, want := Errors([]byte(body)), []string{"not found (at user.0.name)", "rate limited"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Errors() = %v, want %v", got, want)
+	}
+	for _, body := range []string{`{"data":{"ok":true}}`, `{"data":{},"errors":[]}`, `not json`} {
+		if got := Errors([]byte(body)); got != nil {
+			t.Errorf("Errors(%s) = %v, want none", body, got)
+		}
+	}
+}
+
+const introspection = `{"data":{"__schema":{
+  "queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},"subscriptionType":null,
+  "directives":[
+    {"name":"include","locations":["FIELD"],"args":[]},
+    {"name":"auth","description":"Requires a role.","locations":["FIELD_DEFINITION","OBJECT"],"args":[{"name":"role","type":{"kind":"SCALAR","name":"String"},"defaultValue":"\"user\""}]}
+  ],
+  "types":[
+    {"kind":"OBJECT","name":"Query","fields":[
+      {"name":"user","args":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}}],"type":{"kind":"OBJECT","name":"User"}},
+      {"name":"users","args":[{"name":"first","type":{"kind":"SCALAR","name":"Int"},"defaultValue":"10"}],"type":{"kind":"NON_NULL","ofType":{"kind":"LIST","ofType":{"kind":"NON_NULL","ofType":{"kind":"OBJECT","name":"User"}}}}}
+    ],"interfaces":[]},
+    {"kind":"OBJECT","name":"Mutation","fields":[
+      {"name":"createUser","args":[{"name":"input","type":{"kind":"NON_NULL","ofType":{"kind":"INPUT_OBJECT","name":"NewUser"}}}],"type":{"kind":"OBJECT","name":"User"}}
+    ],"interfaces":[]},
+    {"kind":"OBJECT","name":"User","description":"A person.","fields":[
+      {"name":"id","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}},
+      {"name":"login","args":[],"type":{"kind":"SCALAR","name":"String"},"isDeprecated":true,"deprecationReason":"Use email."},
+      {"name":"role","args":[],"type":{"kind":"ENUM","name":"Role"}}
+    ],"interfaces":[{"kind":"INTERFACE","name":"Node"}]},
+    {"kind":"INTERFACE","name":"Node","fields":[{"name":"id","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}}]},
+    {"kind":"ENUM","name":"Role","enumValues":[{"name":"ADMIN"},{"name":"GUEST","isDeprecated":true,"deprecationReason":"No longer supported"}]},
+    {"kind":"INPUT_OBJECT","name":"NewUser","inputFields":[{"name":"email","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"String"}}},{"name":"role","type":{"kind":"ENUM","name":"Role"},"defaultValue":"GUEST"}]},
+    {"kind":"UNION","name":"SearchResult","possibleTypes":[{"kind":"OBJECT","name":"User"},{"kind":"OBJECT","name":"Query"}]},
+    {"kind":"SCALAR","name":"DateTime"},
+    {"kind":"SCALAR","name":"String"},
+    {"kind":"OBJECT","name":"__Type","fields":[]}
+  ]}}}`
+
+func TestSDL(t *testing.T) {
+	got, err := SDL([]byte(introspection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"Requires a role."
+directive @auth(role: String = "user") on FIELD_DEFINITION | OBJECT
+
+scalar DateTime
+
+type Mutation {
+  createUser(input: NewUser!): User
+}
+
+input NewUser {
+  email: String!
+  role: Role = GUEST
+}
+
+interface Node {
+  id: ID!
+}
+
+type Query {
+  user(id: ID!): User
+  users(first: Int = 10): [User!]!
+}
+
+enum Role {
+  ADMIN
+  GUEST @deprecated
+}
+
+union SearchResult = User | Query
+
+"A person."
+type User implements Node {
+  id: ID!
+  login: String @deprecated(reason: "Use email.")
+  role: Role
+}
+`
+	if got != want {
+		t.Fatalf("SDL mismatch:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := SDL([]byte(`{"errors":[{"message":"introspection is disabled"}]}`)); err == nil || err.Error() != "introspection failed: introspection is disabled" {
+		t.Fatalf("expected the server error, got %v", err)
+	}
+}
+
+func TestSDLCustomRootTypes(t *testing.T) {
+	got, err := SDL([]byte(`{"data":{"__schema":{"queryType":{"name":"Root"},"types":[{"kind":"OBJECT","name":"Root","fields":[{"name":"ok","args":[],"type":{"kind":"SCALAR","name":"Boolean"}}]}]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "schema {\n  query: Root\n}\n\ntype Root {\n  ok: Boolean\n}\n"; got != want {
+		t.Fatalf("SDL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,296 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IntrospectionQuery asks a server for its full schema.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+type schema struct {
+	QueryType        *named      `json:"queryType"`
+	MutationType     *named      `json:"mutationType"`
+	SubscriptionType *named      `json:"subscriptionType"`
+	Types            []fullType  `json:"types"`
+	Directives       []directive `json:"directives"`
+}
+
+type named struct {
+	Name string `json:"name"`
+}
+
+type fullType struct {
+	Kind          string       `json:"kind"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	Fields        []field      `json:"fields"`
+	InputFields   []inputValue `json:"inputFields"`
+	Interfaces    []typeRef    `json:"interfaces"`
+	EnumValues    []enumValue  `json:"enumValues"`
+	PossibleTypes []typeRef    `json:"possibleTypes"`
+}
+
+type field struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	Args              []inputValue `json:"args"`
+	Type              typeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason"`
+}
+
+type inputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         typeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type enumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason"`
+}
+
+type directive struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Locations   []string     `json:"locations"`
+	Args        []inputValue `json:"args"`
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+func (t typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType != nil {
+			return t.OfType.String() + "!"
+		}
+	case "LIST":
+		if t.OfType != nil {
+			return "[" + t.OfType.String() + "]"
+		}
+	}
+	return t.Name
+}
+
+var builtinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+
+var builtinDirectives = map[string]bool{"skip": true, "include": true, "deprecated": true, "specifiedBy": true, "oneOf": true}
+
+const defaultDeprecationReason = "No longer supported"
+
+// SDL turns the response to IntrospectionQuery into schema definition
+// language. Built-in scalars, directives and introspection types are left
+// out; types are sorted by name.
+func SDL(response []byte) (string, error) {
+	var resp struct {
+		Data struct {
+			Schema *schema `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return "", fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if resp.Data.Schema == nil {
+		if errs := Errors(response); len(errs) > 0 {
+			return "", fmt.Errorf("introspection failed: %s", strings.Join(errs, "; "))
+		}
+		return "", fmt.Errorf("introspection response has no data.__schema (is introspection disabled?)")
+	}
+	s := resp.Data.Schema
+
+	var blocks []string
+	if def := schemaDefinition(s); def != "" {
+		blocks = append(blocks, def)
+	}
+	directives := append([]directive(nil), s.Directives...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, d := range directives {
+		if builtinDirectives[d.Name] {
+			continue
+		}
+		blocks = append(blocks, description(d.Description, "")+"directive @"+d.Name+arguments(d.Args)+" on "+strings.Join(d.Locations, " | "))
+	}
+
+	types := append([]fullType(nil), s.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || (t.Kind == "SCALAR" && builtinScalars[t.Name]) {
+			continue
+		}
+		blocks = append(blocks, typeDefinition(t))
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+// schemaDefinition is only needed when the root types are not named
+// Query, Mutation and Subscription.
+func schemaDefinition(s *schema) string {
+	roots := []struct {
+		op, conventional string
+		t                *named
+	}{
+		{"query", "Query", s.QueryType},
+		{"mutation", "Mutation", s.MutationType},
+		{"subscription", "Subscription", s.SubscriptionType},
+	}
+	conventional := true
+	var lines []string
+	for _, r := range roots {
+		if r.t == nil {
+			continue
+		}
+		conventional = conventional && r.t.Name == r.conventional
+		lines = append(lines, "  "+r.op+": "+r.t.Name)
+	}
+	if conventional {
+		return ""
+	}
+	return "schema {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+func typeDefinition(t fullType) string {
+	var b strings.Builder
+	b.WriteString(description(t.Description, ""))
+	switch t.Kind {
+	case "SCALAR":
+		b.WriteString("scalar " + t.Name)
+	case "UNION":
+		names := make([]string, len(t.PossibleTypes))
+		for i, p := range t.PossibleTypes {
+			names[i] = p.Name
+		}
+		b.WriteString("union " + t.Name + " = " + strings.Join(names, " | "))
+	case "ENUM":
+		b.WriteString("enum " + t.Name + " {\n")
+		for _, v := range t.EnumValues {
+			b.WriteString(description(v.Description, "  ") + "  " + v.Name + deprecated(v.IsDeprecated, v.DeprecationReason) + "\n")
+		}
+		b.WriteString("}")
+	case "INPUT_OBJECT":
+		b.WriteString("input " + t.Name + " {\n")
+		for _, f := range t.InputFields {
+			b.WriteString(description(f.Description, "  ") + "  " + inputValueString(f) + "\n")
+		}
+		b.WriteString("}")
+	default: // OBJECT, INTERFACE
+		keyword := "type "
+		if t.Kind == "INTERFACE" {
+			keyword = "interface "
+		}
+		b.WriteString(keyword + t.Name)
+		if len(t.Interfaces) > 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, iface := range t.Interfaces {
+				names[i] = iface.Name
+			}
+			b.WriteString(" implements " + strings.Join(names, " & "))
+		}
+		b.WriteString(" {\n")
+		for _, f := range t.Fields {
+			b.WriteString(description(f.Description, "  ") + "  " + f.Name + arguments(f.Args) + ": " + f.Type.String() + deprecated(f.IsDeprecated, f.DeprecationReason) + "\n")
+		}
+		b.WriteString("}")
+	}
+	return b.String()
+}
+
+func arguments(args []inputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = inputValueString(a)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func inputValueString(v inputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func deprecated(isDeprecated bool, reason string) string {
+	if !isDeprecated {
+		return ""
+	}
+	if reason == "" || reason == defaultDeprecationReason {
+		return " @deprecated"
+	}
+	return " @deprecated(reason: " + strconv.Quote(reason) + ")"
+}
+
+func description(text, indent string) string {
+	if text == "" {
+		return ""
+	}
+	if !strings.Contains(text, "\n") && !strings.Contains(text, `"`) {
+		return indent + strconv.Quote(text) + "\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(text, `"""`, `\"""`), "\n")
+	return indent + `"""` + "\n" + indent + strings.Join(lines, "\n"+indent) + "\n" + indent + `"""` + "\n"
+}
@@ -0,0 +1,305 @@
+// Package har converts between HTTP Archive (HAR 1.2) files and history
+// records.
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+// HAR is the root of an HTTP Archive file.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           struct{} `json:"cache"`
+	Timings         Timings  `json:"timings"`
+	Comment         string   `json:"comment,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string      `json:"mimeType"`
+	Text     string      `json:"text,omitempty"`
+	Params   []NameValue `json:"params,omitempty"`
+}
+
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Read parses a HAR document.
+func Read(r io.Reader) (*HAR, error) {
+	var doc HAR
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid HAR: %w", err)
+	}
+	return &doc, nil
+}
+
+// Write encodes doc as indented JSON.
+func Write(w io.Writer, doc *HAR) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// FromRecords builds a HAR document from records, oldest entry first.
+func FromRecords(records []storage.Record, creatorVersion string) *HAR {
+	sorted := append([]storage.Record{}, records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	doc := &HAR{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "kest", Version: creatorVersion},
+		Entries: make([]Entry, 0, len(sorted)),
+	}}
+	for _, record := range sorted {
+		doc.Log.Entries = append(doc.Log.Entries, entryFromRecord(record))
+	}
+	return doc
+}
+
+func entryFromRecord(record storage.Record) Entry {
+	var reqHeaders map[string]string
+	_ = json.Unmarshal(record.RequestHeaders, &reqHeaders)
+	var respHeaders map[string][]string
+	_ = json.Unmarshal(record.ResponseHeaders, &respHeaders)
+	var query url.Values
+	_ = json.Unmarshal(record.QueryParams, &query)
+	if len(query) == 0 {
+		if u, err := url.Parse(record.URL); err == nil {
+			query = u.Query()
+		}
+	}
+
+	req := Request{
+		Method:      record.Method,
+		URL:         record.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []NameValue{},
+		Headers:     []NameValue{},
+		QueryString: []NameValue{},
+		HeadersSize: -1,
+		BodySize:    len(record.RequestBody),
+	}
+	for _, name := range sortedKeys(reqHeaders) {
+		req.Headers = append(req.Headers, NameValue{Name: name, Value: reqHeaders[name]})
+	}
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			req.QueryString = append(req.QueryString, NameValue{Name: name, Value: value})
+		}
+	}
+	if record.RequestBody != "" {
+		req.PostData = &PostData{MimeType: headerValue(reqHeaders, "Content-Type"), Text: record.RequestBody}
+	}
+
+	resp := Response{
+		Status:      record.ResponseStatus,
+		StatusText:  http.StatusText(record.ResponseStatus),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []NameValue{},
+		Headers:     []NameValue{},
+		HeadersSize: -1,
+		BodySize:    len(record.ResponseBody),
+		Content: Content{
+			Size: len(record.ResponseBody),
+			Text: record.ResponseBody,
+		},
+	}
+	for _, name := range sortedKeys(respHeaders) {
+		for _, value := range respHeaders[name] {
+			resp.Headers = append(resp.Headers, NameValue{Name: name, Value: value})
+		}
+		if strings.EqualFold(name, "Content-Type") && len(respHeaders[name]) > 0 {
+			resp.Content.MimeType = respHeaders[name][0]
+		}
+		if strings.EqualFold(name, "Location") && len(respHeaders[name]) > 0 {
+			resp.RedirectURL = respHeaders[name][0]
+		}
+	}
+	if !utf8.ValidString(record.ResponseBody) {
+		resp.Content.Text = base64.StdEncoding.EncodeToString([]byte(record.ResponseBody))
+		resp.Content.Encoding = "base64"
+	}
+
+	return Entry{
+		StartedDateTime: record.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Time:            float64(record.DurationMs),
+		Request:         req,
+		Response:        resp,
+		Timings:         Timings{Wait: float64(record.DurationMs)},
+		Comment:         fmt.Sprintf("kest #%d", record.ID),
+	}
+}
+
+// ToRecords converts HAR entries to records in file order. Entries without
+// a response (aborted or blocked requests) and non-HTTP URLs are skipped and
+// reported in skipped. Project and Environment are left for the caller.
+func ToRecords(doc *HAR) (records []storage.Record, skipped []string) {
+	for i, entry := range doc.Log.Entries {
+		record, err := recordFromEntry(entry)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("entry %d (%s %s): %v", i+1, entry.Request.Method, entry.Request.URL, err))
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, skipped
+}
+
+func recordFromEntry(entry Entry) (storage.Record, error) {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return storage.Record{}, fmt.Errorf("not an HTTP URL")
+	}
+	if entry.Response.Status == 0 {
+		return storage.Record{}, fmt.Errorf("no response")
+	}
+
+	reqHeaders := make(map[string]string)
+	for _, h := range entry.Request.Headers {
+		if strings.HasPrefix(h.Name, ":") {
+			continue // HTTP/2 pseudo-headers
+		}
+		if existing, ok := reqHeaders[h.Name]; ok {
+			reqHeaders[h.Name] = existing + ", " + h.Value
+		} else {
+			reqHeaders[h.Name] = h.Value
+		}
+	}
+	respHeaders := make(map[string][]string)
+	for _, h := range entry.Response.Headers {
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		name := http.CanonicalHeaderKey(h.Name)
+		respHeaders[name] = append(respHeaders[name], h.Value)
+	}
+
+	var reqBody string
+	if pd := entry.Request.PostData; pd != nil {
+		reqBody = pd.Text
+		if reqBody == "" && len(pd.Params) > 0 {
+			form := url.Values{}
+			for _, p := range pd.Params {
+				form.Add(p.Name, p.Value)
+			}
+			reqBody = form.Encode()
+		}
+	}
+
+	respBody := entry.Response.Content.Text
+	if entry.Response.Content.Encoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(respBody); err == nil {
+			respBody = string(decoded)
+		}
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime)
+	if err != nil {
+		createdAt = time.Now()
+	}
+
+	headerJSON, _ := json.Marshal(reqHeaders)
+	respHeaderJSON, _ := json.Marshal(respHeaders)
+	queryJSON, _ := json.Marshal(u.Query())
+
+	return storage.Record{
+		Method:          strings.ToUpper(entry.Request.Method),
+		URL:             u.String(),
+		BaseURL:         u.Scheme + "://" + u.Host,
+		Path:            u.Path,
+		QueryParams:     queryJSON,
+		RequestHeaders:  headerJSON,
+		RequestBody:     reqBody,
+		ResponseStatus:  entry.Response.Status,
+		ResponseHeaders: respHeaderJSON,
+		ResponseBody:    respBody,
+		DurationMs:      int64(entry.Time + 0.5),
+		CreatedAt:       createdAt.UTC(),
+	}, nil
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+func TestRoundTripRecords(t *testing.T) {
+	t.Parallel()
+
+	older := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	records := []storage.Record{
+		{
+			ID:              2,
+			Method:          "POST",
+			URL:             "https://api.example.com/users?page=2&tag=a&tag=b",
+			RequestHeaders:  json.RawMessage(`{"Content-Type":"application/json","Authorization":"Bearer t"}`),
+			RequestBody:     `{"name":"Ada"}`,
+			ResponseStatus:  201,
+			ResponseHeaders: json.RawMessage(`{"Content-Type":["application/json"],"Set-Cookie":["a=1","b=2"]}`),
+			ResponseBody:    `{"id":7}`,
+			DurationMs:      42,
+			CreatedAt:       older.Add(time.Minute),
+		},
+		{
+			ID:              1,
+			Method:          "GET",
+			URL:             "http://localhost:3000/image",
+			ResponseStatus:  200,
+			ResponseHeaders: json.RawMessage(`{"Content-Type":["image/png"]}`),
+			ResponseBody:    "\x89PNG\x00\xff",
+			DurationMs:      3,
+			CreatedAt:       older,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FromRecords(records, "test")); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	doc, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if doc.Log.Version != "1.2" || doc.Log.Creator.Name != "kest" || len(doc.Log.Entries) != 2 {
+		t.Fatalf("unexpected log: %+v", doc.Log)
+	}
+	if doc.Log.Entries[0].Request.URL != "http://localhost:3000/image" {
+		t.Fatalf("expected the oldest entry first, got %s", doc.Log.Entries[0].Request.URL)
+	}
+	post := doc.Log.Entries[1]
+	if len(post.Request.QueryString) != 3 || post.Request.PostData == nil || post.Request.PostData.MimeType != "application/json" {
+		t.Fatalf("unexpected request: %+v", post.Request)
+	}
+	if post.Response.StatusText != "Created" || post.Response.Content.MimeType != "application/json" {
+		t.Fatalf("unexpected response: %+v", post.Response)
+	}
+	if doc.Log.Entries[0].Response.Content.Encoding != "base64" {
+		t.Fatalf("expected binary content to be base64 encoded")
+	}
+
+	back, skipped := ToRecords(doc)
+	if len(skipped) != 0 || len(back) != 2 {
+		t.Fatalf("unexpected conversion: %d records, skipped %v", len(back), skipped)
+	}
+	image, user := back[0], back[1]
+	if image.ResponseBody != "\x89PNG\x00\xff" || !image.CreatedAt.Equal(older) {
+		t.Fatalf("binary record did not round-trip: %q %s", image.ResponseBody, image.CreatedAt)
+	}
+	if user.Method != "POST" || user.BaseURL != "https://api.example.com" || user.Path != "/users" {
+		t.Fatalf("unexpected URL parts: %s %s %s", user.Method, user.BaseURL, user.Path)
+	}
+	if user.RequestBody != `{"name":"Ada"}` || user.ResponseBody != `{"id":7}` || user.ResponseStatus != 201 || user.DurationMs != 42 {
+		t.Fatalf("unexpected record: %+v", user)
+	}
+	var headers map[string]string
+	_ = json.Unmarshal(user.RequestHeaders, &headers)
+	if headers["Authorization"] != "Bearer t" {
+		t.Fatalf("request headers lost: %v", headers)
+	}
+	var respHeaders map[string][]string
+	_ = json.Unmarshal(user.ResponseHeaders, &respHeaders)
+	if len(respHeaders["Set-Cookie"]) != 2 {
+		t.Fatalf("repeated response headers lost: %v", respHeaders)
+	}
+	if !strings.Contains(string(user.QueryParams), `"tag":["a","b"]`) {
+		t.Fatalf("query params lost: %s", user.QueryParams)
+	}
+}
+
+func TestToRecordsFromBrowserHAR(t *testing.T) {
+	t.Parallel()
+
+	input := `{"log":{"version":"1.2","creator":{"name":"WebInspector","version":"537.36"},"entries":[
+	  {"startedDateTime":"2026-03-01T10:00:00.123Z","time":12.6,
+	   "request":{"method":"post","url":"https://app.example.com/login","httpVersion":"h2",
+	     "headers":[{"name":":authority","value":"app.example.com"},{"name":"accept","value":"a"},{"name":"accept","value":"b"}],
+	     "postData":{"mimeType":"application/x-www-form-urlencoded","params":[{"name":"user","value":"ada"}]}},
+	   "response":{"status":302,"statusText":"","headers":[{"name":"location","value":"/home"}],
+	     "content":{"size":0,"mimeType":"text/html"}}},
+	  {"startedDateTime":"2026-03-01T10:00:01Z","time":0,
+	   "request":{"method":"GET","url":"https://app.example.com/blocked","headers":[]},
+	   "response":{"status":0,"headers":[],"content":{"size":0,"mimeType":""}}},
+	  {"startedDateTime":"2026-03-01T10:00:02Z","time":0,
+	   "request":{"method":"GET","url":"data:image/png;base64,AAAA","headers":[]},
+	   "response":{"status":200,"headers":[],"content":{"size":0,"mimeType":"image/png"}}}
+	]}}`
+
+	doc, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	records, skipped := ToRecords(doc)
+	if len(records) != 1 || len(skipped) != 2 {
+		t.Fatalf("expected 1 record and 2 skipped entries, got %d and %v", len(records), skipped)
+	}
+	login := records[0]
+	if login.Method != "POST" || login.RequestBody != "user=ada" || login.DurationMs != 13 {
+		t.Fatalf("unexpected record: %+v", login)
+	}
+	var headers map[string]string
+	_ = json.Unmarshal(login.RequestHeaders, &headers)
+	if _, ok := headers[":authority"]; ok || headers["accept"] != "a, b" {
+		t.Fatalf("unexpected request headers: %v", headers)
+	}
+	var respHeaders map[string][]string
+	_ = json.Unmarshal(login.ResponseHeaders, &respHeaders)
+	if respHeaders["Location"][0] != "/home" {
+		t.Fatalf("expected canonical response header names, got %v", respHeaders)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"sync"
 
 	"github.com/kest-labs/kest/cli/internal/config"
+	"github.com/kest-labs/kest/cli/internal/secret"
 )
 
 var (
@@ -138,7 +139,7 @@ func LogRequest(method, url string, headers map[string]string, body string, stat
 	timestamp := time.Now().Format("15:04:05")
 	separator := "================================================================================\n"
 
-	logEntry := fmt.Sprintf("%s [%s] %s %s\n", separator, timestamp, method, url)
+	logEntry := fmt.Sprintf("%s [%s] %s %s\n", separator, timestamp, method, secret.Mask(url))
 	logEntry += fmt.Sprintf("Duration: %v | Status: %d\n", duration, status)
 
 	logEntry += "\n--- Request Headers ---\n"
@@ -269,6 +270,7 @@ func sanitizeStringSliceMap(input map[string][]string) map[string][]string {
 }
 
 func sanitizeLogText(value string) string {
+	value = secret.Mask(value)
 	value = sensitiveHeaderPattern.ReplaceAllString(value, "${1}${2}${3}[REDACTED]")
 	return sensitiveJSONPattern.ReplaceAllString(value, "${1}[REDACTED]${4}")
 }
@@ -0,0 +1,257 @@
+package mock
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChaosHeaderPrefix marks chaos directives given as headers, either on a
+// mock response (X-Kest-Chaos-Latency: 2s) or on the incoming request, which
+// lets a client under test ask for a fault. The headers are never sent back.
+const ChaosHeaderPrefix = "X-Kest-Chaos-"
+
+// Chaos configures fault injection. Keys (also accepted with dashes):
+//
+//	latency       fixed delay ("300ms") or random range ("100ms-2s")
+//	error_rate    share of requests answered with error_status ("0.2" or "20%")
+//	error_status  status of injected errors, 503 by default
+//	reset_rate    share of connections dropped without a response
+//	truncate      send only part of the body, then drop the connection ("50%" or bytes)
+//	drip          delay between body chunks ("200ms")
+//	drip_chunk    bytes per chunk when dripping, 16 by default
+//
+// Settings are layered: command flags, then the route and response of a
+// mock file, then response headers, then request headers.
+type Chaos map[string]string
+
+var chaosKeys = map[string]bool{
+	"latency": true, "error_rate": true, "error_status": true, "reset_rate": true,
+	"truncate": true, "drip": true, "drip_chunk": true,
+}
+
+// Validate checks keys and values.
+func (c Chaos) Validate() error {
+	_, err := c.plan()
+	return err
+}
+
+// merge returns c overlaid with the settings of layers, later layers
+// winning.
+func (c Chaos) merge(layers ...Chaos) Chaos {
+	merged := Chaos{}
+	for _, layer := range append([]Chaos{c}, layers...) {
+		for key, value := range layer {
+			merged[chaosKey(key)] = value
+		}
+	}
+	return merged
+}
+
+// chaosFromHeaders collects X-Kest-Chaos-* directives.
+func chaosFromHeaders(header map[string][]string) Chaos {
+	var c Chaos
+	for name, values := range header {
+		if len(values) == 0 || !isChaosHeader(name) {
+			continue
+		}
+		if c == nil {
+			c = Chaos{}
+		}
+		c[chaosKey(name[len(ChaosHeaderPrefix):])] = values[0]
+	}
+	return c
+}
+
+func isChaosHeader(name string) bool {
+	return len(name) > len(ChaosHeaderPrefix) && strings.EqualFold(name[:len(ChaosHeaderPrefix)], ChaosHeaderPrefix)
+}
+
+func chaosKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "-", "_")
+}
+
+// chaosPlan is a parsed Chaos.
+type chaosPlan struct {
+	latencyMin, latencyMax time.Duration
+	errorRate              float64
+	errorStatus            int
+	resetRate              float64
+	truncateBytes          int
+	truncatePercent        float64
+	drip                   time.Duration
+	dripChunk              int
+}
+
+func (c Chaos) plan() (chaosPlan, error) {
+	p := chaosPlan{errorStatus: http.StatusServiceUnavailable, dripChunk: 16}
+	for rawKey, value := range c {
+		key := chaosKey(rawKey)
+		if !chaosKeys[key] {
+			return p, fmt.Errorf("unknown chaos option %q", rawKey)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "latency":
+			p.latencyMin, p.latencyMax, err = parseLatency(value)
+		case "error_rate":
+			p.errorRate, err = parseRate(value)
+		case "reset_rate":
+			p.resetRate, err = parseRate(value)
+		case "error_status":
+			p.errorStatus, err = strconv.Atoi(value)
+			if err == nil && (p.errorStatus < 100 || p.errorStatus > 599) {
+				err = fmt.Errorf("not an HTTP status")
+			}
+		case "truncate":
+			if strings.HasSuffix(value, "%") {
+				p.truncatePercent, err = parseRate(value)
+			} else {
+				p.truncateBytes, err = strconv.Atoi(value)
+				if err == nil && p.truncateBytes < 0 {
+					err = fmt.Errorf("must not be negative")
+				}
+			}
+		case "drip":
+			p.drip, err = parseMillis(value)
+		case "drip_chunk":
+			p.dripChunk, err = strconv.Atoi(value)
+			if err == nil && p.dripChunk <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		}
+		if err != nil {
+			return p, fmt.Errorf("invalid chaos %s %q: %v", key, value, err)
+		}
+	}
+	return p, nil
+}
+
+// parseLatency reads "300ms", "2s", "250" (milliseconds) or a range such as
+// "100ms-2s".
+func parseLatency(value string) (time.Duration, time.Duration, error) {
+	low, high, isRange := strings.Cut(value, "-")
+	from, err := parseMillis(low)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	to, err := parseMillis(high)
+	if err != nil {
+		return 0, 0, err
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("range upper bound is below its lower bound")
+	}
+	return from, to, nil
+}
+
+func parseMillis(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if ms, err := strconv.Atoi(value); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("must not be negative")
+	}
+	return d, err
+}
+
+// parseRate reads a share as "0.25" or "25%".
+func parseRate(value string) (float64, error) {
+	percent := strings.HasSuffix(value, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		rate /= 100
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("must be between 0 and 1 (or 0%% and 100%%)")
+	}
+	return rate, nil
+}
+
+func (p chaosPlan) latency() time.Duration {
+	if p.latencyMax <= p.latencyMin {
+		return p.latencyMin
+	}
+	return p.latencyMin + rand.N(p.latencyMax-p.latencyMin+1)
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// truncated returns how many bytes of body to send, or -1 to send it all.
+func (p chaosPlan) truncated(size int) int {
+	switch {
+	case p.truncatePercent > 0:
+		return int(float64(size) * p.truncatePercent)
+	case p.truncateBytes > 0 && p.truncateBytes < size:
+		return p.truncateBytes
+	}
+	return -1
+}
+
+// sleep waits for d unless the client goes away first.
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// resetConnection drops the client connection without a response. A TCP
+// connection is closed with a reset rather than a clean shutdown.
+func resetConnection(w http.ResponseWriter) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				_ = tcp.SetLinger(0)
+			}
+			_ = conn.Close()
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// writeBody sends body, dripping it in chunks when configured. It returns
+// false when the client went away.
+func (p chaosPlan) writeBody(w http.ResponseWriter, r *http.Request, body string) bool {
+	if p.drip <= 0 {
+		fmt.Fprint(w, body)
+		return true
+	}
+	flusher, _ := w.(http.Flusher)
+	for start := 0; start < len(body); start += p.dripChunk {
+		if start > 0 && !sleep(r, p.drip) {
+			return false
+		}
+		end := min(start+p.dripChunk, len(body))
+		if _, err := fmt.Fprint(w, body[start:end]); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return true
+}
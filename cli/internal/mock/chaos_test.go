@@ -0,0 +1,113 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaosValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := Chaos{"latency": "100ms-2s", "Error-Rate": "10%", "error_status": "502", "reset_rate": "0", "truncate": "64", "drip": "50", "drip_chunk": "4"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid chaos, got %v", err)
+	}
+	for _, bad := range []Chaos{
+		{"latency": "2s-1s"},
+		{"error_rate": "1.5"},
+		{"error_status": "99"},
+		{"truncate": "-1"},
+		{"drip_chunk": "0"},
+		{"jitter": "1s"},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("expected %v to be rejected", bad)
+		}
+	}
+}
+
+func chaosServer(t *testing.T, opts Options, responses ...Response) *httptest.Server {
+	t.Helper()
+	route := NewRoute("GET", "/items")
+	route.Responses = responses
+	server := httptest.NewServer(NewServer([]*Route{route}, opts))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string, header map[string]string) (*http.Response, string, error) {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp, string(body), err
+}
+
+func TestChaosLatencyAndErrors(t *testing.T) {
+	t.Parallel()
+
+	server := chaosServer(t, Options{Chaos: Chaos{"latency": "60ms"}},
+		Response{Status: 200, Body: `{"ok":true}`, Headers: map[string][]string{"X-Kest-Chaos-Error-Rate": {"1"}, "X-Kest-Chaos-Error-Status": {"502"}}})
+
+	start := time.Now()
+	resp, body, err := get(t, server.URL+"/items", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected the response to be delayed, took %s", elapsed)
+	}
+	if resp.StatusCode != http.StatusBadGateway || !strings.Contains(body, "fault injected") {
+		t.Fatalf("expected an injected 502, got %d %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Kest-Chaos-Error-Rate") != "" {
+		t.Fatalf("chaos headers must not be sent back: %v", resp.Header)
+	}
+
+	// Request headers override the response's directives.
+	resp, body, err = get(t, server.URL+"/items", map[string]string{"X-Kest-Chaos-Error-Rate": "0", "X-Kest-Chaos-Latency": "0"})
+	if err != nil || resp.StatusCode != http.StatusOK || body != `{"ok":true}` {
+		t.Fatalf("expected the real response, got %v %v %s", err, resp, body)
+	}
+
+	resp, _, _ = get(t, server.URL+"/items", map[string]string{"X-Kest-Chaos-Latency": "soon"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid chaos header, got %d", resp.StatusCode)
+	}
+}
+
+func TestChaosResetTruncateAndDrip(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("x", 40)
+	server := chaosServer(t, Options{}, Response{Status: 200, Body: body})
+
+	if _, _, err := get(t, server.URL+"/items", map[string]string{"X-Kest-Chaos-Reset-Rate": "100%"}); err == nil {
+		t.Fatal("expected the connection to be reset")
+	}
+
+	_, got, err := get(t, server.URL+"/items", map[string]string{"X-Kest-Chaos-Truncate": "50%"})
+	if err == nil || got != body[:20] {
+		t.Fatalf("expected a truncated body and a read error, got %q (%v)", got, err)
+	}
+
+	start := time.Now()
+	_, got, err = get(t, server.URL+"/items", map[string]string{"X-Kest-Chaos-Drip": "20ms", "X-Kest-Chaos-Drip-Chunk": "10"})
+	if err != nil || got != body {
+		t.Fatalf("expected the full body, got %q (%v)", got, err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected 4 chunks 20ms apart, took %s", elapsed)
+	}
+}
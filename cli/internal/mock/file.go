@@ -0,0 +1,150 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a hand-written mock definition (kest mock --file mocks.yaml):
+//
+//	routes:
+//	  - method: GET
+//	    path: /users/{id}
+//	    responses:
+//	      - match:
+//	          headers: {X-Role: admin}
+//	        status: 200
+//	        body: {id: "{{request.path.id}}", role: admin}
+//	      - status: 200
+//	        body: {id: "{{request.path.id}}", role: member}
+//	        chaos: {latency: 100ms-2s, error_rate: 0.1}
+//
+// A route with a single answer may put status, headers, body, match and
+// chaos directly on the route; with a responses list, route-level chaos
+// applies to all of them. Bodies given as YAML values are sent as JSON.
+type File struct {
+	Routes []FileRoute `yaml:"routes"`
+}
+
+// FileRoute is one route of a mock file.
+type FileRoute struct {
+	Method       string         `yaml:"method"`
+	Path         string         `yaml:"path"`
+	Responses    []FileResponse `yaml:"responses"`
+	FileResponse `yaml:",inline"`
+}
+
+// FileResponse is one response of a mock file route.
+type FileResponse struct {
+	Match    FileMatch         `yaml:"match"`
+	Status   int               `yaml:"status"`
+	Headers  map[string]string `yaml:"headers"`
+	Body     any               `yaml:"body"`
+	Template *bool             `yaml:"template"` // defaults to true
+	Chaos    map[string]any    `yaml:"chaos"`
+}
+
+// FileMatch lists the request values a response requires.
+type FileMatch struct {
+	Query   map[string]string `yaml:"query"`
+	Headers map[string]string `yaml:"headers"`
+	Body    map[string]string `yaml:"body"`
+}
+
+// LoadFile reads a mock file and returns its routes in file order.
+func LoadFile(path string) ([]*Route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid mock file %s: %w", path, err)
+	}
+
+	var routes []*Route
+	for i, fr := range file.Routes {
+		if fr.Path == "" {
+			return nil, fmt.Errorf("%s: route %d has no path", path, i+1)
+		}
+		method := strings.ToUpper(fr.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+		route := NewRoute(method, fr.Path)
+
+		responses := fr.Responses
+		if len(responses) == 0 {
+			responses = []FileResponse{fr.FileResponse}
+		} else {
+			route.Chaos = fileChaos(fr.Chaos)
+			if err := route.Chaos.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s %s: %w", path, method, fr.Path, err)
+			}
+		}
+		for _, r := range responses {
+			resp, err := r.response(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s %s: %w", path, method, fr.Path, err)
+			}
+			route.Responses = append(route.Responses, resp)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func (r FileResponse) response(source string) (Response, error) {
+	resp := Response{
+		Status:   r.Status,
+		Template: r.Template == nil || *r.Template,
+		Source:   source,
+		Chaos:    fileChaos(r.Chaos),
+		Match: Match{
+			Query:   r.Match.Query,
+			Headers: r.Match.Headers,
+			Body:    r.Match.Body,
+		},
+	}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	if err := resp.Chaos.Validate(); err != nil {
+		return Response{}, err
+	}
+	if len(r.Headers) > 0 {
+		resp.Headers = make(map[string][]string, len(r.Headers))
+		for name, value := range r.Headers {
+			resp.Headers[name] = []string{value}
+		}
+	}
+
+	switch body := r.Body.(type) {
+	case nil:
+	case string:
+		resp.Body = body
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("body is not JSON-compatible: %w", err)
+		}
+		resp.Body = string(data)
+	}
+	return resp, nil
+}
+
+func fileChaos(values map[string]any) Chaos {
+	if len(values) == 0 {
+		return nil
+	}
+	c := make(Chaos, len(values))
+	for key, value := range values {
+		c[key] = fmt.Sprint(value)
+	}
+	return c
+}
@@ -0,0 +1,81 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+// FromRecords turns request history into routes. Records are expected
+// latest first, as returned by the store. Paths are grouped under the
+// matching template from templates (e.g. an OpenAPI file) or under an
+// inferred one; each distinct path and query becomes a response variant that
+// prefers requests with the same path and query values.
+func FromRecords(records []storage.Record, templates []string) []*Route {
+	known := make([]*Route, 0, len(templates))
+	for _, template := range templates {
+		known = append(known, NewRoute("", template))
+	}
+	sortRoutes(known)
+
+	var routes []*Route
+	byKey := make(map[string]*Route)
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if record.Path == "" {
+			continue
+		}
+		method := strings.ToUpper(record.Method)
+		query := decodeQuery(record.QueryParams)
+		variant := method + " " + normalizePath(record.Path) + "?" + query.Encode()
+		if seen[variant] {
+			continue
+		}
+		seen[variant] = true
+
+		template := InferTemplate(record.Path)
+		for _, candidate := range known {
+			if _, ok := candidate.match(record.Path); ok {
+				template = candidate.Template
+				break
+			}
+		}
+		route := byKey[method+" "+template]
+		if route == nil {
+			route = NewRoute(method, template)
+			byKey[method+" "+template] = route
+			routes = append(routes, route)
+		}
+		params, _ := route.match(record.Path)
+
+		var headers map[string][]string
+		_ = json.Unmarshal(record.ResponseHeaders, &headers)
+		match := Match{Path: params, Soft: true}
+		if len(query) > 0 {
+			match.Query = make(map[string]string, len(query))
+			for name := range query {
+				match.Query[name] = query.Get(name)
+			}
+		}
+		route.Responses = append(route.Responses, Response{
+			Status:  record.ResponseStatus,
+			Headers: cloneHeader(headers),
+			Body:    record.ResponseBody,
+			Match:   match,
+			Source:  fmt.Sprintf("#%d", record.ID),
+		})
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+func decodeQuery(raw json.RawMessage) url.Values {
+	var query url.Values
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &query)
+	}
+	return query
+}
@@ -0,0 +1,176 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// FromOpenAPI builds one route per operation of an OpenAPI file. Each route
+// answers with the first documented success response, using its example or
+// an example generated from its schema.
+func FromOpenAPI(specPath string) ([]*Route, error) {
+	doc, err := openapi3.NewLoader().LoadFromFile(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec: %v", err)
+	}
+
+	paths := make([]string, 0, len(doc.Paths.Map()))
+	for path := range doc.Paths.Map() {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var routes []*Route
+	for _, path := range paths {
+		item := doc.Paths.Find(path)
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			op := item.GetOperation(method)
+			if op == nil {
+				continue
+			}
+			route := NewRoute(method, path)
+			route.Responses = append(route.Responses, openAPIResponse(op))
+			routes = append(routes, route)
+		}
+	}
+	sortRoutes(routes)
+	return routes, nil
+}
+
+func openAPIResponse(op *openapi3.Operation) Response {
+	resp := Response{Status: http.StatusOK, Match: Match{Soft: true}, Source: "openapi"}
+	if op.Responses == nil {
+		return resp
+	}
+
+	codes := make([]string, 0, op.Responses.Len())
+	for code := range op.Responses.Map() {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var ref *openapi3.ResponseRef
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			ref = op.Responses.Value(code)
+			if status, err := strconv.Atoi(code); err == nil {
+				resp.Status = status
+			}
+			break
+		}
+	}
+	if ref == nil {
+		ref = op.Responses.Default()
+	}
+	if ref == nil || ref.Value == nil {
+		return resp
+	}
+
+	media := ref.Value.Content.Get("application/json")
+	if media == nil {
+		return resp
+	}
+	example := mediaExample(media)
+	if example == nil {
+		return resp
+	}
+	body, err := json.MarshalIndent(example, "", "  ")
+	if err == nil {
+		resp.Body = string(body)
+		resp.Headers = map[string][]string{"Content-Type": {"application/json"}}
+	}
+	return resp
+}
+
+func mediaExample(media *openapi3.MediaType) any {
+	if media.Example != nil {
+		return media.Example
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := media.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value
+		}
+	}
+	if media.Schema == nil {
+		return nil
+	}
+	return schemaExample(media.Schema.Value, 0)
+}
+
+// schemaExample generates a value that satisfies schema, preferring
+// documented examples, defaults and enum values.
+func schemaExample(schema *openapi3.Schema, depth int) any {
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+	for _, group := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf} {
+		if len(group) > 0 {
+			return schemaExample(group[0].Value, depth+1)
+		}
+	}
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, part := range schema.AllOf {
+			if obj, ok := schemaExample(part.Value, depth+1).(map[string]any); ok {
+				for key, value := range obj {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	}
+
+	switch {
+	case schema.Type.Is("object") || (schema.Type == nil && len(schema.Properties) > 0):
+		obj := map[string]any{}
+		for name, prop := range schema.Properties {
+			if prop != nil {
+				obj[name] = schemaExample(prop.Value, depth+1)
+			}
+		}
+		return obj
+	case schema.Type.Is("array"):
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{schemaExample(schema.Items.Value, depth+1)}
+	case schema.Type.Is("integer"):
+		return 1
+	case schema.Type.Is("number"):
+		return 1.5
+	case schema.Type.Is("boolean"):
+		return true
+	case schema.Type.Is("string"):
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "uuid":
+			return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		case "email":
+			return "user@example.com"
+		}
+		return "string"
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package mock implements the server behind "kest mock": routes with path
+// templates built from request history, OpenAPI files or a mock file,
+// response selection by matchers, response templating and an optional
+// in-memory CRUD state.
+package mock
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Route answers one method on one path template such as /users/{id}.
+type Route struct {
+	Method    string
+	Template  string
+	Responses []Response // candidates in priority order
+	Chaos     Chaos      // faults injected into every response
+
+	segments []string
+}
+
+// Response is one canned answer of a route.
+type Response struct {
+	Status   int
+	Headers  map[string][]string
+	Body     string
+	Match    Match
+	Template bool   // render {{request.*}} placeholders in body and headers
+	Source   string // where the response came from, e.g. "#42" or "openapi"
+	Chaos    Chaos
+}
+
+// Match restricts when a response is chosen. Values are compared as
+// strings; Body keys are gjson paths into a JSON request body.
+//
+// Preferred matchers (Soft) only rank candidates: history responses prefer
+// the recorded path and query values, but any of them may answer. Required
+// matchers must all hold.
+type Match struct {
+	Path    map[string]string
+	Query   map[string]string
+	Headers map[string]string
+	Body    map[string]string
+	Soft    bool
+}
+
+// NewRoute builds a route for method and path template.
+func NewRoute(method, template string) *Route {
+	template = normalizePath(template)
+	return &Route{
+		Method:   strings.ToUpper(method),
+		Template: template,
+		segments: splitPath(template),
+	}
+}
+
+// match returns the path parameters when path fits the template.
+func (r *Route) match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	if len(parts) != len(r.segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range r.segments {
+		if name, ok := paramName(segment); ok {
+			params[name] = parts[i]
+			continue
+		}
+		if segment != parts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// literalCount ranks templates: /users/me wins over /users/{id}.
+func (r *Route) literalCount() int {
+	n := 0
+	for _, segment := range r.segments {
+		if _, ok := paramName(segment); !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// sortRoutes orders routes from most to least specific.
+func sortRoutes(routes []*Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].literalCount() > routes[j].literalCount()
+	})
+}
+
+// score reports whether the response may answer req and how many of its
+// matchers hold.
+func (m Match) score(req *requestView) (int, bool) {
+	score := 0
+	check := func(want, got string) bool {
+		if want == got {
+			score++
+			return true
+		}
+		return m.Soft
+	}
+	for name, want := range m.Path {
+		if !check(want, req.params[name]) {
+			return 0, false
+		}
+	}
+	for name, want := range m.Query {
+		if !check(want, req.query.Get(name)) {
+			return 0, false
+		}
+	}
+	for name, want := range m.Headers {
+		if !check(want, req.header.Get(name)) {
+			return 0, false
+		}
+	}
+	for path, want := range m.Body {
+		if !check(want, gjson.GetBytes(req.body, path).String()) {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+var (
+	numericSegment = regexp.MustCompile(`^\d+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexIDSegment   = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	paramSegment   = regexp.MustCompile(`^\{([^{}]+)\}$|^:(.+)$`)
+)
+
+// InferTemplate replaces ID-like path segments (numbers, UUIDs, long hex
+// IDs) with parameters: /users/42/posts/7 → /users/{userId}/posts/{id}.
+func InferTemplate(path string) string {
+	parts := splitPath(path)
+	last := -1
+	for i, part := range parts {
+		if isIDSegment(part) {
+			last = i
+		}
+	}
+	if last == -1 {
+		return normalizePath(path)
+	}
+	for i, part := range parts {
+		if !isIDSegment(part) {
+			continue
+		}
+		name := "id"
+		if i != last && i > 0 {
+			name = strings.TrimSuffix(parts[i-1], "s") + "Id"
+		}
+		parts[i] = "{" + name + "}"
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+func isIDSegment(segment string) bool {
+	return numericSegment.MatchString(segment) || uuidSegment.MatchString(segment) || hexIDSegment.MatchString(segment)
+}
+
+// paramName returns the parameter name of a {name} or :name segment.
+func paramName(segment string) (string, bool) {
+	m := paramSegment.FindStringSubmatch(segment)
+	if m == nil {
+		return "", false
+	}
+	if m[1] != "" {
+		return m[1], true
+	}
+	return m[2], true
+}
+
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func normalizePath(path string) string {
+	return "/" + strings.Join(splitPath(path), "/")
+}
+
+// cloneHeader copies recorded headers, dropping hop-by-hop ones.
+func cloneHeader(headers map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		switch http.CanonicalHeaderKey(name) {
+		case "Transfer-Encoding", "Connection", "Keep-Alive", "Content-Length":
+			continue
+		}
+		out[name] = append([]string{}, values...)
+	}
+	return out
+}
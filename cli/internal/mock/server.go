@@ -0,0 +1,244 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Options configures a Server.
+type Options struct {
+	// Stateful keeps created, updated and deleted resources in memory so
+	// that a POST followed by a GET behaves like a real API.
+	Stateful bool
+	// Chaos injects faults into every response; mock file routes and
+	// X-Kest-Chaos-* headers can override it.
+	Chaos Chaos
+	// Logf, when set, receives one line per served request.
+	Logf func(format string, args ...any)
+}
+
+// Server answers requests from a route table.
+type Server struct {
+	routes []*Route
+	state  *state
+	chaos  Chaos
+	logf   func(format string, args ...any)
+}
+
+// NewServer builds a server. Routes are tried from the most specific
+// template to the least specific; for equally specific templates the
+// earlier route wins.
+func NewServer(routes []*Route, opts Options) *Server {
+	sorted := append([]*Route{}, routes...)
+	sortRoutes(sorted)
+	s := &Server{routes: sorted, chaos: opts.Chaos, logf: opts.Logf}
+	if opts.Stateful {
+		s.state = newState(sorted)
+	}
+	return s
+}
+
+// Routes returns the route table in matching order.
+func (s *Server) Routes() []*Route {
+	return s.routes
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	req := newRequestView(r)
+	route, params := s.find(req.method, req.path)
+	req.params = params
+	if s.state != nil {
+		if resp, ok := s.state.handle(s, req); ok {
+			s.write(w, r, req, route, resp)
+			return
+		}
+	}
+
+	if route == nil {
+		if s.pathKnown(req.path) {
+			s.write(w, r, req, nil, errorResponse(http.StatusMethodNotAllowed,
+				fmt.Sprintf("method %s not recorded for %s", req.method, req.path), ""))
+			return
+		}
+		s.write(w, r, req, nil, errorResponse(http.StatusNotFound,
+			fmt.Sprintf("no recorded response for %s %s", req.method, req.path),
+			fmt.Sprintf("make a real request first: kest %s %s", strings.ToLower(req.method), req.path)))
+		return
+	}
+
+	resp := route.pick(req)
+	if resp == nil {
+		s.write(w, r, req, route, errorResponse(http.StatusNotFound,
+			fmt.Sprintf("no mock response of %s %s matches the request", route.Method, route.Template), ""))
+		return
+	}
+	s.write(w, r, req, route, *resp)
+}
+
+// find returns the first route serving method on path.
+func (s *Server) find(method, path string) (*Route, map[string]string) {
+	for _, route := range s.routes {
+		if route.Method != method {
+			continue
+		}
+		if params, ok := route.match(path); ok {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) pathKnown(path string) bool {
+	for _, route := range s.routes {
+		if _, ok := route.match(path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// recorded returns the response a GET on path would get without state.
+func (s *Server) recorded(path string) *Response {
+	route, params := s.find(http.MethodGet, path)
+	if route == nil {
+		return nil
+	}
+	return route.pick(&requestView{method: http.MethodGet, path: path, params: params, header: http.Header{}})
+}
+
+// pick chooses the response for req. Responses are tried in order: the
+// first one whose required matchers hold answers, unless preferred
+// (history) responses came before it, in which case the one matching the
+// most preferred values wins, ties going to the earlier response.
+func (r *Route) pick(req *requestView) *Response {
+	var best *Response
+	bestScore := -1
+	for i := range r.Responses {
+		resp := &r.Responses[i]
+		score, ok := resp.Match.score(req)
+		if !ok {
+			continue
+		}
+		if !resp.Match.Soft {
+			if best != nil {
+				return best
+			}
+			return resp
+		}
+		if score > bestScore {
+			best, bestScore = resp, score
+		}
+	}
+	return best
+}
+
+// write sends resp after applying the chaos settings of the server, the
+// route, the response and the request.
+func (s *Server) write(w http.ResponseWriter, r *http.Request, req *requestView, route *Route, resp Response) {
+	var routeChaos Chaos
+	if route != nil {
+		routeChaos = route.Chaos
+	}
+	plan, err := s.chaos.merge(routeChaos, resp.Chaos, chaosFromHeaders(resp.Headers), chaosFromHeaders(req.header)).plan()
+	if err != nil {
+		resp, plan = errorResponse(http.StatusBadRequest, err.Error(), ""), chaosPlan{}
+	}
+
+	var notes []string
+	if resp.Source != "" {
+		notes = append(notes, resp.Source)
+	}
+	if delay := plan.latency(); delay > 0 {
+		if !sleep(r, delay) {
+			return
+		}
+		notes = append(notes, "+"+delay.String())
+	}
+	if hit(plan.resetRate) {
+		s.log(req, "reset", append(notes, "connection reset"))
+		resetConnection(w)
+		return
+	}
+	if hit(plan.errorRate) {
+		resp = errorResponse(plan.errorStatus, "fault injected by kest mock", "")
+		notes = append(notes, "injected error")
+	}
+
+	body := resp.Body
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	for name, values := range resp.Headers {
+		if isChaosHeader(name) {
+			continue
+		}
+		for _, value := range values {
+			if resp.Template {
+				value = render(value, req)
+			}
+			w.Header().Add(name, value)
+		}
+	}
+	if resp.Template {
+		body = render(body, req)
+	}
+	if w.Header().Get("Content-Type") == "" && body != "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	cut := plan.truncated(len(body))
+	if cut >= 0 {
+		// Announce the full length so clients notice the missing bytes.
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		notes = append(notes, fmt.Sprintf("truncated at %d/%d bytes", cut, len(body)))
+	}
+	if plan.drip > 0 {
+		notes = append(notes, fmt.Sprintf("drip %d B/%s", plan.dripChunk, plan.drip))
+	}
+	s.log(req, strconv.Itoa(status), notes)
+
+	w.WriteHeader(status)
+	if cut < 0 {
+		plan.writeBody(w, r, body)
+		return
+	}
+	if plan.writeBody(w, r, body[:cut]) {
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+func (s *Server) log(req *requestView, status string, notes []string) {
+	if s.logf == nil {
+		return
+	}
+	if len(notes) == 0 {
+		s.logf("  → %s %s → %s\n", req.method, req.path, status)
+		return
+	}
+	s.logf("  → %s %s → %s  (%s)\n", req.method, req.path, status, strings.Join(notes, ", "))
+}
+
+func errorResponse(status int, message, hint string) Response {
+	payload := map[string]string{"error": message}
+	if hint != "" {
+		payload["hint"] = hint
+	}
+	body, _ := json.Marshal(payload)
+	return Response{Status: status, Body: string(body)}
+}
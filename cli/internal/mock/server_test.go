@@ -0,0 +1,234 @@
+package mock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+func TestInferTemplate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"/users":            "/users",
+		"/users/42":         "/users/{id}",
+		"/users/42/posts/7": "/users/{userId}/posts/{id}",
+		"/orders/3fa85f64-5717-4562-b3fc-2c963f66afa6": "/orders/{id}",
+		"/v1/items/507f1f77bcf86cd799439011/":          "/v1/items/{id}",
+		"/v2/status":                                   "/v2/status",
+	}
+	for path, want := range cases {
+		if got := InferTemplate(path); got != want {
+			t.Errorf("InferTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func record(id int64, method, path, query string, status int, body string) storage.Record {
+	values := map[string][]string{}
+	if query != "" {
+		name, value, _ := strings.Cut(query, "=")
+		values[name] = []string{value}
+	}
+	queryJSON, _ := json.Marshal(values)
+	return storage.Record{
+		ID:              id,
+		Method:          method,
+		Path:            path,
+		QueryParams:     queryJSON,
+		ResponseStatus:  status,
+		ResponseHeaders: json.RawMessage(`{"Content-Type":["application/json"],"Content-Length":["12"]}`),
+		ResponseBody:    body,
+	}
+}
+
+func do(t *testing.T, handler http.Handler, method, target, body string, header map[string]string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	data, _ := io.ReadAll(rec.Result().Body)
+	return rec.Code, string(data)
+}
+
+func TestServerPrefersMatchingHistoryVariant(t *testing.T) {
+	t.Parallel()
+
+	// Latest first, as returned by the store.
+	routes := FromRecords([]storage.Record{
+		record(4, "GET", "/users/2", "", 200, `{"id":2,"name":"Bob"}`),
+		record(3, "GET", "/users/1", "", 200, `{"id":1,"name":"Ann"}`),
+		record(2, "GET", "/users", "page=2", 200, `[{"id":3}]`),
+		record(1, "GET", "/users/1", "", 500, `{"error":"old"}`),
+	}, nil)
+	if len(routes) != 2 || routes[0].Template != "/users/{id}" || len(routes[0].Responses) != 2 {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+	server := NewServer(routes, Options{})
+
+	if _, body := do(t, server, "GET", "/users/1", "", nil); body != `{"id":1,"name":"Ann"}` {
+		t.Fatalf("expected the latest /users/1 recording, got %s", body)
+	}
+	if _, body := do(t, server, "GET", "/users/99", "", nil); body != `{"id":2,"name":"Bob"}` {
+		t.Fatalf("expected unknown IDs to fall back to the latest recording, got %s", body)
+	}
+	if _, body := do(t, server, "GET", "/users?page=7", "", nil); body != `[{"id":3}]` {
+		t.Fatalf("expected the list recording, got %s", body)
+	}
+	if status, _ := do(t, server, "DELETE", "/users/1", "", nil); status != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for an unrecorded method, got %d", status)
+	}
+	if status, body := do(t, server, "GET", "/teams", "", nil); status != http.StatusNotFound || !strings.Contains(body, "kest get /teams") {
+		t.Fatalf("expected 404 with a hint, got %d %s", status, body)
+	}
+}
+
+func TestServerMockFileMatchersAndTemplates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mocks.yaml")
+	content := `routes:
+  - method: GET
+    path: /users/{id}
+    responses:
+      - match:
+          headers: {X-Role: admin}
+          query: {expand: "true"}
+        body: {id: "{{request.path.id}}", role: admin, trace: "{{request.header.X-Trace}}"}
+      - status: 200
+        headers: {X-Echo: "{{request.path.id}}"}
+        body: '{"id": {{request.path.id}}, "role": "member"}'
+  - method: POST
+    path: /users
+    match:
+      body: {role: admin}
+    status: 201
+    body: '{"name": "{{request.body.name}}", "tags": {{request.body.tags}}, "page": "{{request.query.page | default: "1"}}"}'
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile returned error: %v", err)
+	}
+	server := NewServer(routes, Options{})
+
+	_, body := do(t, server, "GET", "/users/7?expand=true", "", map[string]string{"X-Role": "admin", "X-Trace": "abc"})
+	if body != `{"id":"7","role":"admin","trace":"abc"}` {
+		t.Fatalf("unexpected admin response: %s", body)
+	}
+
+	req := httptest.NewRequest("GET", "/users/8", nil)
+	req.Header.Set("X-Role", "admin")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Body.String() != `{"id": 8, "role": "member"}` || rec.Header().Get("X-Echo") != "8" {
+		t.Fatalf("expected the fallback response, got %s (%v)", rec.Body.String(), rec.Header())
+	}
+
+	status, body := do(t, server, "POST", "/users", `{"name":"Ann","role":"admin","tags":["a"]}`, nil)
+	if status != http.StatusCreated || body != `{"name": "Ann", "tags": ["a"], "page": "1"}` {
+		t.Fatalf("unexpected POST response: %d %s", status, body)
+	}
+	if status, _ := do(t, server, "POST", "/users", `{"role":"member"}`, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 when no response matches, got %d", status)
+	}
+}
+
+func TestServerStatefulCRUD(t *testing.T) {
+	t.Parallel()
+
+	routes := FromRecords([]storage.Record{
+		record(3, "GET", "/users/1", "", 200, `{"id":1,"name":"Ann","role":"admin"}`),
+		record(2, "GET", "/users", "", 200, `{"data":[{"id":1,"name":"Ann"}]}`),
+		record(1, "POST", "/login", "", 200, `{"token":"t"}`),
+	}, nil)
+	server := NewServer(routes, Options{Stateful: true})
+
+	status, body := do(t, server, "POST", "/users", `{"name":"Bob"}`, nil)
+	if status != http.StatusCreated || body != `{"id":2,"name":"Bob"}` {
+		t.Fatalf("unexpected create response: %d %s", status, body)
+	}
+	if _, body := do(t, server, "GET", "/users/2", "", nil); body != `{"id":2,"name":"Bob"}` {
+		t.Fatalf("expected the created user, got %s", body)
+	}
+	if _, body := do(t, server, "GET", "/users", "", nil); body != `{"data":[{"id":1,"name":"Ann"},{"id":2,"name":"Bob"}]}` {
+		t.Fatalf("expected the list to keep its wrapper and include the new user, got %s", body)
+	}
+	if _, body := do(t, server, "PATCH", "/users/2", `{"name":"Bobby"}`, nil); body != `{"id":2,"name":"Bobby"}` {
+		t.Fatalf("unexpected patch response: %s", body)
+	}
+	if status, _ := do(t, server, "DELETE", "/users/2", "", nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete, got %d", status)
+	}
+	if status, _ := do(t, server, "GET", "/users/2", "", nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", status)
+	}
+	if _, body := do(t, server, "POST", "/login", `{"user":"ann"}`, nil); body != `{"token":"t"}` {
+		t.Fatalf("expected action endpoints to keep replaying history, got %s", body)
+	}
+}
+
+func TestFromOpenAPIUsesExamplesAndTemplates(t *testing.T) {
+	t.Parallel()
+
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	content := `openapi: 3.0.3
+info: {title: Users, version: "1"}
+paths:
+  /users/{userId}:
+    get:
+      parameters:
+        - {name: userId, in: path, required: true, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id: {type: integer}
+                  email: {type: string, format: email}
+  /users:
+    post:
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              example: {id: 10}
+`
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := FromOpenAPI(spec)
+	if err != nil {
+		t.Fatalf("FromOpenAPI returned error: %v", err)
+	}
+	server := NewServer(routes, Options{})
+
+	if _, body := do(t, server, "GET", "/users/abc", "", nil); !strings.Contains(body, `"email": "user@example.com"`) {
+		t.Fatalf("expected a schema-generated example, got %s", body)
+	}
+	if status, body := do(t, server, "POST", "/users", `{}`, nil); status != http.StatusCreated || !strings.Contains(body, `"id": 10`) {
+		t.Fatalf("expected the documented example, got %d %s", status, body)
+	}
+
+	// History is grouped under the spec's templates.
+	grouped := FromRecords([]storage.Record{record(1, "GET", "/users/abc", "", 200, `{}`)}, []string{"/users/{userId}"})
+	if grouped[0].Template != "/users/{userId}" || grouped[0].Responses[0].Match.Path["userId"] != "abc" {
+		t.Fatalf("expected the OpenAPI template, got %+v", grouped[0])
+	}
+}
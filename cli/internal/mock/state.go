@@ -0,0 +1,282 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// state is the in-memory store of stateful mode. Each collection (e.g.
+// /users or /users/1/posts) is seeded from its recorded list response the
+// first time it changes; from then on reads of the collection and its items
+// are answered from memory. Untouched collections keep replaying history.
+type state struct {
+	mu          sync.Mutex
+	resources   []resource
+	collections map[string]*collection
+}
+
+// resource pairs a collection template with its item template, e.g.
+// /users and /users/{id}. Only paths seen with a trailing parameter become
+// resources, so action endpoints such as POST /login keep their recording.
+type resource struct {
+	collection *Route
+	item       *Route
+}
+
+type collection struct {
+	items   []map[string]any
+	deleted map[string]bool
+	wrapper string // field holding the list in the recorded response, "" for a bare array
+}
+
+func newState(routes []*Route) *state {
+	st := &state{collections: make(map[string]*collection)}
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		n := len(route.segments)
+		if n < 2 {
+			continue
+		}
+		if _, ok := paramName(route.segments[n-1]); !ok {
+			continue
+		}
+		collectionTemplate := normalizePath(strings.Join(route.segments[:n-1], "/"))
+		if seen[collectionTemplate] {
+			continue
+		}
+		seen[collectionTemplate] = true
+		st.resources = append(st.resources, resource{
+			collection: NewRoute("", collectionTemplate),
+			item:       NewRoute("", route.Template),
+		})
+	}
+	return st
+}
+
+// handle answers req from memory. ok is false when the request should be
+// served from the route table instead.
+func (st *state) handle(s *Server, req *requestView) (resp Response, ok bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, res := range st.resources {
+		if _, match := res.collection.match(req.path); match {
+			return st.handleCollection(s, req)
+		}
+		if _, match := res.item.match(req.path); match {
+			parts := splitPath(req.path)
+			return st.handleItem(s, req, normalizePath(strings.Join(parts[:len(parts)-1], "/")), parts[len(parts)-1])
+		}
+	}
+	return Response{}, false
+}
+
+func (st *state) handleCollection(s *Server, req *requestView) (Response, bool) {
+	path := normalizePath(req.path)
+	switch req.method {
+	case http.MethodGet:
+		coll := st.collections[path]
+		if coll == nil {
+			return Response{}, false
+		}
+		return jsonResponse(http.StatusOK, coll.list()), true
+	case http.MethodPost:
+		var item map[string]any
+		if json.Unmarshal(req.body, &item) != nil || item == nil {
+			return Response{}, false
+		}
+		coll := st.collection(s, path)
+		if _, has := item["id"]; !has {
+			item["id"] = coll.nextID()
+		}
+		delete(coll.deleted, idString(item["id"]))
+		coll.put(item)
+		return jsonResponse(http.StatusCreated, item), true
+	}
+	return Response{}, false
+}
+
+func (st *state) handleItem(s *Server, req *requestView, collPath, id string) (Response, bool) {
+	coll := st.collections[collPath]
+	switch req.method {
+	case http.MethodGet:
+		if coll == nil {
+			return Response{}, false
+		}
+		if item := coll.get(id); item != nil {
+			return jsonResponse(http.StatusOK, item), true
+		}
+		if coll.deleted[id] {
+			return notFound(req.path), true
+		}
+		return Response{}, false
+	case http.MethodPut, http.MethodPatch:
+		var patch map[string]any
+		if json.Unmarshal(req.body, &patch) != nil || patch == nil {
+			return Response{}, false
+		}
+		coll = st.collection(s, collPath)
+		if coll.deleted[id] {
+			return notFound(req.path), true
+		}
+		item := coll.get(id)
+		if item == nil {
+			item = st.recordedItem(s, req.path)
+		}
+		if req.method == http.MethodPut || item == nil {
+			item = map[string]any{}
+		}
+		for key, value := range patch {
+			item[key] = value
+		}
+		item["id"] = typedID(id, item["id"])
+		coll.put(item)
+		return jsonResponse(http.StatusOK, item), true
+	case http.MethodDelete:
+		coll = st.collection(s, collPath)
+		if coll.deleted[id] {
+			return notFound(req.path), true
+		}
+		coll.remove(id)
+		coll.deleted[id] = true
+		return Response{Status: http.StatusNoContent}, true
+	}
+	return Response{}, false
+}
+
+// collection returns the in-memory collection at path, seeding it from the
+// recorded list response on first use.
+func (st *state) collection(s *Server, path string) *collection {
+	if coll, ok := st.collections[path]; ok {
+		return coll
+	}
+	coll := &collection{deleted: make(map[string]bool)}
+	if recorded := s.recorded(path); recorded != nil {
+		body := gjson.Parse(recorded.Body)
+		list := body
+		if body.IsObject() {
+			for _, key := range []string{"data", "items", "results"} {
+				if field := body.Get(key); field.IsArray() {
+					list, coll.wrapper = field, key
+					break
+				}
+			}
+		}
+		if list.IsArray() {
+			var items []map[string]any
+			if json.Unmarshal([]byte(list.Raw), &items) == nil {
+				for _, item := range items {
+					if item != nil {
+						coll.items = append(coll.items, item)
+					}
+				}
+			}
+		}
+	}
+	st.collections[path] = coll
+	return coll
+}
+
+// recordedItem returns the recorded GET response of an item as a starting
+// point for PATCH.
+func (st *state) recordedItem(s *Server, path string) map[string]any {
+	recorded := s.recorded(path)
+	if recorded == nil {
+		return nil
+	}
+	var item map[string]any
+	if json.Unmarshal([]byte(recorded.Body), &item) != nil {
+		return nil
+	}
+	return item
+}
+
+func (c *collection) list() any {
+	items := c.items
+	if items == nil {
+		items = []map[string]any{}
+	}
+	if c.wrapper != "" {
+		return map[string]any{c.wrapper: items}
+	}
+	return items
+}
+
+func (c *collection) get(id string) map[string]any {
+	for _, item := range c.items {
+		if idString(item["id"]) == id {
+			return item
+		}
+	}
+	return nil
+}
+
+func (c *collection) put(item map[string]any) {
+	id := idString(item["id"])
+	for i, existing := range c.items {
+		if idString(existing["id"]) == id {
+			c.items[i] = item
+			return
+		}
+	}
+	c.items = append(c.items, item)
+}
+
+func (c *collection) remove(id string) {
+	for i, item := range c.items {
+		if idString(item["id"]) == id {
+			c.items = append(c.items[:i], c.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// nextID continues numeric IDs after the highest one seen.
+func (c *collection) nextID() any {
+	max := 0
+	for _, item := range c.items {
+		if n, err := strconv.Atoi(idString(item["id"])); err == nil && n > max {
+			max = n
+		}
+	}
+	return max + 1
+}
+
+func idString(id any) string {
+	switch v := id.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// typedID keeps numeric IDs numeric when the path carries the ID.
+func typedID(id string, current any) any {
+	if _, isString := current.(string); isString {
+		return id
+	}
+	if n, err := strconv.Atoi(id); err == nil {
+		return n
+	}
+	return id
+}
+
+func jsonResponse(status int, value any) Response {
+	body, _ := json.Marshal(value)
+	return Response{Status: status, Body: string(body), Source: "state"}
+}
+
+func notFound(path string) Response {
+	resp := errorResponse(http.StatusNotFound, fmt.Sprintf("%s was deleted", path), "")
+	resp.Source = "state"
+	return resp
+}
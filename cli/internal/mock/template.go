@@ -0,0 +1,95 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/variable"
+	"github.com/tidwall/gjson"
+)
+
+// requestView is what matchers and templates see of an incoming request.
+type requestView struct {
+	method string
+	path   string
+	params map[string]string
+	query  url.Values
+	header http.Header
+	body   []byte
+}
+
+func newRequestView(r *http.Request) *requestView {
+	body, _ := io.ReadAll(r.Body)
+	return &requestView{
+		method: r.Method,
+		path:   r.URL.Path,
+		query:  r.URL.Query(),
+		header: r.Header,
+		body:   body,
+	}
+}
+
+// render fills {{request.*}} placeholders with values from the request:
+//
+//	{{request.method}}, {{request.path}}, {{request.body}}
+//	{{request.path.id}}       path parameter
+//	{{request.query.page}}    query parameter
+//	{{request.header.X-Id}}   request header
+//	{{request.body.user.id}}  gjson path into a JSON body
+//
+// Body values that are not strings are inserted as raw JSON. The usual
+// built-ins ({{$uuid}}, {{$timestamp}}, ...) and defaults
+// ({{request.query.page | default: "1"}}) work as in flows.
+func render(text string, req *requestView) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	vars := make(map[string]string)
+	for _, name := range variable.ExtractPlaceholders(text) {
+		if value, ok := req.lookup(name); ok {
+			vars[name] = value
+		}
+	}
+	return variable.Interpolate(text, vars)
+}
+
+func (req *requestView) lookup(name string) (string, bool) {
+	switch name {
+	case "request.method":
+		return req.method, true
+	case "request.path":
+		return req.path, true
+	case "request.body":
+		return string(req.body), true
+	}
+
+	switch {
+	case strings.HasPrefix(name, "request.path."):
+		value, ok := req.params[strings.TrimPrefix(name, "request.path.")]
+		return value, ok
+	case strings.HasPrefix(name, "request.query."):
+		values, ok := req.query[strings.TrimPrefix(name, "request.query.")]
+		if !ok || len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	case strings.HasPrefix(name, "request.header."):
+		values := req.header.Values(strings.TrimPrefix(name, "request.header."))
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	case strings.HasPrefix(name, "request.body."):
+		result := gjson.GetBytes(req.body, strings.TrimPrefix(name, "request.body."))
+		if !result.Exists() {
+			return "", false
+		}
+		if result.Type == gjson.String {
+			return result.String(), true
+		}
+		return result.Raw, true
+	}
+	return "", false
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kest-labs/kest/cli/internal/secret"
 	"golang.org/x/term"
 )
 
@@ -50,6 +51,8 @@ var (
 )
 
 func PrintResponse(method, url string, status int, duration string, body []byte, recordID int64, startTime time.Time) {
+	url, body = secret.Mask(url), secret.MaskBytes(body)
+
 	// JSON output mode: structured, machine-readable
 	if JSONOutput {
 		printResponseJSON(method, url, status, duration, body, recordID, startTime)
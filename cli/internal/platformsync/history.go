@@ -397,6 +397,33 @@ func buildRunHistoryData(sourcePath, sourceName string, summ *summary.Summary, l
 			"response_body":    responseBody,
 			"command":          command,
 		}
+		if result.Group != "" {
+			item["group"] = result.Group
+		}
+		if len(result.Params) > 0 {
+			item["params"] = SanitizeStringMap(result.Params)
+		}
+		if len(result.Tags) > 0 {
+			item["tags"] = result.Tags
+		}
+		if len(result.Assertions) > 0 {
+			asserts := make([]map[string]any, 0, len(result.Assertions))
+			for _, a := range result.Assertions {
+				asserts = append(asserts, map[string]any{
+					"expression": a.Expression,
+					"passed":     a.Passed,
+					"expected":   sanitizeLooseText(a.Expected),
+					"actual":     sanitizeLooseText(a.Actual),
+					"path":       a.Path,
+					"soft":       a.Soft,
+				})
+			}
+			item["assert_results"] = asserts
+		}
+		if result.Skipped {
+			item["skipped"] = true
+			item["skip_reason"] = result.SkipReason
+		}
 		if result.Error != nil {
 			item["error"] = sanitizeLooseText(result.Error.Error())
 		}
@@ -406,18 +433,24 @@ func buildRunHistoryData(sourcePath, sourceName string, summ *summary.Summary, l
 		results = append(results, item)
 	}
 
+	run := map[string]any{
+		"source_name":       sourceName,
+		"source_path":       sourcePath,
+		"status":            runDisplayStatus(summ),
+		"total_steps":       summ.TotalTests,
+		"passed_steps":      summ.PassedTests,
+		"failed_steps":      summ.FailedTests,
+		"skipped_steps":     summ.SkippedTests,
+		"total_duration_ms": summ.TotalTime.Milliseconds(),
+		"started_at":        normalizedEventTime(summ.StartTime).Format(time.RFC3339),
+		"finished_at":       normalizedEventTime(summ.StartTime.Add(summ.TotalTime)).Format(time.RFC3339),
+	}
+	if tags := summ.Tags(); len(tags) > 0 {
+		run["tags"] = tags
+	}
+
 	data := map[string]any{
-		"run": map[string]any{
-			"source_name":       sourceName,
-			"source_path":       sourcePath,
-			"status":            runDisplayStatus(summ),
-			"total_steps":       summ.TotalTests,
-			"passed_steps":      summ.PassedTests,
-			"failed_steps":      summ.FailedTests,
-			"total_duration_ms": summ.TotalTime.Milliseconds(),
-			"started_at":        normalizedEventTime(summ.StartTime).Format(time.RFC3339),
-			"finished_at":       normalizedEventTime(summ.StartTime.Add(summ.TotalTime)).Format(time.RFC3339),
-		},
+		"run":     run,
 		"results": results,
 		"sync": map[string]any{
 			"source":    HistorySyncSource,
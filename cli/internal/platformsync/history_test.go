@@ -130,6 +130,7 @@ func TestQueueRunHistoryEnqueuesAggregateEvent(t *testing.T) {
 		Duration:     150 * time.Millisecond,
 		Success:      true,
 		StartTime:    summ.StartTime,
+		Tags:         []string{"smoke", "auth"},
 	})
 
 	if err := QueueRunHistory(conf, store, "auth.flow.md", summ, ""); err != nil {
@@ -156,6 +157,9 @@ func TestQueueRunHistoryEnqueuesAggregateEvent(t *testing.T) {
 	if runRecord["source_name"] != "auth.flow.md" {
 		t.Fatalf("expected source name auth.flow.md, got %#v", runRecord["source_name"])
 	}
+	if tags, _ := runRecord["tags"].([]interface{}); len(tags) != 2 || tags[0] != "smoke" {
+		t.Fatalf("expected run tags, got %#v", runRecord["tags"])
+	}
 
 	results := entry.Data["results"].([]interface{})
 	if len(results) != 1 {
@@ -165,4 +169,7 @@ func TestQueueRunHistoryEnqueuesAggregateEvent(t *testing.T) {
 	if body := first["response_body"].(string); !strings.Contains(body, "[REDACTED]") {
 		t.Fatalf("expected response body redacted, got %s", body)
 	}
+	if tags, _ := first["tags"].([]interface{}); len(tags) != 2 || tags[1] != "auth" {
+		t.Fatalf("expected step tags, got %#v", first["tags"])
+	}
 }
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/secret"
 )
 
 const (
@@ -117,6 +119,7 @@ func sanitizeStructuredText(value string, limit int) (string, bool) {
 }
 
 func sanitizeLooseText(value string) string {
+	value = secret.Mask(value)
 	value = headerLinePattern.ReplaceAllString(value, "${1}${2}${3}[REDACTED]")
 	for _, key := range sensitiveFieldNames {
 		pattern := regexp.MustCompile(`(?i)("` + regexp.QuoteMeta(key) + `"\s*:\s*")([^"]*)(")`)
@@ -0,0 +1,342 @@
+// Package proxy implements the recording proxy behind "kest proxy". It
+// works as a reverse proxy in front of one target, as a forward proxy for
+// any host, or both, and reports every request/response pair that passes
+// through it.
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxRecordedBody caps how much of each body is kept for recording; the
+// proxied traffic itself is never cut.
+const maxRecordedBody = 10 << 20
+
+// Exchange is one request/response pair that went through the proxy.
+type Exchange struct {
+	Method          string
+	URL             *url.URL // upstream URL
+	RequestHeaders  http.Header
+	RequestBody     []byte
+	Status          int
+	ResponseHeaders http.Header
+	ResponseBody    []byte // gzip bodies are decoded
+	Start           time.Time
+	Duration        time.Duration
+	Err             error // upstream failure, answered with 502
+}
+
+// Options configures a Proxy. At least one of Target and Forward is needed.
+type Options struct {
+	// Target receives requests that are not in absolute form (reverse mode).
+	Target *url.URL
+	// Forward accepts absolute-form requests and CONNECT tunnels for any host.
+	Forward bool
+	// CA, when set, intercepts CONNECT tunnels so HTTPS traffic is recorded
+	// too; without it tunnels are passed through unrecorded.
+	CA *CA
+	// Transport sends upstream requests; http.DefaultTransport when nil.
+	Transport http.RoundTripper
+	// Record receives every completed exchange. It may be called
+	// concurrently.
+	Record func(Exchange)
+}
+
+// Proxy is an http.Handler that relays and records traffic.
+type Proxy struct {
+	opts Options
+}
+
+// New validates opts and returns a proxy.
+func New(opts Options) (*Proxy, error) {
+	if opts.Target == nil && !opts.Forward {
+		return nil, errors.New("proxy needs a target, forward mode, or both")
+	}
+	if opts.Target != nil && (opts.Target.Scheme == "" || opts.Target.Host == "") {
+		return nil, fmt.Errorf("invalid target %q: expected a URL such as http://localhost:3000", opts.Target)
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	return &Proxy{opts: opts}, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodConnect:
+		if !p.opts.Forward {
+			http.Error(w, "kest proxy: CONNECT needs --forward", http.StatusMethodNotAllowed)
+			return
+		}
+		p.connect(w, r)
+	case r.URL.IsAbs() && p.opts.Forward:
+		upstream := *r.URL
+		p.relay(w, r, &upstream)
+	case p.opts.Target != nil:
+		p.relay(w, r, nil)
+	default:
+		http.Error(w, "kest proxy: forward proxy requests need an absolute URL", http.StatusBadRequest)
+	}
+}
+
+// relay sends r upstream, to upstream when given or to the target
+// otherwise, and records the exchange.
+func (p *Proxy) relay(w http.ResponseWriter, r *http.Request, upstream *url.URL) {
+	ex := Exchange{Method: r.Method, RequestHeaders: r.Header.Clone(), Start: time.Now()}
+	reqBody := &limitedBuffer{}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &teeBody{ReadCloser: r.Body, copy: reqBody}
+	}
+	capture := &captureWriter{ResponseWriter: w}
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if upstream != nil {
+				pr.Out.URL = upstream
+				pr.Out.Host = upstream.Host
+			} else {
+				pr.SetURL(p.opts.Target)
+				pr.SetXForwarded()
+			}
+			ex.URL = pr.Out.URL
+		},
+		Transport:     p.opts.Transport,
+		FlushInterval: -1,
+		ErrorLog:      log.New(io.Discard, "", 0),
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			ex.Err = err
+			http.Error(w, fmt.Sprintf("kest proxy: %v", err), http.StatusBadGateway)
+		},
+	}
+	rp.ServeHTTP(capture, r)
+
+	ex.Duration = time.Since(ex.Start)
+	ex.RequestBody = reqBody.Bytes()
+	ex.Status = capture.status
+	if ex.Status == 0 {
+		ex.Status = http.StatusOK
+	}
+	ex.ResponseHeaders = w.Header().Clone()
+	ex.ResponseBody = decodeBody(ex.ResponseHeaders, capture.body.Bytes())
+	if p.opts.Record != nil {
+		p.opts.Record(ex)
+	}
+}
+
+// connect handles a CONNECT tunnel, intercepting it when a CA is set.
+func (p *Proxy) connect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "kest proxy: connection cannot be hijacked", http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	client := net.Conn(conn)
+	if buffered.Reader.Buffered() > 0 {
+		client = &bufferedConn{Conn: conn, r: buffered.Reader}
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		return
+	}
+
+	if p.opts.CA == nil {
+		tunnel(client, r.Host)
+		return
+	}
+
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	tlsConn := tls.Server(client, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name = host
+			}
+			return p.opts.CA.certificate(name)
+		},
+	})
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			upstream := *req.URL
+			upstream.Scheme = "https"
+			upstream.Host = req.Host
+			if upstream.Host == "" {
+				upstream.Host = r.Host
+			}
+			p.relay(w, req, &upstream)
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	_ = server.Serve(newSingleConnListener(tlsConn))
+}
+
+// tunnel copies bytes between client and host without looking at them.
+func tunnel(client net.Conn, host string) {
+	defer client.Close()
+	upstream, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	done := make(chan struct{}, 2)
+	go func() { _, _ = io.Copy(upstream, client); done <- struct{}{} }()
+	go func() { _, _ = io.Copy(client, upstream); done <- struct{}{} }()
+	<-done
+}
+
+// decodeBody gunzips a recorded body and drops the encoding headers that
+// no longer describe it.
+func decodeBody(header http.Header, body []byte) []byte {
+	if header.Get("Content-Encoding") != "gzip" || len(body) == 0 {
+		return body
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxRecordedBody))
+	if err != nil {
+		return body
+	}
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	return decoded
+}
+
+// limitedBuffer keeps the first maxRecordedBody bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxRecordedBody - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+type teeBody struct {
+	io.ReadCloser
+	copy *limitedBuffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.copy.Write(p[:n])
+	return n, err
+}
+
+// captureWriter records the status and body sent to the client.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   limitedBuffer
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(p)
+	return c.ResponseWriter.Write(p)
+}
+
+func (c *captureWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	return hijacker.Hijack()
+}
+
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// bufferedConn replays bytes the HTTP server read ahead before hijacking.
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// singleConnListener serves one intercepted connection, then reports
+// itself closed once that connection is.
+type singleConnListener struct {
+	conn     net.Conn
+	accepted bool
+	mu       sync.Mutex
+	done     chan struct{}
+}
+
+func newSingleConnListener(conn net.Conn) *singleConnListener {
+	return &singleConnListener{conn: conn, done: make(chan struct{})}
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if !l.accepted {
+		l.accepted = true
+		l.mu.Unlock()
+		return &notifyConn{Conn: l.conn, done: l.done}, nil
+	}
+	l.mu.Unlock()
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+func (l *singleConnListener) Close() error {
+	return nil
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+type notifyConn struct {
+	net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+func (c *notifyConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { close(c.done) })
+	return err
+}
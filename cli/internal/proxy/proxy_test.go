@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+func (r *recorder) record(ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, ex)
+}
+
+// nth waits for the n-th exchange: recording happens after the response
+// has been sent, so the client may see it first.
+func (r *recorder) nth(t *testing.T, n int) Exchange {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		if len(r.exchanges) >= n {
+			defer r.mu.Unlock()
+			return r.exchanges[n-1]
+		}
+		r.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("exchange %d was not recorded", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func upstreamHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if strings.HasSuffix(r.URL.Path, "/gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(`{"compressed":true}`))
+		_ = gz.Close()
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, `{"path":"`+r.URL.Path+`","query":"`+r.URL.RawQuery+`","body":"`+string(body)+`","forwarded":"`+r.Header.Get("X-Forwarded-Host")+`"}`)
+}
+
+func TestReverseProxyRecordsExchanges(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(upstreamHandler))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL + "/api")
+
+	rec := &recorder{}
+	p, err := New(Options{Target: target, Record: rec.record})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	front := httptest.NewServer(p)
+	defer front.Close()
+
+	resp, err := http.Post(front.URL+"/users?page=2", "application/json", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || !strings.Contains(string(body), `"path":"/api/users"`) {
+		t.Fatalf("unexpected proxied response: %d %s", resp.StatusCode, body)
+	}
+
+	ex := rec.nth(t, 1)
+	if ex.Method != "POST" || ex.URL.String() != upstream.URL+"/api/users?page=2" {
+		t.Fatalf("unexpected recorded request: %s %s", ex.Method, ex.URL)
+	}
+	if string(ex.RequestBody) != "hello" || ex.RequestHeaders.Get("Content-Type") != "application/json" {
+		t.Fatalf("request body or headers not recorded: %q %v", ex.RequestBody, ex.RequestHeaders)
+	}
+	if ex.Status != http.StatusCreated || string(ex.ResponseBody) != string(body) || ex.ResponseHeaders.Get("Content-Type") != "application/json" {
+		t.Fatalf("response not recorded: %d %q", ex.Status, ex.ResponseBody)
+	}
+
+	req, _ := http.NewRequest("GET", front.URL+"/gzip", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if ex := rec.nth(t, 2); string(ex.ResponseBody) != `{"compressed":true}` || ex.ResponseHeaders.Get("Content-Encoding") != "" {
+		t.Fatalf("expected the recorded body to be decoded, got %q (%v)", ex.ResponseBody, ex.ResponseHeaders)
+	}
+}
+
+func TestReverseProxyRecordsUpstreamFailures(t *testing.T) {
+	t.Parallel()
+
+	target, _ := url.Parse("http://127.0.0.1:1")
+	rec := &recorder{}
+	p, _ := New(Options{Target: target, Record: rec.record})
+	front := httptest.NewServer(p)
+	defer front.Close()
+
+	resp, err := http.Get(front.URL + "/down")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", resp.StatusCode)
+	}
+	if ex := rec.nth(t, 1); ex.Err == nil || ex.Status != http.StatusBadGateway {
+		t.Fatalf("expected the failure to be recorded, got %+v", ex)
+	}
+}
+
+func TestForwardProxyInterceptsHTTPS(t *testing.T) {
+	t.Parallel()
+
+	plain := httptest.NewServer(http.HandlerFunc(upstreamHandler))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(upstreamHandler))
+	defer secure.Close()
+
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA returned error: %v", err)
+	}
+	rec := &recorder{}
+	p, _ := New(Options{Forward: true, CA: ca, Transport: secure.Client().Transport, Record: rec.record})
+	front := httptest.NewServer(p)
+	defer front.Close()
+
+	proxyURL, _ := url.Parse(front.URL)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: ca.Pool()},
+	}}
+
+	resp, err := client.Get(plain.URL + "/plain")
+	if err != nil {
+		t.Fatalf("forward HTTP request failed: %v", err)
+	}
+	resp.Body.Close()
+	if ex := rec.nth(t, 1); ex.URL.String() != plain.URL+"/plain" || ex.Status != http.StatusCreated {
+		t.Fatalf("unexpected forward exchange: %s %d", ex.URL, ex.Status)
+	}
+
+	resp, err = client.Post(secure.URL+"/secure?x=1", "text/plain", strings.NewReader("secret"))
+	if err != nil {
+		t.Fatalf("intercepted HTTPS request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"body":"secret"`) {
+		t.Fatalf("unexpected HTTPS response: %s", body)
+	}
+	ex := rec.nth(t, 2)
+	if ex.URL.Scheme != "https" || ex.URL.Path != "/secure" || string(ex.RequestBody) != "secret" {
+		t.Fatalf("unexpected intercepted exchange: %s %q", ex.URL, ex.RequestBody)
+	}
+
+	// The CA is reused on the next start.
+	again, err := LoadOrCreateCA(strings.TrimSuffix(ca.CertPath, "/ca.pem"))
+	if err != nil || !again.cert.Equal(ca.cert) {
+		t.Fatalf("expected the stored CA to be loaded, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/bench"
+)
+
+type BenchReportOptions struct {
+	OutputPath string
+	SourcePath string
+}
+
+type benchBarView struct {
+	Label string
+	Count int
+	Width float64 // percent of the widest bar
+}
+
+type benchRowView struct {
+	Cells []string
+	Class string
+}
+
+type benchTableView struct {
+	Title   string
+	Headers []string
+	Rows    []benchRowView
+}
+
+type chartLineView struct {
+	Label  string
+	Color  string
+	Points string
+}
+
+type chartView struct {
+	Title    string
+	MaxLabel string
+	EndLabel string
+	Lines    []chartLineView
+}
+
+type benchPageView struct {
+	PageTitle      string
+	HeaderTitle    string
+	HeaderSubtitle string
+	GeneratedAt    string
+	Metrics        []metricView
+	Charts         []chartView
+	Histogram      []benchBarView
+	Tables         []benchTableView
+}
+
+// WriteBenchJSON writes the benchmark report, including its time series, as
+// JSON to opts.OutputPath (or the default report directory).
+func WriteBenchJSON(rep *bench.Report, opts BenchReportOptions) (string, error) {
+	return writeBenchFile(rep, opts, ".json", func(w io.Writer) error { return rep.WriteJSON(w) })
+}
+
+// WriteBenchHTML renders the benchmark report as a standalone HTML page with
+// throughput and latency charts.
+func WriteBenchHTML(rep *bench.Report, opts BenchReportOptions) (string, error) {
+	outputPath, err := resolveOutputPath(opts.OutputPath, defaultBenchFilename(opts.SourcePath, ".html"))
+	if err != nil {
+		return "", err
+	}
+	if err := renderPage(outputPath, buildBenchPageView(rep, time.Now()), benchPageBodyTemplate); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+func writeBenchFile(rep *bench.Report, opts BenchReportOptions, ext string, write func(io.Writer) error) (string, error) {
+	if rep == nil {
+		return "", fmt.Errorf("report is required")
+	}
+	outputPath, err := resolveOutputPath(opts.OutputPath, defaultBenchFilename(opts.SourcePath, ext))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", err
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return "", err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+func defaultBenchFilename(sourcePath, ext string) string {
+	return "bench-" + strings.TrimPrefix(defaultRunFilename(sourcePath, ext), "run-")
+}
+
+func buildBenchPageView(rep *bench.Report, generatedAt time.Time) benchPageView {
+	view := benchPageView{
+		PageTitle:      "Kest Benchmark",
+		HeaderTitle:    "Benchmark",
+		HeaderSubtitle: rep.Target,
+		GeneratedAt:    formatTimestamp(generatedAt),
+		Metrics: []metricView{
+			{Label: "Requests", Value: strconv.Itoa(rep.Requests)},
+			{Label: "Throughput", Value: fmt.Sprintf("%.1f req/s", rep.Throughput)},
+			{Label: "Errors", Value: fmt.Sprintf("%d (%.2f%%)", rep.Errors, rep.ErrorRate*100)},
+			{Label: "P50", Value: bench.FormatMs(rep.Latency.P50)},
+			{Label: "P90", Value: bench.FormatMs(rep.Latency.P90)},
+			{Label: "P95", Value: bench.FormatMs(rep.Latency.P95)},
+			{Label: "P99", Value: bench.FormatMs(rep.Latency.P99)},
+			{Label: "Max", Value: bench.FormatMs(rep.Latency.Max)},
+		},
+	}
+	if rep.Kind == "flow" {
+		view.Metrics = append(view.Metrics, metricView{Label: "Iterations", Value: fmt.Sprintf("%d (%.1f/s)", rep.Iterations, rep.IterationRate)})
+	}
+
+	var requests, errors, users, p50, p95, p99 []float64
+	for _, p := range rep.Series {
+		requests = append(requests, float64(p.Requests))
+		errors = append(errors, float64(p.Errors))
+		users = append(users, float64(p.Users))
+		p50 = append(p50, p.P50)
+		p95 = append(p95, p.P95)
+		p99 = append(p99, p.P99)
+	}
+	end := fmt.Sprintf("%ds", len(rep.Series))
+	view.Charts = []chartView{
+		buildChart("Throughput (requests per second)", end, func(v float64) string { return strconv.FormatFloat(v, 'f', 0, 64) },
+			chartSeries{"requests", "#0f766e", requests}, chartSeries{"errors", "#b91c1c", errors}, chartSeries{"users", "#b45309", users}),
+		buildChart("Latency", end, bench.FormatMs,
+			chartSeries{"p50", "#0f766e", p50}, chartSeries{"p95", "#b45309", p95}, chartSeries{"p99", "#b91c1c", p99}),
+	}
+
+	peak := 0
+	for _, b := range rep.Histogram {
+		peak = max(peak, b.Count)
+	}
+	for _, b := range rep.Histogram {
+		view.Histogram = append(view.Histogram, benchBarView{Label: b.Label(), Count: b.Count, Width: float64(b.Count) * 100 / float64(max(peak, 1))})
+	}
+
+	if len(rep.Steps) > 0 {
+		table := benchTableView{Title: "Steps", Headers: []string{"Step", "Requests", "Errors", "P50", "P95", "P99", "Max"}}
+		for _, s := range rep.Steps {
+			row := benchRowView{Cells: []string{s.Name, strconv.Itoa(s.Requests), strconv.Itoa(s.Errors),
+				bench.FormatMs(s.Latency.P50), bench.FormatMs(s.Latency.P95), bench.FormatMs(s.Latency.P99), bench.FormatMs(s.Latency.Max)}}
+			if s.Errors > 0 {
+				row.Class = "badge-status-failure"
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		view.Tables = append(view.Tables, table)
+	}
+	view.Tables = append(view.Tables, countTable("Status Codes", "Status", rep.Statuses))
+	if len(rep.Assertions) > 0 {
+		view.Tables = append(view.Tables, countTable("Failed Assertions", "Assertion", rep.Assertions))
+	}
+	if len(rep.ErrorKinds) > 0 {
+		view.Tables = append(view.Tables, countTable("Request Errors", "Error", rep.ErrorKinds))
+	}
+	if len(rep.Thresholds) > 0 {
+		table := benchTableView{Title: "Thresholds", Headers: []string{"Threshold", "Actual", "Result"}}
+		for _, t := range rep.Thresholds {
+			row := benchRowView{Cells: []string{t.Expression, t.Actual, "passed"}, Class: "badge-status-success"}
+			if !t.Passed {
+				row.Cells[2], row.Class = "failed", "badge-status-failure"
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		view.Tables = append(view.Tables, table)
+	}
+	return view
+}
+
+func countTable(title, header string, counts map[string]int) benchTableView {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	table := benchTableView{Title: title, Headers: []string{header, "Count"}}
+	for _, k := range keys {
+		table.Rows = append(table.Rows, benchRowView{Cells: []string{k, strconv.Itoa(counts[k])}})
+	}
+	return table
+}
+
+type chartSeries struct {
+	label  string
+	color  string
+	values []float64
+}
+
+// Chart coordinates match the viewBox of the SVG in benchPageBodyTemplate.
+const (
+	chartWidth  = 1000.0
+	chartHeight = 240.0
+	chartPad    = 10.0
+)
+
+func buildChart(title, end string, format func(float64) string, series ...chartSeries) chartView {
+	peak := 0.0
+	for _, s := range series {
+		for _, v := range s.values {
+			peak = max(peak, v)
+		}
+	}
+	chart := chartView{Title: title, MaxLabel: format(peak), EndLabel: end}
+	if peak == 0 {
+		peak = 1
+	}
+	for _, s := range series {
+		points := make([]string, len(s.values))
+		for i, v := range s.values {
+			x := chartPad
+			if len(s.values) > 1 {
+				x += float64(i) * (chartWidth - 2*chartPad) / float64(len(s.values)-1)
+			}
+			y := chartHeight - chartPad - v/peak*(chartHeight-2*chartPad)
+			points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		chart.Lines = append(chart.Lines, chartLineView{Label: s.label, Color: s.color, Points: strings.Join(points, " ")})
+	}
+	return chart
+}
+
+const benchPageBodyTemplate = `
+{{define "body"}}
+  <section class="metrics">
+    {{range .Metrics}}
+      <article class="metric">
+        <span class="metric-label">{{.Label}}</span>
+        <span class="metric-value">{{.Value}}</span>
+      </article>
+    {{end}}
+  </section>
+
+  {{range .Charts}}
+    <section class="card" style="margin-bottom: 18px;">
+      <div class="card-header">
+        <div>
+          <h2 class="card-title">{{.Title}}</h2>
+          <p class="card-subtitle">
+            {{range .Lines}}<span style="color: {{.Color}}; margin-right: 14px;">━ {{.Label}}</span>{{end}}
+          </p>
+        </div>
+        <span class="badge badge-status-neutral">peak {{.MaxLabel}}</span>
+      </div>
+      <svg viewBox="0 0 1000 240" preserveAspectRatio="none" style="width: 100%; height: 240px; background: var(--panel-strong); border-radius: 14px;">
+        {{range .Lines}}<polyline fill="none" stroke="{{.Color}}" stroke-width="2" vector-effect="non-scaling-stroke" points="{{.Points}}"/>{{end}}
+      </svg>
+      <p class="empty" style="display: flex; justify-content: space-between;"><span>0s</span><span>{{.EndLabel}}</span></p>
+    </section>
+  {{end}}
+
+  {{if .Histogram}}
+    <section class="card" style="margin-bottom: 18px;">
+      <div class="card-header">
+        <div>
+          <h2 class="card-title">Latency Histogram</h2>
+          <p class="card-subtitle">Requests per latency bucket.</p>
+        </div>
+      </div>
+      <table>
+        <tbody>
+          {{range .Histogram}}
+            <tr>
+              <th style="width: 90px;">{{.Label}}</th>
+              <td><div style="background: var(--accent); height: 14px; border-radius: 7px; width: {{printf "%.1f" .Width}}%;"></div></td>
+              <td style="width: 80px; text-align: right;"><code>{{.Count}}</code></td>
+            </tr>
+          {{end}}
+        </tbody>
+      </table>
+    </section>
+  {{end}}
+
+  {{range .Tables}}
+    <section class="card" style="margin-bottom: 18px;">
+      <div class="card-header">
+        <div>
+          <h2 class="card-title">{{.Title}}</h2>
+        </div>
+      </div>
+      <table>
+        <thead>
+          <tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+        </thead>
+        <tbody>
+          {{range .Rows}}
+            <tr class="{{.Class}}">{{range .Cells}}<td><code>{{.}}</code></td>{{end}}</tr>
+          {{end}}
+        </tbody>
+      </table>
+    </section>
+  {{end}}
+{{end}}`
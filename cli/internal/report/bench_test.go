@@ -0,0 +1,68 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/bench"
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+func TestWriteBenchReports(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.May, 2, 10, 0, 0, 0, time.UTC)
+	rec := bench.NewRecorder(start)
+	for i := 0; i < 30; i++ {
+		at := start.Add(time.Duration(i) * 100 * time.Millisecond)
+		res := summary.TestResult{Name: "checkout", StartTime: at, Status: 200, Duration: time.Duration(10+i) * time.Millisecond, Success: true}
+		if i == 7 {
+			res.Status, res.Success, res.FailedAssertion = 500, false, "status < 400"
+		}
+		rec.AddIteration(at, res.Duration, []summary.TestResult{res})
+	}
+	rep := rec.Report(bench.Config{Target: "checkout.flow.md", Kind: "flow", Users: 5}, 3*time.Second)
+	rep.Thresholds = []bench.ThresholdResult{{Expression: "p95<20ms", Actual: "38ms", Passed: false}}
+
+	dir := t.TempDir()
+	htmlPath, err := WriteBenchHTML(rep, BenchReportOptions{OutputPath: filepath.Join(dir, "bench.html")})
+	if err != nil {
+		t.Fatalf("WriteBenchHTML: %v", err)
+	}
+	content, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("read html: %v", err)
+	}
+	html := string(content)
+	for _, want := range []string{"checkout.flow.md", "<polyline", "Latency Histogram", "Failed Assertions", "status &lt; 400", "p95&lt;20ms", "failed"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML report is missing %q", want)
+		}
+	}
+
+	jsonPath, err := WriteBenchJSON(rep, BenchReportOptions{OutputPath: filepath.Join(dir, "bench.json")})
+	if err != nil {
+		t.Fatalf("WriteBenchJSON: %v", err)
+	}
+	raw, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read json: %v", err)
+	}
+	var decoded struct {
+		Requests int `json:"requests"`
+		Series   []struct {
+			Second   int `json:"second"`
+			Requests int `json:"requests"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if decoded.Requests != 30 || len(decoded.Series) != 3 || decoded.Series[1].Requests != 10 {
+		t.Fatalf("unexpected JSON report: %s", raw)
+	}
+}
@@ -79,9 +79,16 @@ type recordPageView struct {
 	ResponseBody    codeSectionView
 }
 
+type groupView struct {
+	Name        string
+	Summary     string
+	StatusClass string
+}
+
 type runResultView struct {
 	AnchorID        string
 	Name            string
+	Group           string
 	Method          string
 	MethodClass     string
 	URL             string
@@ -90,7 +97,11 @@ type runResultView struct {
 	Duration        string
 	StartedAt       string
 	Error           string
+	SkipReason      string
+	Params          string
+	Tags            string
 	RecordID        int64
+	Assertions      assertionTableView
 	CommandSection  codeSectionView
 	RequestHeaders  headerTableView
 	RequestBody     codeSectionView
@@ -98,6 +109,22 @@ type runResultView struct {
 	ResponseBody    codeSectionView
 }
 
+type assertionRowView struct {
+	Expression  string
+	StatusText  string
+	StatusClass string
+	Path        string
+	Expected    string
+	Actual      string
+	Diff        string
+}
+
+type assertionTableView struct {
+	Rows   []assertionRowView
+	Failed int
+	Open   bool
+}
+
 type runPageView struct {
 	PageTitle      string
 	HeaderTitle    string
@@ -105,6 +132,7 @@ type runPageView struct {
 	GeneratedAt    string
 	Metrics        []metricView
 	LogPath        string
+	Groups         []groupView
 	Navigation     []navItemView
 	Results        []runResultView
 }
@@ -121,7 +149,9 @@ func WriteRecordHTML(record *storage.Record, opts RecordHTMLOptions) (string, er
 		return "", err
 	}
 
-	view := buildRecordPageView(record, time.Now())
+	masked := *record
+	storage.MaskRecord(&masked)
+	view := buildRecordPageView(&masked, time.Now())
 	if err := renderPage(outputPath, view, recordPageBodyTemplate); err != nil {
 		return "", err
 	}
@@ -134,12 +164,12 @@ func WriteRunHTML(summ *summary.Summary, opts RunHTMLOptions) (string, error) {
 		return "", fmt.Errorf("summary is required")
 	}
 
-	outputPath, err := resolveOutputPath(opts.OutputPath, defaultRunFilename(opts.SourcePath))
+	outputPath, err := resolveOutputPath(opts.OutputPath, defaultRunFilename(opts.SourcePath, ".html"))
 	if err != nil {
 		return "", err
 	}
 
-	view := buildRunPageView(summ, opts, time.Now())
+	view := buildRunPageView(summ.Masked(), opts, time.Now())
 	if err := renderPage(outputPath, view, runPageBodyTemplate); err != nil {
 		return "", err
 	}
@@ -206,13 +236,16 @@ func buildRecordPageView(record *storage.Record, generatedAt time.Time) recordPa
 }
 
 func buildRunPageView(summ *summary.Summary, opts RunHTMLOptions, generatedAt time.Time) runPageView {
-	slowest, p95 := latencyStats(summ.Results)
+	slowest, p95 := summary.LatencyStats(summ.Results)
 	metrics := []metricView{
 		{Label: "Total Steps", Value: fmt.Sprintf("%d", summ.TotalTests)},
 		{Label: "Passed", Value: fmt.Sprintf("%d", summ.PassedTests)},
 		{Label: "Failed", Value: fmt.Sprintf("%d", summ.FailedTests)},
 		{Label: "Total Time", Value: summ.TotalTime.Round(time.Millisecond).String()},
 	}
+	if summ.SkippedTests > 0 {
+		metrics = append(metrics[:3:3], append([]metricView{{Label: "Skipped", Value: fmt.Sprintf("%d", summ.SkippedTests)}}, metrics[3:]...)...)
+	}
 	if slowest > 0 {
 		metrics = append(metrics, metricView{Label: "Slowest", Value: slowest.Round(time.Millisecond).String()})
 	}
@@ -226,9 +259,13 @@ func buildRunPageView(summ *summary.Summary, opts RunHTMLOptions, generatedAt ti
 		anchorID := fmt.Sprintf("result-%d", index+1)
 		statusText := runStatusText(result)
 		statusTone := statusClass(result.Status, result.Success, result.Method)
+		if result.Skipped {
+			statusTone = "badge badge-status-neutral"
+		}
 		results = append(results, runResultView{
 			AnchorID:    anchorID,
 			Name:        fallback(result.Name, fmt.Sprintf("Step %d", index+1)),
+			Group:       result.Group,
 			Method:      fallback(result.Method, "STEP"),
 			MethodClass: methodClass(result.Method),
 			URL:         result.URL,
@@ -237,7 +274,11 @@ func buildRunPageView(summ *summary.Summary, opts RunHTMLOptions, generatedAt ti
 			Duration:    formatDuration(result.Duration),
 			StartedAt:   formatTimestamp(result.StartTime),
 			Error:       errorString(result.Error),
+			SkipReason:  result.SkipReason,
+			Params:      formatParams(result.Params),
+			Tags:        strings.Join(result.Tags, ", "),
 			RecordID:    result.RecordID,
+			Assertions:  buildAssertionTable(result.Assertions),
 			CommandSection: codeSectionView{
 				ID:           fmt.Sprintf("%s-command", anchorID),
 				Title:        "Command",
@@ -271,7 +312,7 @@ func buildRunPageView(summ *summary.Summary, opts RunHTMLOptions, generatedAt ti
 				Title:        "Response Body",
 				Content:      formatJSONLikeString(result.ResponseBody),
 				EmptyMessage: "This step did not return any body output.",
-				Open:         !result.Success,
+				Open:         !result.Success && !result.Skipped,
 			},
 		})
 		navigation = append(navigation, navItemView{
@@ -282,6 +323,23 @@ func buildRunPageView(summ *summary.Summary, opts RunHTMLOptions, generatedAt ti
 		})
 	}
 
+	var groups []groupView
+	for _, group := range summ.Groups() {
+		tone := "badge badge-status-success"
+		if group.Failed > 0 {
+			tone = "badge badge-status-failure"
+		}
+		groupSummary := fmt.Sprintf("%d/%d passed · %s", group.Passed, group.Total, formatDuration(group.TotalTime))
+		if group.Skipped > 0 {
+			groupSummary = fmt.Sprintf("%d/%d passed · %d skipped · %s", group.Passed, group.Total, group.Skipped, formatDuration(group.TotalTime))
+		}
+		groups = append(groups, groupView{
+			Name:        group.Name,
+			Summary:     groupSummary,
+			StatusClass: tone,
+		})
+	}
+
 	sourcePath := strings.TrimSpace(opts.SourcePath)
 	if sourcePath == "" {
 		sourcePath = "Kest CLI run"
@@ -294,6 +352,7 @@ func buildRunPageView(summ *summary.Summary, opts RunHTMLOptions, generatedAt ti
 		GeneratedAt:    formatTimestamp(generatedAt),
 		Metrics:        metrics,
 		LogPath:        strings.TrimSpace(opts.LogPath),
+		Groups:         groups,
 		Navigation:     navigation,
 		Results:        results,
 	}
@@ -368,7 +427,7 @@ func defaultRecordFilename(record *storage.Record) string {
 	return fmt.Sprintf("record-%d-%s.html", record.ID, timestamp)
 }
 
-func defaultRunFilename(sourcePath string) string {
+func defaultRunFilename(sourcePath, ext string) string {
 	base := filepath.Base(strings.TrimSpace(sourcePath))
 	if base == "." || base == string(filepath.Separator) || base == "" {
 		base = "kest-run"
@@ -376,7 +435,7 @@ func defaultRunFilename(sourcePath string) string {
 	base = strings.TrimSuffix(base, filepath.Ext(base))
 	base = sanitizeSlug(base)
 	timestamp := time.Now().Format("20060102-150405")
-	return fmt.Sprintf("run-%s-%s.html", base, timestamp)
+	return fmt.Sprintf("run-%s-%s%s", base, timestamp, ext)
 }
 
 func sanitizeSlug(value string) string {
@@ -500,6 +559,9 @@ func fallback(value, fallbackValue string) string {
 }
 
 func runStatusText(result summary.TestResult) string {
+	if result.Skipped {
+		return "Skipped"
+	}
 	if strings.EqualFold(result.Method, "EXEC") {
 		if result.Success {
 			return "Completed"
@@ -515,37 +577,57 @@ func runStatusText(result summary.TestResult) string {
 	return "Failed"
 }
 
-func errorString(err error) string {
-	if err == nil {
-		return ""
+// buildAssertionTable lists every evaluated assertion; the table starts
+// expanded when one of them failed.
+func buildAssertionTable(assertions []summary.AssertionResult) assertionTableView {
+	view := assertionTableView{Rows: make([]assertionRowView, 0, len(assertions))}
+	for _, a := range assertions {
+		row := assertionRowView{
+			Expression:  a.Expression,
+			StatusText:  "Passed",
+			StatusClass: "badge badge-status-success",
+			Path:        a.Path,
+			Expected:    a.Expected,
+			Actual:      a.Actual,
+			Diff:        a.Diff,
+		}
+		switch {
+		case !a.Passed && a.Soft:
+			row.StatusText = "Soft fail"
+			row.StatusClass = "badge badge-status-neutral"
+		case !a.Passed:
+			row.StatusText = "Failed"
+			row.StatusClass = "badge badge-status-failure"
+			view.Failed++
+		}
+		view.Rows = append(view.Rows, row)
 	}
-	return strings.TrimSpace(err.Error())
+	view.Open = view.Failed > 0
+	return view
 }
 
-func latencyStats(results []summary.TestResult) (time.Duration, time.Duration) {
-	if len(results) == 0 {
-		return 0, 0
-	}
-
-	values := make([]time.Duration, 0, len(results))
-	var slowest time.Duration
-	for _, result := range results {
-		values = append(values, result.Duration)
-		if result.Duration > slowest {
-			slowest = result.Duration
-		}
+// formatParams renders data row values as "key=value" pairs sorted by key.
+func formatParams(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
 	}
-
-	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
-	index := int(float64(len(values)-1) * 0.95)
-	if index < 0 {
-		index = 0
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
 	}
-	if index >= len(values) {
-		index = len(values) - 1
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+params[key])
 	}
+	return strings.Join(parts, ", ")
+}
 
-	return slowest, values[index]
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return strings.TrimSpace(err.Error())
 }
 
 func methodClass(method string) string {
@@ -936,6 +1018,29 @@ const sharedPartialTemplates = `
   </details>
 {{end}}
 
+{{define "assertionTable"}}
+  <details class="details" {{if .Open}}open{{end}}>
+    <summary>Assertions<span>{{len .Rows}} checked{{if .Failed}}, {{.Failed}} failed{{end}}</span></summary>
+    <div class="details-body">
+      <table>
+        <tbody>
+          {{range .Rows}}
+            <tr>
+              <th><span class="{{.StatusClass}}">{{.StatusText}}</span></th>
+              <td>
+                <code>{{.Expression}}</code>
+                {{if .Path}}<div class="empty">Path {{.Path}}</div>{{end}}
+                {{if or .Expected .Actual}}<div class="empty">Expected <code>{{.Expected}}</code> · Actual <code>{{.Actual}}</code></div>{{end}}
+                {{if .Diff}}<pre>{{.Diff}}</pre>{{end}}
+              </td>
+            </tr>
+          {{end}}
+        </tbody>
+      </table>
+    </div>
+  </details>
+{{end}}
+
 {{define "codeSection"}}
   <details class="details" {{if .Open}}open{{end}}>
     <summary>{{.Title}}<span>{{if .Content}}Click to inspect{{else}}No content{{end}}</span></summary>
@@ -1028,6 +1133,25 @@ const runPageBodyTemplate = `
     </section>
   {{end}}
 
+  {{if .Groups}}
+    <section class="card" style="margin-bottom: 18px;">
+      <div class="card-header">
+        <div>
+          <h2 class="card-title">Groups</h2>
+          <p class="card-subtitle">Results are grouped by flow file and data iteration.</p>
+        </div>
+      </div>
+      <div class="nav-list">
+        {{range .Groups}}
+          <span class="nav-item">
+            <span>{{.Name}}</span>
+            <span class="{{.StatusClass}}">{{.Summary}}</span>
+          </span>
+        {{end}}
+      </div>
+    </section>
+  {{end}}
+
   {{if .Navigation}}
     <section class="card" style="margin-bottom: 18px;">
       <div class="card-header">
@@ -1065,6 +1189,9 @@ const runPageBodyTemplate = `
           </div>
         </div>
         <p class="meta-line">
+          {{if .Group}}<span>{{.Group}}</span>{{end}}
+          {{if .Params}}<span>Data {{.Params}}</span>{{end}}
+          {{if .Tags}}<span>Tags {{.Tags}}</span>{{end}}
           <span>Started {{.StartedAt}}</span>
           <span>Duration {{.Duration}}</span>
           {{if gt .RecordID 0}}<span>Recorded as #{{.RecordID}}</span>{{end}}
@@ -1075,6 +1202,15 @@ const runPageBodyTemplate = `
             <span>{{.Error}}</span>
           </div>
         {{end}}
+        {{if .SkipReason}}
+          <div class="card" style="margin-top: 14px; padding: 14px 16px; border-radius: 18px; background: rgba(100, 116, 139, 0.08); border-color: rgba(100, 116, 139, 0.18); box-shadow: none;">
+            <strong style="display: block; margin-bottom: 6px; color: #475569;">Skipped</strong>
+            <span>{{.SkipReason}}</span>
+          </div>
+        {{end}}
+        {{if .Assertions.Rows}}
+          <div style="margin-top: 14px;">{{template "assertionTable" .Assertions}}</div>
+        {{end}}
         {{if .CommandSection.Content}}
           <div style="margin-top: 14px;">{{template "codeSection" .CommandSection}}</div>
         {{end}}
@@ -92,6 +92,10 @@ func TestWriteRunHTML(t *testing.T) {
 		StartTime:    time.Date(2026, time.April, 30, 10, 0, 1, 0, time.UTC),
 		RecordID:     7,
 		Success:      true,
+		Assertions: []summary.AssertionResult{
+			{Expression: "status == 200", Passed: true, Path: "status", Expected: "== 200", Actual: "200"},
+			{Expression: `body.user == {"id":1}`, Path: "user", Expected: `== {"id":1}`, Actual: `{"id":2}`, Diff: "~ /id: 1 → 2"},
+		},
 	})
 
 	summ.AddResult(summary.TestResult{
@@ -104,6 +108,15 @@ func TestWriteRunHTML(t *testing.T) {
 		Success:      true,
 	})
 
+	summ.AddResult(summary.TestResult{
+		Name:       "Recover Session",
+		Method:     "POST",
+		URL:        "https://api.example.com/session/recover",
+		StartTime:  time.Date(2026, time.April, 30, 10, 0, 3, 0, time.UTC),
+		Skipped:    true,
+		SkipReason: "@on failure not met by login",
+	})
+
 	outputPath := filepath.Join(t.TempDir(), "run.html")
 	writtenPath, err := WriteRunHTML(summ, RunHTMLOptions{
 		OutputPath: outputPath,
@@ -129,7 +142,11 @@ func TestWriteRunHTML(t *testing.T) {
 	assertContains(t, content, "printf")
 	assertContains(t, content, "signature")
 	assertContains(t, content, "Recorded as #7")
+	assertContains(t, content, "2 checked, 1 failed")
+	assertContains(t, content, "~ /id: 1 → 2")
 	assertContains(t, content, "/tmp/kest-session.log")
+	assertContains(t, content, "Skipped")
+	assertContains(t, content, "@on failure not met by login")
 }
 
 func assertContains(t *testing.T, content, want string) {
@@ -0,0 +1,262 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// RunReportOptions configures the machine-readable run reports.
+type RunReportOptions struct {
+	OutputPath string
+	SourcePath string
+}
+
+// WriteRunJUnit writes the run as JUnit XML to opts.OutputPath (or the
+// default report directory) and returns the absolute path.
+func WriteRunJUnit(summ *summary.Summary, opts RunReportOptions) (string, error) {
+	return writeRunReport(summ, opts, ".xml", WriteJUnit)
+}
+
+func writeRunReport(summ *summary.Summary, opts RunReportOptions, ext string, write func(io.Writer, *summary.Summary, string) error) (string, error) {
+	if summ == nil {
+		return "", fmt.Errorf("summary is required")
+	}
+
+	outputPath, err := resolveOutputPath(opts.OutputPath, defaultRunFilename(opts.SourcePath, ext))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", err
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return "", err
+	}
+	if err := write(file, summ.Masked(), opts.SourcePath); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// WriteJUnit renders the run as JUnit XML: one <testsuite> per flow file
+// and one <testcase> per step. Failed assertions become <failure>, steps
+// that could not run (request or exec errors) become <error>, skipped steps
+// become <skipped> and exec output is kept in <system-out>.
+func WriteJUnit(w io.Writer, summ *summary.Summary, sourcePath string) error {
+	if summ == nil {
+		return fmt.Errorf("summary is required")
+	}
+
+	root := junitTestSuites{Name: sourcePath}
+	index := make(map[string]int)
+	var durations []time.Duration
+	for _, result := range summ.Results {
+		name := result.Group
+		if name == "" {
+			name = sourcePath
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(root.Suites)
+			index[name] = i
+			suite := junitTestSuite{Name: name}
+			if !result.StartTime.IsZero() {
+				suite.Timestamp = result.StartTime.Format("2006-01-02T15:04:05")
+			}
+			root.Suites = append(root.Suites, suite)
+			durations = append(durations, 0)
+		}
+		durations[i] += result.Duration
+
+		suite := &root.Suites[i]
+		testCase := junitTestCase{
+			Name:      junitCaseName(result),
+			Classname: junitClassname(name),
+			Time:      junitSeconds(result.Duration),
+		}
+		switch {
+		case result.Skipped:
+			testCase.Skipped = &junitSkipped{Message: result.SkipReason}
+			suite.Skipped++
+		case result.Success:
+		case len(result.FailedAssertions()) > 0 || result.FailedAssertion != "":
+			testCase.Failure = &junitProblem{
+				Message: junitFailureMessage(result),
+				Type:    "AssertionError",
+				Body:    failureDetails(result),
+			}
+			suite.Failures++
+		default:
+			testCase.Error = &junitProblem{
+				Message: junitFailureMessage(result),
+				Type:    "Error",
+				Body:    failureDetails(result),
+			}
+			suite.Errors++
+		}
+		if isExecResult(result) {
+			testCase.SystemOut = result.ResponseBody
+		}
+
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	var total time.Duration
+	for i := range root.Suites {
+		root.Suites[i].Time = junitSeconds(durations[i])
+		total += durations[i]
+
+		root.Tests += root.Suites[i].Tests
+		root.Failures += root.Suites[i].Failures
+		root.Errors += root.Suites[i].Errors
+		root.Skipped += root.Suites[i].Skipped
+	}
+	root.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitCaseName(result summary.TestResult) string {
+	if result.Name != "" {
+		return result.Name
+	}
+	if result.StepID != "" {
+		return result.StepID
+	}
+	return strings.TrimSpace(result.Method + " " + result.URL)
+}
+
+// junitClassname turns a flow file path into the dotted name CI tools use
+// to group test cases, e.g. tests/auth/login.flow.md → tests.auth.login.
+func junitClassname(name string) string {
+	base, rest, nested := strings.Cut(name, " › ")
+	base = filepath.ToSlash(base)
+	base = strings.TrimSuffix(strings.TrimSuffix(base, ".md"), ".flow")
+	base = strings.TrimSuffix(base, ".kest")
+	base = strings.Trim(strings.ReplaceAll(base, "/", "."), ".")
+	if nested {
+		return base + " › " + rest
+	}
+	return base
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func junitFailureMessage(result summary.TestResult) string {
+	if failed := result.FailedAssertions(); len(failed) > 0 {
+		message := failed[0].Expression
+		if len(failed) > 1 {
+			message += fmt.Sprintf(" (and %d more)", len(failed)-1)
+		}
+		return message
+	}
+	if result.FailedAssertion != "" {
+		return result.FailedAssertion
+	}
+	if result.Error != nil {
+		return firstLine(result.Error.Error())
+	}
+	return "step failed"
+}
+
+// failureDetails lists every failed assertion with its expected and actual
+// values and diff, followed by the step error.
+func failureDetails(result summary.TestResult) string {
+	var b strings.Builder
+	for _, a := range result.FailedAssertions() {
+		fmt.Fprintf(&b, "✗ %s\n", a.Expression)
+		if a.Expected != "" || a.Actual != "" {
+			fmt.Fprintf(&b, "  expected: %s\n", a.Expected)
+			fmt.Fprintf(&b, "  actual:   %s\n", a.Actual)
+		}
+		if a.Message != "" {
+			fmt.Fprintf(&b, "  %s\n", strings.ReplaceAll(a.Message, "\n", "\n  "))
+		}
+		if a.Diff != "" {
+			fmt.Fprintf(&b, "  %s\n", strings.ReplaceAll(a.Diff, "\n", "\n  "))
+		}
+	}
+	if result.Error != nil {
+		fmt.Fprintf(&b, "%s\n", result.Error.Error())
+	}
+	if result.Status != 0 || result.URL != "" {
+		fmt.Fprintf(&b, "%s %s → %d\n", result.Method, result.URL, result.Status)
+	}
+	return b.String()
+}
+
+func isExecResult(result summary.TestResult) bool {
+	return result.Method == "EXEC" || result.Command != ""
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,152 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+func machineReportSummary() *summary.Summary {
+	start := time.Date(2026, time.April, 30, 10, 0, 0, 0, time.UTC)
+	summ := summary.NewSummary()
+	summ.StartTime = start
+
+	suite := summary.NewSummary()
+	suite.AddResult(summary.TestResult{
+		Name:      "Login",
+		Method:    "POST",
+		URL:       "https://api.example.com/login",
+		Status:    200,
+		Duration:  120 * time.Millisecond,
+		StartTime: start,
+		Success:   true,
+	})
+	suite.AddResult(summary.TestResult{
+		Name:            "Get Profile",
+		Method:          "GET",
+		URL:             "https://api.example.com/me",
+		Status:          200,
+		Duration:        80 * time.Millisecond,
+		StartTime:       start.Add(time.Second),
+		FailedAssertion: `body.user == {"id":1}`,
+		Assertions: []summary.AssertionResult{
+			{Expression: "status == 200", Passed: true},
+			{Expression: `body.user == {"id":1}`, Expected: `== {"id":1}`, Actual: `{"id":2}`, Diff: "~ /id: 1 → 2"},
+		},
+		Error: errors.New("assertion failed"),
+	})
+	suite.AddResult(summary.TestResult{
+		Name:       "Recover Session",
+		Method:     "POST",
+		URL:        "https://api.example.com/session/recover",
+		StartTime:  start.Add(2 * time.Second),
+		Skipped:    true,
+		SkipReason: "@on failure not met by login",
+	})
+	summ.Merge(suite, "tests/auth/login.flow.md")
+
+	other := summary.NewSummary()
+	other.AddResult(summary.TestResult{
+		Name:         "Sign # payload",
+		Method:       "EXEC",
+		Command:      "./sign.sh",
+		ResponseBody: "signing...",
+		Duration:     20 * time.Millisecond,
+		StartTime:    start.Add(3 * time.Second),
+		Error:        errors.New("exec failed: exit status 1\nstderr: no key"),
+	})
+	summ.Merge(other, "tests/sign.flow.md")
+	return summ
+}
+
+func TestWriteJUnit(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, machineReportSummary(), "tests/"); err != nil {
+		t.Fatalf("WriteJUnit returned error: %v", err)
+	}
+
+	var parsed junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("report is not valid XML: %v\n%s", err, buf.String())
+	}
+	if parsed.Tests != 4 || parsed.Failures != 1 || parsed.Errors != 1 || parsed.Skipped != 1 {
+		t.Fatalf("unexpected totals: %+v", parsed)
+	}
+	if len(parsed.Suites) != 2 {
+		t.Fatalf("expected one testsuite per flow file, got %d", len(parsed.Suites))
+	}
+
+	login := parsed.Suites[0]
+	if login.Name != "tests/auth/login.flow.md" || login.Tests != 3 || login.Time != "0.200" {
+		t.Fatalf("unexpected login suite: %+v", login)
+	}
+	if login.Cases[0].Classname != "tests.auth.login" || login.Cases[0].Time != "0.120" {
+		t.Fatalf("unexpected testcase: %+v", login.Cases[0])
+	}
+	failure := login.Cases[1].Failure
+	if failure == nil || failure.Type != "AssertionError" {
+		t.Fatalf("expected an assertion failure, got %+v", login.Cases[1])
+	}
+	if !strings.Contains(failure.Body, "actual:   {\"id\":2}") || !strings.Contains(failure.Body, "~ /id: 1 → 2") {
+		t.Fatalf("failure body misses assertion details:\n%s", failure.Body)
+	}
+	if skipped := login.Cases[2].Skipped; skipped == nil || skipped.Message != "@on failure not met by login" {
+		t.Fatalf("expected skipped testcase, got %+v", login.Cases[2])
+	}
+
+	exec := parsed.Suites[1].Cases[0]
+	if exec.Error == nil || exec.Error.Message != "exec failed: exit status 1" {
+		t.Fatalf("expected exec error, got %+v", exec)
+	}
+	if exec.SystemOut != "signing..." {
+		t.Fatalf("expected exec stdout in system-out, got %q", exec.SystemOut)
+	}
+}
+
+func TestWriteRunJUnitCreatesDirectories(t *testing.T) {
+	t.Parallel()
+
+	outputPath := filepath.Join(t.TempDir(), "reports", "kest.xml")
+	writtenPath, err := WriteRunJUnit(machineReportSummary(), RunReportOptions{OutputPath: outputPath, SourcePath: "tests/"})
+	if err != nil {
+		t.Fatalf("WriteRunJUnit returned error: %v", err)
+	}
+	if writtenPath != outputPath {
+		t.Fatalf("expected path %q, got %q", outputPath, writtenPath)
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	assertContains(t, string(content), `<testsuites name="tests/" tests="4" failures="1" errors="1" skipped="1"`)
+}
+
+func TestWriteTAP(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := WriteTAP(&buf, machineReportSummary(), "tests/"); err != nil {
+		t.Fatalf("WriteTAP returned error: %v", err)
+	}
+
+	content := buf.String()
+	if !strings.HasPrefix(content, "TAP version 13\n1..4\n") {
+		t.Fatalf("missing TAP header:\n%s", content)
+	}
+	assertContains(t, content, "# tests/auth/login.flow.md\nok 1 - Login\n")
+	assertContains(t, content, "not ok 2 - Get Profile\n  ---\n")
+	assertContains(t, content, "  assertions:\n    - expression: body.user == {\"id\":1}\n")
+	assertContains(t, content, "ok 3 - Recover Session # SKIP @on failure not met by login\n")
+	assertContains(t, content, `not ok 4 - Sign \# payload`)
+	assertContains(t, content, "  output: signing...")
+	assertContains(t, content, "# fail 2\n")
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"gopkg.in/yaml.v3"
+)
+
+// tapDiagnostic is the YAML block printed under a failing test point.
+type tapDiagnostic struct {
+	Message    string            `yaml:"message"`
+	Severity   string            `yaml:"severity"`
+	File       string            `yaml:"file,omitempty"`
+	Request    string            `yaml:"request,omitempty"`
+	Status     int               `yaml:"status,omitempty"`
+	DurationMs int64             `yaml:"duration_ms"`
+	Assertions []tapAssertion    `yaml:"assertions,omitempty"`
+	Error      string            `yaml:"error,omitempty"`
+	Output     string            `yaml:"output,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+}
+
+type tapAssertion struct {
+	Expression string `yaml:"expression"`
+	Expected   string `yaml:"expected,omitempty"`
+	Actual     string `yaml:"actual,omitempty"`
+	Diff       string `yaml:"diff,omitempty"`
+}
+
+// WriteRunTAP writes the run as TAP to opts.OutputPath (or the default
+// report directory) and returns the absolute path.
+func WriteRunTAP(summ *summary.Summary, opts RunReportOptions) (string, error) {
+	return writeRunReport(summ, opts, ".tap", WriteTAP)
+}
+
+// WriteTAP renders the run as TAP version 13: one test point per step,
+// "# SKIP" for skipped steps and a YAML diagnostic block for failures.
+func WriteTAP(w io.Writer, summ *summary.Summary, sourcePath string) error {
+	if summ == nil {
+		return fmt.Errorf("summary is required")
+	}
+
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(summ.Results))
+
+	currentGroup := ""
+	for i, result := range summ.Results {
+		if result.Group != "" && result.Group != currentGroup {
+			currentGroup = result.Group
+			fmt.Fprintf(&b, "# %s\n", tapEscape(currentGroup))
+		}
+
+		name := tapEscape(junitCaseName(result))
+		switch {
+		case result.Skipped:
+			fmt.Fprintf(&b, "ok %d - %s # SKIP %s\n", i+1, name, tapEscape(result.SkipReason))
+		case result.Success:
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, name)
+		default:
+			fmt.Fprintf(&b, "not ok %d - %s\n", i+1, name)
+			var diagnostic bytes.Buffer
+			encoder := yaml.NewEncoder(&diagnostic)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(tapDiagnosticFor(result, sourcePath)); err != nil {
+				return err
+			}
+			b.WriteString("  ---\n")
+			for _, line := range strings.Split(strings.TrimRight(diagnostic.String(), "\n"), "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+			b.WriteString("  ...\n")
+		}
+	}
+
+	fmt.Fprintf(&b, "# tests %d\n", summ.TotalTests)
+	fmt.Fprintf(&b, "# pass %d\n", summ.PassedTests)
+	fmt.Fprintf(&b, "# fail %d\n", summ.FailedTests)
+	if summ.SkippedTests > 0 {
+		fmt.Fprintf(&b, "# skip %d\n", summ.SkippedTests)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func tapDiagnosticFor(result summary.TestResult, sourcePath string) tapDiagnostic {
+	file := result.Group
+	if file == "" {
+		file = sourcePath
+	}
+	diagnostic := tapDiagnostic{
+		Message:    junitFailureMessage(result),
+		Severity:   "fail",
+		File:       file,
+		Status:     result.Status,
+		DurationMs: result.Duration.Milliseconds(),
+		Data:       result.Params,
+	}
+	if result.URL != "" {
+		diagnostic.Request = strings.TrimSpace(result.Method + " " + result.URL)
+	}
+	for _, a := range result.FailedAssertions() {
+		diagnostic.Assertions = append(diagnostic.Assertions, tapAssertion{
+			Expression: a.Expression,
+			Expected:   a.Expected,
+			Actual:     a.Actual,
+			Diff:       a.Diff,
+		})
+	}
+	if result.Error != nil {
+		diagnostic.Error = result.Error.Error()
+	}
+	if isExecResult(result) {
+		diagnostic.Output = result.ResponseBody
+	}
+	return diagnostic
+}
+
+// tapEscape keeps a description on one line and escapes the directive
+// marker so names containing "#" are not read as SKIP/TODO.
+func tapEscape(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "#", `\#`)
+}
@@ -0,0 +1,161 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix marks values encrypted by Encrypt, so plain values stored
+// by older versions keep working.
+const encryptedPrefix = "enc:v1:"
+
+// KeyEnv overrides the local key file, e.g. to share encrypted stores
+// between CI runs. Any string works; it is hashed into an AES-256 key.
+const KeyEnv = "KEST_SECRET_KEY"
+
+var keys = struct {
+	sync.Mutex
+	byPath map[string][]byte
+}{byPath: make(map[string][]byte)}
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt seals plain with AES-256-GCM under the local key.
+func Encrypt(plain string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values without the encrypted
+// prefix are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt value (was %s or ~/.kest/secret.key changed?): %w", KeyEnv, err)
+	}
+	return string(plain), nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	key, err := loadKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadKey returns the key from KEST_SECRET_KEY or ~/.kest/secret.key,
+// creating the file with a random key on first use.
+func loadKey() ([]byte, error) {
+	if passphrase := os.Getenv(KeyEnv); passphrase != "" {
+		sum := sha256.Sum256([]byte(passphrase))
+		return sum[:], nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	keyPath := filepath.Join(home, ".kest", "secret.key")
+
+	keys.Lock()
+	defer keys.Unlock()
+	if key, ok := keys.byPath[keyPath]; ok {
+		return key, nil
+	}
+	key, err := readKeyFile(keyPath)
+	if os.IsNotExist(err) {
+		key, err = createKeyFile(keyPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys.byPath[keyPath] = key
+	return key, nil
+}
+
+func readKeyFile(keyPath string) ([]byte, error) {
+	raw, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid secret key in %s", keyPath)
+	}
+	return key, nil
+}
+
+// createKeyFile writes a new random key. The key is written to a temporary
+// file and linked into place, so a concurrent kest process either wins the
+// race or reads the complete key of the one that did.
+func createKeyFile(keyPath string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(keyPath), ".secret.key-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if _, err := tmp.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Link(tmp.Name(), keyPath); err != nil {
+		if os.IsExist(err) {
+			return readKeyFile(keyPath)
+		}
+		return nil, err
+	}
+	return key, nil
+}
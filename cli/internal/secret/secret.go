@@ -0,0 +1,216 @@
+// Package secret keeps track of secret variable values and masks them in
+// everything kest prints or stores: terminal output, session logs, history
+// records, reports and AI prompts.
+//
+// A variable is secret when its name matches one of the patterns given by
+// Mark (config secret_vars, --secret-var) or the built-in ones such as
+// *token* and *password*. Values are registered with Track as variables are
+// loaded or captured; Mask then replaces every registered value.
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Masked replaces a secret value in output.
+const Masked = "****"
+
+// minLength keeps very short values out of the mask list: hiding "1" or
+// "ok" would garble every response without protecting anything.
+const minLength = 4
+
+// DefaultPatterns are always treated as secret.
+var DefaultPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*api_key*", "*apikey*", "*private_key*"}
+
+var state = struct {
+	sync.RWMutex
+	patterns []string
+	values   map[string]bool
+	replacer *strings.Replacer
+}{values: make(map[string]bool)}
+
+// Mark declares variable names, or glob patterns such as "*_key", as
+// secret. Matching is case-insensitive.
+func Mark(patterns ...string) {
+	state.Lock()
+	defer state.Unlock()
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || containsString(state.patterns, p) {
+			continue
+		}
+		state.patterns = append(state.patterns, p)
+	}
+}
+
+// IsSecret reports whether a variable name is secret.
+func IsSecret(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return false
+	}
+	state.RLock()
+	defer state.RUnlock()
+	for _, patterns := range [][]string{DefaultPatterns, state.patterns} {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok || p == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Track registers value for masking when name is secret and reports
+// whether it did.
+func Track(name, value string) bool {
+	if !IsSecret(name) {
+		return false
+	}
+	Add(value)
+	return true
+}
+
+// TrackAll registers the values of every secret variable in vars.
+func TrackAll(vars map[string]string) {
+	for name, value := range vars {
+		Track(name, value)
+	}
+}
+
+// Add registers value for masking regardless of any variable name, e.g.
+// an OAuth2 access token.
+func Add(value string) {
+	if len(value) < minLength {
+		return
+	}
+	state.Lock()
+	defer state.Unlock()
+	if state.values[value] {
+		return
+	}
+	state.values[value] = true
+	state.replacer = nil
+}
+
+// Mask replaces every registered secret in s, including its JSON- and
+// URL-escaped forms.
+func Mask(s string) string {
+	if s == "" {
+		return s
+	}
+	r := replacer()
+	if r == nil {
+		return s
+	}
+	return r.Replace(s)
+}
+
+// MaskBytes is Mask for raw bodies.
+func MaskBytes(b []byte) []byte {
+	if len(b) == 0 || replacer() == nil {
+		return b
+	}
+	return []byte(Mask(string(b)))
+}
+
+// MaskMap returns a copy of m with every value masked.
+func MaskMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = Mask(v)
+	}
+	return out
+}
+
+// MaskHeaders returns a copy of h with every value masked.
+func MaskHeaders(h map[string][]string) map[string][]string {
+	if h == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(h))
+	for k, values := range h {
+		masked := make([]string, len(values))
+		for i, v := range values {
+			masked[i] = Mask(v)
+		}
+		out[k] = masked
+	}
+	return out
+}
+
+// Reset forgets every pattern and value; used by tests.
+func Reset() {
+	state.Lock()
+	defer state.Unlock()
+	state.patterns = nil
+	state.values = make(map[string]bool)
+	state.replacer = nil
+}
+
+func replacer() *strings.Replacer {
+	state.RLock()
+	r, empty := state.replacer, len(state.values) == 0
+	state.RUnlock()
+	if r != nil || empty {
+		return r
+	}
+
+	state.Lock()
+	defer state.Unlock()
+	if state.replacer != nil {
+		return state.replacer
+	}
+	variants := make(map[string]bool)
+	for value := range state.values {
+		variants[value] = true
+		// encoding/json escapes <, > and & by default; other encoders don't.
+		if quoted, err := json.Marshal(value); err == nil {
+			variants[string(quoted[1:len(quoted)-1])] = true
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if enc.Encode(value) == nil {
+			quoted := strings.TrimSpace(buf.String())
+			variants[quoted[1:len(quoted)-1]] = true
+		}
+		variants[url.QueryEscape(value)] = true
+		variants[url.PathEscape(value)] = true
+	}
+	// Longest first, so a secret that contains another is masked whole.
+	sorted := make([]string, 0, len(variants))
+	for v := range variants {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, v := range sorted {
+		pairs = append(pairs, v, Masked)
+	}
+	state.replacer = strings.NewReplacer(pairs...)
+	return state.replacer
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsSecret(t *testing.T) {
+	Reset()
+	defer Reset()
+	Mark("stripe_key", "*_pin")
+
+	for name, want := range map[string]bool{
+		"token":          true,
+		"Access_Token":   true,
+		"db_password":    true,
+		"client_secret":  true,
+		"STRIPE_KEY":     true,
+		"card_pin":       true,
+		"user_id":        false,
+		"stripe_key_id":  false,
+		"":               false,
+		"pin_attempts_1": false,
+	} {
+		if got := IsSecret(name); got != want {
+			t.Errorf("IsSecret(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMaskReplacesValueAndEscapedForms(t *testing.T) {
+	Reset()
+	defer Reset()
+	if Track("user_id", "12345") {
+		t.Fatal("user_id must not be secret")
+	}
+	Track("token", "abc/def+ghi")
+	Track("token_prefix", "abc")
+	TrackAll(map[string]string{"password": `p"w&1`, "name": "Alice"})
+
+	got := Mask(`GET /users/12345?t=abc%2Fdef%2Bghi Authorization: Bearer abc/def+ghi {"password":"p\"w&1","name":"Alice"}`)
+	want := `GET /users/12345?t=**** Authorization: Bearer **** {"password":"****","name":"Alice"}`
+	if got != want {
+		t.Fatalf("unexpected mask:\n got %s\nwant %s", got, want)
+	}
+	if got := MaskMap(map[string]string{"Authorization": "Bearer abc/def+ghi"}); got["Authorization"] != "Bearer ****" {
+		t.Fatalf("unexpected masked map: %v", got)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(KeyEnv, "")
+
+	sealed, err := Encrypt("s3cr3t-value")
+	if err != nil {
+		t.Fatalf("Encrypt returned error: %v", err)
+	}
+	if !IsEncrypted(sealed) || strings.Contains(sealed, "s3cr3t") {
+		t.Fatalf("value not encrypted: %s", sealed)
+	}
+	info, err := os.Stat(filepath.Join(home, ".kest", "secret.key"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected a private key file, got %v %v", info, err)
+	}
+	if plain, err := Decrypt(sealed); err != nil || plain != "s3cr3t-value" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	if plain, err := Decrypt("legacy plain value"); err != nil || plain != "legacy plain value" {
+		t.Fatalf("plain values must pass through, got %q, %v", plain, err)
+	}
+
+	t.Setenv(KeyEnv, "another key")
+	if _, err := Decrypt(sealed); err == nil {
+		t.Fatal("expected decryption with a different key to fail")
+	}
+}
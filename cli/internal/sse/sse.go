@@ -0,0 +1,82 @@
+// Package sse parses Server-Sent Events streams (text/event-stream) into
+// events, following the WHATWG event stream format.
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Event is one dispatched event. Event is "message" unless the stream
+// named it; ID is the last event ID seen so far, as the spec defines it.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry int // reconnection time in milliseconds, 0 when not set
+}
+
+// Reader reads events from a stream.
+type Reader struct {
+	r      *bufio.Reader
+	lastID string
+}
+
+// NewReader returns a Reader for r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next event. Comments and events without data are
+// skipped. At the end of the stream it returns io.EOF; an event that was
+// not terminated by a blank line is discarded.
+func (r *Reader) Next() (Event, error) {
+	var data strings.Builder
+	var hasData bool
+	ev := Event{}
+	for {
+		line, err := r.r.ReadString('\n')
+		if err != nil {
+			// An unterminated last line never completes an event.
+			return Event{}, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if !hasData {
+				ev = Event{}
+				continue
+			}
+			ev.ID = r.lastID
+			if ev.Event == "" {
+				ev.Event = "message"
+			}
+			ev.Data = strings.TrimSuffix(data.String(), "\n")
+			return ev, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				r.lastID = value
+			}
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				ev.Retry = n
+			}
+		}
+	}
+}
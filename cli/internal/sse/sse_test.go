@@ -0,0 +1,40 @@
+package sse
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReaderEvents(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"data: {\"n\":1}\n\n" +
+		"event: progress\r\nid: 7\r\ndata: first line\r\ndata:second line\r\nretry: 3000\r\n\r\n" +
+		"id\n\n" +
+		"data: after id reset\n\n" +
+		"event: ignored\n\n" +
+		"data: unterminated"
+
+	r := NewReader(strings.NewReader(stream))
+	var got []Event
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev)
+	}
+
+	want := []Event{
+		{Event: "message", Data: `{"n":1}`},
+		{ID: "7", Event: "progress", Data: "first line\nsecond line", Retry: 3000},
+		{Event: "message", Data: "after id reset"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %+v\nwant %+v", got, want)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kest-labs/kest/cli/internal/secret"
 	_ "modernc.org/sqlite"
 )
 
@@ -45,7 +46,9 @@ func NewStore() (*Store, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("sqlite", dbPath+"?_journal_mode=WAL&parseTime=true")
+	// busy_timeout lets long-running writers such as kest proxy share the
+	// database with other kest commands.
+	db, err := sql.Open("sqlite", dbPath+"?_journal_mode=WAL&parseTime=true&_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +69,31 @@ type Variable struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Cookie is a persisted cookie-jar entry, scoped to a project and
+// environment. Expires is zero for session cookies.
+type Cookie struct {
+	Name        string    `json:"name"`
+	Value       string    `json:"value"`
+	Domain      string    `json:"domain"`
+	Path        string    `json:"path"`
+	HostOnly    bool      `json:"host_only"`
+	Secure      bool      `json:"secure"`
+	HTTPOnly    bool      `json:"http_only"`
+	Expires     time.Time `json:"expires"`
+	Environment string    `json:"environment"`
+	Project     string    `json:"project"`
+}
+
+// AuthToken is a cached OAuth2 access token. Key identifies the grant
+// (token URL, client, scope, ...) it was issued for.
+type AuthToken struct {
+	Key          string    `json:"key"`
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
 type SyncOutboxItem struct {
 	ID                int64     `json:"id"`
 	SyncKind          string    `json:"sync_kind"`
@@ -107,6 +135,28 @@ func (s *Store) Init() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		PRIMARY KEY (name, environment, project)
 	);
+	CREATE TABLE IF NOT EXISTS cookies (
+		name TEXT NOT NULL,
+		value TEXT,
+		domain TEXT NOT NULL,
+		path TEXT NOT NULL,
+		host_only INTEGER NOT NULL DEFAULT 0,
+		secure INTEGER NOT NULL DEFAULT 0,
+		http_only INTEGER NOT NULL DEFAULT 0,
+		expires INTEGER NOT NULL DEFAULT 0,
+		environment VARCHAR(50),
+		project VARCHAR(100),
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (name, domain, path, environment, project)
+	);
+	CREATE TABLE IF NOT EXISTS auth_tokens (
+		key TEXT PRIMARY KEY,
+		access_token TEXT NOT NULL,
+		token_type TEXT,
+		refresh_token TEXT,
+		expires_at INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
 	CREATE TABLE IF NOT EXISTS sync_meta (
 		key TEXT PRIMARY KEY,
 		value TEXT NOT NULL,
@@ -267,16 +317,21 @@ func (s *Store) GetRecordsForSync(project string, includeAllProjects bool, since
 	return records, nil
 }
 
+// SaveRecord stores r after masking known secret values in place (see
+// MaskRecord), so callers that sync r afterwards never see them either.
 func (s *Store) SaveRecord(r *Record) (int64, error) {
+	MaskRecord(r)
 	query := `
 	INSERT INTO records (
 		method, url, base_url, path, query_params, request_headers, request_body,
-		response_status, response_headers, response_body, duration_ms, environment, project
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		response_status, response_headers, response_body, duration_ms, environment, project, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
+	// A zero CreatedAt means "now"; imported records keep their original time.
 	res, err := s.db.Exec(query,
 		r.Method, r.URL, r.BaseURL, r.Path, r.QueryParams, r.RequestHeaders, r.RequestBody,
 		r.ResponseStatus, r.ResponseHeaders, r.ResponseBody, r.DurationMs, r.Environment, r.Project,
+		sqliteTimestamp(r.CreatedAt),
 	)
 	if err != nil {
 		return 0, err
@@ -284,6 +339,18 @@ func (s *Store) SaveRecord(r *Record) (int64, error) {
 	return res.LastInsertId()
 }
 
+// MaskRecord replaces secret variable values in the URL, headers and bodies
+// of r.
+func MaskRecord(r *Record) {
+	r.URL = secret.Mask(r.URL)
+	r.Path = secret.Mask(r.Path)
+	r.QueryParams = secret.MaskBytes(r.QueryParams)
+	r.RequestHeaders = secret.MaskBytes(r.RequestHeaders)
+	r.RequestBody = secret.Mask(r.RequestBody)
+	r.ResponseHeaders = secret.MaskBytes(r.ResponseHeaders)
+	r.ResponseBody = secret.Mask(r.ResponseBody)
+}
+
 func (s *Store) GetHistory(limit int, project string) ([]Record, error) {
 	query := `
 	SELECT id, method, url, response_status, duration_ms, created_at
@@ -341,7 +408,17 @@ func (s *Store) GetLastRecord() (*Record, error) {
 	return s.GetRecord(id)
 }
 
+// SaveVariable stores a variable. Values of secret variables are encrypted
+// with the local key and registered for masking.
 func (s *Store) SaveVariable(v *Variable) error {
+	value := v.Value
+	if secret.Track(v.Name, value) {
+		encrypted, err := secret.Encrypt(value)
+		if err != nil {
+			return err
+		}
+		value = encrypted
+	}
 	query := `
 	INSERT INTO variables (name, value, environment, project, updated_at)
 	VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
@@ -349,7 +426,165 @@ func (s *Store) SaveVariable(v *Variable) error {
 		value = excluded.value,
 		updated_at = CURRENT_TIMESTAMP
 	`
-	_, err := s.db.Exec(query, v.Name, v.Value, v.Environment, v.Project)
+	_, err := s.db.Exec(query, v.Name, value, v.Environment, v.Project)
+	return err
+}
+
+// ReplaceCookies stores the full cookie jar for a project/environment,
+// replacing whatever was saved before. Values are encrypted at rest:
+// session cookies are as good as a password.
+func (s *Store) ReplaceCookies(project, environment string, cookies []Cookie) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM cookies WHERE project = ? AND environment = ?`, project, environment); err != nil {
+		return err
+	}
+	for _, c := range cookies {
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		value := c.Value
+		if value != "" {
+			encrypted, err := secret.Encrypt(value)
+			if err != nil {
+				return err
+			}
+			value = encrypted
+		}
+		_, err := tx.Exec(`
+		INSERT INTO cookies (name, value, domain, path, host_only, secure, http_only, expires, environment, project, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name, domain, path, environment, project) DO UPDATE SET
+			value = excluded.value,
+			host_only = excluded.host_only,
+			secure = excluded.secure,
+			http_only = excluded.http_only,
+			expires = excluded.expires,
+			updated_at = CURRENT_TIMESTAMP
+		`, c.Name, value, c.Domain, c.Path, c.HostOnly, c.Secure, c.HTTPOnly, expires, environment, project)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCookies returns the unexpired cookies saved for a project/environment.
+// Like variables, only cookies with a secret name (e.g. auth_token or a
+// --secret-var pattern) are registered for masking: masking theme=dark or
+// consent=true would rewrite every "dark" and true in recorded history.
+func (s *Store) GetCookies(project, environment string) ([]Cookie, error) {
+	rows, err := s.db.Query(`
+	SELECT name, value, domain, path, host_only, secure, http_only, expires FROM cookies
+	WHERE project = ? AND environment = ? AND (expires = 0 OR expires > ?)
+	ORDER BY domain, path, name
+	`, project, environment, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cookies []Cookie
+	for rows.Next() {
+		var c Cookie
+		var expires int64
+		if err := rows.Scan(&c.Name, &c.Value, &c.Domain, &c.Path, &c.HostOnly, &c.Secure, &c.HTTPOnly, &expires); err != nil {
+			return nil, err
+		}
+		if secret.IsEncrypted(c.Value) {
+			// Like variables: a cookie that no longer decrypts is dropped
+			// and set again by the server on the next login.
+			plain, err := secret.Decrypt(c.Value)
+			if err != nil {
+				continue
+			}
+			c.Value = plain
+		}
+		secret.Track(c.Name, c.Value)
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		c.Project = project
+		c.Environment = environment
+		cookies = append(cookies, c)
+	}
+	return cookies, rows.Err()
+}
+
+// DeleteCookies removes the saved cookies for a project/environment and
+// returns how many were deleted.
+func (s *Store) DeleteCookies(project, environment string) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM cookies WHERE project = ? AND environment = ?`, project, environment)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetAuthToken returns the cached token for key, or nil when there is none.
+func (s *Store) GetAuthToken(key string) (*AuthToken, error) {
+	t := AuthToken{Key: key}
+	var expires int64
+	err := s.db.QueryRow(`
+	SELECT access_token, COALESCE(token_type, ''), COALESCE(refresh_token, ''), expires_at
+	FROM auth_tokens WHERE key = ?
+	`, key).Scan(&t.AccessToken, &t.TokenType, &t.RefreshToken, &expires)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expires > 0 {
+		t.ExpiresAt = time.Unix(expires, 0)
+	}
+	if t.AccessToken, err = secret.Decrypt(t.AccessToken); err != nil {
+		return nil, nil
+	}
+	if t.RefreshToken, err = secret.Decrypt(t.RefreshToken); err != nil {
+		t.RefreshToken = ""
+	}
+	return &t, nil
+}
+
+// SaveAuthToken stores or replaces a cached token. Access and refresh
+// tokens are encrypted with the local key.
+func (s *Store) SaveAuthToken(t *AuthToken) error {
+	var expires int64
+	if !t.ExpiresAt.IsZero() {
+		expires = t.ExpiresAt.Unix()
+	}
+	accessToken, err := secret.Encrypt(t.AccessToken)
+	if err != nil {
+		return err
+	}
+	refreshToken := t.RefreshToken
+	if refreshToken != "" {
+		if refreshToken, err = secret.Encrypt(refreshToken); err != nil {
+			return err
+		}
+	}
+	_, err = s.db.Exec(`
+	INSERT INTO auth_tokens (key, access_token, token_type, refresh_token, expires_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT(key) DO UPDATE SET
+		access_token = excluded.access_token,
+		token_type = excluded.token_type,
+		refresh_token = excluded.refresh_token,
+		expires_at = excluded.expires_at,
+		updated_at = CURRENT_TIMESTAMP
+	`, t.Key, accessToken, t.TokenType, refreshToken, expires)
+	return err
+}
+
+// DeleteAuthToken removes a cached token.
+func (s *Store) DeleteAuthToken(key string) error {
+	_, err := s.db.Exec(`DELETE FROM auth_tokens WHERE key = ?`, key)
 	return err
 }
 
@@ -503,6 +738,18 @@ func (s *Store) GetVariables(project, environment string) (map[string]string, er
 		if err := rows.Scan(&name, &value); err != nil {
 			return nil, err
 		}
+		if secret.IsEncrypted(value) {
+			// A value that no longer decrypts (lost key) is dropped
+			// rather than sent as ciphertext; the next capture stores it
+			// again.
+			plain, err := secret.Decrypt(value)
+			if err != nil {
+				continue
+			}
+			secret.Add(plain)
+			value = plain
+		}
+		secret.Track(name, value)
 		vars[name] = value
 	}
 	return vars, nil
@@ -3,14 +3,31 @@ package summary
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/kest-labs/kest/cli/internal/secret"
 )
 
+// AssertionResult is the outcome of a single assertion. It matches the
+// platform's flow.AssertResult, plus the JSON path that was checked and a
+// diff when an object or array comparison fails.
+type AssertionResult struct {
+	Expression string `json:"expression"`
+	Passed     bool   `json:"passed"`
+	Expected   string `json:"expected,omitempty"`
+	Actual     string `json:"actual,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Diff       string `json:"diff,omitempty"`
+	Soft       bool   `json:"soft,omitempty"` // [Soft Asserts] never fail the step
+}
+
 type TestResult struct {
 	Name            string
 	StepID          string
@@ -27,41 +44,127 @@ type TestResult struct {
 	RequestID       string
 	Captures        map[string]string
 	FailedAssertion string
+	Assertions      []AssertionResult // every evaluated assertion, in order
 	Command         string
+	Group           string            // source file (or iteration) the result belongs to in suite runs
+	Params          map[string]string // data row values for data-driven iterations
+	Tags            []string          // flow and step @tags
 	Error           error
 	Success         bool
+	Skipped         bool   // step was not executed because its incoming @on conditions did not hold
+	SkipReason      string // why the step was skipped
+}
+
+// FailedAssertions returns the hard assertions that did not pass.
+func (r TestResult) FailedAssertions() []AssertionResult {
+	var out []AssertionResult
+	for _, a := range r.Assertions {
+		if !a.Passed && !a.Soft {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Masked returns a copy of r with secret variable values masked, for
+// anything that leaves the process: JSON output, reports and sync.
+func (r TestResult) Masked() TestResult {
+	r.URL = secret.Mask(r.URL)
+	r.RequestHeaders = secret.MaskMap(r.RequestHeaders)
+	r.RequestBody = secret.Mask(r.RequestBody)
+	r.ResponseHeaders = secret.MaskHeaders(r.ResponseHeaders)
+	r.ResponseBody = secret.Mask(r.ResponseBody)
+	r.Command = secret.Mask(r.Command)
+	r.FailedAssertion = secret.Mask(r.FailedAssertion)
+	r.Params = secret.MaskMap(r.Params)
+	if r.Captures != nil {
+		captures := make(map[string]string, len(r.Captures))
+		for name, value := range r.Captures {
+			if secret.IsSecret(name) {
+				value = secret.Masked
+			}
+			captures[name] = secret.Mask(value)
+		}
+		r.Captures = captures
+	}
+	if r.Assertions != nil {
+		assertions := make([]AssertionResult, len(r.Assertions))
+		for i, a := range r.Assertions {
+			a.Expression = secret.Mask(a.Expression)
+			a.Expected = secret.Mask(a.Expected)
+			a.Actual = secret.Mask(a.Actual)
+			a.Message = secret.Mask(a.Message)
+			a.Diff = secret.Mask(a.Diff)
+			assertions[i] = a
+		}
+		r.Assertions = assertions
+	}
+	if r.Error != nil {
+		if msg := secret.Mask(r.Error.Error()); msg != r.Error.Error() {
+			r.Error = errors.New(msg)
+		}
+	}
+	return r
+}
+
+// Masked returns a copy of s whose results are masked (see
+// TestResult.Masked).
+func (s *Summary) Masked() *Summary {
+	masked := *s
+	masked.Results = make([]TestResult, len(s.Results))
+	for i, result := range s.Results {
+		masked.Results[i] = result.Masked()
+	}
+	return &masked
 }
 
-func latencyStats(results []TestResult) (time.Duration, time.Duration) {
+// GroupStats aggregates the results that share a TestResult.Group.
+type GroupStats struct {
+	Name      string
+	Total     int
+	Passed    int
+	Failed    int
+	Skipped   int
+	TotalTime time.Duration
+}
+
+// LatencyStats returns the slowest and the p95 duration of results.
+func LatencyStats(results []TestResult) (time.Duration, time.Duration) {
 	if len(results) == 0 {
 		return 0, 0
 	}
 	values := make([]time.Duration, 0, len(results))
-	var slowest time.Duration
 	for _, r := range results {
 		values = append(values, r.Duration)
-		if r.Duration > slowest {
-			slowest = r.Duration
-		}
 	}
 	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
-	idx := int(float64(len(values)-1) * 0.95)
+	return values[len(values)-1], Percentile(values, 95)
+}
+
+// Percentile returns the p-th percentile (0-100) of durations sorted in
+// ascending order, rounding the rank down.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p / 100)
 	if idx < 0 {
 		idx = 0
 	}
-	if idx >= len(values) {
-		idx = len(values) - 1
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
 	}
-	return slowest, values[idx]
+	return sorted[idx]
 }
 
 type Summary struct {
-	Results     []TestResult
-	TotalTests  int
-	PassedTests int
-	FailedTests int
-	TotalTime   time.Duration
-	StartTime   time.Time
+	Results      []TestResult
+	TotalTests   int
+	PassedTests  int
+	FailedTests  int
+	SkippedTests int
+	TotalTime    time.Duration
+	StartTime    time.Time
 }
 
 func NewSummary() *Summary {
@@ -76,13 +179,81 @@ func (s *Summary) AddResult(result TestResult) {
 	s.TotalTests++
 	s.TotalTime += result.Duration
 
-	if result.Success {
+	switch {
+	case result.Skipped:
+		s.SkippedTests++
+	case result.Success:
 		s.PassedTests++
-	} else {
+	default:
 		s.FailedTests++
 	}
 }
 
+// Merge appends every result of other to s under the given group name.
+// Results that already belong to a group (e.g. a data iteration inside a
+// suite file) are nested as "group › existing".
+func (s *Summary) Merge(other *Summary, group string) {
+	if other == nil {
+		return
+	}
+	for _, result := range other.Results {
+		switch {
+		case result.Group == "":
+			result.Group = group
+		case group != "":
+			result.Group = group + " › " + result.Group
+		}
+		s.AddResult(result)
+	}
+	if !other.StartTime.IsZero() && other.StartTime.Before(s.StartTime) {
+		s.StartTime = other.StartTime
+	}
+}
+
+// Groups returns per-group statistics in first-seen order. Results without
+// a group are not included.
+func (s *Summary) Groups() []GroupStats {
+	var groups []GroupStats
+	index := make(map[string]int)
+	for _, result := range s.Results {
+		if result.Group == "" {
+			continue
+		}
+		i, ok := index[result.Group]
+		if !ok {
+			i = len(groups)
+			index[result.Group] = i
+			groups = append(groups, GroupStats{Name: result.Group})
+		}
+		groups[i].Total++
+		groups[i].TotalTime += result.Duration
+		switch {
+		case result.Skipped:
+			groups[i].Skipped++
+		case result.Success:
+			groups[i].Passed++
+		default:
+			groups[i].Failed++
+		}
+	}
+	return groups
+}
+
+// Tags returns the distinct tags of all results in first-seen order.
+func (s *Summary) Tags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, result := range s.Results {
+		for _, tag := range result.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
 func (s *Summary) Print() {
 	elapsed := time.Since(s.StartTime)
 
@@ -90,10 +261,18 @@ func (s *Summary) Print() {
 	fmt.Println("│                        TEST SUMMARY                                 │")
 	fmt.Println("├─────────────────────────────────────────────────────────────────────┤")
 
-	for _, result := range s.Results {
+	currentGroup := ""
+	for _, result := range s.Masked().Results {
+		if result.Group != "" && result.Group != currentGroup {
+			currentGroup = result.Group
+			fmt.Printf("│ \033[1m%-67s\033[0m │\n", truncate(currentGroup, 67))
+		}
 		status := "✓"
 		statusColor := "\033[32m" // Green
-		if !result.Success {
+		if result.Skipped {
+			status = "○"
+			statusColor = "\033[33m" // Yellow
+		} else if !result.Success {
 			status = "✗"
 			statusColor = "\033[31m" // Red
 		}
@@ -105,8 +284,24 @@ func (s *Summary) Print() {
 			truncate(result.URL, 30),
 			int(result.Duration.Milliseconds()))
 
+		if result.Skipped {
+			fmt.Printf("│     Skipped: %-54s │\n", truncate(result.SkipReason, 54))
+			continue
+		}
 		if result.Error != nil {
 			fmt.Printf("│     Error: %-56s │\n", truncate(result.Error.Error(), 56))
+			for _, a := range result.FailedAssertions() {
+				fmt.Printf("│     \033[31m✗\033[0m %-61s │\n", truncate(a.Expression, 61))
+				if a.Expected != "" || a.Actual != "" {
+					fmt.Printf("│       expected %-52s │\n", truncate(a.Expected, 52))
+					fmt.Printf("│       actual   %-52s │\n", truncate(a.Actual, 52))
+				}
+				for _, line := range strings.Split(a.Diff, "\n") {
+					if line != "" {
+						fmt.Printf("│       %-61s │\n", truncate(line, 61))
+					}
+				}
+			}
 			if result.ResponseBody != "" {
 				lines := strings.Split(prettyJSON(result.ResponseBody), "\n")
 				maxLines := 5
@@ -121,12 +316,27 @@ func (s *Summary) Print() {
 		}
 	}
 
+	if groups := s.Groups(); len(groups) > 1 {
+		fmt.Println("├─────────────────────────────────────────────────────────────────────┤")
+		for _, g := range groups {
+			mark := "\033[32m✓\033[0m"
+			if g.Failed > 0 {
+				mark = "\033[31m✗\033[0m"
+			}
+			fmt.Printf("│ %s %-48s %4d/%-4d %6dms │\n",
+				mark, truncate(g.Name, 48), g.Passed, g.Total, int(g.TotalTime.Milliseconds()))
+		}
+	}
+
 	fmt.Println("├─────────────────────────────────────────────────────────────────────┤")
 	fmt.Printf("│ Total: %d  │  Passed: \033[32m%d\033[0m  │  Failed: \033[31m%d\033[0m  │  Time: %v │\n",
 		s.TotalTests, s.PassedTests, s.FailedTests, s.TotalTime.Round(time.Millisecond))
+	if s.SkippedTests > 0 {
+		fmt.Printf("│ Skipped: \033[33m%-58d\033[0m │\n", s.SkippedTests)
+	}
 	fmt.Printf("│ Elapsed: %-58v │\n", elapsed.Round(time.Millisecond))
 	if len(s.Results) > 0 {
-		slowest, p95 := latencyStats(s.Results)
+		slowest, p95 := LatencyStats(s.Results)
 		fmt.Printf("│ Slowest: %-8v │ P95: %-8v │ Total: %-24v │\n",
 			slowest.Round(time.Millisecond),
 			p95.Round(time.Millisecond),
@@ -148,12 +358,23 @@ type RunJSON struct {
 	Total       int              `json:"total"`
 	Passed      int              `json:"passed"`
 	Failed      int              `json:"failed"`
+	Skipped     int              `json:"skipped"`
 	TotalMs     int64            `json:"total_ms"`
 	ElapsedMs   int64            `json:"elapsed_ms"`
 	GeneratedAt string           `json:"generated_at"`
+	Groups      []GroupJSON      `json:"groups,omitempty"`
 	Results     []TestResultJSON `json:"results"`
 }
 
+type GroupJSON struct {
+	Name    string `json:"name"`
+	Total   int    `json:"total"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Skipped int    `json:"skipped,omitempty"`
+	TotalMs int64  `json:"total_ms"`
+}
+
 type TestResultJSON struct {
 	Name            string            `json:"name"`
 	StepID          string            `json:"step_id,omitempty"`
@@ -161,14 +382,20 @@ type TestResultJSON struct {
 	URL             string            `json:"url,omitempty"`
 	Status          int               `json:"status,omitempty"`
 	Success         bool              `json:"success"`
+	Skipped         bool              `json:"skipped,omitempty"`
+	SkipReason      string            `json:"skip_reason,omitempty"`
 	DurationMs      int64             `json:"duration_ms"`
 	StartTime       string            `json:"start_time,omitempty"`
 	RequestID       string            `json:"request_id,omitempty"`
 	RecordID        int64             `json:"record_id,omitempty"`
 	Captures        map[string]string `json:"captures,omitempty"`
 	FailedAssertion string            `json:"failed_assertion,omitempty"`
+	Assertions      []AssertionResult `json:"assertions,omitempty"`
 	Error           string            `json:"error,omitempty"`
 	Command         string            `json:"command,omitempty"`
+	Group           string            `json:"group,omitempty"`
+	Params          map[string]string `json:"params,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
 }
 
 func (s *Summary) PrintJSON(sourcePath, logPath string) {
@@ -183,12 +410,23 @@ func (s *Summary) WriteJSON(w io.Writer, sourcePath, logPath string) error {
 		Total:       s.TotalTests,
 		Passed:      s.PassedTests,
 		Failed:      s.FailedTests,
+		Skipped:     s.SkippedTests,
 		TotalMs:     s.TotalTime.Milliseconds(),
 		ElapsedMs:   elapsed.Milliseconds(),
 		GeneratedAt: time.Now().Format(time.RFC3339),
 		Results:     make([]TestResultJSON, 0, len(s.Results)),
 	}
-	for _, result := range s.Results {
+	for _, g := range s.Groups() {
+		payload.Groups = append(payload.Groups, GroupJSON{
+			Name:    g.Name,
+			Total:   g.Total,
+			Passed:  g.Passed,
+			Failed:  g.Failed,
+			Skipped: g.Skipped,
+			TotalMs: g.TotalTime.Milliseconds(),
+		})
+	}
+	for _, result := range s.Masked().Results {
 		item := TestResultJSON{
 			Name:            result.Name,
 			StepID:          result.StepID,
@@ -196,12 +434,18 @@ func (s *Summary) WriteJSON(w io.Writer, sourcePath, logPath string) error {
 			URL:             result.URL,
 			Status:          result.Status,
 			Success:         result.Success,
+			Skipped:         result.Skipped,
+			SkipReason:      result.SkipReason,
 			DurationMs:      result.Duration.Milliseconds(),
 			RequestID:       result.RequestID,
 			RecordID:        result.RecordID,
 			Captures:        result.Captures,
 			FailedAssertion: result.FailedAssertion,
+			Assertions:      result.Assertions,
 			Command:         result.Command,
+			Group:           result.Group,
+			Params:          result.Params,
+			Tags:            result.Tags,
 		}
 		if !result.StartTime.IsZero() {
 			item.StartTime = result.StartTime.Format(time.RFC3339)
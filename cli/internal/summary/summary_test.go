@@ -49,3 +49,110 @@ func TestWriteJSONIncludesRunAndStepDetails(t *testing.T) {
 		t.Fatalf("missing captures: %#v", result.Captures)
 	}
 }
+
+func TestMergeGroupsResultsBySource(t *testing.T) {
+	login := NewSummary()
+	login.AddResult(TestResult{Name: "login", Success: true, Duration: 10 * time.Millisecond})
+	login.AddResult(TestResult{Name: "profile", Success: false, Duration: 5 * time.Millisecond})
+
+	health := NewSummary()
+	health.AddResult(TestResult{Name: "health", Success: true})
+
+	suite := NewSummary()
+	suite.Merge(login, "login.flow.md")
+	suite.Merge(health, "health.kest")
+
+	if suite.TotalTests != 3 || suite.PassedTests != 2 || suite.FailedTests != 1 {
+		t.Fatalf("unexpected counts: total=%d passed=%d failed=%d", suite.TotalTests, suite.PassedTests, suite.FailedTests)
+	}
+
+	groups := suite.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if groups[0].Name != "login.flow.md" || groups[0].Total != 2 || groups[0].Failed != 1 || groups[0].TotalTime != 15*time.Millisecond {
+		t.Fatalf("unexpected first group: %+v", groups[0])
+	}
+	if groups[1].Name != "health.kest" || groups[1].Passed != 1 {
+		t.Fatalf("unexpected second group: %+v", groups[1])
+	}
+
+	var buf bytes.Buffer
+	if err := suite.WriteJSON(&buf, "tests/", ""); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var payload RunJSON
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(payload.Groups) != 2 || payload.Results[2].Group != "health.kest" {
+		t.Fatalf("expected grouped JSON output, got %+v", payload)
+	}
+}
+
+func TestSkippedResultsAreCountedSeparately(t *testing.T) {
+	s := NewSummary()
+	s.AddResult(TestResult{Name: "login", Success: true})
+	s.AddResult(TestResult{Name: "recover", Skipped: true, SkipReason: "@on failure not met by login"})
+
+	if s.TotalTests != 2 || s.PassedTests != 1 || s.FailedTests != 0 || s.SkippedTests != 1 {
+		t.Fatalf("unexpected counts: total=%d passed=%d failed=%d skipped=%d", s.TotalTests, s.PassedTests, s.FailedTests, s.SkippedTests)
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteJSON(&buf, "login.flow.md", ""); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var payload RunJSON
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if payload.Skipped != 1 || !payload.Results[1].Skipped || payload.Results[1].SkipReason == "" {
+		t.Fatalf("expected skipped step in JSON output, got %+v", payload)
+	}
+}
+
+func TestMergeNestsExistingGroups(t *testing.T) {
+	file := NewSummary()
+	file.AddResult(TestResult{Name: "check", Success: true, Group: "row 1: role=admin", Params: map[string]string{"role": "admin"}})
+
+	suite := NewSummary()
+	suite.Merge(file, "users.flow.md")
+
+	if got := suite.Results[0].Group; got != "users.flow.md › row 1: role=admin" {
+		t.Fatalf("unexpected nested group %q", got)
+	}
+	if suite.Results[0].Params["role"] != "admin" {
+		t.Fatalf("expected params to survive merge, got %+v", suite.Results[0].Params)
+	}
+}
+
+func TestWriteJSONIncludesAssertionResults(t *testing.T) {
+	s := NewSummary()
+	s.AddResult(TestResult{
+		Name:   "profile",
+		Status: 200,
+		Assertions: []AssertionResult{
+			{Expression: "status == 200", Passed: true, Path: "status", Expected: "== 200", Actual: "200"},
+			{Expression: "body.role == admin", Path: "role", Expected: "== admin", Actual: "viewer", Message: "role mismatch"},
+			{Expression: "duration < 100", Path: "duration", Expected: "< 100", Actual: "250", Soft: true},
+		},
+	})
+
+	if failed := s.Results[0].FailedAssertions(); len(failed) != 1 || failed[0].Path != "role" {
+		t.Fatalf("expected only the hard failure, got %+v", failed)
+	}
+
+	var buf bytes.Buffer
+	if err := s.WriteJSON(&buf, "profile.flow.md", ""); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var payload RunJSON
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	got := payload.Results[0].Assertions
+	if len(got) != 3 || got[1].Actual != "viewer" || got[1].Passed || !got[2].Soft {
+		t.Fatalf("unexpected assertions: %+v", got)
+	}
+}
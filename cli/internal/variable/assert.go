@@ -1,57 +1,148 @@
 package variable
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/kest-labs/kest/cli/internal/summary"
 	"github.com/tidwall/gjson"
 )
 
-// Assert checks if the response body matches the assertion expression (e.g. status == 200, body.id != 1)
+// Response is the part of an HTTP response that assertions can inspect.
+type Response struct {
+	Status     int
+	Headers    map[string][]string
+	Body       []byte
+	DurationMs int64
+	BaseDir    string // relative schema @file paths resolve here (the flow's directory); empty means the working directory
+}
+
+// Assert checks if the response body matches the assertion expression (e.g. status == 200, body.id != 1).
+// It cannot see response headers; use AssertResponse for header assertions.
 func Assert(status int, body []byte, durationMs int64, vars map[string]string, assertion string) (bool, string) {
+	return AssertResponse(Response{Status: status, Body: body, DurationMs: durationMs}, vars, assertion)
+}
+
+// AssertResponse evaluates an assertion expression against a response.
+// Predicates can be combined with and, or, not and parentheses, e.g.
+// (status == 200 or status == 201) and header.Content-Type contains json.
+func AssertResponse(resp Response, vars map[string]string, assertion string) (bool, string) {
+	result := EvaluateAssertion(resp, vars, assertion)
+	return result.Passed, result.Message
+}
+
+// EvaluateAssertion evaluates an assertion expression and reports what was
+// expected and found. For a compound expression the details come from the
+// predicate that decided the outcome.
+func EvaluateAssertion(resp Response, vars map[string]string, assertion string) summary.AssertionResult {
+	var result summary.AssertionResult
+	if node, err := parseAssertion(strings.TrimSpace(assertion)); err != nil {
+		result = failed("", "", "", fmt.Sprintf("invalid assertion %q: %v", assertion, err))
+	} else {
+		result = node.eval(resp, vars)
+	}
+	result.Expression = assertion
+	return result
+}
+
+// EvaluateAssertions evaluates every assertion independently, so one
+// failure does not hide the others.
+func EvaluateAssertions(resp Response, vars map[string]string, assertions []string) []summary.AssertionResult {
+	results := make([]summary.AssertionResult, 0, len(assertions))
+	for _, assertion := range assertions {
+		results = append(results, EvaluateAssertion(resp, vars, assertion))
+	}
+	return results
+}
+
+func passed(path, expected, actual string) summary.AssertionResult {
+	return summary.AssertionResult{Passed: true, Path: path, Expected: expected, Actual: actual}
+}
+
+func failed(path, expected, actual, message string) summary.AssertionResult {
+	return summary.AssertionResult{Path: path, Expected: expected, Actual: actual, Message: message}
+}
+
+// assertPath names the value an assertion key reads: status, duration,
+// header.<Name> or the normalized JSON path into the body.
+func assertPath(key string) string {
+	switch {
+	case key == "status", key == "duration", strings.Contains(key, "{{"):
+		return key
+	}
+	if name, ok := headerKey(key); ok {
+		return "header." + http.CanonicalHeaderKey(name)
+	}
+	return bodyPathQuery(key)
+}
+
+// assertPredicate evaluates a single comparison such as status == 200.
+func assertPredicate(resp Response, vars map[string]string, assertion string) summary.AssertionResult {
+	status, body, durationMs := resp.Status, resp.Body, resp.DurationMs
+
+	if result, handled := assertExtended(resp, vars, assertion); handled {
+		return result
+	}
+
 	// 1. Handle "not exists" assertion (must come before "exists" check)
 	if strings.HasSuffix(assertion, " not exists") {
 		key := strings.TrimSpace(strings.TrimSuffix(assertion, " not exists"))
-		query := bodyPathQuery(key)
-		result := gjson.Get(string(body), query)
+		path := assertPath(key)
+		if name, ok := headerKey(key); ok {
+			value, found := resp.header(name)
+			if !found {
+				return passed(path, "not exists", "")
+			}
+			return failed(path, "not exists", value, fmt.Sprintf("expected header to not exist: %s", name))
+		}
+		result := gjson.Get(string(body), path)
 		if !result.Exists() {
-			return true, ""
+			return passed(path, "not exists", "")
 		}
-		return false, fmt.Sprintf("expected body path to not exist: %s", query)
+		return failed(path, "not exists", result.Raw, fmt.Sprintf("expected body path to not exist: %s", path))
 	}
 
 	// 2. Handle "exists" assertion
 	if strings.HasSuffix(assertion, " exists") {
 		key := strings.TrimSpace(strings.TrimSuffix(assertion, " exists"))
-		query := bodyPathQuery(key)
-		result := gjson.Get(string(body), query)
+		path := assertPath(key)
+		if name, ok := headerKey(key); ok {
+			if value, found := resp.header(name); found {
+				return passed(path, "exists", value)
+			}
+			return failed(path, "exists", "", fmt.Sprintf("header does not exist: %s", name))
+		}
+		result := gjson.Get(string(body), path)
 		if result.Exists() {
-			return true, ""
+			return passed(path, "exists", result.Raw)
 		}
-		return false, fmt.Sprintf("body path does not exist: %s", query)
+		return failed(path, "exists", "", fmt.Sprintf("body path does not exist: %s", path))
 	}
 
 	// 3. Handle "contains" operator: body.field contains "substring" or body.array contains "item"
 	if idx := strings.Index(assertion, " contains "); idx != -1 {
 		key := strings.TrimSpace(assertion[:idx])
+		path := assertPath(key)
 		expected := strings.Trim(strings.TrimSpace(assertion[idx+10:]), "\"'")
 		expected = Interpolate(expected, vars)
-		actual := resolveKey(key, status, durationMs, body)
+		actual := resp.resolve(key)
 		// Array contains: check if any element matches
 		if gjsonResult := gjson.Get(string(body), bodyPathQuery(key)); gjsonResult.IsArray() {
 			for _, item := range gjsonResult.Array() {
 				if item.String() == expected {
-					return true, ""
+					return passed(path, "contains "+expected, gjsonResult.Raw)
 				}
 			}
-			return false, fmt.Sprintf("%s does not contain %q (array check)", key, expected)
+			return failed(path, "contains "+expected, gjsonResult.Raw, fmt.Sprintf("%s does not contain %q (array check)", key, expected))
 		}
 		if strings.Contains(actual, expected) {
-			return true, ""
+			return passed(path, "contains "+expected, actual)
 		}
-		return false, fmt.Sprintf("%s does not contain %q\n  Actual: %s", key, expected, actual)
+		return failed(path, "contains "+expected, actual, fmt.Sprintf("%s does not contain %q\n  Actual: %s", key, expected, actual))
 	}
 
 	// 4. Handle "startsWith" operator
@@ -59,11 +150,11 @@ func Assert(status int, body []byte, durationMs int64, vars map[string]string, a
 		key := strings.TrimSpace(assertion[:idx])
 		expected := strings.Trim(strings.TrimSpace(assertion[idx+12:]), "\"'")
 		expected = Interpolate(expected, vars)
-		actual := resolveKey(key, status, durationMs, body)
+		actual := resp.resolve(key)
 		if strings.HasPrefix(actual, expected) {
-			return true, ""
+			return passed(assertPath(key), "startsWith "+expected, actual)
 		}
-		return false, fmt.Sprintf("%s does not start with %q\n  Actual: %s", key, expected, actual)
+		return failed(assertPath(key), "startsWith "+expected, actual, fmt.Sprintf("%s does not start with %q\n  Actual: %s", key, expected, actual))
 	}
 
 	// 5. Handle "endsWith" operator
@@ -71,11 +162,11 @@ func Assert(status int, body []byte, durationMs int64, vars map[string]string, a
 		key := strings.TrimSpace(assertion[:idx])
 		expected := strings.Trim(strings.TrimSpace(assertion[idx+10:]), "\"'")
 		expected = Interpolate(expected, vars)
-		actual := resolveKey(key, status, durationMs, body)
+		actual := resp.resolve(key)
 		if strings.HasSuffix(actual, expected) {
-			return true, ""
+			return passed(assertPath(key), "endsWith "+expected, actual)
 		}
-		return false, fmt.Sprintf("%s does not end with %q\n  Actual: %s", key, expected, actual)
+		return failed(assertPath(key), "endsWith "+expected, actual, fmt.Sprintf("%s does not end with %q\n  Actual: %s", key, expected, actual))
 	}
 
 	// 6. Handle "length" operator: body.items length == 3
@@ -87,13 +178,18 @@ func Assert(status int, body []byte, durationMs int64, vars map[string]string, a
 		var length int64
 		if result.IsArray() {
 			length = int64(len(result.Array()))
+		} else if _, isHeader := headerKey(key); isHeader {
+			length = int64(len(resp.resolve(key)))
 		} else {
 			length = int64(len(result.String()))
 		}
-		// Delegate numeric comparison on the length value
-		lengthAssertion := fmt.Sprintf("status %s", rest)
 		// Reuse numeric comparison by treating length as a synthetic "status"
-		return Assert(int(length), nil, 0, vars, lengthAssertion)
+		lengthResult := assertPredicate(Response{Status: int(length)}, vars, fmt.Sprintf("status %s", rest))
+		lengthResult.Path = assertPath(key) + " (length)"
+		if !lengthResult.Passed {
+			lengthResult.Message = strings.Replace(lengthResult.Message, "status mismatch", key+" length mismatch", 1)
+		}
+		return lengthResult
 	}
 
 	operators := []string{"==", "!=", ">=", "<=", ">", "<", "matches"}
@@ -127,11 +223,12 @@ func Assert(status int, body []byte, durationMs int64, vars map[string]string, a
 			op = "=="
 			parts = strings.SplitN(assertion, "=", 2)
 		} else {
-			return false, fmt.Sprintf("invalid assertion format: %s", assertion)
+			return failed("", "", "", fmt.Sprintf("invalid assertion format: %s", assertion))
 		}
 	}
 
 	key := strings.TrimSpace(parts[0])
+	path := assertPath(key)
 	expectedRaw := strings.TrimSpace(parts[1])
 	// Interpolate variables in expected value
 	expected := Interpolate(expectedRaw, vars)
@@ -139,75 +236,93 @@ func Assert(status int, body []byte, durationMs int64, vars map[string]string, a
 	expected = strings.Trim(expected, "\"'")
 
 	var actual string
-	if key == "status" {
+	var actualJSON gjson.Result
+	if strings.Contains(key, "{{") {
+		// A templated left-hand side compares the variable value itself,
+		// e.g. {{role}} == "admin".
+		actual = strings.Trim(Interpolate(key, vars), "\"'")
+	} else if name, ok := headerKey(key); ok {
+		value, found := resp.header(name)
+		if !found {
+			return failed(path, op+" "+expected, "", fmt.Sprintf("header not found: %s", name))
+		}
+		actual = value
+	} else if key == "status" {
 		actual = fmt.Sprintf("%d", status)
 	} else if key == "duration" {
 		// Strip "ms" from expected if present for duration
 		expected = strings.TrimSuffix(expected, "ms")
 		actual = fmt.Sprintf("%d", durationMs)
-	} else if strings.HasPrefix(key, "body.") {
-		query := normalizeJSONPath(key[5:])
-		result := gjson.Get(string(body), query)
-		if !result.Exists() {
-			return false, fmt.Sprintf("body path not found: %s", query)
+	} else if key == "body" {
+		// The whole body, so body == {...} diffs the entire response.
+		if !gjson.ValidBytes(body) {
+			actual = string(body)
+		} else {
+			actualJSON = gjson.ParseBytes(body)
+			actual = actualJSON.String()
 		}
-		actual = result.String()
 	} else {
-		// Treat any other key as a direct gjson path into the response body
-		key = normalizeJSONPath(key)
-		result := gjson.Get(string(body), key)
-		if !result.Exists() {
-			return false, fmt.Sprintf("body path not found: %s", key)
+		// body.<path>, or any other key as a direct gjson path into the body
+		actualJSON = gjson.Get(string(body), path)
+		if !actualJSON.Exists() {
+			return failed(path, op+" "+expected, "", fmt.Sprintf("body path not found: %s", path))
 		}
-		actual = result.String()
+		actual = actualJSON.String()
 	}
 
 	if exprValue, ok := evalNumericExpr(expected); ok {
 		expected = strconv.FormatFloat(exprValue, 'f', -1, 64)
 	}
 
+	// Objects and arrays compare structurally, so key order and whitespace
+	// do not matter, and a mismatch is reported as a diff.
+	if (op == "==" || op == "!=") && (actualJSON.IsObject() || actualJSON.IsArray()) {
+		var want, got any
+		if json.Unmarshal([]byte(expected), &want) == nil && json.Unmarshal([]byte(actualJSON.Raw), &got) == nil {
+			diff := JSONDiff(want, got)
+			if (len(diff) == 0) == (op == "==") {
+				return passed(path, op+" "+expected, actualJSON.Raw)
+			}
+			result := failed(path, op+" "+expected, actualJSON.Raw, fmt.Sprintf("%s mismatch\n  Expected: %s %s\n  Actual:   %s", key, op, expected, actualJSON.Raw))
+			if op == "==" {
+				result.Diff = strings.Join(diff, "\n")
+			}
+			return result
+		}
+	}
+
+	ok := false
 	switch op {
 	case "==":
-		if actual == expected {
-			return true, ""
-		}
+		ok = actual == expected
 	case "!=":
-		if actual != expected {
-			return true, ""
-		}
+		ok = actual != expected
 	case "matches":
 		matched, err := regexp.MatchString(expected, actual)
 		if err != nil {
-			return false, fmt.Sprintf("invalid regex: %s", expected)
-		}
-		if matched {
-			return true, ""
+			return failed(path, "matches "+expected, actual, fmt.Sprintf("invalid regex: %s", expected))
 		}
+		ok = matched
 	case ">", ">=", "<", "<=":
 		// Numeric comparison
-		actualInt, err1 := strconv.ParseFloat(actual, 64)
-		expectedInt, err2 := strconv.ParseFloat(expected, 64)
+		actualNum, err1 := strconv.ParseFloat(actual, 64)
+		expectedNum, err2 := strconv.ParseFloat(expected, 64)
 		if err1 == nil && err2 == nil {
 			switch op {
 			case ">":
-				if actualInt > expectedInt {
-					return true, ""
-				}
+				ok = actualNum > expectedNum
 			case ">=":
-				if actualInt >= expectedInt {
-					return true, ""
-				}
+				ok = actualNum >= expectedNum
 			case "<":
-				if actualInt < expectedInt {
-					return true, ""
-				}
+				ok = actualNum < expectedNum
 			case "<=":
-				if actualInt <= expectedInt {
-					return true, ""
-				}
+				ok = actualNum <= expectedNum
 			}
 		}
 	}
+	if ok {
+		return passed(path, op+" "+expected, actual)
+	}
 
 	// Build detailed error message
 	errorMsg := fmt.Sprintf(
@@ -216,7 +331,7 @@ func Assert(status int, body []byte, durationMs int64, vars map[string]string, a
 			"  Actual:   %s",
 		key, op, expected, actual,
 	)
-	return false, errorMsg
+	return failed(path, op+" "+expected, actual, errorMsg)
 }
 
 var indexSyntaxPattern = regexp.MustCompile(`\[(\d+)\]`)
@@ -230,6 +345,36 @@ func bodyPathQuery(key string) string {
 	return normalizeJSONPath(key)
 }
 
+// headerKey reports whether key addresses a response header
+// (header.Content-Type) and returns the header name.
+func headerKey(key string) (string, bool) {
+	if name, ok := strings.CutPrefix(key, "header."); ok && name != "" {
+		return name, true
+	}
+	if name, ok := strings.CutPrefix(key, "headers."); ok && name != "" {
+		return name, true
+	}
+	return "", false
+}
+
+// header returns the values of a response header joined with ", ".
+func (r Response) header(name string) (string, bool) {
+	values := http.Header(r.Headers).Values(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return strings.Join(values, ", "), true
+}
+
+// resolve returns the actual value for an assertion key, including headers.
+func (r Response) resolve(key string) string {
+	if name, ok := headerKey(key); ok {
+		value, _ := r.header(name)
+		return value
+	}
+	return resolveKey(key, r.Status, r.DurationMs, r.Body)
+}
+
 // resolveKey extracts the actual string value for a given assertion key.
 func resolveKey(key string, status int, durationMs int64, body []byte) string {
 	switch key {
@@ -0,0 +1,358 @@
+package variable
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+// Assertion expressions combine single predicates with boolean logic:
+//
+//	expr := or
+//	or   := and { ("or" | "||") and }
+//	and  := not { ("and" | "&&") not }
+//	not  := ("not" | "!") not | "(" expr ")" | predicate
+//
+// A predicate is everything up to the next logical keyword or closing
+// parenthesis, e.g. `status == 200` or `body.tags contains "go"`. Quoted
+// strings, [lists], {objects} and {{variables}} are kept intact, so their
+// contents never split a predicate. The words and/or only combine predicates
+// when a predicate follows them, so status == 200 and body.genre == rock and
+// roll still reads as two predicates.
+
+type assertNode interface {
+	eval(resp Response, vars map[string]string) summary.AssertionResult
+}
+
+type predicateNode struct{ text string }
+
+func (n predicateNode) eval(resp Response, vars map[string]string) summary.AssertionResult {
+	return assertPredicate(resp, vars, n.text)
+}
+
+type notNode struct {
+	operand assertNode
+	text    string
+}
+
+func (n notNode) eval(resp Response, vars map[string]string) summary.AssertionResult {
+	inner := n.operand.eval(resp, vars)
+	if inner.Passed {
+		return failed(inner.Path, "not "+inner.Expected, inner.Actual, fmt.Sprintf("expected not (%s), but it holds", n.text))
+	}
+	return passed(inner.Path, "not "+inner.Expected, inner.Actual)
+}
+
+type andNode struct{ operands []assertNode }
+
+func (n andNode) eval(resp Response, vars map[string]string) summary.AssertionResult {
+	var last summary.AssertionResult
+	for _, operand := range n.operands {
+		last = operand.eval(resp, vars)
+		if !last.Passed {
+			return last
+		}
+	}
+	return passed("", "", "")
+}
+
+type orNode struct{ operands []assertNode }
+
+func (n orNode) eval(resp Response, vars map[string]string) summary.AssertionResult {
+	var reasons []string
+	for _, operand := range n.operands {
+		result := operand.eval(resp, vars)
+		if result.Passed {
+			return result
+		}
+		reasons = append(reasons, strings.ReplaceAll(result.Message, "\n", "\n    "))
+	}
+	return failed("", "", "", "no alternative holds:\n  - "+strings.Join(reasons, "\n  - "))
+}
+
+// parseAssertion compiles an assertion expression. An assertion without
+// logical structure is a single predicate taken verbatim, so values such as
+// rock and roll, (draft) or "a  b" keep working unquoted.
+func parseAssertion(assertion string) (assertNode, error) {
+	p := &assertParser{input: assertion, tokens: lexAssertion(assertion)}
+	if p.singlePredicate() {
+		return predicateNode{text: assertion}, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return node, nil
+}
+
+// assertToken is a word of an assertion and where it sits in the input, so
+// predicates are cut from the original text rather than re-joined.
+type assertToken struct {
+	text       string
+	start, end int
+}
+
+type assertParser struct {
+	input  string
+	tokens []assertToken
+	pos    int
+}
+
+func (p *assertParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+func isLogicalKeyword(tok string) bool {
+	return tok == "and" || tok == "or" || tok == "&&" || tok == "||"
+}
+
+// singlePredicate reports whether the assertion neither starts with not or a
+// group nor has a logical keyword that begins another predicate.
+func (p *assertParser) singlePredicate() bool {
+	if len(p.tokens) == 0 {
+		return false
+	}
+	switch p.tokens[0].text {
+	case "not", "!", "(":
+		return false
+	}
+	for i, tok := range p.tokens {
+		if isLogicalKeyword(tok.text) && p.startsPredicate(i+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// predicateWords are the operators written as words; any of them after the
+// first word of a segment makes it a predicate.
+var predicateWords = map[string]bool{
+	"matches": true, "contains": true, "startsWith": true, "endsWith": true, "length": true,
+	"exists": true, "is": true, "in": true, "not": true, "schema": true,
+}
+
+// startsPredicate reports whether the tokens from i on begin a predicate
+// (or a negation or group), i.e. whether a logical keyword before them
+// combines two predicates rather than being part of a value.
+func (p *assertParser) startsPredicate(i int) bool {
+	if i >= len(p.tokens) {
+		return false
+	}
+	switch p.tokens[i].text {
+	case "not", "!", "(", "schema":
+		return true
+	}
+	for k := i; k < len(p.tokens); k++ {
+		tok := p.tokens[k].text
+		if isLogicalKeyword(tok) || tok == "(" || tok == ")" {
+			break
+		}
+		if k == i {
+			// status==200 written without spaces
+			if strings.ContainsAny(tok, "=<>") {
+				return true
+			}
+			continue
+		}
+		if predicateWords[tok] || strings.ContainsAny(tok[:1], "=<>!") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *assertParser) parseOr() (assertNode, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []assertNode{first}
+	for tok := p.peek(); tok == "or" || tok == "||"; tok = p.peek() {
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return orNode{operands: operands}, nil
+}
+
+func (p *assertParser) parseAnd() (assertNode, error) {
+	first, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	operands := []assertNode{first}
+	for tok := p.peek(); tok == "and" || tok == "&&"; tok = p.peek() {
+		p.pos++
+		next, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return andNode{operands: operands}, nil
+}
+
+func (p *assertParser) parseNot() (assertNode, error) {
+	switch p.peek() {
+	case "not", "!":
+		p.pos++
+		start := p.pos
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand, text: p.text(start, p.pos)}, nil
+	case "(":
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	}
+
+	// The predicate runs to the next logical keyword that starts another
+	// predicate, or to the parenthesis closing its group. Parentheses inside
+	// the value, as in label == (draft), belong to it.
+	start, open := p.pos, 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		tok := p.tokens[p.pos].text
+		if isLogicalKeyword(tok) && (p.pos == start || p.startsPredicate(p.pos+1)) {
+			break
+		}
+		if tok == "(" {
+			open++
+		}
+		if tok == ")" {
+			if open == 0 {
+				break
+			}
+			open--
+		}
+	}
+	if p.pos == start {
+		if tok := p.peek(); tok != "" {
+			return nil, fmt.Errorf("unexpected %q", tok)
+		}
+		return nil, fmt.Errorf("incomplete assertion")
+	}
+	return predicateNode{text: p.text(start, p.pos)}, nil
+}
+
+// text is the input spanned by tokens[from:to].
+func (p *assertParser) text(from, to int) string {
+	if from >= to {
+		return ""
+	}
+	return p.input[p.tokens[from].start:p.tokens[to-1].end]
+}
+
+// lexAssertion splits an assertion into words. Grouping parentheses become
+// their own tokens; parentheses inside a word (gjson queries such as
+// items.#(id==1).name) stay part of it.
+func lexAssertion(input string) []assertToken {
+	var tokens []assertToken
+	i := 0
+	for i < len(input) {
+		if input[i] == ' ' || input[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		depth := 0
+		for i < len(input) {
+			c := input[i]
+			if depth == 0 && (c == ' ' || c == '\t') {
+				break
+			}
+			switch c {
+			case '"', '\'', '`':
+				// An unmatched quote (e.g. contains don't) is literal text
+				// running to the end of the assertion.
+				end := strings.IndexByte(input[i+1:], c)
+				if end == -1 {
+					end = len(input) - i - 2
+				}
+				i += end + 1
+			case '[', '{':
+				depth++
+			case ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+			i++
+		}
+		tokens = append(tokens, splitGroupParens(input[start:i], start)...)
+	}
+	return tokens
+}
+
+// splitGroupParens peels leading "(" and unbalanced trailing ")" off the
+// word found at offset.
+func splitGroupParens(word string, offset int) []assertToken {
+	var out []assertToken
+	for strings.HasPrefix(word, "(") {
+		out = append(out, assertToken{"(", offset, offset + 1})
+		word = word[1:]
+		offset++
+	}
+
+	balance := parenBalance(word)
+	closing := 0
+	for balance < 0 && strings.HasSuffix(word, ")") {
+		word = word[:len(word)-1]
+		balance++
+		closing++
+	}
+	end := offset + len(word)
+	if word != "" {
+		out = append(out, assertToken{word, offset, end})
+	}
+	for k := 0; k < closing; k++ {
+		out = append(out, assertToken{")", end + k, end + k + 1})
+	}
+	return out
+}
+
+// parenBalance counts "(" minus ")" outside quoted strings.
+func parenBalance(word string) int {
+	balance := 0
+	var quote byte
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(':
+			balance++
+		case c == ')':
+			balance--
+		}
+	}
+	return balance
+}
@@ -0,0 +1,344 @@
+package variable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	typeAssertPattern   = regexp.MustCompile(`^(\S+)\s+is\s+(not\s+)?([A-Za-z]+)$`)
+	inAssertPattern     = regexp.MustCompile(`^(\S+)\s+(not\s+)?in\s+(\[.*\])$`)
+	schemaAssertPattern = regexp.MustCompile(`^(?:(\S+)\s+)?schema\s+(.+)$`)
+)
+
+// assertExtended handles the predicates that take a type, a list or a
+// schema rather than a single value:
+//
+//	body.id is number          body.deleted_at is not null
+//	status in [200, 201]       body.role not in ["root", "admin"]
+//	schema @schemas/user.json  body.items schema {"type": "array"}
+//
+// handled is false when the assertion is none of these.
+func assertExtended(resp Response, vars map[string]string, assertion string) (result summary.AssertionResult, handled bool) {
+	if m := schemaAssertPattern.FindStringSubmatch(assertion); m != nil {
+		return assertSchema(resp, m[1], Interpolate(m[2], vars)), true
+	}
+
+	if m := typeAssertPattern.FindStringSubmatch(assertion); m != nil {
+		want := strings.ToLower(m[3])
+		if _, known := jsonTypeNames[want]; known {
+			return assertType(resp, vars, m[1], want, m[2] != ""), true
+		}
+	}
+
+	if m := inAssertPattern.FindStringSubmatch(assertion); m != nil {
+		return assertIn(resp, vars, m[1], Interpolate(m[3], vars), m[2] != ""), true
+	}
+
+	return summary.AssertionResult{}, false
+}
+
+var jsonTypeNames = map[string]string{
+	"number":  "number",
+	"integer": "integer",
+	"int":     "integer",
+	"string":  "string",
+	"boolean": "boolean",
+	"bool":    "boolean",
+	"object":  "object",
+	"array":   "array",
+	"null":    "null",
+}
+
+// typedValue resolves key to a JSON value so its type can be inspected.
+// "body" addresses the whole body; status and duration are numbers and
+// headers are strings.
+func (r Response) typedValue(key string, vars map[string]string) (gjson.Result, bool) {
+	switch {
+	case strings.Contains(key, "{{"):
+		value := Interpolate(key, vars)
+		if gjson.Valid(value) {
+			return gjson.Parse(value), true
+		}
+		return gjson.Result{Type: gjson.String, Str: value}, true
+	case key == "status":
+		return gjson.Result{Type: gjson.Number, Num: float64(r.Status), Raw: strconv.Itoa(r.Status)}, true
+	case key == "duration":
+		return gjson.Result{Type: gjson.Number, Num: float64(r.DurationMs), Raw: strconv.FormatInt(r.DurationMs, 10)}, true
+	case key == "body":
+		if !gjson.ValidBytes(r.Body) {
+			return gjson.Result{Type: gjson.String, Str: string(r.Body)}, len(r.Body) > 0
+		}
+		return gjson.ParseBytes(r.Body), true
+	}
+	if name, ok := headerKey(key); ok {
+		value, found := r.header(name)
+		return gjson.Result{Type: gjson.String, Str: value}, found
+	}
+	result := gjson.GetBytes(r.Body, bodyPathQuery(key))
+	return result, result.Exists()
+}
+
+// jsonTypeOf names the JSON type of a value.
+func jsonTypeOf(v gjson.Result) string {
+	switch v.Type {
+	case gjson.Null:
+		return "null"
+	case gjson.False, gjson.True:
+		return "boolean"
+	case gjson.Number:
+		return "number"
+	case gjson.String:
+		return "string"
+	}
+	if v.IsArray() {
+		return "array"
+	}
+	return "object"
+}
+
+func assertType(resp Response, vars map[string]string, key, want string, negate bool) summary.AssertionResult {
+	path := assertPath(key)
+	if key == "body" {
+		path = "body"
+	}
+	want = jsonTypeNames[want]
+	expected := "is " + want
+	if negate {
+		expected = "is not " + want
+	}
+
+	value, found := resp.typedValue(key, vars)
+	if !found {
+		return failed(path, expected, "", fmt.Sprintf("%s not found", key))
+	}
+
+	actual := jsonTypeOf(value)
+	matches := actual == want
+	if want == "integer" && actual == "number" {
+		matches = value.Num == float64(int64(value.Num))
+		if !matches {
+			actual = "number (not an integer)"
+		}
+	}
+
+	if matches != negate {
+		return passed(path, expected, actual)
+	}
+	if negate {
+		return failed(path, expected, actual, fmt.Sprintf("%s is %s\n  Expected: not %s", key, actual, want))
+	}
+	return failed(path, expected, actual, fmt.Sprintf("%s type mismatch\n  Expected: %s\n  Actual:   %s (%s)", key, want, actual, value.Raw))
+}
+
+func assertIn(resp Response, vars map[string]string, key, list string, negate bool) summary.AssertionResult {
+	options := parseAssertList(list)
+
+	var actual string
+	if strings.Contains(key, "{{") {
+		actual = strings.Trim(Interpolate(key, vars), "\"'")
+	} else {
+		actual = resp.resolve(key)
+	}
+
+	member := false
+	for _, option := range options {
+		if option == actual {
+			member = true
+			break
+		}
+	}
+	expected := "in " + list
+	if negate {
+		expected = "not in " + list
+	}
+	path := assertPath(key)
+	if member != negate {
+		return passed(path, expected, actual)
+	}
+	if negate {
+		return failed(path, expected, actual, fmt.Sprintf("%s must not be one of %s\n  Actual: %s", key, list, actual))
+	}
+	return failed(path, expected, actual, fmt.Sprintf("%s is not one of %s\n  Actual: %s", key, list, actual))
+}
+
+// parseAssertList reads [a, b, c]. A valid JSON array is decoded as such;
+// otherwise items are split on commas and stripped of quotes.
+func parseAssertList(list string) []string {
+	var decoded []json.RawMessage
+	if err := json.Unmarshal([]byte(list), &decoded); err == nil {
+		out := make([]string, 0, len(decoded))
+		for _, raw := range decoded {
+			out = append(out, gjson.ParseBytes(raw).String())
+		}
+		return out
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(list), "["), "]")
+	var out []string
+	for _, item := range strings.Split(inner, ",") {
+		item = strings.Trim(strings.TrimSpace(item), "\"'")
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// assertSchema validates the body (or the value at key) against a JSON
+// Schema given inline or as a file path (optionally prefixed with @).
+func assertSchema(resp Response, key, source string) summary.AssertionResult {
+	target, path := "body", "body"
+	raw := resp.Body
+	if key != "" && key != "body" {
+		target, path = key, bodyPathQuery(key)
+	}
+	expected := "matches schema " + source
+
+	schema, err := loadAssertSchema(source, resp.BaseDir)
+	if err != nil {
+		return failed(path, expected, "", err.Error())
+	}
+	if path != "body" {
+		result := gjson.GetBytes(resp.Body, path)
+		if !result.Exists() {
+			return failed(path, expected, "", fmt.Sprintf("body path not found: %s", path))
+		}
+		raw = []byte(result.Raw)
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return failed(path, expected, string(raw), fmt.Sprintf("%s is not valid JSON: %v", target, err))
+	}
+	if err := schema.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+		violations := schemaErrorLines(err)
+		return failed(path, expected, strings.Join(violations, "; "), fmt.Sprintf("%s does not match schema:\n  %s", target, strings.Join(violations, "\n  ")))
+	}
+	return passed(path, expected, "valid")
+}
+
+func loadAssertSchema(source, baseDir string) (*openapi3.Schema, error) {
+	source = strings.TrimSpace(source)
+	var data []byte
+	if strings.HasPrefix(source, "{") {
+		data = []byte(source)
+	} else {
+		path := strings.Trim(strings.TrimPrefix(source, "@"), "\"'")
+		if baseDir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read schema: %v", err)
+		}
+		data = content
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	resolved, err := inlineSchemaRefs(doc, doc, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, _ = json.Marshal(resolved)
+
+	schema := &openapi3.Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %v", err)
+	}
+	return schema, nil
+}
+
+// inlineSchemaRefs replaces local references ("$ref": "#/$defs/user") with
+// the definitions they point to; the validator does not resolve them itself.
+func inlineSchemaRefs(node, root any, depth int) (any, error) {
+	if depth > 32 {
+		return nil, fmt.Errorf("schema $ref nesting too deep (recursive schemas are not supported)")
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			target, err := lookupSchemaRef(root, ref)
+			if err != nil {
+				return nil, err
+			}
+			return inlineSchemaRefs(target, root, depth+1)
+		}
+		out := make(map[string]any, len(n))
+		for k, v := range n {
+			if k == "$defs" || k == "definitions" || k == "$schema" || k == "$id" {
+				continue
+			}
+			resolved, err := inlineSchemaRefs(v, root, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(n))
+		for i, v := range n {
+			resolved, err := inlineSchemaRefs(v, root, depth)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	}
+	return node, nil
+}
+
+func lookupSchemaRef(root any, ref string) (any, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported schema $ref %q: only local references (#/...) are supported", ref)
+	}
+	current := root
+	for _, part := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("schema $ref %q not found", ref)
+		}
+		if current, ok = obj[part]; !ok {
+			return nil, fmt.Errorf("schema $ref %q not found", ref)
+		}
+	}
+	return current, nil
+}
+
+// schemaErrorLines flattens validation errors into "path: reason" lines.
+func schemaErrorLines(err error) []string {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		var lines []string
+		for _, e := range multi {
+			lines = append(lines, schemaErrorLines(e)...)
+		}
+		return lines
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		path := "/" + strings.Join(schemaErr.JSONPointer(), "/")
+		return []string{fmt.Sprintf("%s: %s", path, schemaErr.Reason)}
+	}
+	return []string{err.Error()}
+}
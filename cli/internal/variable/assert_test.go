@@ -1,6 +1,10 @@
 package variable
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -166,6 +170,18 @@ func TestAssertDuration(t *testing.T) {
 	}
 }
 
+func TestAssertTemplatedKey(t *testing.T) {
+	vars := map[string]string{"role": "admin"}
+	ok, msg := Assert(200, body(`{}`), 10, vars, `{{role}} == "admin"`)
+	if !ok {
+		t.Errorf("expected pass, got: %s", msg)
+	}
+	ok, _ = Assert(200, body(`{}`), 10, vars, `{{role}} == "viewer"`)
+	if ok {
+		t.Error("expected fail for different role")
+	}
+}
+
 // ── bodyPathQuery helper ──────────────────────────────────────────────────────
 
 func TestBodyPathQuery(t *testing.T) {
@@ -205,3 +221,332 @@ func TestResolveKeyBody(t *testing.T) {
 		t.Errorf("expected 'kest', got %q", got)
 	}
 }
+
+// ── boolean logic ────────────────────────────────────────────────────────────
+
+func TestAssertBooleanLogic(t *testing.T) {
+	resp := Response{Status: 201, Body: body(`{"id":7,"role":"admin","tags":["a"]}`)}
+	cases := []struct {
+		assertion string
+		want      bool
+	}{
+		{"status == 200 or status == 201", true},
+		{"status == 200 || status == 204", false},
+		{"status == 201 and body.id == 7", true},
+		{"status == 201 && body.id == 8", false},
+		{"not body.error exists", true},
+		{"not status == 201", false},
+		{"(status == 200 or status == 201) and body.role == admin", true},
+		{"status == 201 and (body.id == 1 or body.id == 2)", false},
+		{"not (body.id == 1 or body.id == 2)", true},
+		{`body.role == "a and b" or body.role == admin`, true},
+		{"body.error not exists and body.tags.#(==\"a\") exists", true},
+	}
+	for _, c := range cases {
+		ok, msg := AssertResponse(resp, nil, c.assertion)
+		if ok != c.want {
+			t.Errorf("%s: got %v (%s), want %v", c.assertion, ok, msg, c.want)
+		}
+	}
+}
+
+// Values that look like expression syntax keep working unquoted, as they
+// did before assertions could be combined.
+func TestAssertValuesWithLogicWordsAndParens(t *testing.T) {
+	resp := Response{Status: 200, Body: body(`{"genre":"rock and roll","label":"(draft)","name":"a  b","mood":"this or that"}`)}
+	cases := []struct {
+		assertion string
+		want      bool
+	}{
+		{"body.genre == rock and roll", true},
+		{"body.mood == this or that", true},
+		{"body.label == (draft)", true},
+		{"body.name == a  b", true},
+		{"body.name == a b", false},
+		{"status == 200 and body.genre == rock and roll", true},
+		{"status == 200 and body.label == (draft)", true},
+		{"(status == 201 or body.label == (draft)) and body.name == a  b", true},
+		{"body.genre == rock and status == 404", false},
+	}
+	for _, c := range cases {
+		ok, msg := AssertResponse(resp, nil, c.assertion)
+		if ok != c.want {
+			t.Errorf("%s: got %v (%s), want %v", c.assertion, ok, msg, c.want)
+		}
+	}
+}
+
+func TestAssertInvalidExpression(t *testing.T) {
+	for _, assertion := range []string{"(status == 200", "status == 200 and", "status == 200)"} {
+		if ok, msg := Assert(200, body(`{}`), 0, nil, assertion); ok || msg == "" {
+			t.Errorf("%s: expected an error, got %v %q", assertion, ok, msg)
+		}
+	}
+}
+
+// ── types and membership ─────────────────────────────────────────────────────
+
+func TestAssertTypes(t *testing.T) {
+	resp := Response{
+		Status:  200,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body(`{"id":7,"price":9.5,"name":"kest","ok":true,"items":[],"meta":{},"deleted_at":null}`),
+	}
+	cases := []struct {
+		assertion string
+		want      bool
+	}{
+		{"body.id is number", true},
+		{"body.id is integer", true},
+		{"body.price is integer", false},
+		{"body.name is string", true},
+		{"body.ok is boolean", true},
+		{"body.items is array", true},
+		{"body.meta is object", true},
+		{"body.deleted_at is null", true},
+		{"body.name is not null", true},
+		{"body.id is string", false},
+		{"body is object", true},
+		{"status is number", true},
+		{"header.Content-Type is string", true},
+		{"body.missing is string", false},
+	}
+	for _, c := range cases {
+		ok, msg := AssertResponse(resp, nil, c.assertion)
+		if ok != c.want {
+			t.Errorf("%s: got %v (%s), want %v", c.assertion, ok, msg, c.want)
+		}
+	}
+}
+
+func TestAssertIn(t *testing.T) {
+	resp := Response{Status: 201, Body: body(`{"role":"editor","level":2}`)}
+	vars := map[string]string{"allowed": "editor"}
+	cases := []struct {
+		assertion string
+		want      bool
+	}{
+		{"status in [200, 201]", true},
+		{"status in [200, 204]", false},
+		{`body.role in ["admin", "editor"]`, true},
+		{"body.role in [admin, viewer]", false},
+		{`body.role not in ["root"]`, true},
+		{"body.level in [1, 2, 3]", true},
+		{"body.role in [{{allowed}}]", true},
+	}
+	for _, c := range cases {
+		ok, msg := AssertResponse(resp, vars, c.assertion)
+		if ok != c.want {
+			t.Errorf("%s: got %v (%s), want %v", c.assertion, ok, msg, c.want)
+		}
+	}
+}
+
+// ── headers ──────────────────────────────────────────────────────────────────
+
+func TestAssertHeaders(t *testing.T) {
+	resp := Response{
+		Status: 200,
+		Headers: map[string][]string{
+			"Content-Type": {"application/json; charset=utf-8"},
+			"X-Request-Id": {"req-1"},
+		},
+		Body: body(`{}`),
+	}
+	cases := []struct {
+		assertion string
+		want      bool
+	}{
+		{"header.Content-Type contains json", true},
+		{"header.content-type startsWith application/", true},
+		{`header.X-Request-Id == "req-1"`, true},
+		{"header.X-Request-Id exists", true},
+		{"header.Set-Cookie not exists", true},
+		{"header.Set-Cookie exists", false},
+		{"header.X-Missing == 1", false},
+		{"header.X-Request-Id matches ^req-", true},
+	}
+	for _, c := range cases {
+		ok, msg := AssertResponse(resp, nil, c.assertion)
+		if ok != c.want {
+			t.Errorf("%s: got %v (%s), want %v", c.assertion, ok, msg, c.want)
+		}
+	}
+}
+
+// ── JSON Schema ──────────────────────────────────────────────────────────────
+
+func TestAssertSchemaInline(t *testing.T) {
+	resp := Response{Status: 200, Body: body(`{"id":7,"email":"a@b.co","tags":["x"]}`)}
+	schema := `{"type":"object","required":["id","email"],"properties":{"id":{"type":"integer"},"email":{"type":"string"}}}`
+
+	if ok, msg := AssertResponse(resp, nil, "schema "+schema); !ok {
+		t.Fatalf("expected pass, got: %s", msg)
+	}
+	if ok, msg := AssertResponse(resp, nil, `body.tags schema {"type":"array","items":{"type":"string"}}`); !ok {
+		t.Fatalf("expected sub-path pass, got: %s", msg)
+	}
+
+	bad := `{"type":"object","required":["name"],"properties":{"id":{"type":"string"}}}`
+	ok, msg := AssertResponse(resp, nil, "schema "+bad)
+	if ok {
+		t.Fatal("expected schema failure")
+	}
+	if !strings.Contains(msg, "name") || !strings.Contains(msg, "/id") {
+		t.Fatalf("expected both violations in message, got: %s", msg)
+	}
+}
+
+func TestAssertSchemaFileWithRefs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.json")
+	schema := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {"user": {"$ref": "#/$defs/user"}},
+  "$defs": {"user": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}}
+}`
+	if err := os.WriteFile(path, []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars := map[string]string{"schemas": filepath.Dir(path)}
+	if ok, msg := Assert(200, body(`{"user":{"id":1}}`), 0, vars, "schema @{{schemas}}/user.json"); !ok {
+		t.Fatalf("expected pass, got: %s", msg)
+	}
+	if ok, _ := Assert(200, body(`{"user":{"id":"1"}}`), 0, vars, "schema "+path); ok {
+		t.Fatal("expected failure for string id")
+	}
+	if ok, msg := Assert(200, body(`{}`), 0, nil, "schema missing.json"); ok || !strings.Contains(msg, "cannot read schema") {
+		t.Fatalf("expected read error, got %v %q", ok, msg)
+	}
+	resp := Response{Status: 200, Body: body(`{"user":{"id":1}}`), BaseDir: filepath.Dir(path)}
+	if ok, msg := AssertResponse(resp, nil, "schema @user.json"); !ok {
+		t.Fatalf("expected a relative schema to resolve against BaseDir, got: %s", msg)
+	}
+}
+
+func TestAssertUnmatchedQuoteIsLiteral(t *testing.T) {
+	ok, msg := Assert(200, body(`{"msg":"please don't retry"}`), 0, nil, "body.msg contains don't")
+	if !ok {
+		t.Errorf("expected pass, got: %s", msg)
+	}
+}
+
+// ── structured results ───────────────────────────────────────────────────────
+
+func TestEvaluateAssertionDetails(t *testing.T) {
+	resp := Response{
+		Status:  404,
+		Headers: map[string][]string{"Content-Type": {"text/html"}},
+		Body:    body(`{"data":{"items":[1,2]}}`),
+	}
+	cases := []struct {
+		assertion              string
+		passed                 bool
+		path, expected, actual string
+	}{
+		{"status == 200", false, "status", "== 200", "404"},
+		{"body.data.items[1] >= 2", true, "data.items.1", ">= 2", "2"},
+		{"header.content-type contains json", false, "header.Content-Type", "contains json", "text/html"},
+		{"body.data.items length == 3", false, "data.items (length)", "== 3", "2"},
+		{"body.data is object", true, "data", "is object", "object"},
+		{"status in [200, 201]", false, "status", "in [200, 201]", "404"},
+	}
+	for _, c := range cases {
+		got := EvaluateAssertion(resp, nil, c.assertion)
+		if got.Expression != c.assertion || got.Passed != c.passed || got.Path != c.path || got.Expected != c.expected || got.Actual != c.actual {
+			t.Errorf("%s: got %+v", c.assertion, got)
+		}
+		if !got.Passed && got.Message == "" {
+			t.Errorf("%s: expected a failure message", c.assertion)
+		}
+	}
+}
+
+func TestEvaluateAssertionsAreIndependent(t *testing.T) {
+	results := EvaluateAssertions(Response{Status: 500, Body: body(`{"ok":false}`)}, nil,
+		[]string{"status == 200", "body.ok == true", "body.ok exists"})
+	if len(results) != 3 || results[0].Passed || results[1].Passed || !results[2].Passed {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestAssertObjectEqualityDiff(t *testing.T) {
+	resp := Response{Status: 200, Body: body(`{"user":{"id":1,"name":"alice","admin":true,"tags":["a","b"]}}`)}
+
+	ok, msg := AssertResponse(resp, nil, `body.user == {"tags":["a","b"],"admin":true,"name":"alice","id":1}`)
+	if !ok {
+		t.Fatalf("key order should not matter: %s", msg)
+	}
+
+	got := EvaluateAssertion(resp, nil, `body.user == {"id":1,"name":"bob","email":"b@x.io","tags":["a"]}`)
+	if got.Passed {
+		t.Fatal("expected mismatch")
+	}
+	want := strings.Join([]string{
+		`+ /admin: true`,
+		`- /email: "b@x.io"`,
+		`~ /name: "bob" → "alice"`,
+		`+ /tags/1: "b"`,
+	}, "\n")
+	if got.Diff != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got.Diff, want)
+	}
+
+	if ok, _ := AssertResponse(resp, nil, `body.user.tags != ["a","b"]`); ok {
+		t.Fatal("expected != to fail for equal arrays")
+	}
+}
+
+func TestAssertWholeBodyEqualityDiff(t *testing.T) {
+	resp := Response{Status: 200, Body: body(`{"id":2,"name":"alice"}`)}
+
+	got := EvaluateAssertion(resp, nil, `body == {"id":1,"name":"alice"}`)
+	if got.Passed {
+		t.Fatal("expected mismatch")
+	}
+	if got.Diff != "~ /id: 1 → 2" {
+		t.Fatalf("unexpected diff %q (message %q)", got.Diff, got.Message)
+	}
+	if ok, msg := AssertResponse(resp, nil, `body == {"name":"alice","id":2}`); !ok {
+		t.Fatalf("expected whole-body match: %s", msg)
+	}
+
+	list := Response{Status: 200, Body: body(`[1,2]`)}
+	if got := EvaluateAssertion(list, nil, `body == [1,2,3]`); got.Passed || got.Diff == "" {
+		t.Fatalf("expected an array diff, got %+v", got)
+	}
+	text := Response{Status: 200, Body: body(`pong`)}
+	if ok, msg := AssertResponse(text, nil, `body == pong`); !ok {
+		t.Fatalf("expected plain-text body to compare as a string: %s", msg)
+	}
+}
+
+func TestJSONDiffScalarsAndTypes(t *testing.T) {
+	if diff := JSONDiff(map[string]any{"a": 1.0}, map[string]any{"a": 1.0}); diff != nil {
+		t.Fatalf("expected no diff, got %v", diff)
+	}
+	diff := JSONDiff(map[string]any{"a": []any{1.0}}, map[string]any{"a": "x"})
+	if len(diff) != 1 || diff[0] != `~ /a: [1] → "x"` {
+		t.Fatalf("unexpected diff: %v", diff)
+	}
+}
+
+func TestJSONShapeDiffComparesKeysAndTypes(t *testing.T) {
+	var expected, actual any
+	json.Unmarshal([]byte(`{"id":1,"name":"a","tags":[],"items":[{"id":1,"sku":"x"}],"meta":{"email":"a@b.co"}}`), &expected)                    //nolint: errcheck
+	json.Unmarshal([]byte(`{"id":2,"name":"b","tags":["go"],"items":[{"id":7,"sku":"y"},{"id":"8","sku":"z"}],"meta":{"admin":true}}`), &actual) //nolint: errcheck
+
+	diff := JSONShapeDiff(expected, actual)
+	want := []string{
+		"~ /items/1/id: number → string",
+		"+ /meta/admin: boolean",
+		"- /meta/email: string",
+	}
+	if strings.Join(diff, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected shape diff:\n%s", strings.Join(diff, "\n"))
+	}
+	if diff := JSONShapeDiff(expected, expected); diff != nil {
+		t.Fatalf("expected no diff, got %v", diff)
+	}
+}
@@ -0,0 +1,91 @@
+package variable
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fakers generate realistic-looking test data for {{$faker.NAME}}. They
+// draw from the shared generator, so --seed makes them reproducible.
+var fakers = map[string]func() string{
+	"name":      func() string { return pick(firstNames) + " " + pick(lastNames) },
+	"firstName": func() string { return pick(firstNames) },
+	"lastName":  func() string { return pick(lastNames) },
+	"email":     fakeEmail,
+	"username":  func() string { return strings.ToLower(pick(firstNames)) + fmt.Sprint(secureRandomInt(1000)) },
+	"phone":     func() string { return fmt.Sprintf("+1-555-%03d-%04d", secureRandomInt(1000), secureRandomInt(10000)) },
+	"street":    fakeStreet,
+	"address":   func() string { return fmt.Sprintf("%s, %s %s", fakeStreet(), pick(cities), fakeZip()) },
+	"city":      func() string { return pick(cities) },
+	"country":   func() string { return pick(countries) },
+	"zip":       fakeZip,
+	"company":   func() string { return pick(lastNames) + " " + pick(companySuffixes) },
+	"word":      func() string { return pick(words) },
+	"sentence":  fakeSentence,
+	"url":       func() string { return "https://" + strings.ToLower(pick(lastNames)) + ".example.com/" + pick(words) },
+	"ipv4": func() string {
+		return fmt.Sprintf("10.%d.%d.%d", secureRandomInt(256), secureRandomInt(256), 1+secureRandomInt(254))
+	},
+}
+
+// fakerNames lists the generators for error messages.
+func fakerNames() []string {
+	names := make([]string, 0, len(fakers))
+	for name := range fakers {
+		names = append(names, "$faker."+name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func pick(list []string) string {
+	return list[secureRandomInt(len(list))]
+}
+
+func fakeEmail() string {
+	return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(pick(firstNames)), strings.ToLower(pick(lastNames)), secureRandomInt(100))
+}
+
+func fakeStreet() string {
+	return fmt.Sprintf("%d %s %s", 1+secureRandomInt(9999), pick(streetNames), pick(streetSuffixes))
+}
+
+func fakeZip() string {
+	return fmt.Sprintf("%05d", secureRandomInt(100000))
+}
+
+func fakeSentence() string {
+	n := 5 + secureRandomInt(6)
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(words)
+	}
+	s := strings.Join(parts, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+var (
+	firstNames = []string{
+		"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Henry", "Isabel", "Jack",
+		"Karen", "Liam", "Maria", "Noah", "Olivia", "Peter", "Quinn", "Rosa", "Samuel", "Tara",
+		"Umar", "Vera", "Wei", "Xavier", "Yuki", "Zoe",
+	}
+	lastNames = []string{
+		"Anderson", "Brown", "Chen", "Davis", "Evans", "Garcia", "Harris", "Ito", "Johnson", "Kim",
+		"Lopez", "Miller", "Nguyen", "Okafor", "Patel", "Rossi", "Smith", "Taylor", "Walker", "Young",
+	}
+	streetNames = []string{
+		"Maple", "Oak", "Pine", "Cedar", "Elm", "Willow", "Lake", "Hill", "Park", "River",
+		"Sunset", "Main", "Church", "Mill", "Spring",
+	}
+	streetSuffixes  = []string{"Street", "Avenue", "Road", "Lane", "Drive", "Court", "Boulevard", "Way"}
+	cities          = []string{"Springfield", "Riverside", "Fairview", "Franklin", "Greenville", "Bristol", "Clinton", "Madison", "Georgetown", "Salem", "Oakland", "Ashland"}
+	countries       = []string{"United States", "Canada", "United Kingdom", "Germany", "France", "Japan", "Australia", "Brazil", "India", "Spain", "Netherlands", "Sweden"}
+	companySuffixes = []string{"Inc", "LLC", "Group", "Labs", "Systems", "Partners", "Holdings", "Technologies"}
+	words           = []string{
+		"alpha", "bright", "cloud", "delta", "echo", "forest", "garden", "harbor", "island", "journey",
+		"kernel", "lemon", "meadow", "nova", "orbit", "pixel", "quartz", "river", "signal", "timber",
+		"union", "vector", "window", "yellow", "zephyr",
+	}
+)
@@ -0,0 +1,170 @@
+package variable
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONDiff compares two decoded JSON values and describes every difference
+// as one line keyed by its JSON pointer:
+//
+//	~ /user/name: "bob" → "alice"   changed
+//	- /user/email: "a@b.co"         expected but missing
+//	+ /user/admin: true             present but not expected
+//
+// It returns nil when the values are equal.
+func JSONDiff(expected, actual any) []string {
+	var lines []string
+	diffJSON("", expected, actual, &lines)
+	return lines
+}
+
+func diffJSON(pointer string, expected, actual any, lines *[]string) {
+	switch want := expected.(type) {
+	case map[string]any:
+		got, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(want)+len(got))
+		for k := range want {
+			keys = append(keys, k)
+		}
+		for k := range got {
+			if _, seen := want[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+			wantValue, inWant := want[k]
+			gotValue, inGot := got[k]
+			switch {
+			case !inGot:
+				*lines = append(*lines, fmt.Sprintf("- %s: %s", child, compactJSON(wantValue)))
+			case !inWant:
+				*lines = append(*lines, fmt.Sprintf("+ %s: %s", child, compactJSON(gotValue)))
+			default:
+				diffJSON(child, wantValue, gotValue, lines)
+			}
+		}
+		return
+	case []any:
+		got, ok := actual.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(want) || i < len(got); i++ {
+			child := pointer + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(got):
+				*lines = append(*lines, fmt.Sprintf("- %s: %s", child, compactJSON(want[i])))
+			case i >= len(want):
+				*lines = append(*lines, fmt.Sprintf("+ %s: %s", child, compactJSON(got[i])))
+			default:
+				diffJSON(child, want[i], got[i], lines)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		if pointer == "" {
+			pointer = "/"
+		}
+		*lines = append(*lines, fmt.Sprintf("~ %s: %s → %s", pointer, compactJSON(expected), compactJSON(actual)))
+	}
+}
+
+// JSONShapeDiff compares only the structure of two decoded JSON values:
+// object keys and value types, not the values themselves. Every element of
+// an actual array is checked against the first expected element, and an
+// empty array on either side matches any array.
+//
+//	~ /user/id: number → string
+//	- /user/email: string
+//	+ /user/admin: boolean
+func JSONShapeDiff(expected, actual any) []string {
+	var lines []string
+	diffShape("", expected, actual, &lines)
+	return lines
+}
+
+func diffShape(pointer string, expected, actual any, lines *[]string) {
+	wantType, gotType := jsonValueType(expected), jsonValueType(actual)
+	if wantType != gotType {
+		if pointer == "" {
+			pointer = "/"
+		}
+		*lines = append(*lines, fmt.Sprintf("~ %s: %s → %s", pointer, wantType, gotType))
+		return
+	}
+
+	switch want := expected.(type) {
+	case map[string]any:
+		got := actual.(map[string]any)
+		keys := make([]string, 0, len(want)+len(got))
+		for k := range want {
+			keys = append(keys, k)
+		}
+		for k := range got {
+			if _, seen := want[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+			wantValue, inWant := want[k]
+			gotValue, inGot := got[k]
+			switch {
+			case !inGot:
+				*lines = append(*lines, fmt.Sprintf("- %s: %s", child, jsonValueType(wantValue)))
+			case !inWant:
+				*lines = append(*lines, fmt.Sprintf("+ %s: %s", child, jsonValueType(gotValue)))
+			default:
+				diffShape(child, wantValue, gotValue, lines)
+			}
+		}
+	case []any:
+		got := actual.([]any)
+		if len(want) == 0 {
+			return
+		}
+		for i, item := range got {
+			diffShape(pointer+"/"+strconv.Itoa(i), want[0], item, lines)
+		}
+	}
+}
+
+// jsonValueType names the JSON type of a value decoded by encoding/json.
+func jsonValueType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
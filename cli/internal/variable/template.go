@@ -0,0 +1,627 @@
+package variable
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholderRegex matches a whole {{ ... }} placeholder. The content is a
+// pipeline:
+//
+//	{{name}}                                   variable
+//	{{name | default: "x"}}                    fallback for undefined variables
+//	{{token | base64}}                         value piped into a function
+//	{{ $hmac "sha256" secret payload }}        function call
+//	{{ $now | add "24h" | format "2006-01-02" }}
+//
+// A piped value becomes the last argument of the next function. Arguments
+// are string literals ("x", \"x\" inside JSON, 'x'), numbers, variables or
+// zero-argument builtins such as $uuid.
+var placeholderRegex = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+
+// missingVarError reports an undefined variable; default: recovers from it.
+type missingVarError struct{ name string }
+
+func (e *missingVarError) Error() string { return "undefined variable " + e.name }
+
+// digest is the raw output of a hash or HMAC. It prints as hex, while
+// base64 and hex encode the raw bytes, so {{ $hmac "sha256" k p | base64 }}
+// yields the usual base64 signature.
+type digest []byte
+
+type templateFunc func(args []any) (any, error)
+
+// templateFuncs is keyed by lower-case name, so $jsonEscape and
+// $jsonescape are the same function.
+var templateFuncs map[string]templateFunc
+
+func init() {
+	templateFuncs = map[string]templateFunc{
+		// Strings
+		"upper":      stringFunc(strings.ToUpper),
+		"lower":      stringFunc(strings.ToLower),
+		"trim":       stringFunc(strings.TrimSpace),
+		"jsonescape": stringFunc(jsonEscape),
+		"urlencode":  stringFunc(url.QueryEscape),
+		"urldecode":  urlDecode,
+		"replace":    replaceFunc,
+
+		// Encodings and hashes
+		"base64":       encodeFunc(base64.StdEncoding.EncodeToString),
+		"base64url":    encodeFunc(base64.RawURLEncoding.EncodeToString),
+		"base64decode": base64Decode,
+		"hex":          encodeFunc(hex.EncodeToString),
+		"md5":          hashFunc(md5.New),
+		"sha1":         hashFunc(sha1.New),
+		"sha256":       hashFunc(sha256.New),
+		"sha512":       hashFunc(sha512.New),
+		"hmac":         hmacFunc,
+
+		// Time
+		"now":       func(args []any) (any, error) { return now(), arity("now", args, 0, 0) },
+		"add":       addFunc,
+		"format":    formatFunc,
+		"unix":      unixFunc("unix", time.Time.Unix),
+		"timestamp": unixFunc("timestamp", time.Time.Unix),
+		"unixms":    unixFunc("unixMs", time.Time.UnixMilli),
+		"isodate":   isoDateFunc,
+
+		// Random values
+		"randomint":    randomIntFunc,
+		"randomstring": randomStringFunc,
+		"uuid":         noArgs("uuid", generateUUID),
+		"randomemail":  noArgs("randomEmail", func() string { return fmt.Sprintf("user%d@example.com", secureRandomInt(999999)) }),
+	}
+	for name, gen := range fakers {
+		templateFuncs["faker."+strings.ToLower(name)] = noArgs("faker."+name, gen)
+	}
+}
+
+// now is replaced in tests.
+var now = func() time.Time { return time.Now().UTC() }
+
+// evalPlaceholder evaluates the content of one {{ ... }} placeholder.
+func evalPlaceholder(content string, vars map[string]string) (string, error) {
+	cmds, err := parsePipeline(content)
+	if err != nil {
+		return "", err
+	}
+	// An undefined variable skips the stages up to the next default.
+	val, err := evalCommand(cmds[0], nil, false, vars)
+	for _, cmd := range cmds[1:] {
+		var missing *missingVarError
+		switch {
+		case isDefault(cmd):
+			if errors.As(err, &missing) {
+				val, err = evalDefault(cmd, vars)
+			}
+		case err == nil:
+			val, err = evalCommand(cmd, val, true, vars)
+		case !errors.As(err, &missing):
+			return "", err
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return toString(val), nil
+}
+
+// evalCommand runs one pipeline stage. The first stage of a pipeline may
+// be a plain value; every later stage is a function receiving the previous
+// value as its last argument.
+func evalCommand(cmd []templateToken, piped any, hasPiped bool, vars map[string]string) (any, error) {
+	head := cmd[0]
+	if !hasPiped && len(cmd) == 1 && !strings.HasPrefix(head.text, "$") {
+		return evalArg(head, vars)
+	}
+	if head.quoted {
+		return nil, fmt.Errorf("%q is not a function", head.text)
+	}
+	fn, err := lookupFunc(head.text)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]any, 0, len(cmd))
+	for _, tok := range cmd[1:] {
+		arg, err := evalArg(tok, vars)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if hasPiped {
+		args = append(args, piped)
+	}
+	return fn(args)
+}
+
+func evalArg(tok templateToken, vars map[string]string) (any, error) {
+	if tok.quoted || isNumber(tok.text) {
+		return tok.text, nil
+	}
+	if strings.HasPrefix(tok.text, "$") {
+		fn, err := lookupFunc(tok.text)
+		if err != nil {
+			return nil, err
+		}
+		return fn(nil)
+	}
+	if val, ok := vars[tok.text]; ok {
+		return val, nil
+	}
+	return nil, &missingVarError{name: tok.text}
+}
+
+func evalDefault(cmd []templateToken, vars map[string]string) (any, error) {
+	if len(cmd) == 1 {
+		return "", nil
+	}
+	if len(cmd) > 2 {
+		return nil, errors.New("default takes one value")
+	}
+	return evalArg(cmd[1], vars)
+}
+
+func isDefault(cmd []templateToken) bool {
+	return !cmd[0].quoted && funcName(cmd[0].text) == "default"
+}
+
+// funcName normalises $name and the legacy "default:" spelling.
+func funcName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(name, "$"), ":"))
+}
+
+func lookupFunc(name string) (templateFunc, error) {
+	if strings.HasPrefix(name, envVarPrefix) {
+		key := strings.TrimPrefix(name, envVarPrefix)
+		return func(args []any) (any, error) { return os.Getenv(key), arity(name, args, 0, 0) }, nil
+	}
+	if fn, ok := templateFuncs[funcName(name)]; ok {
+		return fn, nil
+	}
+	if strings.HasPrefix(funcName(name), "faker.") {
+		return nil, fmt.Errorf("unknown faker generator %s (available: %s)", name, strings.Join(fakerNames(), ", "))
+	}
+	return nil, fmt.Errorf("unknown function %s", name)
+}
+
+// ── Parsing ──────────────────────────────────────────────────────────────────
+
+type templateToken struct {
+	text   string
+	quoted bool
+}
+
+// parsePipeline splits placeholder content into commands separated by |.
+func parsePipeline(content string) ([][]templateToken, error) {
+	tokens, err := lexTemplate(content)
+	if err != nil {
+		return nil, err
+	}
+	var cmds [][]templateToken
+	var cmd []templateToken
+	for _, tok := range tokens {
+		if tok == pipeToken {
+			if len(cmd) == 0 {
+				return nil, errors.New("empty pipeline stage")
+			}
+			cmds = append(cmds, cmd)
+			cmd = nil
+			continue
+		}
+		cmd = append(cmd, tok)
+	}
+	if len(cmd) == 0 {
+		return nil, errors.New("empty pipeline stage")
+	}
+	return append(cmds, cmd), nil
+}
+
+// pipeToken stands for an unquoted | in the token stream.
+var pipeToken = templateToken{text: "|"}
+
+func lexTemplate(s string) ([]templateToken, error) {
+	var tokens []templateToken
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '|':
+			tokens = append(tokens, pipeToken)
+			i++
+		case c == '"':
+			end := closingQuote(s, i+1)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", s)
+			}
+			text, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				text = s[i+1 : end]
+			}
+			tokens = append(tokens, templateToken{text: text, quoted: true})
+			i = end + 1
+		case c == '\\' && i+1 < len(s) && s[i+1] == '"':
+			// \"x\" is how a quoted argument looks inside a JSON string.
+			end := strings.Index(s[i+2:], `\"`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", s)
+			}
+			text := strings.ReplaceAll(s[i+2:i+2+end], `\\`, `\`)
+			tokens = append(tokens, templateToken{text: text, quoted: true})
+			i += end + 4
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", s)
+			}
+			tokens = append(tokens, templateToken{text: s[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\r\n|\"'", rune(s[i])) && !(s[i] == '\\' && i+1 < len(s) && s[i+1] == '"') {
+				i++
+			}
+			word := s[start:i]
+			// default:x, e.g. after a step line lost its quotes, keeps x literal.
+			if value, ok := strings.CutPrefix(word, "default:"); ok && value != "" {
+				tokens = append(tokens, templateToken{text: "default:"}, templateToken{text: value, quoted: true})
+				continue
+			}
+			tokens = append(tokens, templateToken{text: word})
+		}
+	}
+	return tokens, nil
+}
+
+// closingQuote returns the index of the quote ending a string that starts
+// at i, skipping backslash escapes.
+func closingQuote(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// placeholderRefs returns the variables a placeholder reads; optional
+// reports the ones with a default: fallback.
+func placeholderRefs(content string) (names []string, optional bool) {
+	cmds, err := parsePipeline(content)
+	if err != nil {
+		return nil, false
+	}
+	for i, cmd := range cmds {
+		if isDefault(cmd) {
+			optional = optional || i > 0
+			continue
+		}
+		args := cmd[1:]
+		if i == 0 && len(cmd) == 1 {
+			args = cmd
+		}
+		for _, tok := range args {
+			if !tok.quoted && !isNumber(tok.text) && !strings.HasPrefix(tok.text, "$") {
+				names = append(names, tok.text)
+			}
+		}
+	}
+	return names, optional
+}
+
+// ── Values ───────────────────────────────────────────────────────────────────
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case digest:
+		return hex.EncodeToString(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func toBytes(v any) []byte {
+	if d, ok := v.(digest); ok {
+		return d
+	}
+	return []byte(toString(v))
+}
+
+// toTime accepts times, RFC 3339 strings and Unix seconds.
+func toTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	}
+	s := toString(v)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time (use $now, an RFC 3339 date or Unix seconds)", s)
+}
+
+func arity(name string, args []any, min, max int) error {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+	if min == max {
+		return fmt.Errorf("%s takes %d argument(s), got %d", name, min, len(args))
+	}
+	return fmt.Errorf("%s takes %d to %d arguments, got %d", name, min, max, len(args))
+}
+
+// ── Functions ────────────────────────────────────────────────────────────────
+
+func stringFunc(f func(string) string) templateFunc {
+	return func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return f(toString(args[0])), nil
+	}
+}
+
+func encodeFunc(f func([]byte) string) templateFunc {
+	return func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return f(toBytes(args[0])), nil
+	}
+}
+
+func hashFunc(newHash func() hash.Hash) templateFunc {
+	return func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		h := newHash()
+		h.Write(toBytes(args[0]))
+		return digest(h.Sum(nil)), nil
+	}
+}
+
+func noArgs(name string, f func() string) templateFunc {
+	return func(args []any) (any, error) {
+		return f(), arity(name, args, 0, 0)
+	}
+}
+
+func jsonEscape(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) //nolint: errcheck — strings always encode
+	quoted := strings.TrimSpace(buf.String())
+	return quoted[1 : len(quoted)-1]
+}
+
+func urlDecode(args []any) (any, error) {
+	if err := arity("urldecode", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return url.QueryUnescape(toString(args[0]))
+}
+
+// replaceFunc is replace OLD NEW VALUE.
+func replaceFunc(args []any) (any, error) {
+	if err := arity("replace", args, 3, 3); err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(toString(args[2]), toString(args[0]), toString(args[1])), nil
+}
+
+// base64Decode accepts standard and URL-safe input, padded or not.
+func base64Decode(args []any) (any, error) {
+	if err := arity("base64decode", args, 1, 1); err != nil {
+		return nil, err
+	}
+	s := strings.TrimRight(toString(args[0]), "=")
+	for _, enc := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		if raw, err := enc.DecodeString(s); err == nil {
+			return string(raw), nil
+		}
+	}
+	return nil, fmt.Errorf("base64decode: %q is not base64", toString(args[0]))
+}
+
+var hmacHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// hmacFunc is hmac ALGORITHM KEY PAYLOAD.
+func hmacFunc(args []any) (any, error) {
+	if err := arity("hmac", args, 3, 3); err != nil {
+		return nil, err
+	}
+	newHash, ok := hmacHashes[strings.ToLower(toString(args[0]))]
+	if !ok {
+		return nil, fmt.Errorf("hmac: unsupported algorithm %q (use md5, sha1, sha256 or sha512)", toString(args[0]))
+	}
+	mac := hmac.New(newHash, toBytes(args[1]))
+	mac.Write(toBytes(args[2]))
+	return digest(mac.Sum(nil)), nil
+}
+
+// addFunc is add DELTA VALUE: a duration for times ("24h", "-30m", "7d"),
+// a number otherwise.
+func addFunc(args []any) (any, error) {
+	if err := arity("add", args, 2, 2); err != nil {
+		return nil, err
+	}
+	delta, value := toString(args[0]), args[1]
+	if t, err := toTime(value); err == nil && !isNumber(toString(value)) {
+		d, err := parseDuration(delta)
+		if err != nil {
+			return nil, err
+		}
+		return t.Add(d), nil
+	}
+	a, errA := strconv.ParseFloat(toString(value), 64)
+	b, errB := strconv.ParseFloat(delta, 64)
+	if errA != nil || errB != nil {
+		return nil, fmt.Errorf("add: cannot add %q to %q", delta, toString(value))
+	}
+	if sum := a + b; sum == float64(int64(sum)) {
+		return int64(sum), nil
+	}
+	return a + b, nil
+}
+
+// parseDuration extends time.ParseDuration with a d (day) unit.
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// timeLayouts are the named layouts accepted by format besides Go layouts.
+var timeLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc822":      time.RFC822,
+	"dateonly":    time.DateOnly,
+	"datetime":    time.DateTime,
+	"timeonly":    time.TimeOnly,
+	"http":        "Mon, 02 Jan 2006 15:04:05 GMT",
+}
+
+// formatFunc is format LAYOUT TIME, with a Go reference-time layout.
+func formatFunc(args []any) (any, error) {
+	if err := arity("format", args, 2, 2); err != nil {
+		return nil, err
+	}
+	t, err := toTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	layout := toString(args[0])
+	if named, ok := timeLayouts[strings.ToLower(layout)]; ok {
+		layout = named
+	}
+	return t.UTC().Format(layout), nil
+}
+
+// unixFunc returns the current time, or a piped time, as a Unix number.
+func unixFunc(name string, f func(time.Time) int64) templateFunc {
+	return func(args []any) (any, error) {
+		if err := arity(name, args, 0, 1); err != nil {
+			return nil, err
+		}
+		t := now()
+		if len(args) == 1 {
+			var err error
+			if t, err = toTime(args[0]); err != nil {
+				return nil, err
+			}
+		}
+		return f(t), nil
+	}
+}
+
+func isoDateFunc(args []any) (any, error) {
+	if err := arity("isoDate", args, 0, 1); err != nil {
+		return nil, err
+	}
+	t := now()
+	if len(args) == 1 {
+		var err error
+		if t, err = toTime(args[0]); err != nil {
+			return nil, err
+		}
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// randomIntFunc is randomInt, randomInt MAX ([0, MAX)) or randomInt MIN MAX
+// (MIN to MAX inclusive).
+func randomIntFunc(args []any) (any, error) {
+	if err := arity("randomInt", args, 0, 2); err != nil {
+		return nil, err
+	}
+	bounds := make([]int, len(args))
+	for i, arg := range args {
+		n, err := strconv.Atoi(toString(arg))
+		if err != nil {
+			return nil, fmt.Errorf("randomInt: %q is not an integer", toString(arg))
+		}
+		bounds[i] = n
+	}
+	switch len(bounds) {
+	case 0:
+		return int64(secureRandomInt(10000)), nil
+	case 1:
+		return int64(secureRandomInt(bounds[0])), nil
+	}
+	if bounds[1] < bounds[0] {
+		return nil, fmt.Errorf("randomInt: max %d is below min %d", bounds[1], bounds[0])
+	}
+	return int64(bounds[0] + secureRandomInt(bounds[1]-bounds[0]+1)), nil
+}
+
+func randomStringFunc(args []any) (any, error) {
+	if err := arity("randomString", args, 0, 1); err != nil {
+		return nil, err
+	}
+	n := 12
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(toString(args[0])); err != nil || n < 0 {
+			return nil, fmt.Errorf("randomString: %q is not a length", toString(args[0]))
+		}
+	}
+	return generateRandomString(n), nil
+}
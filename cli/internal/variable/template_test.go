@@ -0,0 +1,128 @@
+package variable
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplatePipesAndFunctions(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 3, 1, 22, 30, 0, 0, time.UTC) }
+	defer func() { now = func() time.Time { return time.Now().UTC() } }()
+
+	mac := hmac.New(sha256.New, []byte("k3y"))
+	mac.Write([]byte(`{"a":1}`))
+	sum := sha256.Sum256([]byte("hello"))
+
+	vars := map[string]string{
+		"token":   "user:pass",
+		"name":    "alice",
+		"body":    "hello",
+		"secret":  "k3y",
+		"payload": `{"a":1}`,
+		"text":    "say \"hi\"\n<b>",
+		"count":   "41",
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"{{token | base64}}", "dXNlcjpwYXNz"},
+		{"{{body | sha256}}", hex.EncodeToString(sum[:])},
+		{`{{ $hmac "sha256" secret payload }}`, hex.EncodeToString(mac.Sum(nil))},
+		{`{{ $hmac "sha256" secret payload | base64 }}`, base64.StdEncoding.EncodeToString(mac.Sum(nil))},
+		{`{{ payload | hmac "sha256" secret }}`, hex.EncodeToString(mac.Sum(nil))},
+		{`{{ $now | add "24h" | format "2006-01-02" }}`, "2026-03-02"},
+		{`{{ $now | add "-1d" | format 'DateTime' }}`, "2026-02-28 22:30:00"},
+		{`{{ $now | unix }}`, "1772404200"},
+		{"{{name | upper}}", "ALICE"},
+		{"{{ name | upper | lower }}", "alice"},
+		{`{{ $jsonEscape text }}`, `say \"hi\"\n<b>`},
+		{`{{ count | add 1 }}`, "42"},
+		{`{{ name | replace "a" "A" }}`, "Alice"},
+		{`{"sig": "{{ $hmac \"sha256\" secret payload }}"}`, `{"sig": "` + hex.EncodeToString(mac.Sum(nil)) + `"}`},
+		{`{{ missing | default: "x" | upper }}`, "X"},
+		{`{{ missing | upper | default: name }}`, "alice"},
+		{`{{missing|default:1}}`, "1"},
+		{`{{ "dXNlcjpwYXNz" | base64decode }}`, "user:pass"},
+	}
+	for _, tt := range tests {
+		if got := Interpolate(tt.text, vars); got != tt.want {
+			t.Errorf("Interpolate(%s) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateRandomRanges(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		got := Interpolate("{{ $randomInt 1 3 }}", nil)
+		if got != "1" && got != "2" && got != "3" {
+			t.Fatalf("$randomInt 1 3 returned %q", got)
+		}
+	}
+	if got := Interpolate("{{ $randomString 5 }}", nil); len(got) != 5 {
+		t.Fatalf("expected 5 characters, got %q", got)
+	}
+	phone := regexp.MustCompile(`^\+1-555-\d{3}-\d{4}$`)
+	if got := Interpolate("{{$faker.phone}}", nil); !phone.MatchString(got) {
+		t.Fatalf("unexpected phone %q", got)
+	}
+	if got := Interpolate("{{$faker.name}}", nil); len(strings.Fields(got)) != 2 {
+		t.Fatalf("unexpected name %q", got)
+	}
+}
+
+func TestSetSeedMakesRandomValuesReproducible(t *testing.T) {
+	text := "{{$faker.name}} {{$faker.address}} {{$uuid}} {{ $randomInt 1 1000000 }} {{$randomString}}"
+	SetSeed(42)
+	first := Interpolate(text, nil)
+	SetSeed(42)
+	second := Interpolate(text, nil)
+	SetSeed(43)
+	third := Interpolate(text, nil)
+	SetSeed(int64(cryptoSeed()))
+
+	if first != second {
+		t.Fatalf("same seed gave different values:\n%s\n%s", first, second)
+	}
+	if first == third {
+		t.Fatalf("different seeds gave the same values: %s", first)
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for _, text := range []string{
+		"{{ name | nosuchfunc }}",
+		"{{ $faker.nosuch }}",
+		`{{ $hmac "sha3" secret payload }}`,
+		`{{ $now | add "soon" }}`,
+		`{{ name | upper "extra" }}`,
+		`{{ "unterminated }}`,
+	} {
+		if got := Interpolate(text, map[string]string{"name": "x"}); got != text {
+			t.Errorf("permissive mode should keep %s, got %q", text, got)
+		}
+		if _, err := InterpolateStrict(text, map[string]string{"name": "x"}); err == nil {
+			t.Errorf("strict mode should reject %s", text)
+		}
+	}
+	if _, err := InterpolateStrict("{{ token | base64 }}", nil); err == nil || err.Error() != "required variables not provided: token" {
+		t.Fatalf("expected a missing variable error, got %v", err)
+	}
+}
+
+func TestExtractPlaceholdersSkipsFunctionsAndLiterals(t *testing.T) {
+	text := `{{ $hmac "sha256" secret payload | base64 }} {{$uuid}} {{user | default: "x"}} {{ $now | add "1h" }} {{ upper name }}`
+	if got, want := ExtractPlaceholders(text), []string{"secret", "payload", "user", "name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractPlaceholders = %v, want %v", got, want)
+	}
+	if got, want := RequiredPlaceholders(text), []string{"secret", "payload", "name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("RequiredPlaceholders = %v, want %v", got, want)
+	}
+}
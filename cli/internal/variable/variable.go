@@ -4,29 +4,35 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
-	"regexp"
-	"strconv"
+	mrand "math/rand/v2"
 	"strings"
 	"sync"
 	"time"
 )
 
-// Thread-safe random number generator
+// rng backs every random builtin. It is seeded from crypto/rand unless
+// SetSeed asks for a reproducible sequence.
 var (
-	rngMu   sync.Mutex
-	rngSeed uint64
+	rngMu sync.Mutex
+	rng   = mrand.New(mrand.NewPCG(cryptoSeed(), cryptoSeed()))
 )
 
-func init() {
-	// Initialize with crypto/rand for better randomness
+func cryptoSeed() uint64 {
 	var b [8]byte
-	if _, err := rand.Read(b[:]); err == nil {
-		rngSeed = binary.LittleEndian.Uint64(b[:])
-	} else {
-		rngSeed = uint64(time.Now().UnixNano())
+	if _, err := rand.Read(b[:]); err != nil {
+		return uint64(time.Now().UnixNano())
 	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// SetSeed makes $randomInt, $randomString, $randomEmail, $uuid and $faker.*
+// reproducible: the same seed yields the same values in the same order.
+func SetSeed(seed int64) {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	rng = mrand.New(mrand.NewPCG(uint64(seed), 0))
 }
 
 // InterpolationMode defines how to handle undefined variables
@@ -41,76 +47,37 @@ const (
 	ModeStrict
 )
 
-// Regular expressions for variable parsing
-var (
-	// Optimized: single regex matches both {{var}} and {{var | default: "value"}}
-	// Supports both escaped quotes (\" in JSON) and normal quotes
-	// Group 1: variable name (allows dots for $env.VAR syntax)
-	// Group 2: default value (optional, captured only if | default: syntax present)
-	combinedRegex = regexp.MustCompile(`\{\{([^|{}]+?)(?:\s*\|\s*default:\s*\\?"([^}]*)\\?")?\}\}`)
-	// envVarPrefix is the prefix for reading OS environment variables
-	envVarPrefix = "$env."
-)
+// envVarPrefix is the prefix for reading OS environment variables
+const envVarPrefix = "$env."
 
-// interpolateWithMode is the unified implementation for all interpolation modes
-// This eliminates code duplication and improves performance with a single regex
+// interpolateWithMode is the unified implementation for all interpolation modes.
+// Placeholders that fail to evaluate, e.g. an unknown function, are kept as
+// written except in strict mode, which reports them.
 func interpolateWithMode(text string, vars map[string]string, mode InterpolationMode) (string, []string, error) {
 	var warnings []string
 	var missing []string
+	var failed []string
 
-	result := combinedRegex.ReplaceAllStringFunc(text, func(match string) string {
-		// Extract submatches
-		matches := combinedRegex.FindStringSubmatch(match)
-		if len(matches) < 2 {
-			return match // Invalid match, keep original
-		}
-
-		varName := strings.TrimSpace(matches[1])
-		if varName == "" {
+	result := placeholderRegex.ReplaceAllStringFunc(text, func(match string) string {
+		content := strings.TrimSpace(match[2 : len(match)-2])
+		if content == "" {
 			return match
 		}
 
-		// Default value is in group 2 (may be empty string)
-		defaultValue := ""
-		hasDefault := false
-		if strings.Contains(match, "| default:") {
-			// Check if default syntax was present
-			hasDefault = true
-			if len(matches) >= 3 {
-				defaultValue = matches[2]
-			}
-
-			// Clean up the default value:
-			// 1. Remove leading/trailing quotes (both \" and ")
-			defaultValue = strings.Trim(defaultValue, "\"")
-			defaultValue = strings.Trim(defaultValue, "\\")
-
-			// 2. Unescape any escaped characters
-			defaultValue = strings.ReplaceAll(defaultValue, "\\\"", "\"")
-			defaultValue = strings.ReplaceAll(defaultValue, "\\\\", "\\")
-		}
-
-		// Built-in dynamic variables
-		if isBuiltinVar(varName) {
-			return resolveBuiltin(varName)
-		}
-
-		// Check if variable exists
-		if val, ok := vars[varName]; ok {
+		val, err := evalPlaceholder(content, vars)
+		if err == nil {
 			return val
 		}
 
-		// Use default value if provided
-		if hasDefault {
-			return defaultValue
-		}
-
 		// Variable not found - handle based on mode
-		switch mode {
-		case ModeWarning:
-			warnings = append(warnings, varName)
-		case ModeStrict:
-			missing = append(missing, varName)
+		var undefined *missingVarError
+		switch {
+		case !errors.As(err, &undefined):
+			failed = append(failed, fmt.Sprintf("{{%s}}: %v", content, err))
+		case mode == ModeWarning:
+			warnings = append(warnings, undefined.name)
+		case mode == ModeStrict:
+			missing = append(missing, undefined.name)
 		}
 
 		return match // Keep original for undefined variables
@@ -120,6 +87,9 @@ func interpolateWithMode(text string, vars map[string]string, mode Interpolation
 	if mode == ModeStrict && len(missing) > 0 {
 		return "", nil, fmt.Errorf("required variables not provided: %s", strings.Join(missing, ", "))
 	}
+	if mode == ModeStrict && len(failed) > 0 {
+		return "", nil, fmt.Errorf("invalid placeholder %s", strings.Join(failed, "; "))
+	}
 
 	return result, warnings, nil
 }
@@ -148,10 +118,22 @@ func InterpolateStrict(text string, vars map[string]string) (string, error) {
 	return result, err
 }
 
-// ExtractPlaceholders returns all variable names found in {{var}} placeholders.
-// Duplicates are removed while preserving first-seen order.
+// ExtractPlaceholders returns the variable names read by {{...}}
+// placeholders, including ones with a default. Builtins, functions and
+// literals are skipped. Duplicates are removed while preserving first-seen
+// order.
 func ExtractPlaceholders(text string) []string {
-	matches := combinedRegex.FindAllStringSubmatch(text, -1)
+	return extractPlaceholders(text, false)
+}
+
+// RequiredPlaceholders is ExtractPlaceholders without the variables that
+// fall back to a default.
+func RequiredPlaceholders(text string) []string {
+	return extractPlaceholders(text, true)
+}
+
+func extractPlaceholders(text string, requiredOnly bool) []string {
+	matches := placeholderRegex.FindAllStringSubmatch(text, -1)
 	if len(matches) == 0 {
 		return nil
 	}
@@ -159,18 +141,17 @@ func ExtractPlaceholders(text string) []string {
 	seen := make(map[string]struct{}, len(matches))
 	vars := make([]string, 0, len(matches))
 	for _, m := range matches {
-		if len(m) < 2 {
-			continue
-		}
-		name := strings.TrimSpace(m[1])
-		if name == "" {
+		names, optional := placeholderRefs(m[1])
+		if requiredOnly && optional {
 			continue
 		}
-		if _, ok := seen[name]; ok {
-			continue
+		for _, name := range names {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			vars = append(vars, name)
 		}
-		seen[name] = struct{}{}
-		vars = append(vars, name)
 	}
 	return vars
 }
@@ -195,47 +176,29 @@ func parseVarWithDefault(content string) (string, string) {
 
 // isBuiltinVar checks if a variable is a built-in variable
 func isBuiltinVar(name string) bool {
-	switch name {
-	case "$randomInt", "$timestamp", "$uuid", "$randomEmail", "$randomString", "$isoDate", "$unixMs":
-		return true
+	if !strings.HasPrefix(name, "$") {
+		return false
 	}
-	// $env.VAR_NAME reads OS environment variables
-	if strings.HasPrefix(name, envVarPrefix) {
-		return true
-	}
-	return false
+	_, err := lookupFunc(name)
+	return err == nil
 }
 
 // resolveBuiltin resolves built-in variable values
-// Thread-safe implementation using crypto/rand
 func resolveBuiltin(name string) string {
-	switch name {
-	case "$randomInt":
-		return strconv.Itoa(secureRandomInt(10000))
-	case "$timestamp":
-		return strconv.FormatInt(time.Now().Unix(), 10)
-	case "$unixMs":
-		return strconv.FormatInt(time.Now().UnixMilli(), 10)
-	case "$isoDate":
-		return time.Now().UTC().Format(time.RFC3339)
-	case "$uuid":
-		return generateUUID()
-	case "$randomEmail":
-		return fmt.Sprintf("user%d@example.com", secureRandomInt(999999))
-	case "$randomString":
-		return generateRandomString(12)
+	fn, err := lookupFunc(name)
+	if err != nil {
+		return ""
 	}
-	// $env.VAR_NAME → read from OS environment
-	if strings.HasPrefix(name, envVarPrefix) {
-		return os.Getenv(strings.TrimPrefix(name, envVarPrefix))
+	val, err := fn(nil)
+	if err != nil {
+		return ""
 	}
-	return ""
+	return toString(val)
 }
 
 // generateUUID produces a random UUID v4 string.
 func generateUUID() string {
-	var b [16]byte
-	rand.Read(b[:])             //nolint: errcheck — crypto/rand rarely fails
+	b := randomBytes(16)
 	b[6] = (b[6] & 0x0f) | 0x40 // version 4
 	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC4122
 	return fmt.Sprintf("%s-%s-%s-%s-%s",
@@ -249,9 +212,7 @@ func generateUUID() string {
 
 // generateRandomString returns a random hex string of the given length.
 func generateRandomString(n int) string {
-	raw := make([]byte, (n+1)/2)
-	rand.Read(raw[:]) //nolint: errcheck
-	return hex.EncodeToString(raw)[:n]
+	return hex.EncodeToString(randomBytes((n + 1) / 2))[:n]
 }
 
 // secureRandomInt generates a thread-safe random integer in [0, max)
@@ -262,8 +223,15 @@ func secureRandomInt(max int) int {
 
 	rngMu.Lock()
 	defer rngMu.Unlock()
+	return rng.IntN(max)
+}
 
-	// Simple LCG (Linear Congruential Generator)
-	rngSeed = (rngSeed*1103515245 + 12345) & 0x7fffffff
-	return int(rngSeed % uint64(max))
+func randomBytes(n int) []byte {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(rng.Uint32())
+	}
+	return b
 }
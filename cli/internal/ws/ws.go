@@ -0,0 +1,304 @@
+// Package ws is a small WebSocket (RFC 6455) implementation: the opening
+// handshake headers, a message-level connection over any byte stream, and
+// a server-side Upgrade for local servers and tests.
+package ws
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Opcodes of the frames this package reads and writes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// maxMessageSize bounds a single message so a misbehaving peer cannot
+// exhaust memory.
+const maxMessageSize = 16 << 20
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// NewKey returns a random Sec-WebSocket-Key.
+func NewKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Accept is the Sec-WebSocket-Accept value a server answers key with.
+func Accept(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// HandshakeHeaders are the request headers that ask for an upgrade to a
+// WebSocket.
+func HandshakeHeaders(key string) map[string]string {
+	return map[string]string{
+		"Connection":            "Upgrade",
+		"Upgrade":               "websocket",
+		"Sec-WebSocket-Version": "13",
+		"Sec-WebSocket-Key":     key,
+	}
+}
+
+// CheckHandshake verifies that resp accepts the upgrade requested with key.
+func CheckHandshake(resp *http.Response, key string) error {
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("websocket handshake failed: server answered %s", resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return fmt.Errorf("websocket handshake failed: missing Upgrade: websocket header")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != Accept(key) {
+		return fmt.Errorf("websocket handshake failed: bad Sec-WebSocket-Accept")
+	}
+	if _, ok := resp.Body.(io.ReadWriteCloser); !ok {
+		return fmt.Errorf("websocket handshake failed: connection is not writable")
+	}
+	return nil
+}
+
+// Message is one complete (reassembled) data message.
+type Message struct {
+	Binary bool
+	Data   []byte
+}
+
+// CloseError is returned by ReadMessage once the peer closed the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("websocket closed: %d %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("websocket closed: %d", e.Code)
+}
+
+// Conn is a WebSocket connection. One goroutine may read while others
+// write.
+type Conn struct {
+	rw     io.ReadWriteCloser
+	br     *bufio.Reader
+	client bool // clients mask the frames they send
+
+	mu     sync.Mutex // serialises writes
+	closed bool
+}
+
+// NewConn wraps an upgraded connection. client is true on the side that
+// sent the handshake request.
+func NewConn(rw io.ReadWriteCloser, client bool) *Conn {
+	return &Conn{rw: rw, br: bufio.NewReader(rw), client: client}
+}
+
+// WriteText sends a text message.
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// WriteBinary sends a binary message.
+func (c *Conn) WriteBinary(data []byte) error {
+	return c.writeFrame(opBinary, data)
+}
+
+// Close sends a normal closure frame and closes the connection.
+func (c *Conn) Close() error {
+	payload := binary.BigEndian.AppendUint16(nil, 1000)
+	_ = c.writeFrame(opClose, payload)
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return c.rw.Close()
+}
+
+func (c *Conn) writeFrame(op byte, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errors.New("websocket is closed")
+	}
+
+	header := []byte{0x80 | op, 0}
+	switch n := len(data); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	payload := data
+	if c.client {
+		header[1] |= 0x80
+		mask := make([]byte, 4)
+		_, _ = rand.Read(mask)
+		header = append(header, mask...)
+		payload = make([]byte, len(data))
+		for i := range data {
+			payload[i] = data[i] ^ mask[i%4]
+		}
+	}
+	if _, err := c.rw.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadMessage returns the next data message. Pings are answered and
+// fragmented messages reassembled on the way; a close frame from the peer
+// is answered and returned as a *CloseError.
+func (c *Conn) ReadMessage() (Message, error) {
+	var msg Message
+	var started bool
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return Message{}, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return Message{}, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: 1005}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			_ = c.writeFrame(opClose, payload[:min(len(payload), 2)])
+			c.mu.Lock()
+			c.closed = true
+			c.mu.Unlock()
+			return Message{}, closeErr
+		case opText, opBinary:
+			if started {
+				return Message{}, errors.New("websocket protocol error: new message inside a fragmented one")
+			}
+			started = true
+			msg.Binary = op == opBinary
+		case opContinuation:
+			if !started {
+				return Message{}, errors.New("websocket protocol error: continuation without a message")
+			}
+		default:
+			return Message{}, fmt.Errorf("websocket protocol error: unknown opcode %d", op)
+		}
+
+		if len(msg.Data)+len(payload) > maxMessageSize {
+			return Message{}, fmt.Errorf("websocket message larger than %d bytes", maxMessageSize)
+		}
+		msg.Data = append(msg.Data, payload...)
+		if fin {
+			if !msg.Binary && !utf8.Valid(msg.Data) {
+				return Message{}, errors.New("websocket protocol error: text message is not valid UTF-8")
+			}
+			return msg, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxMessageSize {
+		err = fmt.Errorf("websocket frame larger than %d bytes", maxMessageSize)
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// Upgrade answers a WebSocket handshake and returns the server side of the
+// connection.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response writer cannot be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + Accept(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c := NewConn(conn, false)
+	c.br = rw.Reader
+	return c, nil
+}
@@ -0,0 +1,114 @@
+package ws
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccept(t *testing.T) {
+	// The example from RFC 6455, section 1.3.
+	if got := Accept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Accept() = %s", got)
+	}
+}
+
+func TestRoundTripOverHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(msg.Data) == "bye" {
+				return
+			}
+			conn.WriteText(append([]byte("echo: "), msg.Data...))
+		}
+	}))
+	defer server.Close()
+
+	key := NewKey()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	for k, v := range HandshakeHeaders(key) {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckHandshake(resp, key); err != nil {
+		t.Fatal(err)
+	}
+	conn := NewConn(resp.Body.(io.ReadWriteCloser), true)
+	defer conn.Close()
+
+	long := strings.Repeat("x", 70000) // 64-bit length encoding
+	for _, text := range []string{"hi", strings.Repeat("y", 300), long} {
+		if err := conn.WriteText([]byte(text)); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg.Data) != "echo: "+text || msg.Binary {
+			t.Fatalf("unexpected echo of %d bytes: %d bytes", len(text), len(msg.Data))
+		}
+	}
+
+	conn.WriteText([]byte("bye"))
+	var closeErr *CloseError
+	if _, err := conn.ReadMessage(); !errors.As(err, &closeErr) || closeErr.Code != 1000 {
+		t.Fatalf("expected a normal closure, got %v", err)
+	}
+}
+
+// pipe is an in-memory connection: reads come from in, writes go to out.
+type pipe struct {
+	in  io.Reader
+	out bytes.Buffer
+}
+
+func (p *pipe) Read(b []byte) (int, error)  { return p.in.Read(b) }
+func (p *pipe) Write(b []byte) (int, error) { return p.out.Write(b) }
+func (p *pipe) Close() error                { return nil }
+
+func TestReadMessageControlFramesAndFragments(t *testing.T) {
+	frames := []byte{
+		0x01, 3, 'a', 'b', 'c', // text, not final
+		0x89, 2, 'p', '!', // ping in between fragments
+		0x80, 2, 'd', 'e', // final continuation
+		0x82, 1, 0xFF, // binary
+		0x88, 6, 0x03, 0xE9, 'g', 'o', 'n', 'e', // close 1001 "gone"
+	}
+	p := &pipe{in: bytes.NewReader(frames)}
+	conn := NewConn(p, false)
+
+	msg, err := conn.ReadMessage()
+	if err != nil || string(msg.Data) != "abcde" || msg.Binary {
+		t.Fatalf("reassembled message = %q (binary %v), err %v", msg.Data, msg.Binary, err)
+	}
+	if pong := p.out.Bytes(); !bytes.Equal(pong, []byte{0x8A, 2, 'p', '!'}) {
+		t.Fatalf("ping was not answered: % x", pong)
+	}
+	if msg, err := conn.ReadMessage(); err != nil || !msg.Binary || !bytes.Equal(msg.Data, []byte{0xFF}) {
+		t.Fatalf("binary message = % x, err %v", msg.Data, err)
+	}
+	var closeErr *CloseError
+	if _, err := conn.ReadMessage(); !errors.As(err, &closeErr) || closeErr.Code != 1001 || closeErr.Reason != "gone" {
+		t.Fatalf("expected close 1001 gone, got %v", err)
+	}
+	if err := conn.WriteText([]byte("late")); err == nil {
+		t.Fatal("writing after the peer closed should fail")
+	}
+}
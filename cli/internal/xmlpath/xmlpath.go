@@ -0,0 +1,245 @@
+// Package xmlpath evaluates a practical subset of XPath against XML
+// documents, enough for capturing values from SOAP and other XML APIs.
+//
+// Supported syntax:
+//
+//	/a/b/c          absolute child steps
+//	//c             descendant search from the root (or from the previous step)
+//	*               any element
+//	@attr           attribute of the selected element
+//	text()          text content of the selected element
+//	[2]             1-based position among the step's matches
+//	[@id='7']       attribute equality predicate
+//	[name='value']  child element text equality predicate
+package xmlpath
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     strings.Builder
+}
+
+// textContent returns the concatenated character data of n and its
+// descendants, trimmed of surrounding whitespace.
+func (n *node) textContent() string {
+	var b strings.Builder
+	var walk func(*node)
+	walk = func(cur *node) {
+		b.WriteString(cur.text.String())
+		for _, child := range cur.children {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
+
+type step struct {
+	descendant bool
+	name       string // element name, "*", "@attr" or "text()"
+	predicate  string
+}
+
+// Query returns the string value of the first node matched by expr in the
+// XML document. ok is false when nothing matches.
+func Query(document []byte, expr string) (string, bool, error) {
+	root, err := parse(document)
+	if err != nil {
+		return "", false, err
+	}
+	steps, err := compile(expr)
+	if err != nil {
+		return "", false, err
+	}
+
+	current := []*node{root}
+	for i, st := range steps {
+		last := i == len(steps)-1
+		if strings.HasPrefix(st.name, "@") || st.name == "text()" {
+			if !last {
+				return "", false, fmt.Errorf("%s must be the last step in %q", st.name, expr)
+			}
+			for _, n := range current {
+				if st.name == "text()" {
+					return strings.TrimSpace(n.text.String()), true, nil
+				}
+				if value, ok := n.attrs[st.name[1:]]; ok {
+					return value, true, nil
+				}
+			}
+			return "", false, nil
+		}
+
+		var next []*node
+		for _, n := range current {
+			next = append(next, selectStep(n, st)...)
+		}
+		current = next
+		if len(current) == 0 {
+			return "", false, nil
+		}
+	}
+	return current[0].textContent(), true, nil
+}
+
+func selectStep(n *node, st step) []*node {
+	var candidates []*node
+	var collect func(*node)
+	collect = func(cur *node) {
+		for _, child := range cur.children {
+			if st.name == "*" || child.name == st.name {
+				candidates = append(candidates, child)
+			}
+			if st.descendant {
+				collect(child)
+			}
+		}
+	}
+	collect(n)
+	return applyPredicate(candidates, st.predicate)
+}
+
+func applyPredicate(nodes []*node, predicate string) []*node {
+	if predicate == "" {
+		return nodes
+	}
+	if pos, err := strconv.Atoi(predicate); err == nil {
+		if pos < 1 || pos > len(nodes) {
+			return nil
+		}
+		return nodes[pos-1 : pos]
+	}
+
+	key, value, ok := strings.Cut(predicate, "=")
+	if !ok {
+		return nil
+	}
+	key = strings.TrimSpace(key)
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+	var out []*node
+	for _, n := range nodes {
+		if strings.HasPrefix(key, "@") {
+			if n.attrs[key[1:]] == value {
+				out = append(out, n)
+			}
+			continue
+		}
+		for _, child := range n.children {
+			if child.name == key && child.textContent() == value {
+				out = append(out, n)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func compile(expr string) ([]step, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "/") {
+		return nil, fmt.Errorf("xpath must start with / or //: %q", expr)
+	}
+
+	var steps []step
+	for len(expr) > 0 {
+		st := step{}
+		switch {
+		case strings.HasPrefix(expr, "//"):
+			st.descendant = true
+			expr = expr[2:]
+		case strings.HasPrefix(expr, "/"):
+			expr = expr[1:]
+		default:
+			return nil, fmt.Errorf("invalid xpath near %q", expr)
+		}
+
+		end := 0
+		depth := 0
+		for end < len(expr) {
+			c := expr[end]
+			if c == '[' {
+				depth++
+			} else if c == ']' {
+				depth--
+			} else if c == '/' && depth == 0 {
+				break
+			}
+			end++
+		}
+		token := expr[:end]
+		expr = expr[end:]
+
+		if open := strings.Index(token, "["); open != -1 {
+			if !strings.HasSuffix(token, "]") {
+				return nil, fmt.Errorf("unterminated predicate in %q", token)
+			}
+			st.predicate = strings.TrimSpace(token[open+1 : len(token)-1])
+			token = token[:open]
+		}
+		st.name = localName(strings.TrimSpace(token))
+		if st.name == "" {
+			return nil, fmt.Errorf("empty xpath step")
+		}
+		steps = append(steps, st)
+	}
+	return steps, nil
+}
+
+// localName drops a namespace prefix so soap:Body matches <Body> in any
+// namespace.
+func localName(name string) string {
+	if strings.HasPrefix(name, "@") {
+		return "@" + localName(name[1:])
+	}
+	if i := strings.LastIndex(name, ":"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func parse(document []byte) (*node, error) {
+	decoder := xml.NewDecoder(strings.NewReader(string(document)))
+	decoder.Strict = false
+
+	root := &node{}
+	stack := []*node{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				n.attrs[attr.Name.Local] = attr.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.text.Write(t)
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("invalid XML: no root element")
+	}
+	return root, nil
+}
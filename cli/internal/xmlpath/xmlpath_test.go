@@ -0,0 +1,53 @@
+package xmlpath
+
+import "testing"
+
+const orderXML = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <order id="42" status="paid">
+      <item sku="A1"><name>Pen</name><qty>2</qty></item>
+      <item sku="B2"><name>Ink</name><qty>5</qty></item>
+      <note>  fragile  </note>
+    </order>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestQuery(t *testing.T) {
+	cases := map[string]string{
+		"/Envelope/Body/order/@id":               "42",
+		"/soap:Envelope/soap:Body/order/@status": "paid",
+		"//order/note":                           "fragile",
+		"//item[2]/name":                         "Ink",
+		"//item[@sku='A1']/qty":                  "2",
+		"//item[name='Ink']/@sku":                "B2",
+		"//order/*[1]/name/text()":               "Pen",
+		"//qty":                                  "2",
+	}
+	for expr, want := range cases {
+		got, ok, err := Query([]byte(orderXML), expr)
+		if err != nil || !ok || got != want {
+			t.Errorf("Query(%q) = %q, %v, %v; want %q", expr, got, ok, err, want)
+		}
+	}
+}
+
+func TestQueryNoMatch(t *testing.T) {
+	for _, expr := range []string{"//missing", "//item[3]", "//order/@nope"} {
+		if _, ok, err := Query([]byte(orderXML), expr); ok || err != nil {
+			t.Errorf("Query(%q) expected no match, got ok=%v err=%v", expr, ok, err)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	if _, _, err := Query([]byte(`{"json":true}`), "//a"); err == nil {
+		t.Error("expected error for non-XML document")
+	}
+	if _, _, err := Query([]byte(orderXML), "order/id"); err == nil {
+		t.Error("expected error for relative path")
+	}
+	if _, _, err := Query([]byte(orderXML), "//order/@id/name"); err == nil {
+		t.Error("expected error for step after attribute")
+	}
+}
@@ -1,180 +1,200 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/kest-labs/kest/cli/internal/mock"
 	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/spf13/cobra"
 )
 
 var (
-	mockPort int
+	mockPort     int
+	mockOpenAPI  string
+	mockFile     string
+	mockStateful bool
+	mockChaos    = map[string]*string{}
 )
 
 var mockCmd = &cobra.Command{
 	Use:   "mock",
 	Short: "Start a mock server auto-generated from your request history",
 	Long: `Launch a local HTTP server that replays recorded responses from your history.
-Every unique endpoint (method + path) gets a mock handler returning the latest recorded response.
-
-This is a zero-config mock server — no setup, no manual examples. Your real API traffic IS the mock.`,
+Every endpoint (method + path template) gets a mock handler. ID-like path
+segments become parameters, so /users/1 and /users/2 are served by
+/users/{id}; a request is answered by the recording whose path and query
+match it best, falling back to the latest one.
+
+This is a zero-config mock server — no setup, no manual examples. Your real API traffic IS the mock.
+
+--openapi adds every operation of an OpenAPI file (with its example
+responses) and names path parameters after the spec. --file adds routes from
+a YAML file with request matchers and templated responses such as
+{{request.path.id}} or {{request.body.name}}. --stateful keeps created,
+updated and deleted resources in memory so POST-then-GET behaves like the
+real API.
+
+Chaos options exercise client retry and timeout logic: --latency,
+--error-rate and --reset-rate apply to every route; a mock file can set
+latency, error_rate, error_status, reset_rate, truncate, drip and drip_chunk
+per route or response under "chaos:", and the same options can be sent as
+X-Kest-Chaos-<Option> headers (e.g. X-Kest-Chaos-Latency: 2s), either in a
+mock response or on the request itself.`,
 	Example: `  # Start mock server on default port
   kest mock
 
   # Start on a custom port
-  kest mock --port 9090`,
+  kest mock --port 9090
+
+  # Use OpenAPI path templates and examples alongside history
+  kest mock --openapi openapi.yaml
+
+  # Hand-written routes first, in-memory CRUD on top
+  kest mock --file mocks.yaml --stateful
+
+  # Slow, flaky upstream: 100ms-2s latency, 10% 503s, 2% connection resets
+  kest mock --latency 100ms-2s --error-rate 0.1 --reset-rate 0.02
+
+  # Ask for a fault from the client side
+  curl -H 'X-Kest-Chaos-Truncate: 50%' localhost:8787/api/users`,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		store, err := storage.NewStore()
+		routes, err := loadMockRoutes()
 		if err != nil {
 			return err
 		}
-		defer store.Close()
-
-		records, err := store.GetAllRecords()
-		if err != nil {
-			return err
-		}
-		if len(records) == 0 {
-			return fmt.Errorf("no request history found. Make some API requests first")
-		}
-
-		// Build route table: method+path → latest record
-		type route struct {
-			Method   string
-			Path     string
-			Status   int
-			Headers  map[string][]string
-			Body     string
-			RecordID int64
+		if len(routes) == 0 {
+			return fmt.Errorf("no mockable endpoints found in history")
 		}
 
-		routes := make(map[string]*route)
-		// Records are ordered DESC, so first occurrence is latest
-		for _, r := range records {
-			if r.Path == "" {
-				continue
-			}
-			key := r.Method + " " + r.Path
-			if _, exists := routes[key]; !exists {
-				var respHeaders map[string][]string
-				json.Unmarshal(r.ResponseHeaders, &respHeaders)
-				routes[key] = &route{
-					Method:   r.Method,
-					Path:     r.Path,
-					Status:   r.ResponseStatus,
-					Headers:  respHeaders,
-					Body:     r.ResponseBody,
-					RecordID: r.ID,
-				}
+		chaos := mock.Chaos{}
+		for key, value := range mockChaos {
+			if *value != "" {
+				chaos[key] = *value
 			}
 		}
+		if err := chaos.Validate(); err != nil {
+			return err
+		}
 
-		if len(routes) == 0 {
-			return fmt.Errorf("no mockable endpoints found in history")
+		opts := mock.Options{Stateful: mockStateful, Chaos: chaos}
+		if !QuietMode {
+			opts.Logf = func(format string, args ...any) { fmt.Printf(format, args...) }
 		}
+		server := mock.NewServer(routes, opts)
 
 		// Print route table
 		fmt.Printf("🎭 Mock server starting on :%d\n\n", mockPort)
 		fmt.Printf("  %-7s %-40s %s\n", "METHOD", "PATH", "STATUS")
 		fmt.Printf("  %s\n", strings.Repeat("─", 60))
-		for _, r := range routes {
-			fmt.Printf("  %-7s %-40s %d  (from #%d)\n", r.Method, r.Path, r.Status, r.RecordID)
+		for _, r := range server.Routes() {
+			first := r.Responses[0]
+			fmt.Printf("  %-7s %-40s %d  (from %s", r.Method, r.Template, first.Status, first.Source)
+			if n := len(r.Responses); n > 1 {
+				fmt.Printf(", %d variants", n)
+			}
+			fmt.Println(")")
 		}
-		fmt.Printf("\n  Total: %d endpoints\n\n", len(routes))
-
-		// Build mux
-		mux := http.NewServeMux()
-
-		for _, r := range routes {
-			rt := r // capture
-			pattern := rt.Path
-			mux.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
-				// Check method
-				if req.Method != rt.Method && req.Method != "OPTIONS" {
-					// Try to find the right method
-					key := req.Method + " " + rt.Path
-					if alt, ok := routes[key]; ok {
-						serveMockResponse(w, alt.Status, alt.Headers, alt.Body)
-						return
-					}
-					w.WriteHeader(http.StatusMethodNotAllowed)
-					fmt.Fprintf(w, `{"error":"method %s not recorded for %s"}`, req.Method, rt.Path)
-					return
-				}
-
-				// Handle CORS preflight
-				if req.Method == "OPTIONS" {
-					w.Header().Set("Access-Control-Allow-Origin", "*")
-					w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-					w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-					w.WriteHeader(http.StatusOK)
-					return
-				}
-
-				serveMockResponse(w, rt.Status, rt.Headers, rt.Body)
-				if !QuietMode {
-					fmt.Printf("  → %s %s → %d\n", req.Method, req.URL.Path, rt.Status)
-				}
-			})
+		fmt.Printf("\n  Total: %d endpoints\n", len(server.Routes()))
+		if mockStateful {
+			fmt.Printf("  Stateful: created, updated and deleted resources are kept in memory\n")
 		}
-
-		// Catch-all for unmatched routes
-		mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
-			// Check if any route matches
-			key := req.Method + " " + req.URL.Path
-			if rt, ok := routes[key]; ok {
-				serveMockResponse(w, rt.Status, rt.Headers, rt.Body)
-				if !QuietMode {
-					fmt.Printf("  → %s %s → %d\n", req.Method, req.URL.Path, rt.Status)
-				}
-				return
-			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, `{"error":"no recorded response for %s %s","hint":"make a real request first: kest %s %s"}`,
-				req.Method, req.URL.Path,
-				strings.ToLower(req.Method), req.URL.Path)
-		})
+		if len(chaos) > 0 {
+			fmt.Printf("  Chaos: %s\n", describeMockChaos(chaos))
+		}
+		fmt.Println()
 
 		fmt.Printf("💡 Press Ctrl+C to stop\n\n")
 		addr := fmt.Sprintf(":%d", mockPort)
-		return http.ListenAndServe(addr, mux)
+		return http.ListenAndServe(addr, server)
 	},
 }
 
 func init() {
 	mockCmd.Flags().IntVar(&mockPort, "port", 8787, "Port to listen on")
+	mockCmd.Flags().StringVar(&mockOpenAPI, "openapi", "", "OpenAPI spec providing path templates and example responses")
+	mockCmd.Flags().StringVarP(&mockFile, "file", "f", "", "YAML file with mock routes (matchers and templated responses)")
+	mockCmd.Flags().BoolVar(&mockStateful, "stateful", false, "Keep POST/PUT/PATCH/DELETE changes in memory and serve them on GET")
+	mockChaos["latency"] = mockCmd.Flags().String("latency", "", "Delay every response, fixed (300ms) or random (100ms-2s)")
+	mockChaos["error_rate"] = mockCmd.Flags().String("error-rate", "", "Share of requests answered with 503 (0.1 or 10%)")
+	mockChaos["reset_rate"] = mockCmd.Flags().String("reset-rate", "", "Share of connections dropped without a response")
 	rootCmd.AddCommand(mockCmd)
 }
 
-func serveMockResponse(w http.ResponseWriter, status int, headers map[string][]string, body string) {
-	// Set CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// Set recorded headers (skip hop-by-hop headers)
-	skipHeaders := map[string]bool{
-		"Transfer-Encoding": true,
-		"Connection":        true,
-		"Keep-Alive":        true,
+// loadMockRoutes gathers routes by priority: the mock file, then history,
+// then OpenAPI examples for operations that were never recorded.
+func loadMockRoutes() ([]*mock.Route, error) {
+	var routes []*mock.Route
+	if mockFile != "" {
+		fileRoutes, err := mock.LoadFile(mockFile)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, fileRoutes...)
 	}
-	for k, vals := range headers {
-		if skipHeaders[k] {
-			continue
+	var templates []string
+	for _, r := range routes {
+		templates = append(templates, r.Template)
+	}
+
+	var specRoutes []*mock.Route
+	if mockOpenAPI != "" {
+		var err error
+		specRoutes, err = mock.FromOpenAPI(mockOpenAPI)
+		if err != nil {
+			return nil, err
 		}
-		for _, v := range vals {
-			w.Header().Set(k, v)
+		for _, r := range specRoutes {
+			templates = append(templates, r.Template)
 		}
 	}
 
-	// Ensure content-type is set
-	if w.Header().Get("Content-Type") == "" {
-		w.Header().Set("Content-Type", "application/json")
+	store, err := storage.NewStore()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	records, err := store.GetAllRecords()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 && len(routes) == 0 && len(specRoutes) == 0 {
+		return nil, fmt.Errorf("no request history found. Make some API requests first")
 	}
 
-	w.WriteHeader(status)
-	fmt.Fprint(w, body)
+	recorded := mock.FromRecords(records, templates)
+	routes = mergeMockRoutes(routes, recorded)
+	return mergeMockRoutes(routes, specRoutes), nil
+}
+
+// mergeMockRoutes appends the responses of extra routes to existing routes
+// with the same method and template, so earlier sources take priority.
+func mergeMockRoutes(routes, extra []*mock.Route) []*mock.Route {
+	byKey := make(map[string]*mock.Route, len(routes))
+	for _, r := range routes {
+		byKey[r.Method+" "+r.Template] = r
+	}
+	for _, r := range extra {
+		if existing, ok := byKey[r.Method+" "+r.Template]; ok {
+			existing.Responses = append(existing.Responses, r.Responses...)
+			continue
+		}
+		byKey[r.Method+" "+r.Template] = r
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// describeMockChaos lists chaos settings in a stable order for the banner.
+func describeMockChaos(chaos mock.Chaos) string {
+	var parts []string
+	for _, key := range []string{"latency", "error_rate", "reset_rate"} {
+		if value, ok := chaos[key]; ok {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kest-labs/kest/cli/internal/config"
+	"github.com/kest-labs/kest/cli/internal/logger"
+	"github.com/kest-labs/kest/cli/internal/platformsync"
+	"github.com/kest-labs/kest/cli/internal/proxy"
+	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+const defaultProxyHost = "127.0.0.1"
+
+var (
+	proxyTarget      string
+	proxyHost        string
+	proxyPort        int
+	proxyForward     bool
+	proxyNoIntercept bool
+	proxyCADir       string
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Record real app traffic into history through a local proxy",
+	Long: `Run a local proxy that records every request/response passing through it into
+your history, tagged with the active project and environment. Recorded traffic
+works everywhere history does: kest history, kest mock, kest snap and
+kest sync push.
+
+With --target the proxy sits in front of one API (reverse proxy): point your
+frontend or mobile app at the proxy instead of the API. With --forward it is a
+regular HTTP proxy for any host; HTTPS is intercepted with a locally generated
+CA (~/.kest/proxy/ca.pem), which your browser, device or runtime must trust.
+Use --no-intercept to tunnel HTTPS through unrecorded instead.
+
+The proxy binds to 127.0.0.1 by default. Use --host 0.0.0.0 only to record a
+device on your network: anyone who can reach the port can then use it as a
+proxy, and their traffic lands in your history.`,
+	Example: `  # Reverse proxy in front of a local API
+  kest proxy --target http://localhost:3000 --port 8080
+
+  # Forward proxy recording HTTP and HTTPS traffic
+  kest proxy --forward --port 8080
+  HTTPS_PROXY=http://localhost:8080 SSL_CERT_FILE=~/.kest/proxy/ca.pem ./my-app
+
+  # Record a phone on the same network
+  kest proxy --forward --host 0.0.0.0 --port 8080
+
+  # Tag recordings with another environment
+  kest proxy --target https://staging.example.com --env staging`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := proxy.Options{Forward: proxyForward}
+		if proxyTarget != "" {
+			target, err := url.Parse(proxyTarget)
+			if err != nil || target.Scheme == "" || target.Host == "" {
+				return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("invalid --target %q: expected a URL such as http://localhost:3000", proxyTarget)}
+			}
+			opts.Target = target
+		}
+		if opts.Target == nil && !opts.Forward {
+			return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("nothing to proxy: use --target <url>, --forward, or both")}
+		}
+		if proxyForward && !proxyNoIntercept {
+			caDir := proxyCADir
+			if caDir == "" {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return err
+				}
+				caDir = filepath.Join(home, ".kest", "proxy")
+			}
+			ca, err := proxy.LoadOrCreateCA(caDir)
+			if err != nil {
+				return err
+			}
+			opts.CA = ca
+		}
+
+		store, err := storage.NewStore()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		conf := loadConfigWarn()
+		// Register the stored secret variables so recorded traffic is masked.
+		_, _ = store.GetVariables(conf.ProjectID, conf.ActiveEnv)
+		recorder := &proxyRecorder{store: store, conf: conf}
+		opts.Record = recorder.record
+		handler, err := proxy.New(opts)
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: err}
+		}
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(proxyHost, fmt.Sprint(proxyPort)))
+		if err != nil {
+			return err
+		}
+		addr := listener.Addr().String()
+
+		fmt.Printf("🛰️  Recording proxy listening on http://%s\n\n", addr)
+		if opts.Target != nil {
+			fmt.Printf("  Reverse:  http://%s → %s\n", addr, opts.Target)
+		}
+		if opts.Forward {
+			fmt.Printf("  Forward:  HTTP_PROXY / HTTPS_PROXY = http://%s\n", addr)
+			if opts.CA != nil {
+				fmt.Printf("  HTTPS CA: %s (trust it in your client to record HTTPS)\n", opts.CA.CertPath)
+			} else {
+				fmt.Printf("  HTTPS:    tunneled, not recorded\n")
+			}
+		}
+		if conf.ProjectID != "" || conf.ActiveEnv != "" {
+			fmt.Printf("  Tags:     project=%s env=%s\n", conf.ProjectID, conf.ActiveEnv)
+		}
+		fmt.Println()
+		fmt.Printf("💡 Press Ctrl+C to stop\n\n")
+
+		return http.Serve(listener, handler)
+	},
+}
+
+func init() {
+	proxyCmd.Flags().StringVar(&proxyTarget, "target", "", "Upstream base URL for reverse proxy mode (e.g. http://localhost:3000)")
+	proxyCmd.Flags().StringVar(&proxyHost, "host", defaultProxyHost, "Host interface to bind")
+	proxyCmd.Flags().IntVar(&proxyPort, "port", 8080, "Port to listen on")
+	proxyCmd.Flags().BoolVar(&proxyForward, "forward", false, "Also act as a forward HTTP(S) proxy for any host")
+	proxyCmd.Flags().BoolVar(&proxyNoIntercept, "no-intercept", false, "Tunnel HTTPS in forward mode instead of recording it")
+	proxyCmd.Flags().StringVar(&proxyCADir, "ca-dir", "", "Directory of the generated HTTPS CA (default ~/.kest/proxy)")
+	proxyCmd.Flags().StringVarP(&runEnv, "env", "e", "", "Environment to tag recordings with (default: active environment)")
+	rootCmd.AddCommand(proxyCmd)
+}
+
+// proxyRecorder saves proxied exchanges as history records.
+type proxyRecorder struct {
+	mu    sync.Mutex
+	store *storage.Store
+	conf  *config.Config
+}
+
+func (p *proxyRecorder) record(ex proxy.Exchange) {
+	record := proxyHistoryRecord(ex, p.conf)
+
+	p.mu.Lock()
+	id, err := p.store.SaveRecord(record)
+	if err == nil && id > 0 {
+		record.ID = id
+		if err := platformsync.QueueRequestHistory(p.conf, p.store, record, "proxy"); err != nil {
+			logger.LogToSession("history auto-sync enqueue failed for record %d: %v", id, err)
+		} else {
+			platformsync.MaybeFlushHistoryOutbox(p.conf, p.store, 5)
+		}
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to record %s %s: %v\n", ex.Method, ex.URL, err)
+		return
+	}
+	if QuietMode {
+		return
+	}
+	line := fmt.Sprintf("  ⇄ %s %s → %d  (%dms, #%d)", ex.Method, record.URL, ex.Status, ex.Duration.Milliseconds(), id)
+	if ex.Err != nil {
+		line += "  " + ex.Err.Error()
+	}
+	fmt.Println(line)
+}
+
+// proxyHistoryRecord converts an exchange into a history record shaped like
+// the ones kest writes for its own requests.
+func proxyHistoryRecord(ex proxy.Exchange, conf *config.Config) *storage.Record {
+	upstream := ex.URL
+	if upstream == nil {
+		upstream = &url.URL{}
+	}
+	headers := make(map[string]string, len(ex.RequestHeaders))
+	for name, values := range ex.RequestHeaders {
+		headers[name] = strings.Join(values, ", ")
+	}
+	headerJSON, _ := json.Marshal(headers)
+	respHeaderJSON, _ := json.Marshal(ex.ResponseHeaders)
+	queryJSON, _ := json.Marshal(upstream.Query())
+
+	return &storage.Record{
+		Method:          strings.ToUpper(ex.Method),
+		URL:             upstream.String(),
+		BaseURL:         upstream.Scheme + "://" + upstream.Host,
+		Path:            upstream.Path,
+		QueryParams:     queryJSON,
+		RequestHeaders:  headerJSON,
+		RequestBody:     string(ex.RequestBody),
+		ResponseStatus:  ex.Status,
+		ResponseHeaders: respHeaderJSON,
+		ResponseBody:    string(ex.ResponseBody),
+		DurationMs:      ex.Duration.Milliseconds(),
+		Environment:     conf.ActiveEnv,
+		Project:         conf.ProjectID,
+		CreatedAt:       ex.Start.UTC(),
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/config"
+	"github.com/kest-labs/kest/cli/internal/proxy"
+)
+
+func TestProxyHistoryRecord(t *testing.T) {
+	upstream, _ := url.Parse("https://api.example.com/v1/users?page=2")
+	start := time.Date(2026, time.May, 1, 9, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	record := proxyHistoryRecord(proxy.Exchange{
+		Method:          "post",
+		URL:             upstream,
+		RequestHeaders:  http.Header{"Accept": {"application/json", "text/plain"}},
+		RequestBody:     []byte(`{"name":"Ann"}`),
+		Status:          201,
+		ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+		ResponseBody:    []byte(`{"id":1}`),
+		Start:           start,
+		Duration:        42 * time.Millisecond,
+	}, &config.Config{ProjectID: "shop", ActiveEnv: "staging"})
+
+	if record.Method != "POST" || record.BaseURL != "https://api.example.com" || record.Path != "/v1/users" {
+		t.Fatalf("unexpected request fields: %+v", record)
+	}
+	if record.Project != "shop" || record.Environment != "staging" || !record.CreatedAt.Equal(start) || record.CreatedAt.Location() != time.UTC {
+		t.Fatalf("unexpected tags: %+v", record)
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(record.RequestHeaders, &headers); err != nil || headers["Accept"] != "application/json, text/plain" {
+		t.Fatalf("expected flattened request headers, got %s", record.RequestHeaders)
+	}
+	var query url.Values
+	if err := json.Unmarshal(record.QueryParams, &query); err != nil || query.Get("page") != "2" {
+		t.Fatalf("expected query params, got %s", record.QueryParams)
+	}
+	if record.ResponseStatus != 201 || record.ResponseBody != `{"id":1}` || record.DurationMs != 42 {
+		t.Fatalf("unexpected response fields: %+v", record)
+	}
+}
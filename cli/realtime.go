@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/sse"
+	"github.com/kest-labs/kest/cli/internal/summary"
+	"github.com/kest-labs/kest/cli/internal/variable"
+	"github.com/kest-labs/kest/cli/internal/ws"
+)
+
+// RealtimeOptions drives a WebSocket (@type ws) or Server-Sent Events
+// (@type sse) step. The connection stays open while Script runs; the
+// messages received become the response body, a JSON array, so asserts
+// and captures can address them as [0].type, #(event=="done").data, etc.
+type RealtimeOptions struct {
+	Protocol string // "ws" or "sse"
+	Script   []RealtimeAction
+}
+
+// RealtimeAction is one [Script] line:
+//
+//	send <text>          send a text message (ws only; interpolated)
+//	expect <assertion>   wait for the next message the assertion holds for
+//	sleep <duration>     keep receiving for a while, e.g. sleep 2s
+type RealtimeAction struct {
+	Kind  string
+	Value string
+	Wait  time.Duration // sleep only
+}
+
+// parseRealtimeAction parses a [Script] line for protocol.
+func parseRealtimeAction(line, protocol string) (RealtimeAction, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+	action := RealtimeAction{Kind: strings.ToLower(kind), Value: strings.TrimSpace(value)}
+	switch action.Kind {
+	case "send":
+		if protocol != "ws" {
+			return action, fmt.Errorf("send is only supported in ws steps")
+		}
+	case "expect":
+		if action.Value == "" {
+			return action, fmt.Errorf("expect needs an assertion, e.g. expect type == ready")
+		}
+	case "sleep":
+		wait, err := time.ParseDuration(action.Value)
+		if err != nil || wait <= 0 {
+			return action, fmt.Errorf("invalid sleep duration %q", action.Value)
+		}
+		action.Wait = wait
+	default:
+		return action, fmt.Errorf("unknown script action %q; expected send, expect or sleep", kind)
+	}
+	return action, nil
+}
+
+// wsURLToHTTP maps ws:// and wss:// to the http(s) URL the handshake is
+// sent to.
+func wsURLToHTTP(rawURL string) string {
+	if rest, ok := strings.CutPrefix(rawURL, "ws://"); ok {
+		return "http://" + rest
+	}
+	if rest, ok := strings.CutPrefix(rawURL, "wss://"); ok {
+		return "https://" + rest
+	}
+	return rawURL
+}
+
+func isWebSocketURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "ws://") || strings.HasPrefix(rawURL, "wss://")
+}
+
+// realtimeSession runs the script of one ws/sse request. Each expect
+// becomes an assertion result in checks.
+type realtimeSession struct {
+	opts    *RealtimeOptions
+	vars    map[string]string
+	strict  bool
+	timeout time.Duration
+	key     string // Sec-WebSocket-Key
+	baseDir string // for schema @file expects
+
+	checks   []summary.AssertionResult
+	received []json.RawMessage
+}
+
+func newRealtimeSession(opts *RealtimeOptions, vars map[string]string, strict bool, timeout time.Duration) *realtimeSession {
+	return &realtimeSession{opts: opts, vars: vars, strict: strict, timeout: timeout, key: ws.NewKey()}
+}
+
+// prepareHeaders adds the headers the protocol needs; user headers win
+// except for the WebSocket handshake itself.
+func (s *realtimeSession) prepareHeaders(headers map[string]string) {
+	if s.opts.Protocol == "ws" {
+		for k, v := range ws.HandshakeHeaders(s.key) {
+			headers[k] = v
+		}
+		return
+	}
+	if _, ok := headers["Accept"]; !ok {
+		headers["Accept"] = "text/event-stream"
+	}
+	if _, ok := headers["Cache-Control"]; !ok {
+		headers["Cache-Control"] = "no-cache"
+	}
+}
+
+func (s *realtimeSession) noun() string {
+	if s.opts.Protocol == "ws" {
+		return "message"
+	}
+	return "event"
+}
+
+// run is the client session: it plays the script against the live
+// response and returns the received messages as a JSON array.
+func (s *realtimeSession) run(resp *http.Response) ([]byte, error) {
+	s.checks, s.received = nil, nil
+
+	var next func() (json.RawMessage, error)
+	var conn *ws.Conn
+	if s.opts.Protocol == "ws" {
+		if err := ws.CheckHandshake(resp, s.key); err != nil {
+			return nil, err
+		}
+		conn = ws.NewConn(resp.Body.(io.ReadWriteCloser), true)
+		defer conn.Close()
+		next = func() (json.RawMessage, error) {
+			msg, err := conn.ReadMessage()
+			return wsMessageJSON(msg), err
+		}
+	} else {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if resp.StatusCode >= 300 || mediaType != "text/event-stream" {
+			// Not a stream: keep the body for asserts and fail the expects.
+			body, err := io.ReadAll(resp.Body)
+			if first := s.firstExpect(); first != nil {
+				s.checks = append(s.checks, summary.AssertionResult{
+					Expression: "expect " + first.Value,
+					Message:    fmt.Sprintf("no event stream: status %d, Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type")),
+				})
+			}
+			return body, err
+		}
+		reader := sse.NewReader(resp.Body)
+		next = func() (json.RawMessage, error) {
+			ev, err := reader.Next()
+			return sseEventJSON(ev), err
+		}
+	}
+
+	incoming := make(chan json.RawMessage)
+	ended := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			msg, err := next()
+			if err != nil {
+				ended <- err
+				return
+			}
+			select {
+			case incoming <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	deadline := time.NewTimer(s.timeout)
+	defer deadline.Stop()
+	var stopped string // why no more messages will come
+	// receive waits for one message; it reports false when the stream has
+	// ended, the step timed out, or until fired first.
+	receive := func(until <-chan time.Time) bool {
+		if stopped != "" {
+			return false
+		}
+		select {
+		case msg := <-incoming:
+			s.received = append(s.received, msg)
+			return true
+		case err := <-ended:
+			stopped = fmt.Sprintf("stream ended after %d %s(s)", len(s.received), s.noun())
+			if !errors.Is(err, io.EOF) {
+				stopped += ": " + err.Error()
+			}
+		case <-deadline.C:
+			stopped = fmt.Sprintf("timed out after %s with %d %s(s) received", s.timeout, len(s.received), s.noun())
+		case <-until:
+		}
+		return false
+	}
+
+	cursor := 0
+	for _, action := range s.opts.Script {
+		switch action.Kind {
+		case "send":
+			text, err := s.interpolate(action.Value)
+			if err != nil {
+				return nil, err
+			}
+			if err := conn.WriteText([]byte(text)); err != nil {
+				return nil, fmt.Errorf("websocket send failed: %w", err)
+			}
+		case "sleep":
+			timer := time.NewTimer(action.Wait)
+			for receive(timer.C) {
+			}
+			timer.Stop()
+		case "expect":
+			check := summary.AssertionResult{Expression: "expect " + action.Value}
+			for !check.Passed {
+				for ; cursor < len(s.received) && !check.Passed; cursor++ {
+					check.Passed = s.matches(resp, s.received[cursor], action.Value)
+				}
+				if !check.Passed && !receive(nil) {
+					break
+				}
+			}
+			if !check.Passed {
+				check.Message = fmt.Sprintf("no matching %s: %s", s.noun(), stopped)
+				s.checks = append(s.checks, check)
+				return s.body(), nil
+			}
+			s.checks = append(s.checks, check)
+		}
+	}
+
+	// An SSE step without a script reads the whole stream.
+	if s.opts.Protocol == "sse" && len(s.opts.Script) == 0 {
+		for receive(nil) {
+		}
+		if !strings.HasPrefix(stopped, "stream ended") {
+			s.checks = append(s.checks, summary.AssertionResult{
+				Expression: "stream ends",
+				Message:    stopped + "; add expect or sleep lines to stop earlier",
+			})
+		}
+	}
+	return s.body(), nil
+}
+
+func (s *realtimeSession) firstExpect() *RealtimeAction {
+	for i := range s.opts.Script {
+		if s.opts.Script[i].Kind == "expect" {
+			return &s.opts.Script[i]
+		}
+	}
+	return nil
+}
+
+// matches evaluates an expect assertion with msg as the response body.
+func (s *realtimeSession) matches(resp *http.Response, msg json.RawMessage, assertion string) bool {
+	check := variable.EvaluateAssertion(variable.Response{
+		Status:  resp.StatusCode,
+		Headers: resp.Header,
+		Body:    msg,
+		BaseDir: s.baseDir,
+	}, s.vars, assertion)
+	return check.Passed
+}
+
+func (s *realtimeSession) interpolate(text string) (string, error) {
+	if s.strict {
+		return variable.InterpolateStrict(text, s.vars)
+	}
+	return variable.Interpolate(text, s.vars), nil
+}
+
+func (s *realtimeSession) body() []byte {
+	if s.received == nil {
+		return []byte("[]")
+	}
+	body, _ := json.Marshal(s.received)
+	return body
+}
+
+// wsMessageJSON is a message as it appears in the body: JSON text as is,
+// other text as a string and binary data base64-encoded.
+func wsMessageJSON(msg ws.Message) json.RawMessage {
+	if msg.Binary {
+		encoded, _ := json.Marshal(base64.StdEncoding.EncodeToString(msg.Data))
+		return encoded
+	}
+	if json.Valid(msg.Data) {
+		return json.RawMessage(msg.Data)
+	}
+	encoded, _ := json.Marshal(string(msg.Data))
+	return encoded
+}
+
+// sseEventJSON is an event as it appears in the body, with data parsed
+// when it is JSON.
+func sseEventJSON(ev sse.Event) json.RawMessage {
+	data := json.RawMessage(ev.Data)
+	if !json.Valid(data) {
+		data, _ = json.Marshal(ev.Data)
+	}
+	encoded, _ := json.Marshal(struct {
+		Event string          `json:"event"`
+		ID    string          `json:"id,omitempty"`
+		Data  json.RawMessage `json:"data"`
+		Retry int             `json:"retry,omitempty"`
+	}{ev.Event, ev.ID, data, ev.Retry})
+	return encoded
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/ws"
+)
+
+// newRealtimeServer serves a WebSocket chat at /ws and event streams at
+// /sse (a finite job progress stream) and /forever (never ends).
+func newRealtimeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := ws.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteText([]byte(`{"type":"welcome"}`))
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			switch text := string(msg.Data); {
+			case text == "ping":
+				conn.WriteText([]byte("pong"))
+			case strings.HasPrefix(text, `{"subscribe"`):
+				conn.WriteText([]byte(`{"type":"subscribed","id":"sub-42"}`))
+				for i := 1; i <= 3; i++ {
+					conn.WriteText([]byte(fmt.Sprintf(`{"type":"tick","n":%d}`, i)))
+				}
+			}
+		}
+	})
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": connected\n\n")
+		for i, status := range []string{"queued", "running", "done"} {
+			fmt.Fprintf(w, "event: progress\nid: %d\ndata: {\"status\":%q}\n\n", i+1, status)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	mux.HandleFunc("/forever", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"n\":1}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebSocketFlowSteps(t *testing.T) {
+	server := newRealtimeServer(t)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	content := "```step\n@id chat\n@type ws\n" + wsURL + "\n\n[Script]\nexpect type == welcome\nsend ping\nexpect @this == pong\nsend {\"subscribe\": \"orders\"}\nexpect type == subscribed\nexpect n == 3\n\n[Captures]\nsub_id = #(type==\"subscribed\").id\n\n[Asserts]\nstatus == 101\nbody.# == 6\n```\n\n" +
+		"```step\n@id uses-capture\n@type ws\n" + wsURL + "?sub={{sub_id}}\n[Script]\nexpect type == welcome\n```\n\n" +
+		"```step\n@id never\n@type ws\n@timeout 300ms\n@on-fail continue\n" + wsURL + "\n[Script]\nexpect type == goodbye\n```\n"
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "chat:pass,uses-capture:pass,never:fail" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestSSEFlowSteps(t *testing.T) {
+	server := newRealtimeServer(t)
+	content := "```step\n@id job\n@type sse\n" + server.URL + "/sse\n\n[Script]\nexpect event == progress\nexpect data.status == done\n\n[Captures]\nlast_id = #(data.status==\"done\").id\n\n[Asserts]\nbody.0.data.status == queued\n```\n\n" +
+		"```step\n@id whole-stream\n@type sse\nGET " + server.URL + "/sse\n[Asserts]\nbody.# == 4\nbody.3.data == [DONE]\nbody.2.id == {{last_id}}\n```\n\n" +
+		"```step\n@id open-stream\n@type sse\n@timeout 300ms\n@on-fail continue\n" + server.URL + "/forever\n```\n\n" +
+		"```step\n@id collect\n@type sse\n" + server.URL + "/forever\n[Script]\nsleep 100ms\n[Asserts]\nbody.0.data.n == 1\n```\n"
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "job:pass,whole-stream:pass,open-stream:fail,collect:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestRealtimeExpectFailureReportsReceivedMessages(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := newRealtimeServer(t)
+
+	start := time.Now()
+	res, err := ExecuteRequest(RequestOptions{
+		URL:          server.URL + "/sse",
+		SilentOutput: true,
+		NoRecord:     true,
+		TimeoutMs:    2000,
+		Realtime: &RealtimeOptions{Protocol: "sse", Script: []RealtimeAction{
+			{Kind: "expect", Value: "data.status == failed"},
+		}},
+	})
+	if err == nil || res.FailedAssertion != "expect data.status == failed" {
+		t.Fatalf("expected the expect to fail, got %v (%q)", err, res.FailedAssertion)
+	}
+	if msg := res.Assertions[0].Message; msg != "no matching event: stream ended after 4 event(s)" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	if time.Since(start) > time.Second || !strings.Contains(res.ResponseBody, `"status":"done"`) {
+		t.Fatalf("the received events should be kept as the body: %s", res.ResponseBody)
+	}
+}
+
+func TestParseRealtimeAction(t *testing.T) {
+	if a, err := parseRealtimeAction("sleep 250ms", "sse"); err != nil || a.Wait != 250*time.Millisecond {
+		t.Fatalf("sleep: %+v, %v", a, err)
+	}
+	for _, line := range []string{"send hi", "expect", "sleep soon", "recv x"} {
+		if _, err := parseRealtimeAction(line, "sse"); err == nil {
+			t.Errorf("%q should be rejected in an sse step", line)
+		}
+	}
+}
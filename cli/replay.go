@@ -106,7 +106,7 @@ var replayCmd = &cobra.Command{
 			fmt.Println("\nAssertions:")
 			allPassed := true
 			for _, assertion := range replayAsserts {
-				passed, msg := variable.Assert(resp.Status, resp.Body, resp.Duration.Milliseconds(), vars, assertion)
+				passed, msg := variable.AssertResponse(variable.Response{Status: resp.Status, Headers: resp.Headers, Body: resp.Body, DurationMs: resp.Duration.Milliseconds()}, vars, assertion)
 				if passed {
 					fmt.Printf("  ✅ %s\n", assertion)
 				} else {
@@ -13,10 +13,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kest-labs/kest/cli/internal/auth"
 	"github.com/kest-labs/kest/cli/internal/client"
+	"github.com/kest-labs/kest/cli/internal/config"
 	"github.com/kest-labs/kest/cli/internal/logger"
 	"github.com/kest-labs/kest/cli/internal/output"
 	"github.com/kest-labs/kest/cli/internal/platformsync"
+	"github.com/kest-labs/kest/cli/internal/secret"
 	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/kest-labs/kest/cli/internal/summary"
 	"github.com/kest-labs/kest/cli/internal/variable"
@@ -37,13 +40,24 @@ type RequestOptions struct {
 	DebugVars       bool
 	Stream          bool
 	NoRecord        bool
-	MaxDuration     int      // Max response time in milliseconds (--max-time)
-	Retry           int      // Number of retries (0 = no retry)
-	RetryWait       int      // Delay between retries in milliseconds (--retry-delay)
-	StrictVars      bool     // Fail early when required variables are missing
-	SilentOutput    bool     // Suppress PrintResponse box (used by flow runner)
-	Forms           []string // -F/--form fields: "fieldname=value" or "fieldname=@filepath"
-	SkipHistorySync bool     // Skip platform history sync (used by aggregate run commands)
+	MaxDuration     int               // Max response time in milliseconds (--max-time)
+	Retry           int               // Number of retries (0 = no retry)
+	RetryWait       int               // Delay between retries in milliseconds (--retry-delay)
+	StrictVars      bool              // Fail early when required variables are missing
+	SilentOutput    bool              // Suppress PrintResponse box (used by flow runner)
+	Forms           []string          // -F/--form fields: "fieldname=value" or "fieldname=@filepath"
+	SkipHistorySync bool              // Skip platform history sync (used by aggregate run commands)
+	RunCtx          *RunContext       // Run-scoped variables; falls back to ActiveRunCtx when nil
+	NoCookies       bool              // Neither send nor store cookies (step-level @cookies off)
+	SaveCookies     bool              // Ad-hoc requests: replay and update the cookies saved for the environment (--save-cookies)
+	DefaultHeaders  map[string]string // Flow-level @header defaults, applied before Headers
+	BaseURL         string            // Flow-level @base-url; overrides the environment's base_url
+	TimeoutMs       int               // HTTP timeout in milliseconds (@timeout); unlike MaxDuration it is not an assertion
+	Auth            string            // --auth / @auth spec, e.g. "bearer {{token}}"; see internal/auth
+	GraphQL         *GraphQLOptions   // GraphQL operation (kest gql, @type graphql); replaces Data
+	Realtime        *RealtimeOptions  // WebSocket / SSE script (@type ws, @type sse)
+	GRPC            *GRPCOptions      // gRPC call (kest grpc, @type grpc); URL is the service/method
+	BaseDir         string            // Directory of the flow file; relative schema @file assertions resolve against it
 }
 
 var (
@@ -61,6 +75,8 @@ var (
 	reqVars        []string
 	reqDebugVars   bool
 	reqForms       []string // -F/--form fields: "fieldname=value" or "fieldname=@filepath"
+	reqSaveCookies bool
+	reqAuth        string
 )
 
 func init() {
@@ -84,20 +100,16 @@ func createRequestCmd(method string) *cobra.Command {
   kest %[1]s /api/login -H "Content-Type: application/json" -d '{"user":"admin"}'
 
   # %[1]s with query parameters and assertions
-  kest %[1]s /search -q "q=kest" -a "status=200" -a "body.results exists"`, method),
+  kest %[1]s /search -q "q=kest" -a "status=200" -a "body.results exists"
+
+  # %[1]s with built-in auth
+  kest %[1]s /api/me --auth "bearer {{token}}"`, method),
 		Args:         cobra.ExactArgs(1),
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create a temporary RunContext for ad-hoc --var flags
 			if len(reqVars) > 0 {
-				cliVars := make(map[string]string, len(reqVars))
-				for _, v := range reqVars {
-					parts := strings.SplitN(v, "=", 2)
-					if len(parts) == 2 {
-						cliVars[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-					}
-				}
-				ActiveRunCtx = NewRunContext(cliVars)
+				ActiveRunCtx = NewRunContext(parseVarFlags(reqVars))
 				defer func() { ActiveRunCtx = nil }()
 			}
 
@@ -117,13 +129,15 @@ func createRequestCmd(method string) *cobra.Command {
 				Retry:       reqRetry,
 				RetryWait:   reqRetryWait,
 				Forms:       reqForms,
+				SaveCookies: reqSaveCookies,
+				Auth:        reqAuth,
 			})
 			return err
 		},
 	}
 
 	cmd.Flags().StringVarP(&reqData, "data", "d", "", "Request body data")
-	cmd.Flags().StringSliceVarP(&reqHeaders, "header", "H", []string{}, "Request headers")
+	cmd.Flags().StringArrayVarP(&reqHeaders, "header", "H", []string{}, "Request headers")
 	cmd.Flags().StringSliceVarP(&reqQueries, "query", "q", []string{}, "Query parameters")
 	cmd.Flags().StringSliceVarP(&reqCaptures, "capture", "c", []string{}, "Capture values from response (e.g. token=$.auth.token)")
 	cmd.Flags().StringSliceVarP(&reqAsserts, "assert", "a", []string{}, "Assert response (e.g. status=200, body.id=1)")
@@ -136,11 +150,28 @@ func createRequestCmd(method string) *cobra.Command {
 	cmd.Flags().StringArrayVar(&reqVars, "var", []string{}, "Set variables (e.g. --var key=value)")
 	cmd.Flags().BoolVar(&reqDebugVars, "debug-vars", false, "Show variable resolution details")
 	cmd.Flags().StringArrayVarP(&reqForms, "form", "F", []string{}, "Multipart form field (e.g. -F file=@/path/to/file -F name=test)")
+	cmd.Flags().BoolVar(&reqSaveCookies, "save-cookies", false, "Send the cookies saved for the active environment and save the ones the response sets")
+	cmd.Flags().StringVar(&reqAuth, "auth", "", `Authenticate the request: "basic user:pass", "bearer TOKEN", "api-key NAME VALUE", "oauth2 client_credentials token_url=... client_id=...", "hmac secret=..." or "aws-sigv4 REGION SERVICE"`)
 
 	return cmd
 }
 
+// printAssertionDiff prints the JSON diff of a failed object/array
+// comparison, one line per difference.
+func printAssertionDiff(check summary.AssertionResult, indent string) {
+	if check.Diff == "" {
+		return
+	}
+	fmt.Printf("%sDiff (- expected, + actual):\n", indent)
+	for _, line := range strings.Split(secret.Mask(check.Diff), "\n") {
+		fmt.Printf("%s  %s\n", indent, line)
+	}
+}
+
 func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
+	if opts.GRPC != nil {
+		return executeGRPCRequest(opts)
+	}
 	startTime := time.Now()
 	result := summary.TestResult{
 		StartTime: startTime,
@@ -148,14 +179,27 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 		URL:       opts.URL,
 	}
 	method := opts.Method
+	if method == "" && opts.GraphQL != nil {
+		method = "POST"
+		result.Method = method
+	}
+	if method == "" && opts.Realtime != nil {
+		method = "GET"
+		result.Method = method
+	}
 	targetURL := opts.URL
+	rc := opts.RunCtx
+	if rc == nil {
+		rc = ActiveRunCtx
+	}
+	if rc != nil && rc.NoRecord {
+		opts.NoRecord = true
+	}
 
-	conf := loadConfigWarn()
+	conf := loadRunConfig(rc)
 	env := conf.GetActiveEnv()
-	store, _ := storage.NewStore()
-	if store != nil {
-		defer store.Close()
-	}
+	store, releaseStore := openRunStore(rc)
+	defer releaseStore()
 
 	// Load variables - merge config environment variables with runtime captured variables
 	vars := make(map[string]string)
@@ -176,11 +220,12 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 	}
 
 	// Finally, apply run context vars (CLI --var + exec captures, highest priority)
-	if ActiveRunCtx != nil {
-		for k, v := range ActiveRunCtx.All() {
+	if rc != nil {
+		for k, v := range rc.All() {
 			vars[k] = v
 		}
 	}
+	secret.TrackAll(vars)
 
 	// Debug: Show variable resolution if requested
 	if opts.DebugVars && len(vars) > 0 {
@@ -194,8 +239,8 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 
 		for k, v := range vars {
 			source := "config"
-			if ActiveRunCtx != nil {
-				if _, exists := ActiveRunCtx.Get(k); exists {
+			if rc != nil {
+				if _, exists := rc.Get(k); exists {
 					source = "cli --var"
 				}
 			}
@@ -210,9 +255,9 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 			}
 
 			// Truncate long values
-			displayValue := v
-			if len(v) > 50 {
-				displayValue = v[:50] + "..."
+			displayValue := maskedValue(k, v)
+			if len(displayValue) > 50 {
+				displayValue = displayValue[:50] + "..."
 			}
 			fmt.Printf("  {{%s}} → \"%s\" (from: %s)\n", k, displayValue, source)
 		}
@@ -221,8 +266,12 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 
 	// Handle base URL
 	processedURL := targetURL
-	if !strings.HasPrefix(targetURL, "http") && env.BaseURL != "" {
-		processedURL = strings.TrimSuffix(env.BaseURL, "/") + "/" + strings.TrimPrefix(targetURL, "/")
+	baseURL := env.BaseURL
+	if opts.BaseURL != "" {
+		baseURL = opts.BaseURL
+	}
+	if !strings.HasPrefix(targetURL, "http") && !isWebSocketURL(targetURL) && baseURL != "" {
+		processedURL = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(targetURL, "/")
 	}
 
 	// Interpolate URL with warnings in verbose mode
@@ -286,6 +335,21 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 			headers[canonicalKey] = variable.Interpolate(v, vars)
 		}
 	}
+	// Flow-level @header defaults, interpolated per request so tokens
+	// captured by earlier steps are picked up
+	for k, v := range opts.DefaultHeaders {
+		processedValue := variable.Interpolate(v, vars)
+		if opts.StrictVars {
+			strictValue, err := variable.InterpolateStrict(v, vars)
+			if err != nil {
+				result.Error = err
+				result.Success = false
+				return result, &ExitError{Code: ExitRuntimeError, Err: err}
+			}
+			processedValue = strictValue
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(k))] = processedValue
+	}
 	// Command line headers (override config headers if same key)
 	for _, h := range opts.Headers {
 		processedHeader := variable.Interpolate(h, vars)
@@ -331,6 +395,42 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 		}
 	}
 
+	if opts.GraphQL != nil {
+		gql, err := opts.GraphQL.request(vars, opts.StrictVars)
+		if err != nil {
+			result.Error = err
+			result.Success = false
+			return result, &ExitError{Code: ExitRuntimeError, Err: err}
+		}
+		if strings.EqualFold(method, http.MethodGet) {
+			if finalURL, err = gql.AddToURL(finalURL); err != nil {
+				result.Error = err
+				result.Success = false
+				return result, err
+			}
+			result.URL = finalURL
+		} else {
+			body = gql.Body()
+			if _, ok := headers["Content-Type"]; !ok {
+				headers["Content-Type"] = "application/json"
+			}
+		}
+		if _, ok := headers["Accept"]; !ok {
+			headers["Accept"] = "application/graphql-response+json, application/json"
+		}
+	}
+
+	var realtime *realtimeSession
+	if opts.Realtime != nil {
+		timeout := 30 * time.Second
+		if opts.TimeoutMs > 0 {
+			timeout = time.Duration(opts.TimeoutMs) * time.Millisecond
+		}
+		realtime = newRealtimeSession(opts.Realtime, vars, opts.StrictVars, timeout)
+		realtime.baseDir = opts.BaseDir
+		realtime.prepareHeaders(headers)
+	}
+
 	// Handle multipart form data (-F flag)
 	if len(opts.Forms) > 0 {
 		var buf bytes.Buffer
@@ -377,6 +477,75 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 	result.RequestHeaders = cloneStringMap(headers)
 	result.RequestBody = string(body)
 
+	// Built-in auth (--auth / @auth) is resolved once and applied to every
+	// attempt, so signatures and timestamps are fresh on retries.
+	var authConfig *auth.Config
+	var tokens auth.TokenStore
+	if store != nil {
+		tokens = store
+	}
+	if strings.TrimSpace(opts.Auth) != "" {
+		var resolveErr error
+		resolve := func(value string) string {
+			if !opts.StrictVars {
+				return variable.Interpolate(value, vars)
+			}
+			resolved, err := variable.InterpolateStrict(value, vars)
+			if err != nil && resolveErr == nil {
+				resolveErr = err
+			}
+			return resolved
+		}
+		parsed, err := auth.Parse(opts.Auth, resolve)
+		if err == nil {
+			err = resolveErr
+		}
+		if err != nil {
+			result.Error = fmt.Errorf("invalid auth: %w", err)
+			result.Success = false
+			return result, &ExitError{Code: ExitConfigError, Err: result.Error}
+		}
+		authConfig = parsed
+	}
+	applyAuth := func() error {
+		if authConfig == nil {
+			return nil
+		}
+		u, err := url.Parse(finalURL)
+		if err != nil {
+			return err
+		}
+		authReq := &auth.Request{Method: method, URL: u, Headers: headers, Body: body}
+		if err := authConfig.Apply(authReq, tokens); err != nil {
+			return err
+		}
+		finalURL = u.String()
+		result.RequestHeaders = cloneStringMap(headers)
+		return nil
+	}
+
+	// Flow and run files share one cookie jar per RunContext. Ad-hoc
+	// requests have none; with --save-cookies they replay and update the
+	// cookies saved for the active environment instead.
+	var jar *client.Jar
+	persistCookies := false
+	if !opts.NoCookies {
+		if opts.RunCtx != nil {
+			if !opts.RunCtx.CookiesOff {
+				jar = opts.RunCtx.CookieJar()
+			}
+		} else if opts.SaveCookies && store != nil {
+			jar = client.NewJar()
+			if err := loadSavedCookies(store, conf.ProjectID, conf.ActiveEnv, jar); err == nil {
+				persistCookies = true
+			}
+		}
+	}
+	var cookieJar http.CookieJar
+	if jar != nil {
+		cookieJar = jar
+	}
+
 	// Execute request with retry logic
 	var resp *client.Response
 	var err error
@@ -392,17 +561,38 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 		}
 
 		httpTimeout := 30 * time.Second
+		if opts.TimeoutMs > 0 {
+			httpTimeout = time.Duration(opts.TimeoutMs) * time.Millisecond
+		}
 		if opts.MaxDuration > 0 {
 			httpTimeout = time.Duration(opts.MaxDuration) * time.Millisecond
 		}
-		resp, err = client.Execute(client.RequestOptions{
-			Method:  strings.ToUpper(method),
-			URL:     finalURL,
-			Headers: headers,
-			Body:    body,
-			Timeout: httpTimeout,
-			Stream:  opts.Stream,
-		})
+		var session func(*http.Response) ([]byte, error)
+		if realtime != nil {
+			session = realtime.run
+		}
+		send := func() (*client.Response, error) {
+			if err := applyAuth(); err != nil {
+				return nil, fmt.Errorf("auth failed: %w", err)
+			}
+			return client.Execute(client.RequestOptions{
+				Method:  strings.ToUpper(method),
+				URL:     wsURLToHTTP(finalURL),
+				Headers: headers,
+				Body:    body,
+				Timeout: httpTimeout,
+				Stream:  opts.Stream,
+				Jar:     cookieJar,
+				Session: session,
+			})
+		}
+		resp, err = send()
+		// A rejected OAuth2 token may have been revoked early: fetch a new
+		// one and try once more.
+		if err == nil && resp.Status == http.StatusUnauthorized && authConfig != nil && authConfig.Refreshable() {
+			authConfig.Invalidate(tokens)
+			resp, err = send()
+		}
 
 		// Check duration assertion
 		if err == nil && opts.MaxDuration > 0 {
@@ -429,120 +619,77 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 		}
 	}
 
+	if persistCookies {
+		if err := saveCookies(store, conf.ProjectID, conf.ActiveEnv, jar); err != nil {
+			fmt.Printf("⚠️  Failed to save cookies: %v\n", err)
+		}
+	}
+
+	result.Status = resp.Status
+	result.Duration = resp.Duration
+	result.ResponseHeaders = cloneHeaderMap(resp.Headers)
+	result.ResponseBody = string(resp.Body)
+	result.RequestID = extractRequestID(resp.Headers, resp.Body)
+
+	// Resolve captures before anything is logged or printed, so a secret
+	// captured from this very response (e.g. a login token) is masked too.
+	var captured []capturedValue
+	if store != nil {
+		captured = resolveCaptures(opts.Captures, result)
+	}
+
 	// Logging
-	logger.LogRequest(method, finalURL, headers, string(body), resp.Status, resp.Headers, string(resp.Body), resp.Duration)
+	if rc == nil || !rc.NoRecord {
+		logger.LogRequest(method, finalURL, headers, string(body), resp.Status, resp.Headers, string(resp.Body), resp.Duration)
+	}
 
 	if opts.Verbose || resp.Status >= 400 {
 		fmt.Printf("\n--- Debug Info ---\n")
 		fmt.Printf("Note: Headers are canonicalized per HTTP spec (e.g. X-Tenant-ID => X-Tenant-Id).\n")
-		fmt.Printf("Request: %s %s\n", method, finalURL)
+		fmt.Printf("Request: %s %s\n", method, secret.Mask(finalURL))
 		fmt.Printf("Request Headers:\n")
 		for k, v := range headers {
-			fmt.Printf("  %s: %s\n", k, v)
+			fmt.Printf("  %s: %s\n", k, secret.Mask(v))
 		}
 		if len(body) > 0 {
-			fmt.Printf("Request Body: %s\n", string(body))
+			fmt.Printf("Request Body: %s\n", secret.Mask(string(body)))
 		}
 		fmt.Printf("\nResponse Status: %d\n", resp.Status)
 		fmt.Printf("Response Headers:\n")
-		for k, v := range resp.Headers {
+		for k, v := range secret.MaskHeaders(resp.Headers) {
 			fmt.Printf("  %s: %s\n", k, v)
 		}
 	}
 
-	result.Status = resp.Status
-	result.Duration = resp.Duration
-	result.ResponseHeaders = cloneHeaderMap(resp.Headers)
-	result.ResponseBody = string(resp.Body)
-	result.RequestID = extractRequestID(resp.Headers, resp.Body)
-
 	var recordID int64
 
-	// Handle captures
-	if store != nil && len(opts.Captures) > 0 {
-		for _, capExpr := range opts.Captures {
-			// Support both ":" and "="
-			sep := "="
-			if !strings.Contains(capExpr, "=") && strings.Contains(capExpr, ":") {
-				sep = ":"
-			}
-			parts := strings.SplitN(capExpr, sep, 2)
-			if len(parts) == 2 {
-				varName := strings.TrimSpace(parts[0])
-				query := NormalizeJSONPath(strings.TrimSpace(parts[1]))
-
-				// Currently only supporting JSON body capture via gjson
-				captureResult := gjson.Get(string(resp.Body), query)
-				if captureResult.Exists() {
-					store.SaveVariable(&storage.Variable{
-						Name:        varName,
-						Value:       captureResult.String(),
-						Environment: conf.ActiveEnv,
-						Project:     conf.ProjectID,
-					})
-					if result.Captures == nil {
-						result.Captures = make(map[string]string)
-					}
-					result.Captures[varName] = captureResult.String()
-					fmt.Printf("Captured: %s = %s\n", varName, captureResult.String())
-					logger.LogToSession("Captured: %s = %s", varName, captureResult.String())
-				}
-			}
-		}
-	}
-
-	// Handle assertions
-	if len(opts.Asserts) > 0 {
-		fmt.Println("\nAssertions:")
-		allPassed := true
-		var firstErr string
-		for _, assertion := range opts.Asserts {
-			passed, msg := variable.Assert(resp.Status, resp.Body, resp.Duration.Milliseconds(), vars, assertion)
-			if passed {
-				fmt.Printf("  ✅ %s\n", assertion)
-				logger.LogToSession("Assertion Passed: %s", assertion)
-			} else {
-				fmt.Printf("  ❌ Assertion Failed: %s\n", assertion)
-				fmt.Printf("     %s\n", strings.ReplaceAll(msg, "\n", "\n     "))
-
-				// Show response body snippet on failure for context
-				if len(resp.Body) > 0 && len(resp.Body) < 500 {
-					fmt.Printf("\n     Response Body:\n")
-					fmt.Printf("     %s\n", string(resp.Body))
-				} else if len(resp.Body) >= 500 {
-					fmt.Printf("\n     Response Body (truncated):\n")
-					fmt.Printf("     %s...\n", string(resp.Body[:500]))
-				}
+	applyCaptures(&result, captured, store, conf, rc == nil || !rc.NoRecord)
 
-				logger.LogToSession("Assertion Failed: %s (%s)", assertion, msg)
-				if firstErr == "" {
-					firstErr = fmt.Sprintf("assertion failed: %s (%s)", assertion, msg)
-					result.FailedAssertion = assertion
-				}
-				allPassed = false
-			}
-		}
-
-		result.Success = allPassed
-		if !allPassed {
-			result.Error = fmt.Errorf("%s", firstErr)
-			return result, &ExitError{Code: ExitAssertionFailed, Err: result.Error}
-		}
+	assertResponse := variable.Response{
+		Status:     resp.Status,
+		Headers:    resp.Headers,
+		Body:       resp.Body,
+		DurationMs: resp.Duration.Milliseconds(),
+		BaseDir:    opts.BaseDir,
 	}
 
-	if len(opts.SoftAsserts) > 0 {
-		fmt.Println("\nSoft Assertions:")
-		for _, assertion := range opts.SoftAsserts {
-			passed, msg := variable.Assert(resp.Status, resp.Body, resp.Duration.Milliseconds(), vars, assertion)
-			if passed {
-				fmt.Printf("  ✅ %s\n", assertion)
-				continue
-			}
-			fmt.Printf("  ⚠️  Soft assertion failed: %s\n", assertion)
-			fmt.Printf("     %s\n", strings.ReplaceAll(msg, "\n", "\n     "))
-			logger.LogToSession("Soft Assertion Failed: %s (%s)", assertion, msg)
-		}
+	// Handle assertions. GraphQL servers report failures in an "errors"
+	// array, usually with status 200, so that is checked first; so are the
+	// expect lines of a ws/sse script.
+	var checks []summary.AssertionResult
+	if opts.GraphQL != nil && !opts.GraphQL.AllowErrors {
+		checks = append(checks, graphQLErrorsCheck(resp.Body))
+	}
+	if realtime != nil {
+		checks = append(checks, realtime.checks...)
+	}
+	for _, assertion := range opts.Asserts {
+		checks = append(checks, variable.EvaluateAssertion(assertResponse, vars, assertion))
 	}
+	if err := reportChecks(&result, checks, resp.Body); err != nil {
+		return result, err
+	}
+	reportSoftAsserts(&result, assertResponse, vars, opts.SoftAsserts)
 
 	if !opts.NoRecord && store != nil {
 		headerJSON, _ := json.Marshal(headers)
@@ -587,6 +734,122 @@ func ExecuteRequest(opts RequestOptions) (summary.TestResult, error) {
 	return result, nil
 }
 
+type capturedValue struct{ name, value string }
+
+// resolveCaptures evaluates capture expressions against a response and
+// tracks the values as secrets where their names say so.
+func resolveCaptures(exprs []string, res summary.TestResult) []capturedValue {
+	var captured []capturedValue
+	for _, capExpr := range exprs {
+		varName, query, ok := ParseCaptureExpr(capExpr)
+		if !ok {
+			continue
+		}
+		value, found, capErr := ResolveResponseCapture(query, res)
+		if capErr != nil {
+			fmt.Printf("⚠️  Capture %s: %v\n", varName, capErr)
+			continue
+		}
+		if !found {
+			continue
+		}
+		secret.Track(varName, value)
+		captured = append(captured, capturedValue{varName, value})
+	}
+	return captured
+}
+
+// applyCaptures records captured values on the result, prints them and,
+// when save is set, stores them for the active environment.
+func applyCaptures(result *summary.TestResult, captured []capturedValue, store *storage.Store, conf *config.Config, save bool) {
+	for _, c := range captured {
+		if save && store != nil {
+			store.SaveVariable(&storage.Variable{
+				Name:        c.name,
+				Value:       c.value,
+				Environment: conf.ActiveEnv,
+				Project:     conf.ProjectID,
+			})
+		}
+		if result.Captures == nil {
+			result.Captures = make(map[string]string)
+		}
+		result.Captures[c.name] = c.value
+		fmt.Printf("Captured: %s = %s\n", c.name, maskedValue(c.name, c.value))
+		logger.LogToSession("Captured: %s = %s", c.name, c.value)
+	}
+}
+
+// reportChecks prints assertion results, records them on the result and
+// returns an assertion error for the first failure.
+func reportChecks(result *summary.TestResult, checks []summary.AssertionResult, body []byte) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	fmt.Println("\nAssertions:")
+	allPassed := true
+	var firstErr string
+	for _, check := range checks {
+		assertion := check.Expression
+		result.Assertions = append(result.Assertions, check)
+		passed, msg := check.Passed, check.Message
+		if passed {
+			fmt.Printf("  ✅ %s\n", assertion)
+			logger.LogToSession("Assertion Passed: %s", assertion)
+		} else {
+			fmt.Printf("  ❌ Assertion Failed: %s\n", assertion)
+			fmt.Printf("     %s\n", strings.ReplaceAll(secret.Mask(msg), "\n", "\n     "))
+			printAssertionDiff(check, "     ")
+
+			// Show response body snippet on failure for context
+			if shown := secret.MaskBytes(body); len(shown) > 0 && len(shown) < 500 {
+				fmt.Printf("\n     Response Body:\n")
+				fmt.Printf("     %s\n", string(shown))
+			} else if len(shown) >= 500 {
+				fmt.Printf("\n     Response Body (truncated):\n")
+				fmt.Printf("     %s...\n", string(shown[:500]))
+			}
+
+			logger.LogToSession("Assertion Failed: %s (%s)", assertion, msg)
+			if firstErr == "" {
+				firstErr = fmt.Sprintf("assertion failed: %s (%s)", assertion, msg)
+				result.FailedAssertion = assertion
+			}
+			allPassed = false
+		}
+	}
+
+	result.Success = allPassed
+	if !allPassed {
+		result.Error = fmt.Errorf("%s", firstErr)
+		return &ExitError{Code: ExitAssertionFailed, Err: result.Error}
+	}
+	return nil
+}
+
+// reportSoftAsserts evaluates and prints soft assertions; their failures
+// are recorded but never fail the request.
+func reportSoftAsserts(result *summary.TestResult, resp variable.Response, vars map[string]string, asserts []string) {
+	if len(asserts) == 0 {
+		return
+	}
+	fmt.Println("\nSoft Assertions:")
+	for _, assertion := range asserts {
+		check := variable.EvaluateAssertion(resp, vars, assertion)
+		check.Soft = true
+		result.Assertions = append(result.Assertions, check)
+		passed, msg := check.Passed, check.Message
+		if passed {
+			fmt.Printf("  ✅ %s\n", assertion)
+			continue
+		}
+		fmt.Printf("  ⚠️  Soft assertion failed: %s\n", assertion)
+		fmt.Printf("     %s\n", strings.ReplaceAll(secret.Mask(msg), "\n", "\n     "))
+		printAssertionDiff(check, "     ")
+		logger.LogToSession("Soft Assertion Failed: %s (%s)", assertion, msg)
+	}
+}
+
 func cloneStringMap(input map[string]string) map[string]string {
 	if len(input) == 0 {
 		return nil
@@ -8,7 +8,9 @@ import (
 	"github.com/kest-labs/kest/cli/internal/logger"
 	"github.com/kest-labs/kest/cli/internal/output"
 	"github.com/kest-labs/kest/cli/internal/platformsync"
+	"github.com/kest-labs/kest/cli/internal/secret"
 	"github.com/kest-labs/kest/cli/internal/storage"
+	"github.com/kest-labs/kest/cli/internal/variable"
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +22,9 @@ var Version = "dev"
 // Global flags accessible by all commands
 var (
 	QuietMode    bool
-	OutputFormat string // "" (default pretty), "json"
+	OutputFormat string   // "" (default pretty), "json"
+	secretVars   []string // --secret-var names or globs, added to the config's secret_vars
+	randomSeed   int64    // --seed for reproducible $random* and $faker.* values
 )
 
 var rootCmd = &cobra.Command{
@@ -72,21 +76,52 @@ func loadConfigWarn() *config.Config {
 	conf, err := config.LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to load config: %v\n", err)
-		return &config.Config{}
+		conf = &config.Config{}
 	}
 	if runEnv != "" {
 		conf.ActiveEnv = runEnv
 	}
+	registerSecrets(conf)
+	return conf
+}
+
+// registerSecrets marks the configured secret variables and registers the
+// values of the active environment's secret variables for masking.
+func registerSecrets(conf *config.Config) {
+	secret.Mark(conf.SecretVars...)
+	secret.Mark(secretVars...)
+	secret.TrackAll(conf.GetActiveEnv().Variables)
+}
+
+// loadRunConfig loads config like loadConfigWarn, or copies the config the
+// run already loaded, and then applies the per-run environment override
+// carried by rc (e.g. a flow's @env).
+func loadRunConfig(rc *RunContext) *config.Config {
+	var conf *config.Config
+	if rc != nil && rc.Config != nil {
+		copied := *rc.Config
+		conf = &copied
+	} else {
+		conf = loadConfigWarn()
+	}
+	if rc != nil && rc.Env != "" && runEnv == "" {
+		conf.ActiveEnv = rc.Env
+	}
 	return conf
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&QuietMode, "quiet", false, "Suppress decorative output (for CI/CD pipelines)")
 	rootCmd.PersistentFlags().StringVar(&OutputFormat, "output", "", "Output format: json for machine-readable output")
+	rootCmd.PersistentFlags().StringArrayVar(&secretVars, "secret-var", nil, "Treat a variable (name or glob, e.g. *_key) as secret: masked in output and encrypted at rest")
+	rootCmd.PersistentFlags().Int64Var(&randomSeed, "seed", 0, "Seed $randomInt, $uuid, $faker.* and other random values so runs are reproducible")
 
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		output.Quiet = QuietMode
 		output.JSONOutput = OutputFormat == "json"
+		if cmd.Flags().Changed("seed") {
+			variable.SetSeed(randomSeed)
+		}
 
 		if shouldAutoStartBridge(cmd, os.Args[1:]) {
 			if err := ensureBridgeRunning(); err != nil {
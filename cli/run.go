@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,18 +12,20 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kest-labs/kest/cli/internal/client"
 	"github.com/kest-labs/kest/cli/internal/logger"
 	"github.com/kest-labs/kest/cli/internal/output"
 	"github.com/kest-labs/kest/cli/internal/platformsync"
 	"github.com/kest-labs/kest/cli/internal/report"
+	"github.com/kest-labs/kest/cli/internal/secret"
 	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/kest-labs/kest/cli/internal/summary"
 	"github.com/kest-labs/kest/cli/internal/variable"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/tidwall/gjson"
 )
 
 var (
@@ -37,53 +40,87 @@ var (
 	runEnv       string
 	runHTML      bool
 	runOpen      bool
+	runReportArg []string
+	runReports   []runReport
+	runTags      []string
+	runSkipTags  []string
+
+	runUpdateSnapshots bool
 )
 
 var runCmd = &cobra.Command{
-	Use:     "run [file]",
+	Use:     "run [file|dir|glob]...",
 	Aliases: []string{"r"},
-	Short:   "Run a Kest scenario file (.kest) or a Markdown flow file (.flow.md)",
+	Short:   "Run Kest scenario files (.kest) or Markdown flow files (.flow.md)",
 	Long: `Execute API test scenarios defined in .kest or .flow.md files.
-Kest Flow (.flow.md) allows you to use standard Markdown to document and test your APIs simultaneously.`,
+Kest Flow (.flow.md) allows you to use standard Markdown to document and test your APIs simultaneously.
+
+Pass a directory, a glob, or several paths to run them as one suite. Every
+.flow.md/.kest file found is executed with its own isolated variables, and
+the results are merged into a single summary grouped by file.`,
 	Example: `  # Run a single flow
   kest run login.flow.md
 
   # Inject variables from CLI
   kest run login.flow.md --var api_key=secret --var env=prod
 
-  # Run with parallel workers for speed
+  # Run every flow under tests/ as one suite, 8 files at a time
   kest run tests/ --parallel --jobs 8
 
+  # Run a glob and several paths together
+  kest run "tests/*.flow.md" smoke/health.kest
+
   # Set exec step timeout and verbose output
   kest run hmac.flow.md --exec-timeout 10 -v --debug-vars
 
+  # Run only smoke and auth steps, leaving out slow ones
+  kest run tests/ --tags smoke,auth --skip-tags slow
+
+  # Accept the current responses as the new [Snapshot] baselines
+  kest run users.flow.md --update-snapshots
+
   # Generate an HTML report
   kest run login.flow.md --html
 
   # Generate and open the HTML report in your browser
   kest run login.flow.md --open
 
+  # Write a JUnit XML report for CI and print TAP instead of the summary
+  kest run tests/ --report junit=reports/kest.xml --report tap
+
   # Run a legacy .kest scenario
   kest run auth.kest`,
-	Args:         cobra.ExactArgs(1),
+	Args:         cobra.MinimumNArgs(1),
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runScenario(args[0])
+		reports, err := parseRunReports(runReportArg)
+		if err != nil {
+			return &ExitError{Code: ExitConfigError, Err: err}
+		}
+		runReports = reports
+		if len(args) == 1 && !isSuiteTarget(args[0]) {
+			return runScenario(args[0])
+		}
+		return runSuite(args)
 	},
 }
 
 func init() {
-	runCmd.Flags().BoolVarP(&runParallel, "parallel", "p", false, "Run requests in parallel")
+	runCmd.Flags().BoolVarP(&runParallel, "parallel", "p", false, "Run requests, suite files and independent flow steps in parallel")
 	runCmd.Flags().IntVarP(&runJobs, "jobs", "j", 4, "Number of parallel jobs")
 	runCmd.Flags().BoolVarP(&runVerbose, "verbose", "v", false, "Show detailed request/response info")
 	runCmd.Flags().BoolVar(&runDebugVars, "debug-vars", false, "Show variable resolution details")
 	runCmd.Flags().StringArrayVar(&runVars, "var", []string{}, "Set variables (e.g. --var key=value)")
-	runCmd.Flags().IntVar(&execTimeout, "exec-timeout", 30, "Timeout in seconds for exec steps")
+	runCmd.Flags().IntVar(&execTimeout, "exec-timeout", 30, "Timeout in seconds for exec steps without @timeout (on HTTP steps @timeout is the request timeout)")
 	runCmd.Flags().BoolVar(&runFailFast, "fail-fast", false, "Stop execution on first failed step")
 	runCmd.Flags().BoolVar(&runStrict, "strict", false, "Enable strict variable validation (error on undefined variables)")
 	runCmd.Flags().StringVarP(&runEnv, "env", "e", "", "Override active environment for this run (e.g. staging, production)")
 	runCmd.Flags().BoolVar(&runHTML, "html", false, "Generate an HTML report after the run")
 	runCmd.Flags().BoolVar(&runOpen, "open", false, "Generate and open an HTML report after the run")
+	runCmd.Flags().StringSliceVar(&runTags, "tags", []string{}, "Only run flows and steps with one of these tags (flow @tags plus step @tags)")
+	runCmd.Flags().StringSliceVar(&runSkipTags, "skip-tags", []string{}, "Skip flows and steps with any of these tags")
+	runCmd.Flags().BoolVar(&runUpdateSnapshots, "update-snapshots", false, "Rewrite [Snapshot] files that no longer match the response")
+	runCmd.Flags().StringArrayVar(&runReportArg, "report", []string{}, "Write a report as format[=path] (junit, tap); without a path it replaces the summary on stdout")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -91,30 +128,43 @@ func runScenario(filePath string) error {
 	logger.StartSession(filepath.Base(filePath))
 	defer logger.EndSession()
 
-	// Parse --var flags and create a scoped RunContext
-	cliVars := make(map[string]string, len(runVars))
-	for _, v := range runVars {
-		parts := strings.SplitN(v, "=", 2)
-		if len(parts) == 2 {
-			cliVars[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-		}
+	rc := NewRunContext(parseVarFlags(runVars))
+
+	restoreOutput := func() {}
+	if machineOutput() {
+		restoreOutput = suppressStdout()
+	}
+	summ, err := executeScenarioFile(filePath, rc)
+	restoreOutput()
+	if err != nil {
+		return err
 	}
-	ActiveRunCtx = NewRunContext(cliVars)
-	defer func() { ActiveRunCtx = nil }()
 
+	return finishRun(filePath, summ)
+}
+
+// executeScenarioFile runs one .kest or .flow.md file against rc and returns
+// its results. It does not print the final summary or write reports, so the
+// same code path serves single-file runs and suites.
+func executeScenarioFile(filePath string, rc *RunContext) (*summary.Summary, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var blocks []KestBlock
 	if strings.HasSuffix(filePath, ".md") {
 		doc, legacy := ParseFlowDocument(string(content))
 		if len(doc.Steps) > 0 || len(doc.Edges) > 0 || doc.Meta.ID != "" {
-			return runFlowDocument(doc, filePath)
+			return runFlowDocument(doc, filePath, rc), nil
 		}
 		blocks = legacy
-	} else {
+	}
+	// Legacy scenarios carry no tags, so --tags leaves them out.
+	if reason := runTagFilter().skipReason(nil, false); reason != "" {
+		return skippedFileSummary(filepath.Base(filePath), filePath, nil, reason), nil
+	}
+	if !strings.HasSuffix(filePath, ".md") {
 		// Traditional .kest parsing
 		scanner := bufio.NewScanner(strings.NewReader(string(content)))
 		lineNum := 0
@@ -133,11 +183,6 @@ func runScenario(filePath string) error {
 	}
 
 	summ := summary.NewSummary()
-	restoreOutput := func() {}
-	if output.JSONOutput {
-		restoreOutput = suppressStdout()
-		defer restoreOutput()
-	}
 
 	fmt.Printf("\n🚀 Running %d test(s) from %s\n", len(blocks), filePath)
 	if runParallel {
@@ -147,7 +192,9 @@ func runScenario(filePath string) error {
 	}
 
 	if runParallel {
-		// Parallel execution
+		// Parallel execution: blocks would interleave their output, so
+		// stdout is silenced once for all of them.
+		restoreOutput := suppressStdout()
 		var wg sync.WaitGroup
 		semaphore := make(chan struct{}, runJobs)
 		resultChan := make(chan summary.TestResult, len(blocks))
@@ -159,7 +206,7 @@ func runScenario(filePath string) error {
 				semaphore <- struct{}{}        // Acquire
 				defer func() { <-semaphore }() // Release
 
-				result := executeKestBlock(kb, false, runVerbose)
+				result := executeKestBlock(kb, rc, false, runVerbose)
 				resultChan <- result
 			}(block)
 		}
@@ -174,6 +221,7 @@ func runScenario(filePath string) error {
 		for result := range resultChan {
 			summ.AddResult(result)
 		}
+		restoreOutput()
 	} else {
 		// Sequential execution
 		for _, block := range blocks {
@@ -182,7 +230,7 @@ func runScenario(filePath string) error {
 			} else {
 				fmt.Printf("--- Step %d: %s ---\n", block.LineNum, block.Raw)
 			}
-			result := executeKestBlock(block, true, runVerbose)
+			result := executeKestBlock(block, rc, true, runVerbose)
 			summ.AddResult(result)
 			if !result.Success {
 				fmt.Printf("❌ Failed at line %d\n\n", block.LineNum)
@@ -190,25 +238,32 @@ func runScenario(filePath string) error {
 		}
 	}
 
+	return summ, nil
+}
+
+// finishRun prints the summary (or JSON), writes the optional HTML, JUnit
+// and TAP reports, queues history sync and converts failures into the
+// command error.
+func finishRun(sourcePath string, summ *summary.Summary) error {
 	logPath := logger.GetSessionPath()
-	if output.JSONOutput {
-		restoreOutput()
-		restoreOutput = func() {}
-		summ.PrintJSON(filePath, logPath)
-	} else {
+	_, reportOnStdout := stdoutReport(runReports)
+	switch {
+	case output.JSONOutput:
+		summ.PrintJSON(sourcePath, logPath)
+	case !reportOnStdout:
 		summ.Print()
 	}
-	if logPath != "" && !output.JSONOutput {
+	if logPath != "" && !machineOutput() {
 		fmt.Printf("\n📄 Full session logs generated at: %s\n", logPath)
 		fmt.Printf("💡 Tip: Use this path for deep-context debugging in AI Editors (Cursor/Windsurf)\n")
 		fmt.Printf("📘 Need help writing flows? Run 'kest guide' for a quick tutorial.\n")
 	}
 
-	reportErr := maybeGenerateRunReport(filePath, summ, logPath)
-	maybeQueueRunHistory(filePath, summ, logPath)
+	reportErr := errors.Join(writeRunReports(sourcePath, summ), maybeGenerateRunReport(sourcePath, summ, logPath))
+	maybeQueueRunHistory(sourcePath, summ, logPath)
 	if summ.FailedTests > 0 {
 		if reportErr != nil {
-			return fmt.Errorf("test suite failed (also failed to generate HTML report: %w)", reportErr)
+			return fmt.Errorf("test suite failed (also failed to generate report: %w)", reportErr)
 		}
 		return fmt.Errorf("test suite failed")
 	}
@@ -218,14 +273,14 @@ func runScenario(filePath string) error {
 	return nil
 }
 
-func executeKestBlock(kb KestBlock, showOutput bool, verbose bool) summary.TestResult {
+func executeKestBlock(kb KestBlock, rc *RunContext, showOutput bool, verbose bool) summary.TestResult {
 	if kb.IsBlock {
-		return executeMultiLineBlock(kb.Raw, kb.LineNum, showOutput, verbose)
+		return executeMultiLineBlock(kb.Raw, kb.LineNum, rc, showOutput, verbose)
 	}
-	return executeTestLine(kb.Raw, kb.LineNum, showOutput, verbose)
+	return executeTestLine(kb.Raw, kb.LineNum, rc, showOutput, verbose)
 }
 
-func executeMultiLineBlock(raw string, lineNum int, showOutput bool, verbose bool) summary.TestResult {
+func executeMultiLineBlock(raw string, lineNum int, rc *RunContext, showOutput bool, verbose bool) summary.TestResult {
 	result := summary.TestResult{
 		Name: fmt.Sprintf("Block at line %d", lineNum),
 	}
@@ -242,18 +297,10 @@ func executeMultiLineBlock(raw string, lineNum int, showOutput bool, verbose boo
 	opts.Verbose = verbose
 	opts.DebugVars = runDebugVars
 	opts.SkipHistorySync = true
+	opts.RunCtx = rc
 
-	// Capture output if parallel — use devnull to avoid race on os.Stdout
-	var devNull *os.File
-	if !showOutput {
-		devNull, _ = os.Open(os.DevNull)
-		oldStdout := os.Stdout
-		os.Stdout = devNull
-		defer func() {
-			os.Stdout = oldStdout
-			devNull.Close()
-		}()
-	}
+	// Parallel blocks run with stdout already silenced by the caller.
+	opts.SilentOutput = !showOutput
 
 	res, err := ExecuteRequest(opts)
 	result = res
@@ -264,7 +311,7 @@ func executeMultiLineBlock(raw string, lineNum int, showOutput bool, verbose boo
 	return result
 }
 
-func executeTestLine(line string, lineNum int, showOutput bool, verbose bool) summary.TestResult {
+func executeTestLine(line string, lineNum int, rc *RunContext, showOutput bool, verbose bool) summary.TestResult {
 	result := summary.TestResult{
 		Name: fmt.Sprintf("Line %d", lineNum),
 	}
@@ -305,17 +352,7 @@ func executeTestLine(line string, lineNum int, showOutput bool, verbose bool) su
 		return result
 	}
 
-	// Capture output if parallel — use devnull to avoid race on os.Stdout
-	if !showOutput {
-		devNull, _ := os.Open(os.DevNull)
-		oldStdout := os.Stdout
-		os.Stdout = devNull
-		defer func() {
-			os.Stdout = oldStdout
-			devNull.Close()
-		}()
-	}
-
+	// Parallel lines run with stdout already silenced by the caller.
 	res, err := ExecuteRequest(RequestOptions{
 		Method:          method,
 		URL:             url,
@@ -329,7 +366,9 @@ func executeTestLine(line string, lineNum int, showOutput bool, verbose bool) su
 		MaxDuration:     maxDuration,
 		Retry:           retry,
 		RetryWait:       retryWait,
+		SilentOutput:    !showOutput,
 		SkipHistorySync: true,
+		RunCtx:          rc,
 	})
 	result = res
 	result.Name = fmt.Sprintf("Line %d", lineNum)
@@ -339,28 +378,117 @@ func executeTestLine(line string, lineNum int, showOutput bool, verbose bool) su
 	return result
 }
 
-func runFlowDocument(doc FlowDoc, filePath string) error {
+func runFlowDocument(doc FlowDoc, filePath string, rc *RunContext) *summary.Summary {
 	// Apply @env from flow metadata if not already overridden by --env flag
 	if doc.Meta.Env != "" && runEnv == "" {
-		runEnv = doc.Meta.Env
-		defer func() { runEnv = "" }()
+		rc.Env = doc.Meta.Env
+	}
+	if doc.Meta.Cookies == "off" {
+		rc.CookiesOff = true
+	}
+	if reason := flowSkipReason(doc, runTagFilter()); reason != "" {
+		name := doc.Meta.Name
+		if name == "" {
+			name = filepath.Base(filePath)
+		}
+		return skippedFileSummary(name, filePath, doc.Meta.Tags, reason)
+	}
+
+	if doc.Meta.Data == "" && len(doc.Meta.Matrix) == 0 {
+		return runFlowSteps(doc, filePath, rc)
 	}
 
-	steps := orderFlowSteps(doc)
+	// Data-driven flow: run everything once per row, each iteration with its
+	// own RunContext and reported as its own group.
+	summ := summary.NewSummary()
+	rows, err := loadDataRows(doc.Meta.Data, doc.Meta.Matrix, filepath.Dir(filePath))
+	if err != nil {
+		name := doc.Meta.Name
+		if name == "" {
+			name = filepath.Base(filePath)
+		}
+		fmt.Printf("❌ %v\n", err)
+		summ.AddResult(summary.TestResult{
+			Name:      name,
+			StartTime: time.Now(),
+			Error:     err,
+		})
+		return summ
+	}
+
+	fmt.Printf("\n🔁 Data-driven flow: %d iteration(s)\n", len(rows))
+	for i, row := range rows {
+		label := row.label(i)
+		fmt.Printf("\n━━━ %s ━━━\n", label)
+		iteration := runFlowSteps(doc, filePath, rc.Fork(row.Values, "data"))
+		for j := range iteration.Results {
+			iteration.Results[j].Params = row.Values
+		}
+		summ.Merge(iteration, label)
+	}
+	return summ
+}
+
+// runFlowSteps executes one pass over a flow's setup, steps and teardown.
+func runFlowSteps(doc FlowDoc, filePath string, rc *RunContext) *summary.Summary {
+	// Targets of "@on-fail goto" are compensation steps: they only run when
+	// a failing step jumps to them, never in the normal order.
+	compensation := make(map[string]FlowStep)
+	for _, step := range append(append([]FlowStep{}, doc.Setup...), append(doc.Steps, doc.Teardown...)...) {
+		if step.OnFail == "goto" && step.OnFailGoto != "" {
+			compensation[step.OnFailGoto] = FlowStep{}
+		}
+	}
+	var steps []FlowStep
+	for _, step := range orderFlowSteps(doc) {
+		if _, ok := compensation[step.ID]; ok {
+			compensation[step.ID] = step
+			continue
+		}
+		steps = append(steps, step)
+	}
+	for id, step := range compensation {
+		if step.ID == "" {
+			delete(compensation, id)
+		}
+	}
 	setupSteps := doc.Setup
 	teardownSteps := doc.Teardown
 
-	totalSteps := len(setupSteps) + len(steps) + len(teardownSteps)
-	restoreOutput := func() {}
-	if output.JSONOutput {
-		restoreOutput = suppressStdout()
-		defer restoreOutput()
+	// Setup, teardown and compensation steps support the selected steps, so
+	// --tags does not filter them out (keyed by line, which is unique).
+	filter := runTagFilter()
+	support := make(map[int]bool)
+	for _, step := range append(append([]FlowStep{}, setupSteps...), teardownSteps...) {
+		support[step.LineNum] = true
 	}
+	for _, step := range compensation {
+		support[step.LineNum] = true
+	}
+
+	totalSteps := len(setupSteps) + len(steps) + len(teardownSteps)
 	fmt.Printf("\n🚀 Running %d step(s) from %s\n", totalSteps, filePath)
+	if doc.Meta.Cookies == "persist" && !rc.CookiesOff {
+		withCookieStore(rc, "load", loadSavedCookies)
+		defer withCookieStore(rc, "save", saveCookies)
+	}
+	var dag *flowDAG
 	if runParallel {
-		fmt.Printf("⚠️  Parallel mode is ignored for flow steps; running sequentially.\n\n")
+		dag = newFlowDAG(steps, doc.Edges)
+		switch {
+		case len(doc.Edges) == 0:
+			dag = nil
+			fmt.Printf("⚠️  Parallel mode needs @edge dependencies between flow steps; running sequentially.\n\n")
+		case dag.hasCycle():
+			dag = nil
+			fmt.Printf("⚠️  Flow edges contain a cycle; running sequentially.\n\n")
+		}
+	}
+	if dag != nil {
+		fmt.Printf("⚡ Parallel mode: up to %d step(s) at a time along @edge dependencies\n", max(runJobs, 1))
+	} else {
+		fmt.Println("📝 Sequential mode")
 	}
-	fmt.Println("📝 Sequential mode")
 
 	if len(doc.Edges) > 0 && runVerbose {
 		fmt.Println("\nMermaid (flowchart):")
@@ -371,6 +499,30 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 	captureOrigins := make(map[string]string)
 	failedSteps := make(map[string]bool)
 
+	incoming := make(map[string][]FlowEdge)
+	for _, edge := range doc.Edges {
+		if edge.From != "" && edge.To != "" {
+			incoming[edge.To] = append(incoming[edge.To], edge)
+		}
+	}
+	outcomes := make(map[string]summary.TestResult)
+
+	// Steps may run concurrently in parallel mode; mu guards summ,
+	// failedSteps and outcomes. Captured variables go through the
+	// thread-safe RunContext.
+	var mu sync.Mutex
+	addResult := func(result summary.TestResult, failed bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		summ.AddResult(result)
+		if failed {
+			failedSteps[result.Name] = true
+		}
+		if result.StepID != "" {
+			outcomes[result.StepID] = result
+		}
+	}
+
 	registerCaptureOrigins := func(step FlowStep) {
 		for _, capExpr := range step.Request.Captures {
 			varName, _, ok := ParseCaptureExpr(capExpr)
@@ -389,14 +541,37 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 	for _, step := range append(append([]FlowStep{}, setupSteps...), append(steps, teardownSteps...)...) {
 		registerCaptureOrigins(step)
 	}
+	for _, step := range compensation {
+		registerCaptureOrigins(step)
+	}
+
+	// execStep executes a single step (or one data iteration of it) against
+	// the given RunContext, records its result and returns it.
+	execStep := func(step FlowStep, rc *RunContext, iteration *dataRow, label string) summary.TestResult {
+		record := func(result summary.TestResult, failed bool) {
+			result.Tags = stepTags(doc.Meta, step)
+			if iteration != nil {
+				result.Group = label
+				result.Params = iteration.Values
+			}
+			addResult(result, failed)
+		}
 
-	runStep := func(step FlowStep, i int, total int) bool {
+		if step.ExecTimeoutMs == 0 {
+			step.ExecTimeoutMs = doc.Meta.TimeoutMs
+		}
+		if step.Request.Auth == "" && step.Type != "exec" {
+			step.Request.Auth = doc.Meta.Auth
+		}
 		if step.WaitMs > 0 {
 			fmt.Printf("\n  ⏳ %s waiting %dms before execution\n", stepName(step), step.WaitMs)
 			time.Sleep(time.Duration(step.WaitMs) * time.Millisecond)
 		}
 
-		if err := validateFlowStepVariables(step, captureOrigins, failedSteps); err != nil {
+		mu.Lock()
+		err := validateFlowStepVariables(step, rc, captureOrigins, failedSteps)
+		mu.Unlock()
+		if err != nil {
 			result := summary.TestResult{
 				Name:    stepName(step),
 				StepID:  step.ID,
@@ -405,32 +580,22 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 				Success: false,
 				Error:   err,
 			}
-			summ.AddResult(result)
-			failedSteps[stepName(step)] = true
+			record(result, true)
 			fmt.Printf("\n  ▶ %s (line %d)\n", stepName(step), step.LineNum)
 			fmt.Printf("    ❌ %v\n", err)
-			return !runFailFast
+			return result
 		}
 
 		// Handle @type exec steps
 		if step.Type == "exec" {
 			fmt.Printf("\n  ▶ %s (exec, line %d)\n", stepName(step), step.LineNum)
-			result := executeExecStep(step)
+			result := executeExecStep(step, rc)
 			result.StepID = step.ID
-			summ.AddResult(result)
+			record(result, !result.Success)
 			if !result.Success {
-				failedSteps[stepName(step)] = true
 				fmt.Printf("❌ Failed at exec step %s\n\n", stepName(step))
-				if runFailFast {
-					fmt.Printf("\n⚠️  Stopping execution (--fail-fast enabled)\n")
-					fmt.Printf("   Failed step: %s\n", stepName(step))
-					if i+1 < total {
-						fmt.Printf("   Skipped %d remaining step(s)\n", total-i-1)
-					}
-					return false
-				}
 			}
-			return true
+			return result
 		}
 
 		if step.Request.Method == "" || step.Request.URL == "" {
@@ -440,17 +605,10 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 				Success: false,
 				Error:   fmt.Errorf("invalid step (missing METHOD/URL) at line %d", step.LineNum),
 			}
-			summ.AddResult(result)
-			failedSteps[stepName(step)] = true
-			if runFailFast {
-				fmt.Printf("\n⚠️  Stopping execution (--fail-fast enabled)\n")
-				fmt.Printf("   Failed step: %s (invalid step)\n", stepName(step))
-				if i+1 < total {
-					fmt.Printf("   Skipped %d remaining step(s)\n", total-i-1)
-				}
-				return false
-			}
-			return true
+			record(result, true)
+			fmt.Printf("\n  ▶ %s (line %d)\n", stepName(step), step.LineNum)
+			fmt.Printf("    ❌ %v\n", result.Error)
+			return result
 		}
 		fmt.Printf("\n  ▶ %s %s %s (line %d)\n", stepName(step), step.Request.Method, step.Request.URL, step.LineNum)
 
@@ -460,6 +618,12 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 		opts.StrictVars = true
 		opts.SilentOutput = true
 		opts.SkipHistorySync = true
+		opts.RunCtx = rc
+		opts.NoCookies = step.NoCookies
+		opts.DefaultHeaders = doc.Meta.DefaultHeaders
+		opts.BaseURL = doc.Meta.BaseURL
+		opts.TimeoutMs = step.ExecTimeoutMs
+		opts.BaseDir = filepath.Dir(filePath)
 		if step.Retry > 0 {
 			opts.Retry = step.Retry
 		}
@@ -477,44 +641,52 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 		result.Success = (err == nil)
 		result.Error = err
 
+		if step.Snapshot != nil && result.Status != 0 {
+			check := checkSnapshot(*step.Snapshot, snapshotPath(filePath, snapshotName(step, iteration)), result.ResponseBody, err == nil)
+			result.Assertions = append(result.Assertions, check)
+			if !check.Passed && err == nil {
+				err = fmt.Errorf("assertion failed: %s (%s)", check.Expression, check.Message)
+				result.Success = false
+				result.Error = err
+			}
+		}
+
 		// Process captures after successful request
 		if err == nil && len(step.Request.Captures) > 0 {
 			if result.Captures == nil {
 				result.Captures = make(map[string]string)
 			}
-			store, _ := storage.NewStore() //nolint: we need a fresh store per capture block
-			conf := loadConfigWarn()
+			var store *storage.Store
+			if !rc.NoRecord {
+				store, _ = storage.NewStore() //nolint: we need a fresh store per capture block
+			}
+			conf := loadRunConfig(rc)
 			for _, capExpr := range step.Request.Captures {
-				sep := "="
-				if !strings.Contains(capExpr, "=") && strings.Contains(capExpr, ":") {
-					sep = ":"
+				varName, query, ok := ParseCaptureExpr(capExpr)
+				if !ok {
+					continue
+				}
+				value, found, capErr := ResolveResponseCapture(query, res)
+				if capErr != nil {
+					fmt.Printf("    ⚠️  Capture %s: %v\n", varName, capErr)
+					continue
 				}
-				parts := strings.SplitN(capExpr, sep, 2)
-				if len(parts) == 2 {
-					varName := strings.TrimSpace(parts[0])
-					query := strings.TrimSpace(parts[1])
-
-					captureResult := gjson.Get(string(res.ResponseBody), query)
-					if captureResult.Exists() {
-						value := captureResult.String()
-						// Save to ActiveRunCtx
-						if ActiveRunCtx != nil {
-							ActiveRunCtx.Set(varName, value)
-						}
-						result.Captures[varName] = value
-						// Also save to storage for persistence
-						if store != nil && conf != nil {
-							store.SaveVariable(&storage.Variable{
-								Name:        varName,
-								Value:       value,
-								Environment: conf.ActiveEnv,
-								Project:     conf.ProjectID,
-							})
-						}
-						fmt.Printf("    Captured: %s = %s\n", varName, value)
-						logger.LogToSession("Captured: %s = %s", varName, value)
-					}
+				if !found {
+					continue
 				}
+				rc.Set(varName, value)
+				result.Captures[varName] = value
+				// Also save to storage for persistence
+				if store != nil && conf != nil {
+					store.SaveVariable(&storage.Variable{
+						Name:        varName,
+						Value:       value,
+						Environment: conf.ActiveEnv,
+						Project:     conf.ProjectID,
+					})
+				}
+				fmt.Printf("    Captured: %s = %s\n", varName, maskedValue(varName, value))
+				logger.LogToSession("Captured: %s = %s", varName, value)
 			}
 			if store != nil {
 				store.Close()
@@ -525,58 +697,192 @@ func runFlowDocument(doc FlowDoc, filePath string) error {
 			if result.FailedAssertion == "" && strings.Contains(err.Error(), "assertion failed:") {
 				result.FailedAssertion = strings.TrimSpace(strings.TrimPrefix(err.Error(), "assertion failed:"))
 			}
-			failedSteps[stepName(step)] = true
 			fmt.Printf("    ❌ Failed at step %s\n", stepName(step))
-			if runFailFast {
-				fmt.Printf("\n⚠️  Stopping execution (--fail-fast enabled)\n")
-				fmt.Printf("   Failed step: %s\n", stepName(step))
-				fmt.Printf("   Reason: %v\n", err)
-				if i+1 < total {
-					fmt.Printf("   Skipped %d remaining step(s)\n", total-i-1)
+			for _, check := range result.Assertions {
+				if check.Passed || check.Soft {
+					continue
 				}
-				summ.AddResult(result)
-				return false
+				fmt.Printf("      ✗ %s\n", check.Expression)
+				fmt.Printf("        %s\n", strings.ReplaceAll(secret.Mask(check.Message), "\n", "\n        "))
+				printAssertionDiff(check, "        ")
 			}
 		} else {
 			fmt.Printf("    ✅ %s %s → %d (%s)\n", res.Method, step.Request.URL, res.Status, res.Duration.Round(time.Millisecond))
 		}
-		summ.AddResult(result)
+		record(result, err != nil)
+		return result
+	}
+
+	// runStep evaluates the step's incoming edges and then executes it,
+	// once per row when the step is data-driven.
+	runStep := func(step FlowStep) summary.TestResult {
+		skip := func(reason string) summary.TestResult {
+			fmt.Printf("\n  ⏭️  %s skipped (%s)\n", stepName(step), reason)
+			method := strings.ToUpper(step.Request.Method)
+			if step.Type == "exec" {
+				method = "EXEC"
+			}
+			result := summary.TestResult{
+				Name:       stepName(step),
+				StepID:     step.ID,
+				Method:     method,
+				URL:        step.Request.URL,
+				StartTime:  time.Now(),
+				Tags:       stepTags(doc.Meta, step),
+				Skipped:    true,
+				SkipReason: reason,
+			}
+			addResult(result, false)
+			return result
+		}
+
+		if reason := filter.skipReason(stepTags(doc.Meta, step), support[step.LineNum]); reason != "" {
+			return skip(reason)
+		}
+		if edges := incoming[step.ID]; len(edges) > 0 {
+			mu.Lock()
+			ok, reason := gateFlowStep(edges, outcomes, func() map[string]string { return buildVarChain(rc) })
+			mu.Unlock()
+			if !ok {
+				return skip(reason)
+			}
+		}
+
+		if step.Data == "" && len(step.Matrix) == 0 {
+			return execStep(step, rc, nil, "")
+		}
+
+		rows, err := loadDataRows(step.Data, step.Matrix, filepath.Dir(filePath))
+		if err != nil {
+			fmt.Printf("\n  ▶ %s (line %d)\n", stepName(step), step.LineNum)
+			fmt.Printf("    ❌ %v\n", err)
+			result := summary.TestResult{
+				Name:      stepName(step),
+				StepID:    step.ID,
+				StartTime: time.Now(),
+				Tags:      stepTags(doc.Meta, step),
+				Error:     err,
+			}
+			addResult(result, true)
+			return result
+		}
+
+		// The step's outcome for edges and @on-fail is its first failing
+		// iteration, or the last one when every row passed.
+		var outcome summary.TestResult
+		for i := range rows {
+			label := stepName(step) + " · " + rows[i].label(i)
+			result := execStep(step, rc.Fork(rows[i].Values, "data"), &rows[i], label)
+			if i == 0 || outcome.Success {
+				outcome = result
+			}
+		}
+		mu.Lock()
+		outcomes[step.ID] = outcome
+		mu.Unlock()
+		return outcome
+	}
+
+	// stopAfterFailure applies the failed step's @on-fail policy, falling
+	// back to --fail-fast, and reports whether the remaining main steps
+	// should be abandoned. Teardown runs either way.
+	compensated := make(map[string]bool)
+	stopAfterFailure := func(step FlowStep, result summary.TestResult) bool {
+		reason := "--fail-fast enabled"
+		switch step.OnFail {
+		case "continue":
+			fmt.Printf("    ↪️  Continuing after failure (@on-fail continue)\n")
+			return false
+		case "abort", "stop":
+			reason = "@on-fail " + step.OnFail
+		case "goto":
+			reason = "@on-fail goto " + step.OnFailGoto
+			target, ok := compensation[step.OnFailGoto]
+			mu.Lock()
+			first := !compensated[step.OnFailGoto]
+			compensated[step.OnFailGoto] = true
+			mu.Unlock()
+			switch {
+			case !ok:
+				fmt.Printf("    ⚠️  @on-fail goto target %q not found\n", step.OnFailGoto)
+			case first:
+				fmt.Printf("\n  ↪️  Jumping to %s after %s failed\n", stepName(target), stepName(step))
+				runStep(target)
+			}
+		default:
+			if !runFailFast {
+				return false
+			}
+		}
+		fmt.Printf("\n⚠️  Stopping execution (%s)\n", reason)
+		fmt.Printf("   Failed step: %s\n", stepName(step))
+		if result.Error != nil {
+			fmt.Printf("   Reason: %v\n", result.Error)
+		}
 		return true
 	}
 
-	combined := append(append([]FlowStep{}, setupSteps...), append(steps, teardownSteps...)...)
-	for i, step := range combined {
-		if !runStep(step, i, len(combined)) {
+	failed := func(result summary.TestResult) bool {
+		return !result.Success && !result.Skipped
+	}
+
+	stopped := false
+	for _, step := range setupSteps {
+		if result := runStep(step); failed(result) && stopAfterFailure(step, result) {
+			stopped = true
+			if len(steps) > 0 {
+				fmt.Printf("   Skipped %d main step(s)\n", len(steps))
+			}
 			break
 		}
 	}
 
-	logPath := logger.GetSessionPath()
-	if output.JSONOutput {
-		restoreOutput()
-		restoreOutput = func() {}
-		summ.PrintJSON(filePath, logPath)
-	} else {
-		summ.Print()
+	if !stopped && dag == nil {
+		for i, step := range steps {
+			if result := runStep(step); failed(result) && stopAfterFailure(step, result) {
+				if remaining := len(steps) - i - 1; remaining > 0 {
+					fmt.Printf("   Skipped %d remaining step(s)\n", remaining)
+				}
+				break
+			}
+		}
+	} else if !stopped {
+		var halted atomic.Bool
+		executed := dag.run(runJobs, func(step FlowStep) bool {
+			if result := runStep(step); failed(result) && stopAfterFailure(step, result) {
+				halted.Store(true)
+				return false
+			}
+			return true
+		})
+		// Positions are meaningless while steps run concurrently, so the
+		// skipped count is reported once the DAG has drained.
+		if remaining := len(steps) - executed; halted.Load() && remaining > 0 {
+			fmt.Printf("   Skipped %d remaining step(s)\n", remaining)
+		}
 	}
-	if logPath != "" && !output.JSONOutput {
-		fmt.Printf("\n📄 Full session logs generated at: %s\n", logPath)
-		fmt.Printf("💡 Tip: Use this path for deep-context debugging in AI Editors (Cursor/Windsurf)\n")
-		fmt.Printf("📘 Need help writing flows? Run 'kest guide' for a quick tutorial.\n")
+
+	// Teardown always runs, even after an abort, and never stops early.
+	for _, step := range teardownSteps {
+		runStep(step)
 	}
 
-	reportErr := maybeGenerateRunReport(filePath, summ, logPath)
-	maybeQueueRunHistory(filePath, summ, logPath)
-	if summ.FailedTests > 0 {
-		if reportErr != nil {
-			return fmt.Errorf("test suite failed (also failed to generate HTML report: %w)", reportErr)
-		}
-		return fmt.Errorf("test suite failed")
+	return summ
+}
+
+// withCookieStore applies fn (loadSavedCookies or saveCookies) to the run's
+// cookie jar and the cookies stored for its environment ("@cookies persist").
+func withCookieStore(rc *RunContext, action string, fn func(*storage.Store, string, string, *client.Jar) error) {
+	store, err := storage.NewStore()
+	if err != nil {
+		fmt.Printf("⚠️  Failed to %s cookies: %v\n", action, err)
+		return
 	}
-	if reportErr != nil {
-		return reportErr
+	defer store.Close()
+	conf := loadRunConfig(rc)
+	if err := fn(store, conf.ProjectID, conf.ActiveEnv, rc.CookieJar()); err != nil {
+		fmt.Printf("⚠️  Failed to %s cookies: %v\n", action, err)
 	}
-	return nil
 }
 
 func orderFlowSteps(doc FlowDoc) []FlowStep {
@@ -644,7 +950,7 @@ func orderFlowSteps(doc FlowDoc) []FlowStep {
 // The full variable chain (config → captured → CLI → exec) is available
 // for interpolation in the command. Captured values are stored in the
 // active RunContext so subsequent steps can reference them.
-func executeExecStep(step FlowStep) summary.TestResult {
+func executeExecStep(step FlowStep, rc *RunContext) summary.TestResult {
 	startTime := time.Now()
 	result := summary.TestResult{
 		Name:      stepName(step),
@@ -660,10 +966,11 @@ func executeExecStep(step FlowStep) summary.TestResult {
 	result.Command = step.Exec.Command
 
 	// Build the full variable map: config → storage → run context
-	vars := buildVarChain()
+	vars := buildVarChain(rc)
+	secret.TrackAll(vars)
 	command := variable.Interpolate(step.Exec.Command, vars)
 
-	fmt.Printf("  $ %s\n", command)
+	fmt.Printf("  $ %s\n", secret.Mask(command))
 
 	// Use per-step timeout if set (@timeout directive), otherwise fall back to global --exec-timeout
 	timeoutSec := execTimeout
@@ -686,10 +993,12 @@ func executeExecStep(step FlowStep) summary.TestResult {
 	err := cmd.Run()
 	duration := time.Since(startTime)
 	result.Duration = duration
+	// Kept on failure too so reports can show what the command printed.
+	result.ResponseBody = strings.TrimSpace(stdout.String())
 
 	if ctx.Err() == context.DeadlineExceeded {
 		result.Success = false
-		result.Error = fmt.Errorf("exec timed out after %ds", execTimeout)
+		result.Error = fmt.Errorf("exec timed out after %ds", timeoutSec)
 		fmt.Printf("  ❌ %v\n", result.Error)
 		return result
 	}
@@ -700,12 +1009,19 @@ func executeExecStep(step FlowStep) summary.TestResult {
 		return result
 	}
 
-	output := strings.TrimSpace(stdout.String())
+	output := result.ResponseBody
+	// Secrets the command prints (e.g. a generated token) are masked from
+	// here on, starting with the stdout echo below.
+	for _, capExpr := range step.Exec.Captures {
+		if varName, query, ok := ParseCaptureExpr(capExpr); ok {
+			secret.Track(varName, ResolveExecCapture(output, query))
+		}
+	}
 	if runVerbose && output != "" {
-		fmt.Printf("  stdout: %s\n", output)
+		fmt.Printf("  stdout: %s\n", secret.Mask(output))
 	}
 	if runVerbose && stderr.Len() > 0 {
-		fmt.Printf("  stderr: %s\n", strings.TrimSpace(stderr.String()))
+		fmt.Printf("  stderr: %s\n", secret.Mask(strings.TrimSpace(stderr.String())))
 	}
 
 	// Process captures
@@ -717,14 +1033,12 @@ func executeExecStep(step FlowStep) summary.TestResult {
 
 		value := ResolveExecCapture(output, query)
 		if value != "" {
-			if ActiveRunCtx != nil {
-				ActiveRunCtx.SetWithSource(varName, value, stepName(step), "success", "exec")
-			}
+			rc.SetWithSource(varName, value, stepName(step), "success", "exec")
 			if result.Captures == nil {
 				result.Captures = make(map[string]string)
 			}
 			result.Captures[varName] = value
-			fmt.Printf("  Captured: %s = %s\n", varName, value)
+			fmt.Printf("  Captured: %s = %s\n", varName, maskedValue(varName, value))
 			logger.LogToSession("Exec Captured: %s = %s", varName, value)
 		} else {
 			fmt.Printf("  ⚠️  Capture '%s' produced empty value\n", varName)
@@ -732,7 +1046,6 @@ func executeExecStep(step FlowStep) summary.TestResult {
 	}
 
 	result.Success = true
-	result.ResponseBody = output
 	fmt.Printf("  ✅ Exec completed in %s\n", duration.Round(time.Millisecond))
 	return result
 }
@@ -783,12 +1096,12 @@ func maybeQueueRunHistory(filePath string, summ *summary.Summary, logPath string
 	platformsync.MaybeFlushHistoryOutbox(conf, store, 5)
 }
 
-func validateFlowStepVariables(step FlowStep, captureOrigins map[string]string, failedSteps map[string]bool) error {
-	vars := buildVarChain()
+func validateFlowStepVariables(step FlowStep, rc *RunContext, captureOrigins map[string]string, failedSteps map[string]bool) error {
+	vars := buildVarChain(rc)
 	missing := make(map[string]struct{})
 
 	collect := func(text string) {
-		for _, name := range variable.ExtractPlaceholders(text) {
+		for _, name := range variable.RequiredPlaceholders(text) {
 			if _, ok := vars[name]; !ok {
 				missing[name] = struct{}{}
 			}
@@ -809,6 +1122,21 @@ func validateFlowStepVariables(step FlowStep, captureOrigins map[string]string,
 	for _, a := range step.Request.SoftAsserts {
 		collect(a)
 	}
+	collect(step.Request.Auth)
+	if gql := step.Request.GraphQL; gql != nil {
+		collect(gql.Query)
+		collect(gql.Variables)
+		collect(gql.OperationName)
+	}
+	if g := step.Request.GRPC; g != nil {
+		collect(g.Address)
+		collect(g.ProtoPath)
+	}
+	if rt := step.Request.Realtime; rt != nil {
+		for _, action := range rt.Script {
+			collect(action.Value)
+		}
+	}
 	collect(step.Exec.Command)
 
 	if len(missing) == 0 {
@@ -845,8 +1173,10 @@ func executeFlowStepWithPoll(step FlowStep, opts RequestOptions) (summary.TestRe
 		if len(hardAsserts) == 0 {
 			return res, nil
 		}
-		vars := buildVarChain()
-		if ok, failMsg := evaluateAssertionSet(res, vars, hardAsserts); !ok {
+		vars := buildVarChain(opts.RunCtx)
+		checks, ok, failMsg := evaluateAssertionSet(res, opts.BaseDir, vars, hardAsserts)
+		res.Assertions = append(checks, res.Assertions...)
+		if !ok {
 			return res, fmt.Errorf("assertion failed: %s", failMsg)
 		}
 		return res, nil
@@ -864,12 +1194,14 @@ func executeFlowStepWithPoll(step FlowStep, opts RequestOptions) (summary.TestRe
 		res, err := ExecuteRequest(pollOpts)
 		lastRes = res
 		if err == nil {
-			vars := buildVarChain()
-			if ok, failMsg := evaluateAssertionSet(res, vars, hardAsserts); ok {
+			vars := buildVarChain(opts.RunCtx)
+			checks, ok, failMsg := evaluateAssertionSet(res, opts.BaseDir, vars, hardAsserts)
+			res.Assertions = append(checks, res.Assertions...)
+			lastRes = res
+			if ok {
 				return res, nil
-			} else {
-				lastErr = fmt.Errorf("poll assertions pending: %s", failMsg)
 			}
+			lastErr = fmt.Errorf("poll assertions pending: %s", failMsg)
 		} else {
 			lastErr = err
 		}
@@ -886,23 +1218,45 @@ func executeFlowStepWithPoll(step FlowStep, opts RequestOptions) (summary.TestRe
 	return lastRes, lastErr
 }
 
-func evaluateAssertionSet(res summary.TestResult, vars map[string]string, assertions []string) (bool, string) {
-	for _, assertion := range assertions {
-		passed, msg := variable.Assert(res.Status, []byte(res.ResponseBody), res.Duration.Milliseconds(), vars, assertion)
-		if !passed {
-			return false, fmt.Sprintf("%s (%s)", assertion, msg)
+// assertionResponse exposes a recorded result to variable.AssertResponse.
+func assertionResponse(res summary.TestResult) variable.Response {
+	return variable.Response{
+		Status:     res.Status,
+		Headers:    res.ResponseHeaders,
+		Body:       []byte(res.ResponseBody),
+		DurationMs: res.Duration.Milliseconds(),
+	}
+}
+
+// evaluateAssertionSet evaluates every assertion and summarizes the first
+// failure for the step error. Relative schema files resolve against baseDir.
+func evaluateAssertionSet(res summary.TestResult, baseDir string, vars map[string]string, assertions []string) ([]summary.AssertionResult, bool, string) {
+	response := assertionResponse(res)
+	response.BaseDir = baseDir
+	results := variable.EvaluateAssertions(response, vars, assertions)
+	var failures []summary.AssertionResult
+	for _, r := range results {
+		if !r.Passed {
+			failures = append(failures, r)
 		}
 	}
-	return true, ""
+	if len(failures) == 0 {
+		return results, true, ""
+	}
+	msg := fmt.Sprintf("%s (%s)", failures[0].Expression, failures[0].Message)
+	if len(failures) > 1 {
+		msg += fmt.Sprintf(" and %d more", len(failures)-1)
+	}
+	return results, false, msg
 }
 
 // buildVarChain assembles the full variable map following the priority chain:
 // config env vars → storage captured vars → run context (CLI + exec captures).
 // Accepts an optional pre-opened store to avoid repeated DB connections in hot paths.
-func buildVarChain(stores ...*storage.Store) map[string]string {
+func buildVarChain(rc *RunContext, stores ...*storage.Store) map[string]string {
 	vars := make(map[string]string)
 
-	conf := loadConfigWarn()
+	conf := loadRunConfig(rc)
 	if conf != nil {
 		env := conf.GetActiveEnv()
 		if env.Variables != nil {
@@ -912,19 +1266,16 @@ func buildVarChain(stores ...*storage.Store) map[string]string {
 		}
 	}
 
-	// Use provided store if available, otherwise open a transient one
+	// Use provided store if available, otherwise the run's or a transient one
 	var store *storage.Store
-	var ownStore bool
 	if len(stores) > 0 && stores[0] != nil {
 		store = stores[0]
 	} else {
-		store, _ = storage.NewStore()
-		ownStore = true
+		var release func()
+		store, release = openRunStore(rc)
+		defer release()
 	}
 	if store != nil {
-		if ownStore {
-			defer store.Close()
-		}
 		if conf != nil {
 			capturedVars, _ := store.GetVariables(conf.ProjectID, conf.ActiveEnv)
 			for k, v := range capturedVars {
@@ -933,8 +1284,8 @@ func buildVarChain(stores ...*storage.Store) map[string]string {
 		}
 	}
 
-	if ActiveRunCtx != nil {
-		for k, v := range ActiveRunCtx.All() {
+	if rc != nil {
+		for k, v := range rc.All() {
 			vars[k] = v
 		}
 	}
@@ -949,16 +1300,44 @@ func sortByIndex(ids []string, index map[string]int) []string {
 	return ids
 }
 
+// stdoutSilence tracks nested suppressStdout calls: os.Stdout is only
+// swapped by the outermost one, so a parallel suite silenced as a whole can
+// run files that silence their own parallel blocks without the goroutines
+// restoring each other's writers.
+var stdoutSilence struct {
+	sync.Mutex
+	depth int
+	saved *os.File
+	null  *os.File
+}
+
+// suppressStdout sends stdout to /dev/null until the returned function is
+// called. Call it before starting goroutines and restore after they finish.
 func suppressStdout() func() {
-	devNull, err := os.Open(os.DevNull)
-	if err != nil {
-		return func() {}
+	stdoutSilence.Lock()
+	defer stdoutSilence.Unlock()
+	if stdoutSilence.depth == 0 {
+		devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			return func() {}
+		}
+		stdoutSilence.saved, stdoutSilence.null = os.Stdout, devNull
+		os.Stdout = devNull
 	}
-	oldStdout := os.Stdout
-	os.Stdout = devNull
+	stdoutSilence.depth++
+
+	var once sync.Once
 	return func() {
-		os.Stdout = oldStdout
-		_ = devNull.Close()
+		once.Do(func() {
+			stdoutSilence.Lock()
+			defer stdoutSilence.Unlock()
+			stdoutSilence.depth--
+			if stdoutSilence.depth == 0 {
+				os.Stdout = stdoutSilence.saved
+				_ = stdoutSilence.null.Close()
+				stdoutSilence.saved, stdoutSilence.null = nil, nil
+			}
+		})
 	}
 }
 
@@ -7,6 +7,9 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/kest-labs/kest/cli/internal/client"
+	"github.com/kest-labs/kest/cli/internal/config"
+	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/tidwall/gjson"
 )
 
@@ -27,6 +30,26 @@ type RunContext struct {
 	mu      sync.RWMutex
 	vars    map[string]string          // accumulated variables (CLI + exec captures)
 	sources map[string]*VariableSource // track variable sources and status
+
+	// Env overrides the active environment for this run only (set from a
+	// flow's @env directive). The global --env flag still takes precedence.
+	Env string
+
+	// CookiesOff disables the run's cookie jar (flow-level @cookies off).
+	CookiesOff bool
+
+	// NoRecord keeps the run's requests and captures out of history, the
+	// request log and the variable store (kest bench).
+	NoRecord bool
+
+	// Store and Config, when set, are shared by every request of the run
+	// instead of opening the database and loading config per request, so
+	// kest bench measures the API rather than SQLite.
+	Store  *storage.Store
+	Config *config.Config
+
+	jarOnce sync.Once
+	jar     *client.Jar
 }
 
 // NewRunContext creates a RunContext seeded with CLI --var flags.
@@ -103,12 +126,79 @@ func (rc *RunContext) All() map[string]string {
 	return out
 }
 
-// ActiveRunCtx is the current run context. It is set at the start of
-// runScenario and cleared when the run completes. Ad-hoc commands
-// (kest get/post) work fine when this is nil — they simply have no
-// CLI vars to inject.
+// CookieJar returns the cookie jar shared by every request in this run,
+// creating it on first use.
+func (rc *RunContext) CookieJar() *client.Jar {
+	rc.jarOnce.Do(func() {
+		rc.jar = client.NewJar()
+	})
+	return rc.jar
+}
+
+// Fork returns an independent copy of the context with extra variables
+// layered on top (e.g. the columns of a data-driven iteration). Changes to
+// the fork are not visible to the parent. The fork starts with a copy of the
+// parent's cookies.
+func (rc *RunContext) Fork(extra map[string]string, sourceType string) *RunContext {
+	rc.mu.RLock()
+	child := &RunContext{
+		vars:       make(map[string]string, len(rc.vars)+len(extra)),
+		sources:    make(map[string]*VariableSource, len(rc.sources)+len(extra)),
+		Env:        rc.Env,
+		CookiesOff: rc.CookiesOff,
+		NoRecord:   rc.NoRecord,
+		Store:      rc.Store,
+		Config:     rc.Config,
+	}
+	for k, v := range rc.vars {
+		child.vars[k] = v
+	}
+	for k, src := range rc.sources {
+		copied := *src
+		child.sources[k] = &copied
+	}
+	rc.mu.RUnlock()
+
+	child.CookieJar().Load(rc.CookieJar().Saved())
+	for k, v := range extra {
+		child.SetWithSource(k, v, "", "success", sourceType)
+	}
+	return child
+}
+
+// openRunStore returns the run's shared store, or opens one that release
+// closes again. The store is nil when the database cannot be opened.
+func openRunStore(rc *RunContext) (store *storage.Store, release func()) {
+	if rc != nil && rc.Store != nil {
+		return rc.Store, func() {}
+	}
+	store, err := storage.NewStore()
+	if err != nil {
+		return nil, func() {}
+	}
+	return store, func() { store.Close() }
+}
+
+// ActiveRunCtx is the run context used by ad-hoc request commands
+// (kest get/post --var). The run/suite runner does not rely on it: each
+// file gets its own RunContext, passed explicitly via RequestOptions.RunCtx,
+// so that files can execute concurrently. Ad-hoc commands work fine when
+// this is nil — they simply have no CLI vars to inject.
 var ActiveRunCtx *RunContext
 
+// parseVarFlags converts repeated --var key=value flags into a map.
+// Entries without "=" are ignored.
+func parseVarFlags(values []string) map[string]string {
+	vars := make(map[string]string, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) == 2 {
+			vars[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return vars
+}
+
 // ParseCaptureExpr splits a capture expression like "token=data.auth.key"
 // into (varName, query). Supports both "=" and ":" as separators.
 func ParseCaptureExpr(expr string) (varName, query string, ok bool) {
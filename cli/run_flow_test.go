@@ -0,0 +1,446 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/client"
+	"github.com/kest-labs/kest/cli/internal/secret"
+	"github.com/kest-labs/kest/cli/internal/storage"
+)
+
+// runFlowForTest parses content and runs it with an isolated home directory
+// so config and captured variables do not leak into the developer's ~/.kest.
+func runFlowForTest(t *testing.T, content string, failFast bool) []string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	prevFailFast, prevTimeout := runFailFast, execTimeout
+	runFailFast, execTimeout = failFast, 30
+	t.Cleanup(func() { runFailFast, execTimeout = prevFailFast, prevTimeout })
+
+	doc, _ := ParseFlowDocument(content)
+	summ := runFlowDocument(doc, "test.flow.md", NewRunContext(nil))
+
+	var ran []string
+	for _, result := range summ.Results {
+		status := "pass"
+		if !result.Success {
+			status = "fail"
+		}
+		ran = append(ran, result.StepID+":"+status)
+	}
+	return ran
+}
+
+func execBlock(kind, id, onFail, command string) string {
+	block := "```" + kind + "\n@id " + id + "\n@type exec\n"
+	if onFail != "" {
+		block += "@on-fail " + onFail + "\n"
+	}
+	return block + command + "\n```\n\n"
+}
+
+func TestOnFailContinueOverridesFailFast(t *testing.T) {
+	content := execBlock("step", "probe", "continue", "exit 1") +
+		execBlock("step", "login", "", "true")
+
+	got := strings.Join(runFlowForTest(t, content, true), ",")
+	if got != "probe:fail,login:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestOnFailAbortStopsButRunsTeardown(t *testing.T) {
+	content := execBlock("step", "login", "abort", "exit 1") +
+		execBlock("step", "profile", "", "true") +
+		execBlock("teardown", "cleanup", "", "true")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "login:fail,cleanup:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestOnFailGotoRunsCompensationStep(t *testing.T) {
+	content := execBlock("step", "create", "goto rollback", "exit 1") +
+		execBlock("step", "verify", "", "true") +
+		execBlock("step", "rollback", "", "true") +
+		execBlock("teardown", "cleanup", "", "true")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "create:fail,rollback:pass,cleanup:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestCompensationStepIsNotRunOnSuccess(t *testing.T) {
+	content := execBlock("step", "create", "goto rollback", "true") +
+		execBlock("step", "rollback", "", "true")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "create:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestDataDrivenFlowRunsOncePerRow(t *testing.T) {
+	dir := t.TempDir()
+	writeDataFile(t, dir, "users.csv", "name,code\nok,0\nbad,1\n")
+	content := "```flow\n@data " + filepath.Join(dir, "users.csv") + "\n```\n\n" +
+		execBlock("step", "check", "", "exit {{code}}")
+
+	t.Setenv("HOME", t.TempDir())
+	doc, _ := ParseFlowDocument(content)
+	summ := runFlowDocument(doc, filepath.Join(dir, "users.flow.md"), NewRunContext(nil))
+
+	if summ.TotalTests != 2 || summ.PassedTests != 1 || summ.FailedTests != 1 {
+		t.Fatalf("unexpected counts: %+v", summ)
+	}
+	groups := summ.Groups()
+	if len(groups) != 2 || groups[0].Name != "row 1: name=ok, code=0" || groups[1].Failed != 1 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if summ.Results[1].Params["name"] != "bad" {
+		t.Fatalf("expected row values on results, got %+v", summ.Results[1].Params)
+	}
+}
+
+func TestDataDrivenStepDoesNotLeakRowVariables(t *testing.T) {
+	content := "```step\n@id boundary\n@type exec\n@matrix code = 0, 1\nexit {{code}}\n```\n\n" +
+		execBlock("step", "after", "", "true")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "boundary:pass,boundary:fail,after:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+// cookieServer sets a session cookie on /login and only answers 200 on /me
+// when the cookie is sent back.
+func cookieServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		case "/me":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func httpBlock(id, directives, path string) string {
+	return "```step\n@id " + id + "\n" + directives + "GET " + path + "\n\n[Asserts]\nstatus == 200\n```\n\n"
+}
+
+func TestFlowReplaysCookiesAcrossSteps(t *testing.T) {
+	srv := cookieServer(t)
+	content := httpBlock("login", "", srv.URL+"/login") +
+		httpBlock("me", "", srv.URL+"/me") +
+		httpBlock("anonymous", "@cookies off\n", srv.URL+"/me")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "login:pass,me:pass,anonymous:fail" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestFlowCookiesOff(t *testing.T) {
+	srv := cookieServer(t)
+	content := "```flow\n@cookies off\n```\n\n" +
+		httpBlock("login", "", srv.URL+"/login") +
+		httpBlock("me", "", srv.URL+"/me")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "login:pass,me:fail" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestFlowCookiesPersist(t *testing.T) {
+	srv := cookieServer(t)
+	content := "```flow\n@cookies persist\n```\n\n" + httpBlock("login", "", srv.URL+"/login")
+	if got := strings.Join(runFlowForTest(t, content, false), ","); got != "login:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+
+	store, err := storage.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	conf := loadRunConfig(nil)
+	cookies, err := store.GetCookies(conf.ProjectID, conf.ActiveEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "session" || cookies[0].Domain != "127.0.0.1" || !cookies[0].HostOnly {
+		t.Fatalf("unexpected saved cookies: %+v", cookies)
+	}
+
+	jar := client.NewJar()
+	if err := loadSavedCookies(store, conf.ProjectID, conf.ActiveEnv, jar); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL + "/me")
+	if got := jar.Cookies(u); len(got) != 1 || got[0].Value != "abc" {
+		t.Fatalf("saved cookie not replayed: %+v", got)
+	}
+}
+
+func TestAdHocRequestsPersistCookiesOnlyWhenAsked(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := cookieServer(t)
+	send := func(path string, save bool) error {
+		_, err := ExecuteRequest(RequestOptions{Method: "get", URL: srv.URL + path, Asserts: []string{"status == 200"}, SilentOutput: true, NoRecord: true, SaveCookies: save})
+		return err
+	}
+
+	if err := send("/login", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := send("/me", true); err == nil {
+		t.Fatal("a login without --save-cookies should not save its cookie")
+	}
+	if err := send("/login", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := send("/me", false); err == nil {
+		t.Fatal("saved cookies should only be sent with --save-cookies")
+	}
+	if err := send("/me", true); err != nil {
+		t.Fatalf("expected the saved cookie to be replayed: %v", err)
+	}
+}
+
+func TestSavedCookiesAreEncryptedAndSecretOnesMasked(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(secret.Reset)
+
+	store, err := storage.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	cookies := []storage.Cookie{
+		{Name: "session_token", Value: "s3ss10n-abc", Domain: "api.example.com", Path: "/"},
+		{Name: "theme", Value: "dark-mode", Domain: "api.example.com", Path: "/"},
+	}
+	if err := store.ReplaceCookies("proj", "dev", cookies); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(home, ".kest", "records.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var raw string
+	if err := db.QueryRow(`SELECT value FROM cookies WHERE name = 'session_token'`).Scan(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !secret.IsEncrypted(raw) || strings.Contains(raw, "s3ss10n") {
+		t.Fatalf("cookie value stored in plaintext: %q", raw)
+	}
+
+	cookies, err = store.GetCookies("proj", "dev")
+	if err != nil || len(cookies) != 2 || cookies[0].Value != "s3ss10n-abc" || cookies[1].Value != "dark-mode" {
+		t.Fatalf("unexpected cookies %+v (%v)", cookies, err)
+	}
+	if got := secret.Mask("session_token=s3ss10n-abc"); got != "session_token="+secret.Masked {
+		t.Fatalf("secret cookie value not masked: %q", got)
+	}
+
+	// A harmless cookie value must survive in recorded history.
+	id, err := store.SaveRecord(&storage.Record{Method: "GET", URL: "https://api.example.com/prefs", ResponseStatus: 200, ResponseBody: `{"theme":"dark-mode"}`, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := store.GetRecord(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ResponseBody != `{"theme":"dark-mode"}` {
+		t.Fatalf("non-secret cookie value masked in history: %s", record.ResponseBody)
+	}
+}
+
+func TestFlowSchemaFileResolvesNextToTheFlow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`)) //nolint: errcheck
+	}))
+	t.Cleanup(srv.Close)
+
+	root := t.TempDir()
+	flowDir := filepath.Join(root, "flows")
+	if err := os.MkdirAll(filepath.Join(flowDir, "schemas"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	schema := `{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}`
+	if err := os.WriteFile(filepath.Join(flowDir, "schemas", "user.json"), []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+
+	content := "```step\n@id user\nGET " + srv.URL + "/user\n\n[Asserts]\nschema @schemas/user.json\n```\n"
+	doc, _ := ParseFlowDocument(content)
+	summ := runFlowDocument(doc, filepath.Join("flows", "user.flow.md"), NewRunContext(nil))
+	if len(summ.Results) != 1 || !summ.Results[0].Success {
+		t.Fatalf("expected the schema next to the flow to be found: %+v", summ.Results)
+	}
+}
+
+func TestFlowStepReportsEveryAssertion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user":{"id":2,"role":"viewer"}}`)) //nolint: errcheck
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("HOME", t.TempDir())
+
+	content := "```step\n@id profile\nGET " + srv.URL + "\n\n[Asserts]\nstatus == 200\n" +
+		"body.user.role == admin\nbody.user == {\"id\":1,\"role\":\"viewer\"}\n\n[Soft Asserts]\nduration < 0\n```\n"
+	doc, _ := ParseFlowDocument(content)
+	summ := runFlowDocument(doc, "profile.flow.md", NewRunContext(nil))
+
+	if len(summ.Results) != 1 {
+		t.Fatalf("expected one result, got %d", len(summ.Results))
+	}
+	checks := summ.Results[0].Assertions
+	if len(checks) != 4 {
+		t.Fatalf("expected 4 assertion results, got %+v", checks)
+	}
+	if !checks[0].Passed || checks[1].Passed || checks[1].Actual != "viewer" || checks[2].Diff != "~ /id: 1 → 2" || !checks[3].Soft {
+		t.Fatalf("unexpected assertion results: %+v", checks)
+	}
+	if err := summ.Results[0].Error; err == nil || !strings.Contains(err.Error(), "and 1 more") {
+		t.Fatalf("expected error to mention both failures, got %v", err)
+	}
+}
+
+func TestFlowDefaultHeadersAndBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/slow":
+			time.Sleep(300 * time.Millisecond)
+		case "/api/me":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"auth":%q,"client":%q}`, r.Header.Get("Authorization"), r.Header.Get("X-Client"))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := "```flow\n@base-url " + srv.URL + "/api\n@header Authorization: Bearer {{token}}\n@header X-Client: kest\n@timeout 100ms\n```\n\n" +
+		"```step\n@id login\n@type exec\necho tok-1\n\n[Captures]\ntoken = $stdout\n```\n\n" +
+		"```step\n@id me\nGET /me\n\n[Asserts]\nbody.auth == \"Bearer tok-1\"\nbody.client == kest\n```\n\n" +
+		"```step\n@id override\nGET /me\nX-Client: custom\n\n[Asserts]\nbody.client == custom\nbody.auth == \"Bearer tok-1\"\n```\n\n" +
+		httpBlock("slow", "", "/slow") +
+		httpBlock("patient", "@timeout 2s\n", "/slow")
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "login:pass,me:pass,override:pass,slow:fail,patient:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestFlowAuthDefaultAndStepOverride(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"auth":%q,"key":%q}`, r.Header.Get("Authorization"), r.URL.Query().Get("api_key"))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := "```flow\n@base-url " + srv.URL + "\n@auth bearer {{token}}\n```\n\n" +
+		"```step\n@id login\n@type exec\necho tok-1\n\n[Captures]\ntoken = $stdout\n```\n\n" +
+		"```step\n@id me\nGET /me\n\n[Asserts]\nbody.auth == \"Bearer tok-1\"\n```\n\n" +
+		"```step\n@id basic\n@auth basic admin:secret\nGET /me\n\n[Asserts]\nbody.auth == \"Basic YWRtaW46c2VjcmV0\"\n```\n\n" +
+		"```step\n@id key\n@auth api-key api_key {{token}} in=query\nGET /me\n\n[Asserts]\nbody.auth == \"\"\nbody.key == tok-1\n```\n\n" +
+		"```step\n@id anonymous\n@auth none\nGET /me\n\n[Asserts]\nbody.auth == \"\"\n```\n\n"
+
+	got := strings.Join(runFlowForTest(t, content, false), ",")
+	if got != "login:pass,me:pass,basic:pass,key:pass,anonymous:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestFlowTemplateFunctionsAndDefaults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"auth":%q,"page":%q,"id":%q}`, r.Header.Get("Authorization"), r.URL.Query().Get("page"), r.URL.Query().Get("id"))
+	}))
+	t.Cleanup(srv.Close)
+
+	content := "```step\n@id login\n@type exec\necho admin\n\n[Captures]\nuser = $stdout\n```\n\n" +
+		"```step\n@id search\nGET " + srv.URL + "/search?page={{page|default:\"1\"}}&id={{$uuid}}\n" +
+		"Authorization: Basic {{ user | upper | base64 }}\n\n[Asserts]\nbody.auth == \"Basic QURNSU4=\"\nbody.page == \"1\"\nbody.id exists\n```\n\n"
+	if got := strings.Join(runFlowForTest(t, content, false), ","); got != "login:pass,search:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+}
+
+func TestFlowMasksSecretCapturesInHistory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/login" {
+			w.Write([]byte(`{"session_key":"sk-live-4242","user":"ada"}`)) //nolint: errcheck
+			return
+		}
+		fmt.Fprintf(w, `{"echo":%q}`, r.Header.Get("X-Session"))
+	}))
+	t.Cleanup(srv.Close)
+
+	prev := secretVars
+	secretVars = []string{"session_*"}
+	t.Cleanup(func() { secretVars = prev })
+	t.Cleanup(secret.Reset)
+
+	content := "```step\n@id login\nPOST " + srv.URL + "/login\n\n[Captures]\nsession_key = session_key\n```\n\n" +
+		"```step\n@id me\nGET " + srv.URL + "/me\nX-Session: {{session_key}}\n\n[Asserts]\nbody.echo == sk-live-4242\n```\n\n"
+	if got := strings.Join(runFlowForTest(t, content, false), ","); got != "login:pass,me:pass" {
+		t.Fatalf("unexpected results: %s", got)
+	}
+
+	store, err := storage.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	records, err := store.GetAllRecords()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d (%v)", len(records), err)
+	}
+	for _, r := range records {
+		for _, stored := range []string{r.RequestBody, r.ResponseBody, string(r.RequestHeaders)} {
+			if strings.Contains(stored, "sk-live-4242") {
+				t.Fatalf("record #%d leaks the secret: %s", r.ID, stored)
+			}
+		}
+	}
+	if !strings.Contains(records[1].ResponseBody, `"session_key":"****"`) {
+		t.Fatalf("expected the login response to be masked, got %s", records[1].ResponseBody)
+	}
+
+	conf := loadRunConfig(nil)
+	vars, err := store.GetVariables(conf.ProjectID, conf.ActiveEnv)
+	if err != nil || vars["session_key"] != "sk-live-4242" {
+		t.Fatalf("expected the secret to decrypt, got %q (%v)", vars["session_key"], err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kest-labs/kest/cli/internal/output"
+	"github.com/kest-labs/kest/cli/internal/report"
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+// runReport is one --report format[=path] request. An empty path (or "-")
+// writes the report to stdout.
+type runReport struct {
+	Format string
+	Path   string
+}
+
+type runReportFormat struct {
+	Label     string
+	Write     func(io.Writer, *summary.Summary, string) error
+	WriteFile func(*summary.Summary, report.RunReportOptions) (string, error)
+}
+
+var runReportFormats = map[string]runReportFormat{
+	"junit": {Label: "JUnit", Write: report.WriteJUnit, WriteFile: report.WriteRunJUnit},
+	"tap":   {Label: "TAP", Write: report.WriteTAP, WriteFile: report.WriteRunTAP},
+}
+
+// parseRunReports validates the --report flags.
+func parseRunReports(values []string) ([]runReport, error) {
+	var reports []runReport
+	toStdout := 0
+	for _, value := range values {
+		format, path, _ := strings.Cut(value, "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		path = strings.TrimSpace(path)
+		if _, ok := runReportFormats[format]; !ok {
+			return nil, fmt.Errorf("unknown report format %q (supported: junit, tap)", format)
+		}
+		if path == "-" {
+			path = ""
+		}
+		if path == "" {
+			toStdout++
+		}
+		reports = append(reports, runReport{Format: format, Path: path})
+	}
+	if toStdout > 1 {
+		return nil, fmt.Errorf("only one --report can write to stdout; give the others a path (e.g. --report junit=report.xml)")
+	}
+	if toStdout > 0 && output.JSONOutput {
+		return nil, fmt.Errorf("--report without a path writes to stdout and cannot be combined with --output json")
+	}
+	return reports, nil
+}
+
+// stdoutReport returns the report that replaces the normal run output, if
+// any.
+func stdoutReport(reports []runReport) (runReport, bool) {
+	for _, r := range reports {
+		if r.Path == "" {
+			return r, true
+		}
+	}
+	return runReport{}, false
+}
+
+// machineOutput reports whether stdout is reserved for a machine-readable
+// document (--output json or a --report without a path), in which case the
+// per-step progress output is suppressed.
+func machineOutput() bool {
+	if output.JSONOutput {
+		return true
+	}
+	_, ok := stdoutReport(runReports)
+	return ok
+}
+
+// writeRunReports writes every requested report. File reports are announced
+// unless stdout carries a machine-readable document.
+func writeRunReports(sourcePath string, summ *summary.Summary) error {
+	var errs []string
+	for _, r := range runReports {
+		format := runReportFormats[r.Format]
+		if r.Path == "" {
+			if err := format.Write(os.Stdout, summ, sourcePath); err != nil {
+				errs = append(errs, fmt.Sprintf("%s report: %v", r.Format, err))
+			}
+			continue
+		}
+
+		path, err := format.WriteFile(summ, report.RunReportOptions{OutputPath: r.Path, SourcePath: sourcePath})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s report: %v", r.Format, err))
+			continue
+		}
+		if !machineOutput() {
+			fmt.Printf("\n🧾 %s report written to: %s\n", format.Label, path)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to write %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kest-labs/kest/cli/internal/logger"
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+// isSuiteTarget reports whether a run argument should be expanded into a
+// suite (a directory or a glob pattern) rather than run as a single file.
+func isSuiteTarget(target string) bool {
+	if strings.ContainsAny(target, "*?[") {
+		return true
+	}
+	info, err := os.Stat(target)
+	return err == nil && info.IsDir()
+}
+
+// isFlowFile reports whether a path is picked up when walking a directory or
+// matching a glob.
+func isFlowFile(path string) bool {
+	return strings.HasSuffix(path, ".flow.md") || strings.HasSuffix(path, ".kest")
+}
+
+// discoverRunFiles expands directories and glob patterns into runnable
+// .flow.md/.kest files. Explicit file paths are kept as given. Argument order
+// is preserved and duplicates are dropped.
+func discoverRunFiles(targets []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		clean := filepath.Clean(path)
+		if seen[clean] {
+			return
+		}
+		seen[clean] = true
+		files = append(files, clean)
+	}
+
+	walkDir := func(root string) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isFlowFile(path) {
+				add(path)
+			}
+			return nil
+		})
+	}
+
+	for _, target := range targets {
+		if strings.ContainsAny(target, "*?[") {
+			matches, err := filepath.Glob(target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %w", target, err)
+			}
+			for _, match := range matches {
+				info, err := os.Stat(match)
+				if err != nil {
+					continue
+				}
+				if info.IsDir() {
+					if err := walkDir(match); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				// Globs filter like directory walks: docs/*.md skips README.md.
+				if isFlowFile(match) {
+					add(match)
+				}
+			}
+			continue
+		}
+
+		info, err := os.Stat(target)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			if err := walkDir(target); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		add(target)
+	}
+
+	return files, nil
+}
+
+// runSuite executes every file matched by targets with an isolated
+// RunContext and reports one aggregated summary grouped by file. With
+// --parallel, up to --jobs files run at the same time.
+func runSuite(targets []string) error {
+	files, err := discoverRunFiles(targets)
+	if err != nil {
+		return &ExitError{Code: ExitConfigError, Err: err}
+	}
+	if len(files) == 0 {
+		return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("no .flow.md or .kest files found in %s", strings.Join(targets, ", "))}
+	}
+
+	sourcePath := strings.Join(targets, " ")
+	logger.StartSession("suite")
+	defer logger.EndSession()
+
+	cliVars := parseVarFlags(runVars)
+	jobs := 1
+	if runParallel && runJobs > 1 {
+		jobs = runJobs
+	}
+
+	if !machineOutput() {
+		fmt.Printf("\n📂 Running suite of %d file(s)\n", len(files))
+		if jobs > 1 {
+			fmt.Printf("⚡ Parallel mode: %d files at a time\n", jobs)
+		}
+	}
+
+	restoreOutput := func() {}
+	if machineOutput() || jobs > 1 {
+		// Concurrent files would interleave their step output, so only the
+		// per-file outcome lines are printed once everything has finished.
+		restoreOutput = suppressStdout()
+	}
+
+	suite := summary.NewSummary()
+	results := make([]*summary.Summary, len(files))
+
+	runFile := func(i int) {
+		rc := NewRunContext(cliVars)
+		fileSumm, err := executeScenarioFile(files[i], rc)
+		if err != nil {
+			fileSumm = summary.NewSummary()
+			fileSumm.AddResult(summary.TestResult{
+				Name:      filepath.Base(files[i]),
+				StartTime: time.Now(),
+				Error:     err,
+			})
+		}
+		results[i] = fileSumm
+	}
+
+	if jobs > 1 {
+		var wg sync.WaitGroup
+		semaphore := make(chan struct{}, jobs)
+		for i := range files {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+				runFile(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i, file := range files {
+			fmt.Printf("\n━━━ [%d/%d] %s ━━━\n", i+1, len(files), file)
+			runFile(i)
+		}
+	}
+
+	restoreOutput()
+	if !machineOutput() {
+		fmt.Printf("\n📂 Suite results (%d file(s)):\n", len(files))
+	}
+	for i, file := range files {
+		suite.Merge(results[i], file)
+		if machineOutput() {
+			continue
+		}
+		icon := "✅"
+		if results[i].FailedTests > 0 {
+			icon = "❌"
+		}
+		fmt.Printf("  %s %s (%d/%d passed)\n", icon, file, results[i].PassedTests, results[i].TotalTests)
+	}
+
+	return finishRun(sourcePath, suite)
+}
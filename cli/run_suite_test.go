@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kest-labs/kest/cli/internal/summary"
+)
+
+func TestDiscoverRunFilesExpandsDirectoriesAndGlobs(t *testing.T) {
+	root := t.TempDir()
+	mustWrite := func(rel string) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("GET /health\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	login := mustWrite("flows/login.flow.md")
+	nested := mustWrite("flows/admin/users.flow.md")
+	legacy := mustWrite("flows/smoke.kest")
+	mustWrite("flows/README.md")
+	mustWrite("flows/.hidden/skip.flow.md")
+	extra := mustWrite("extra/one.flow.md")
+	mustWrite("extra/NOTES.md")
+	explicit := mustWrite("docs/checkout.md")
+
+	files, err := discoverRunFiles([]string{
+		filepath.Join(root, "flows"),
+		filepath.Join(root, "extra", "*.md"),
+		login,    // duplicate of a directory match
+		explicit, // named files are kept even without .flow.md
+	})
+	if err != nil {
+		t.Fatalf("discoverRunFiles returned error: %v", err)
+	}
+
+	want := []string{nested, login, legacy, extra, explicit}
+	if len(files) != len(want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Fatalf("file %d: expected %s, got %s", i, want[i], files[i])
+		}
+	}
+}
+
+func TestIsSuiteTarget(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "login.flow.md")
+	if err := os.WriteFile(file, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !isSuiteTarget(dir) {
+		t.Fatalf("expected directory to be a suite target")
+	}
+	if !isSuiteTarget(filepath.Join(dir, "*.flow.md")) {
+		t.Fatalf("expected glob to be a suite target")
+	}
+	if isSuiteTarget(file) {
+		t.Fatalf("expected plain file not to be a suite target")
+	}
+}
+
+func TestParallelSuiteSilencesStdoutOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	prevParallel, prevJobs := runParallel, runJobs
+	runParallel, runJobs = true, 4
+	t.Cleanup(func() { runParallel, runJobs = prevParallel, prevJobs })
+
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("api%d.kest", i))
+		line := "get " + server.URL + "/ping -a status==200 -a body.ok==true --no-record\n"
+		if err := os.WriteFile(path, []byte(strings.Repeat(line, 5)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	stdout := os.Stdout
+	restore := suppressStdout()
+	var wg sync.WaitGroup
+	results := make([]*summary.Summary, len(files))
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file string) {
+			defer wg.Done()
+			results[i], _ = executeScenarioFile(file, NewRunContext(nil))
+		}(i, file)
+	}
+	wg.Wait()
+	restore()
+
+	if os.Stdout != stdout {
+		t.Fatal("stdout was not restored after the parallel suite")
+	}
+	for i, summ := range results {
+		if summ == nil || summ.PassedTests != 5 {
+			t.Fatalf("file %d: expected 5 passed tests, got %+v", i, summ)
+		}
+	}
+}
@@ -3,9 +3,13 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kest-labs/kest/cli/internal/curl"
 	"github.com/kest-labs/kest/cli/internal/report"
 	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/spf13/cobra"
@@ -14,6 +18,7 @@ import (
 var (
 	showHTML bool
 	showOpen bool
+	showAs   string
 )
 
 var showCmd = &cobra.Command{
@@ -30,7 +35,10 @@ var showCmd = &cobra.Command{
   kest show 42 --html
 
   # Generate and open the HTML report
-  kest show last --open`,
+  kest show last --open
+
+  # Print the request as a curl, HTTPie or fetch snippet
+  kest show 42 --as curl`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		store, err := storage.NewStore()
@@ -53,6 +61,16 @@ var showCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		maskStoredRecords(store, loadConfigWarn(), record, nil)
+
+		if showAs != "" {
+			snippet, err := curl.Snippet(recordCurlRequest(record), showAs)
+			if err != nil {
+				return &ExitError{Code: ExitConfigError, Err: err}
+			}
+			fmt.Println(snippet)
+			return nil
+		}
 
 		if showHTML || showOpen {
 			reportPath, err := report.WriteRecordHTML(record, report.RecordHTMLOptions{})
@@ -78,9 +96,31 @@ var showCmd = &cobra.Command{
 func init() {
 	showCmd.Flags().BoolVar(&showHTML, "html", false, "Generate an HTML report for this record")
 	showCmd.Flags().BoolVar(&showOpen, "open", false, "Generate and open an HTML report for this record")
+	showCmd.Flags().StringVar(&showAs, "as", "", "Print the request as code: "+strings.Join(curl.Formats, ", "))
 	rootCmd.AddCommand(showCmd)
 }
 
+// recordCurlRequest converts a recorded request for rendering as a snippet.
+// Headers the HTTP client computes itself are left out.
+func recordCurlRequest(r *storage.Record) *curl.Request {
+	req := &curl.Request{Method: r.Method, URL: r.URL, Body: r.RequestBody}
+	var headers map[string]string
+	json.Unmarshal(r.RequestHeaders, &headers)
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Length", "Host", "Connection":
+			continue
+		}
+		req.Headers = append(req.Headers, curl.Header{Name: name, Value: headers[name]})
+	}
+	return req
+}
+
 func printRecord(r *storage.Record) {
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
 	sectionStyle := lipgloss.NewStyle().Bold(true).Underline(true).MarginTop(1)
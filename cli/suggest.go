@@ -37,6 +37,7 @@ the most logical next kest command. Requires ai_key to be configured.`,
 
 		// Get last full record for context
 		lastRecord, _ := store.GetLastRecord()
+		maskStoredRecords(store, conf, lastRecord, history)
 
 		client := ai.NewClient(conf.AIKey, conf.AIBaseURL, conf.AIModel)
 
@@ -80,9 +81,9 @@ func buildSuggestPrompt(history []storage.Record, vars map[string]string, lastRe
 	if len(vars) > 0 {
 		prompt += "\n## Captured Variables:\n"
 		for k, v := range vars {
-			display := v
-			if len(v) > 40 {
-				display = v[:20] + "..." + v[len(v)-10:]
+			display := maskedValue(k, v)
+			if len(display) > 40 {
+				display = display[:20] + "..." + display[len(display)-10:]
 			}
 			prompt += fmt.Sprintf("- {{%s}} = %s\n", k, display)
 		}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/kest-labs/kest/cli/internal/secret"
 	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/spf13/cobra"
 )
@@ -40,7 +41,7 @@ var varsCmd = &cobra.Command{
 		sort.Strings(keys)
 
 		for _, k := range keys {
-			fmt.Printf("  %s = %s\n", k, vars[k])
+			fmt.Printf("  %s = %s\n", k, maskedValue(k, vars[k]))
 		}
 		return nil
 	},
@@ -49,3 +50,12 @@ var varsCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(varsCmd)
 }
+
+// maskedValue is how a variable's value is shown: secret variables are
+// masked entirely, others have any embedded secret masked.
+func maskedValue(name, value string) string {
+	if secret.IsSecret(name) {
+		return secret.Masked
+	}
+	return secret.Mask(value)
+}
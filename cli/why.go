@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/kest-labs/kest/cli/internal/ai"
+	"github.com/kest-labs/kest/cli/internal/config"
 	"github.com/kest-labs/kest/cli/internal/storage"
 	"github.com/spf13/cobra"
 )
@@ -44,6 +45,7 @@ explain root causes, and suggest fixes. Requires ai_key to be configured.`,
 		history, _ := store.GetHistory(10, record.Project)
 
 		conf := loadConfigWarn()
+		maskStoredRecords(store, conf, record, history)
 		client := ai.NewClient(conf.AIKey, conf.AIBaseURL, conf.AIModel)
 
 		fmt.Printf("🧠 Analyzing record #%d: %s %s → %d ...\n\n", record.ID, record.Method, record.URL, record.ResponseStatus)
@@ -77,6 +79,20 @@ Rules:
 - Format suggested commands as: kest <command> <args>
 - Use Markdown formatting for readability.`
 
+// maskStoredRecords masks secret variable values in records read back from
+// history before they are shown or sent to the AI provider; records saved
+// before a variable was marked secret may still hold its value.
+func maskStoredRecords(store *storage.Store, conf *config.Config, record *storage.Record, history []storage.Record) {
+	// GetVariables registers the stored secrets for masking.
+	_, _ = store.GetVariables(conf.ProjectID, conf.ActiveEnv)
+	if record != nil {
+		storage.MaskRecord(record)
+	}
+	for i := range history {
+		storage.MaskRecord(&history[i])
+	}
+}
+
 func buildWhyPrompt(record *storage.Record, history []storage.Record) string {
 	var reqHeaders map[string]string
 	json.Unmarshal(record.RequestHeaders, &reqHeaders)